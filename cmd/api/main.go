@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"crypto/sha256"
 	"log"
 	"net/http"
 	"os"
@@ -10,10 +10,14 @@ import (
 	"syscall"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/auth"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/clock"
 	http_server "github.com/supercakecrumb/adhd-game-bot/internal/infra/http"
-	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/jobs"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"gopkg.in/telebot.v3"
 )
 
 func main() {
@@ -23,38 +27,104 @@ func main() {
 		log.Fatal("DATABASE_URL environment variable is required")
 	}
 
-	// Connect to database
-	db, err := sql.Open("postgres", dbURL)
+	// Logins are Telegram logins for this bot
+	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if botToken == "" {
+		log.Fatal("TELEGRAM_BOT_TOKEN environment variable is required")
+	}
+	sessionSecret := []byte(os.Getenv("SESSION_SECRET"))
+	if len(sessionSecret) == 0 {
+		// Stable across restarts, but rotating the bot token then logs everyone out
+		log.Println("SESSION_SECRET is not set, deriving the session key from the bot token")
+		derived := sha256.Sum256([]byte("session:" + botToken))
+		sessionSecret = derived[:]
+	}
+
+	// Sends join requests to reviewers; the bot handles their buttons
+	bot, err := telebot.NewBot(telebot.Settings{Token: botToken, Offline: true})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to create Telegram client: %v", err)
 	}
-	defer db.Close()
 
-	// Test database connection
+	// Connect to database
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+	store, err := storage.Open(ctx, dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	defer store.Close()
 
 	// Initialize repositories
-	userRepo := postgres.NewUserRepository(db)
-	questRepo := postgres.NewQuestRepository(db)
-	dungeonRepo := postgres.NewDungeonRepository(db)
-	dungeonMemberRepo := postgres.NewDungeonMemberRepository(db)
+	userRepo := store.Users
+	ledgerRepo := store.Ledger
+	questRepo := store.Quests
+	questCompletionRepo := store.Completions
+	questStreakRepo := store.Streaks
+	dungeonRepo := store.Dungeons
+	dungeonMemberRepo := store.DungeonMembers
+	timerRepo := store.Timers
+	timerEventRepo := store.TimerEvents
 
 	// Initialize other components
-	uuidGen := postgres.NewUUIDGenerator()
-	scheduler := postgres.NewPgScheduler(db)
-	txManager := postgres.NewTxManager(db)
-	idempotencyRepo := postgres.NewIdempotencyRepository(db)
+	uuidGen := store.UUIDGen
+	scheduler := store.Scheduler
+	txManager := store.TxManager
+	idempotencyRepo := store.Idempotency
 
 	// Initialize use case services
-	questService := usecase.NewQuestService(questRepo, userRepo, uuidGen, scheduler, idempotencyRepo, txManager)
-	dungeonService := usecase.NewDungeonService(dungeonRepo, dungeonMemberRepo, userRepo, uuidGen, txManager)
+	ledgerService := usecase.NewLedgerService(ledgerRepo, store.Wallets, userRepo, uuidGen, txManager)
+	questService := usecase.NewQuestService(questRepo, questCompletionRepo, questStreakRepo, userRepo, dungeonRepo, dungeonMemberRepo, ledgerService, uuidGen, scheduler, idempotencyRepo, txManager)
+	dungeonService := usecase.NewDungeonService(dungeonRepo, dungeonMemberRepo, store.Invites, store.JoinRequests, userRepo, ledgerService, telegram.NewDungeonNotifier(bot), uuidGen, txManager)
+	purchaseRepo := store.Purchases
+	shopItemRepo := store.ShopItems
+	discountTierRepo := store.DiscountTiers
+	loyaltyService := usecase.NewLoyaltyService(store.RewardTiers, store.LoyaltyStatus, purchaseRepo, userRepo, dungeonRepo, dungeonMemberRepo, nil, txManager) // tier notifications are sent by the bot
+	pricing := usecase.NewPricingPipeline(
+		usecase.MemberPriceRule(store.MemberPrices),
+		usecase.SaleRule(),
+		usecase.DiscountTierRule(discountTierRepo),
+		usecase.LoyaltyTierRule(loyaltyService),
+	)
+	shopService := usecase.NewShopService(
+		shopItemRepo,
+		purchaseRepo,
+		userRepo,
+		ledgerService,
+		store.ChatConfigs,
+		pricing,
+		loyaltyService,
+		uuidGen,
+		txManager,
+		idempotencyRepo,
+		dungeonRepo,
+		dungeonMemberRepo,
+	)
+	shopAdminService := usecase.NewShopAdminService(dungeonRepo, dungeonMemberRepo, shopItemRepo, purchaseRepo, discountTierRepo, txManager)
+	timerService := usecase.NewTimerService(timerRepo, timerEventRepo, questRepo, questService, nil, uuidGen, clock.NewSystemClock(), txManager) // expiry notifications are sent by the bot
 
 	// Initialize HTTP server
-	server := http_server.NewServer(questService, dungeonService)
+	server := http_server.NewServer(questService, dungeonService, timerService, ledgerService, shopService, shopAdminService, loyaltyService, http_server.AuthConfig{
+		BotToken:    botToken,
+		LoginMaxAge: auth.DefaultLoginMaxAge,
+		Sessions:    auth.NewSessions(sessionSecret, auth.DefaultSessionTTL),
+		Users:       userRepo,
+	})
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	runner := jobs.NewRunner(clock.NewSystemClock(), store.Elector)
+	for _, job := range []jobs.Job{
+		jobs.SchedulerTickJob(scheduler, time.Minute),
+		jobs.IdempotencyPurgeJob(idempotencyRepo, time.Hour, 7*24*time.Hour),
+		jobs.TimerEventCleanupJob(timerEventRepo, 24*time.Hour, 90*24*time.Hour),
+	} {
+		if err := runner.Register(job); err != nil {
+			log.Fatalf("Failed to register job: %v", err)
+		}
+	}
+	go runner.Run(jobsCtx)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -80,6 +150,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	// Create a context with timeout for shutdown
 	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
@@ -2,18 +2,16 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	_ "github.com/lib/pq"
-	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
-	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
-	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/clock"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/jobs"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 	"gopkg.in/telebot.v3"
 )
@@ -30,187 +28,136 @@ func main() {
 		connStr = "user=postgres dbname=adhd_bot sslmode=disable"
 	}
 
-	db, err := sql.Open("postgres", connStr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store, err := storage.Open(ctx, connStr)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer db.Close()
-
-	// Test database connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+	defer store.Close()
+
+	userRepo := store.Users
+	shopItemRepo := store.ShopItems
+	dungeonRepo := store.Dungeons
+	purchaseRepo := store.Purchases
+	chatConfigRepo := store.ChatConfigs
+	questRepo := store.Quests
+	questCompletionRepo := store.Completions
+	questStreakRepo := store.Streaks
+	idempotencyRepo := store.Idempotency
+	txManager := store.TxManager
+	scheduler := store.Scheduler
+	// Create bot
+	bot, err := telebot.NewBot(telebot.Settings{
+		Token:  botToken,
+		Poller: &telebot.LongPoller{Timeout: 10 * time.Second},
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Initialize PostgreSQL repositories
-	userRepo := postgres.NewUserRepository(db)
-	shopItemRepo := postgres.NewShopItemRepository(db)
-	purchaseRepo := postgres.NewPurchaseRepository(db)
-	chatConfigRepo := postgres.NewChatConfigRepository(db)
-	txManager := postgres.NewTxManager(db)
+	ledgerService := usecase.NewLedgerService(store.Ledger, store.Wallets, userRepo, store.UUIDGen, txManager)
+	loyaltyService := usecase.NewLoyaltyService(
+		store.RewardTiers,
+		store.LoyaltyStatus,
+		purchaseRepo,
+		userRepo,
+		dungeonRepo,
+		store.DungeonMembers,
+		telegram.NewLoyaltyNotifier(bot),
+		txManager,
+	)
+
+	// Member prices come first so sales and tier discounts apply on top of them
+	pricing := usecase.NewPricingPipeline(
+		usecase.MemberPriceRule(store.MemberPrices),
+		usecase.SaleRule(),
+		usecase.DiscountTierRule(store.DiscountTiers),
+		usecase.LoyaltyTierRule(loyaltyService),
+	)
 
 	// Initialize service with required dependencies
-	shopService := usecase.NewShopServiceV2(
+	shopService := usecase.NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		ledgerService,
 		chatConfigRepo,
-		nil, // discountTierRepo
+		pricing,
+		loyaltyService,
 		nil, // uuidGen
 		txManager,
 		nil, // idempotencyRepo
+		dungeonRepo,
+		store.DungeonMembers,
 	)
 
-	// Create bot
-	bot, err := telebot.NewBot(telebot.Settings{
-		Token:  botToken,
-		Poller: &telebot.LongPoller{Timeout: 10 * time.Second},
-	})
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Command handlers
-	bot.Handle("/start", func(c telebot.Context) error {
-		// Register user if not exists
-		userID := c.Sender().ID
-		chatID := c.Chat().ID
-
-		ctx := context.Background()
-		_, err := userRepo.FindByID(ctx, userID)
-		if err != nil {
-			// User not found, create new user
-			newUser := &entity.User{
-				ID:       userID,
-				ChatID:   chatID,
-				Username: c.Sender().FirstName,
-				Balance:  valueobject.NewDecimal("0.00"),
-				TimeZone: "UTC",
-			}
-			if err := userRepo.Create(ctx, newUser); err != nil {
-				log.Printf("Failed to create user: %v", err)
-				return c.Send("❌ Failed to register user")
-			}
-		}
-
-		return c.Send("🎮 Welcome to ADHD Game Bot!\n" +
-			"Use /shop to see available items\n" +
-			"Use /buy <code> to purchase items\n" +
-			"Use /balance to check your balance")
-	})
-
-	bot.Handle("/shop", func(c telebot.Context) error {
-		userID := c.Sender().ID
-		chatID := c.Chat().ID
-
-		// Register user if not exists
-		ctx := context.Background()
-		_, err := userRepo.FindByID(ctx, userID)
-		if err != nil {
-			// User not found, create new user
-			newUser := &entity.User{
-				ID:       userID,
-				ChatID:   chatID,
-				Username: c.Sender().FirstName,
-				Balance:  valueobject.NewDecimal("0.00"),
-				TimeZone: "UTC",
-			}
-			if err := userRepo.Create(ctx, newUser); err != nil {
-				log.Printf("Failed to create user: %v", err)
-				return c.Send("❌ Failed to register user")
-			}
-		}
-
-		items, err := shopService.GetShopItems(ctx, chatID)
-		if err != nil {
-			return c.Send("❌ Error getting shop items")
-		}
-
-		if len(items) == 0 {
-			return c.Send("🛒 No items available in the shop right now.")
-		}
-
-		message := "🛍️ Available Items:\n"
-		for _, item := range items {
-			stockInfo := ""
-			if item.Stock != nil {
-				stockInfo = fmt.Sprintf(" (Stock: %d)", *item.Stock)
-			}
-			message += fmt.Sprintf("- %s (%s): %s%s\n",
-				item.Name, item.Code, item.Price, stockInfo)
-		}
-		return c.Send(message)
-	})
-
-	bot.Handle("/buy", func(c telebot.Context) error {
-		userID := c.Sender().ID
-		chatID := c.Chat().ID
-
-		if len(c.Args()) == 0 {
-			return c.Send("Usage: /buy <item_code>")
-		}
-
-		// Register user if not exists
-		ctx := context.Background()
-		_, err := userRepo.FindByID(ctx, userID)
-		if err != nil {
-			// User not found, create new user
-			newUser := &entity.User{
-				ID:       userID,
-				ChatID:   chatID,
-				Username: c.Sender().FirstName,
-				Balance:  valueobject.NewDecimal("0.00"),
-				TimeZone: "UTC",
-			}
-			if err := userRepo.Create(ctx, newUser); err != nil {
-				log.Printf("Failed to create user: %v", err)
-				return c.Send("❌ Failed to register user")
-			}
-		}
-
-		itemCode := c.Args()[0]
-		purchase, err := shopService.PurchaseItemWithIdempotency(ctx, userID, itemCode, 1, "")
-		if err != nil {
-			return c.Send(fmt.Sprintf("❌ Purchase failed: %v", err))
-		}
+	questService := usecase.NewQuestService(
+		questRepo,
+		questCompletionRepo,
+		questStreakRepo,
+		userRepo,
+		dungeonRepo,
+		store.DungeonMembers,
+		ledgerService,
+		store.UUIDGen,
+		scheduler,
+		idempotencyRepo,
+		txManager,
+	)
 
-		return c.Send(fmt.Sprintf("✅ Purchased %s for %s!",
-			purchase.ItemName, purchase.TotalCost))
-	})
+	dungeonService := usecase.NewDungeonService(
+		dungeonRepo,
+		store.DungeonMembers,
+		store.Invites,
+		store.JoinRequests,
+		userRepo,
+		ledgerService,
+		telegram.NewDungeonNotifier(bot),
+		store.UUIDGen,
+		txManager,
+	)
 
-	bot.Handle("/balance", func(c telebot.Context) error {
-		userID := c.Sender().ID
-		chatID := c.Chat().ID
-
-		// Register user if not exists
-		ctx := context.Background()
-		user, err := userRepo.FindByID(ctx, userID)
-		if err != nil {
-			// User not found, create new user
-			newUser := &entity.User{
-				ID:       userID,
-				ChatID:   chatID,
-				Username: c.Sender().FirstName,
-				Balance:  valueobject.NewDecimal("0.00"),
-				TimeZone: "UTC",
-			}
-			if err := userRepo.Create(ctx, newUser); err != nil {
-				log.Printf("Failed to create user: %v", err)
-				return c.Send("❌ Failed to register user")
-			}
-			user = newUser
-		}
+	timerService := usecase.NewTimerService(
+		store.Timers,
+		store.TimerEvents,
+		questRepo,
+		questService,
+		telegram.NewTimerNotifier(bot),
+		store.UUIDGen,
+		clock.NewSystemClock(),
+		txManager,
+	)
 
-		// Get currency name
-		currencyName := "Points"
-		config, err := shopService.GetCurrencyName(ctx, chatID)
-		if err == nil {
-			currencyName = config
+	// /newquest asks admins for the quest's details one message at a time
+	questWizard := telegram.NewQuestWizard(clock.NewSystemClock(), 15*time.Minute)
+	telegram.NewHandlers(
+		userRepo,
+		dungeonRepo,
+		ledgerService,
+		loyaltyService,
+		shopService,
+		questService,
+		dungeonService,
+		timerService,
+		questWizard,
+	).Register(bot)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	runner := jobs.NewRunner(clock.NewSystemClock(), store.Elector)
+	for _, job := range []jobs.Job{
+		jobs.SchedulerTickJob(scheduler, time.Minute),
+		jobs.IdempotencyPurgeJob(idempotencyRepo, time.Hour, 7*24*time.Hour),
+		jobs.TimerEventCleanupJob(store.TimerEvents, 24*time.Hour, 90*24*time.Hour),
+		jobs.TimerExpiryJob(timerService, 15*time.Second),
+	} {
+		if err := runner.Register(job); err != nil {
+			log.Fatalf("Failed to register job: %v", err)
 		}
-
-		return c.Send(fmt.Sprintf("💰 Your balance: %s %s", user.Balance, currencyName))
-	})
+	}
+	go runner.Run(jobsCtx)
 
 	// Start bot
 	log.Println("Bot starting...")
@@ -222,5 +169,6 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutting down...")
+	stopJobs()
 	bot.Stop()
 }
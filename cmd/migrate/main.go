@@ -1,17 +1,60 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"strconv"
 
 	_ "github.com/lib/pq"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres/migrations"
 )
 
+const usage = `Usage: migrate [command]
+
+Commands:
+  up              apply all pending migrations (default)
+  down [N]        roll back the last N migrations (default 1)
+  redo            roll back the last migration and apply it again
+  status          list migrations and whether they are applied
+  create NAME     write empty up and down files for a new migration
+`
+
+// migrate applies the migrations built into the binary and records them in
+// schema_migrations.
 func main() {
-	// Connect to PostgreSQL database
+	dir := flag.String("dir", "internal/infra/postgres/migrations", "directory create writes new migrations to")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command, args := "up", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("create takes the migration name")
+		}
+		upPath, downPath, err := migrations.Create(*dir, args[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return
+	}
+
+	all, err := migrations.Load(migrations.Files())
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
 	connStr := os.Getenv("DATABASE_URL")
 	if connStr == "" {
 		connStr = "user=postgres password=password dbname=adhd_bot sslmode=disable"
@@ -22,46 +65,71 @@ func main() {
 	}
 	defer db.Close()
 
-	// Create schema_migrations table if not exists
-	if _, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version TEXT PRIMARY KEY,
-			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)`); err != nil {
-		log.Fatalf("Failed to create migrations table: %v", err)
-	}
+	runner := migrations.NewRunner(db, all)
+	ctx := context.Background()
 
-	// Get list of migration files
-	migrations, err := filepath.Glob("internal/infra/postgres/migrations/*.sql")
-	if err != nil {
-		log.Fatalf("Failed to list migrations: %v", err)
-	}
-
-	// Apply migrations in order
-	for _, file := range migrations {
-		version := filepath.Base(file)[:3] // Get first 3 chars (001, 002 etc.)
-		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&exists)
+	switch command {
+	case "up":
+		applied, err := runner.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %s_%s\n", m.VersionString(), m.Name)
+		}
 		if err != nil {
-			log.Fatalf("Failed to check migration status: %v", err)
+			log.Fatalf("Failed to apply migrations: %v", err)
 		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+			return
+		}
+		fmt.Println("Migrations applied successfully")
 
-		if !exists {
-			fmt.Printf("Applying migration: %s\n", file)
-			migrationSQL, err := os.ReadFile(file)
-			if err != nil {
-				log.Fatalf("Failed to read migration file: %v", err)
+	case "down":
+		n := 1
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				log.Fatalf("down takes a positive number of migrations, got %q", args[0])
 			}
+		}
+		reverted, err := runner.Down(ctx, n)
+		for _, m := range reverted {
+			fmt.Printf("Rolled back %s_%s\n", m.VersionString(), m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to roll back migrations: %v", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
 
-			if _, err := db.Exec(string(migrationSQL)); err != nil {
-				log.Fatalf("Failed to apply migration %s: %v", file, err)
-			}
+	case "redo":
+		m, err := runner.Redo(ctx)
+		if err != nil {
+			log.Fatalf("Failed to redo migration: %v", err)
+		}
+		fmt.Printf("Redid %s_%s\n", m.VersionString(), m.Name)
 
-			if _, err := db.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
-				log.Fatalf("Failed to record migration: %v", err)
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			switch {
+			case s.Missing:
+				state += " (file missing)"
+			case s.Modified:
+				state += " (modified since applied)"
 			}
+			fmt.Printf("%03d_%-40s %s\n", s.Version, s.Name, state)
 		}
-	}
 
-	fmt.Println("Migrations applied successfully")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// reconcile checks every user's cached balance against the points ledger.
+// It exits with status 1 when mismatches are found and -fix was not given.
+func main() {
+	fix := flag.Bool("fix", false, "reset mismatched balances to the ledger sum")
+	flag.Parse()
+
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		connStr = "user=postgres password=password dbname=adhd_bot sslmode=disable"
+	}
+	store, err := storage.Open(context.Background(), connStr)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	ledgerService := usecase.NewLedgerService(store.Ledger, store.Wallets, store.Users, store.UUIDGen, store.TxManager)
+
+	mismatches, err := ledgerService.Reconcile(context.Background(), *fix)
+	if err != nil {
+		log.Fatalf("Failed to reconcile balances: %v", err)
+	}
+
+	for _, m := range mismatches {
+		if m.DungeonID != "" {
+			fmt.Printf("user %d, dungeon %s: cached wallet balance %s, ledger %s\n", m.UserID, m.DungeonID, m.Cached, m.Ledger)
+			continue
+		}
+		fmt.Printf("user %d: cached balance %s, ledger %s\n", m.UserID, m.Cached, m.Ledger)
+	}
+
+	switch {
+	case len(mismatches) == 0:
+		fmt.Println("All balances match the ledger")
+	case *fix:
+		fmt.Printf("Reset %d balance(s) to the ledger sum\n", len(mismatches))
+	default:
+		fmt.Printf("%d balance(s) disagree with the ledger, rerun with -fix to repair them\n", len(mismatches))
+		os.Exit(1)
+	}
+}
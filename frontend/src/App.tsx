@@ -5,26 +5,24 @@ import Quests from './pages/Quests';
 import Shop from './pages/Shop';
 import Profile from './pages/Profile';
 import Admin from './pages/Admin';
-import { getUserID, setUserID } from './services/auth';
+import { getSessionToken, getUserID, loginWithTelegram } from './services/auth';
 
 const App: React.FC = () => {
   const [userId, setUserId] = useState<string | null>(null);
   const [loading, setLoading] = useState(true);
 
   useEffect(() => {
-    const storedUserId = getUserID();
-    if (storedUserId) {
-      setUserId(storedUserId);
-      setLoading(false);
-    } else {
-      // Prompt for user ID if not set
-      const inputUserId = prompt('Please enter your User ID:');
-      if (inputUserId) {
-        setUserID(inputUserId);
-        setUserId(inputUserId);
-      }
+    if (getSessionToken()) {
+      setUserId(getUserID());
       setLoading(false);
+      return;
     }
+
+    // Opened from Telegram, so log in with the Mini App's initData
+    loginWithTelegram()
+      .then((ok) => setUserId(ok ? getUserID() : null))
+      .catch(() => setUserId(null))
+      .finally(() => setLoading(false));
   }, []);
 
   if (loading) {
@@ -39,8 +37,8 @@ const App: React.FC = () => {
     return (
       <div className="flex min-h-screen items-center justify-center bg-slate-900">
         <div className="text-slate-100">
-          <h1 className="text-2xl font-bold mb-4">User ID Required</h1>
-          <p>Please refresh the page and enter your User ID</p>
+          <h1 className="text-2xl font-bold mb-4">Login Required</h1>
+          <p>Please open the app from the bot in Telegram</p>
         </div>
       </div>
     );
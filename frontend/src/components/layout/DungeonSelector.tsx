@@ -49,11 +49,14 @@ const DungeonSelector: React.FC<DungeonSelectorProps> = ({
               <button
                 key={dungeon.id}
                 onClick={() => handleSelect(dungeon.id)}
-                className={`w-full px-4 py-2 text-left text-sm hover:bg-slate-700 ${
+                className={`flex w-full items-center justify-between px-4 py-2 text-left text-sm hover:bg-slate-700 ${
                   selectedDungeon?.id === dungeon.id ? 'bg-slate-700' : ''
                 }`}
               >
-                {dungeon.title}
+                <span>{dungeon.title}</span>
+                {dungeon.balance !== undefined && (
+                  <span className="text-slate-400">{dungeon.balance}</span>
+                )}
               </button>
             ))}
           </div>
@@ -28,12 +28,16 @@ const Dashboard: React.FC = () => {
         title: 'Productivity Palace',
         admin_user_id: 123,
         created_at: new Date().toISOString(),
+        role: 'owner',
+        balance: '1250',
       },
       {
         id: 'dungeon-2',
         title: 'Focus Fortress',
         admin_user_id: 123,
         created_at: new Date().toISOString(),
+        role: 'member',
+        balance: '80',
       },
     ];
 
@@ -155,7 +159,7 @@ const Dashboard: React.FC = () => {
               selectedDungeon={selectedDungeon} 
               onDungeonChange={handleDungeonChange} 
             />
-            <BalanceChip balance={summary?.balance || '0'} />
+            <BalanceChip balance={selectedDungeon?.balance || summary?.balance || '0'} />
           </div>
         </div>
       </div>
@@ -1,3 +1,5 @@
+import { getAuthHeaders } from './auth';
+
 const API_BASE_URL = (import.meta as any).env?.VITE_API_BASE_URL || 'http://localhost:8080/api/v1';
 
 export const apiClient = {
@@ -68,15 +70,4 @@ export const apiClient = {
   },
 };
 
-// We need to import this function to avoid circular dependencies
-const getAuthHeaders = (): Record<string, string> => {
-  const userId = localStorage.getItem('adhd-game-user-id');
-  if (userId) {
-    return {
-      'X-User-ID': userId,
-    };
-  }
-  return {};
-};
-
 export default apiClient;
\ No newline at end of file
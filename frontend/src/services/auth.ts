@@ -1,23 +1,65 @@
+const API_BASE_URL = (import.meta as any).env?.VITE_API_BASE_URL || 'http://localhost:8080/api/v1';
+
 const USER_ID_KEY = 'adhd-game-user-id';
+const TOKEN_KEY = 'adhd-game-session-token';
+const EXPIRES_AT_KEY = 'adhd-game-session-expires-at';
+
+interface SessionResponse {
+  token: string;
+  expires_at: string;
+  user_id: number;
+}
 
 export const getUserID = (): string | null => {
   return localStorage.getItem(USER_ID_KEY);
 };
 
-export const setUserID = (userId: string): void => {
-  localStorage.setItem(USER_ID_KEY, userId);
+export const getSessionToken = (): string | null => {
+  const expiresAt = localStorage.getItem(EXPIRES_AT_KEY);
+  if (expiresAt && new Date(expiresAt) <= new Date()) {
+    clearSession();
+    return null;
+  }
+  return localStorage.getItem(TOKEN_KEY);
 };
 
-export const clearUserID = (): void => {
+export const clearSession = (): void => {
   localStorage.removeItem(USER_ID_KEY);
+  localStorage.removeItem(TOKEN_KEY);
+  localStorage.removeItem(EXPIRES_AT_KEY);
+};
+
+// Exchanges the initData Telegram passes to the Mini App for a session token
+export const loginWithTelegram = async (): Promise<boolean> => {
+  const initData = (window as any).Telegram?.WebApp?.initData;
+  if (!initData) {
+    return false;
+  }
+
+  const response = await fetch(`${API_BASE_URL}/auth/telegram/webapp`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ init_data: initData }),
+  });
+  if (!response.ok) {
+    return false;
+  }
+
+  const session: SessionResponse = await response.json();
+  localStorage.setItem(USER_ID_KEY, String(session.user_id));
+  localStorage.setItem(TOKEN_KEY, session.token);
+  localStorage.setItem(EXPIRES_AT_KEY, session.expires_at);
+  return true;
 };
 
 export const getAuthHeaders = (): Record<string, string> => {
-  const userId = getUserID();
-  if (userId) {
+  const token = getSessionToken();
+  if (token) {
     return {
-      'X-User-ID': userId,
+      Authorization: `Bearer ${token}`,
     };
   }
   return {};
-};
\ No newline at end of file
+};
@@ -4,6 +4,8 @@ export interface Dungeon {
   admin_user_id: number;
   telegram_chat_id?: number;
   created_at: string;
+  role?: 'owner' | 'moderator' | 'member'; // Set by GET /dungeons
+  balance?: string; // The user's wallet in this dungeon, set by GET /dungeons
 }
 
 export interface CreateDungeonRequest {
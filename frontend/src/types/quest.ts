@@ -12,6 +12,7 @@ export interface Quest {
   daily_points_cap?: string;
   cooldown_sec: number;
   streak_enabled: boolean;
+  streak_grace_periods: number;
   status: 'active' | 'paused' | 'archived';
 }
 
@@ -28,6 +29,7 @@ export interface CreateQuestRequest {
   daily_points_cap?: string;
   cooldown_sec?: number;
   streak_enabled?: boolean;
+  streak_grace_periods?: number;
   status?: 'active' | 'paused' | 'archived';
 }
 
@@ -41,4 +43,12 @@ export interface CompleteQuestResponse {
   awarded_points: string;
   submitted_at: string;
   streak_count?: number;
+  best_streak?: number;
+}
+
+export interface QuestStreak {
+  quest_id: string;
+  quest_title: string;
+  current_streak: number;
+  best_streak: number;
 }
\ No newline at end of file
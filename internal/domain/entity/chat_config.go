@@ -6,6 +6,29 @@ import "time"
 type ChatConfig struct {
 	ChatID       int64
 	CurrencyName string // Configurable currency name for this chat
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+
+	// Refund policy. Admins can always refund; members can refund their own
+	// purchases within RefundWindowSec unless RefundsAdminOnly is set.
+	RefundWindowSec  int // 0 disables self-service refunds
+	RefundsAdminOnly bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DefaultRefundWindowSec applies to chats without a config
+const DefaultRefundWindowSec = 24 * 60 * 60
+
+// DefaultChatConfig returns the configuration used for chats that have none stored
+func DefaultChatConfig(chatID int64) *ChatConfig {
+	return &ChatConfig{
+		ChatID:          chatID,
+		CurrencyName:    "Points",
+		RefundWindowSec: DefaultRefundWindowSec,
+	}
+}
+
+// RefundWindow returns how long after a purchase its buyer may still refund it
+func (c *ChatConfig) RefundWindow() time.Duration {
+	return time.Duration(c.RefundWindowSec) * time.Second
 }
@@ -5,13 +5,76 @@ import "time"
 type Dungeon struct {
 	ID             string
 	Title          string
-	AdminUserID    int64
+	AdminUserID    int64  // The owner; their membership has DungeonRoleOwner
 	TelegramChatID *int64 // Optional link to Telegram group
+	TimeZone       string // IANA zone new quests start in, UTC when empty
 	CreatedAt      time.Time
 }
 
 type DungeonMember struct {
 	DungeonID string
 	UserID    int64
+	Role      DungeonRole
 	JoinedAt  time.Time
 }
+
+// DungeonRole is what a member may do in a dungeon
+type DungeonRole string
+
+// Dungeon roles
+const (
+	DungeonRoleOwner     DungeonRole = "owner"
+	DungeonRoleModerator DungeonRole = "moderator"
+	DungeonRoleMember    DungeonRole = "member"
+)
+
+// DungeonPermission is an action restricted to some roles
+type DungeonPermission string
+
+// Dungeon permissions, named so they read as "a moderator cannot <permission>"
+const (
+	PermissionCreateQuests   DungeonPermission = "create quests"
+	PermissionEditShop       DungeonPermission = "edit the shop"
+	PermissionAdjustBalances DungeonPermission = "adjust balances"
+	PermissionInviteMembers  DungeonPermission = "invite members"
+	PermissionRemoveMembers  DungeonPermission = "remove members"
+	PermissionManageRoles    DungeonPermission = "manage roles"
+	PermissionLinkChats      DungeonPermission = "link chats"
+)
+
+// dungeonPermissions is the permission matrix. Owners may do everything;
+// moving points around, changing roles and linking chats are theirs alone.
+var dungeonPermissions = map[DungeonRole][]DungeonPermission{
+	DungeonRoleOwner: {
+		PermissionCreateQuests,
+		PermissionEditShop,
+		PermissionAdjustBalances,
+		PermissionInviteMembers,
+		PermissionRemoveMembers,
+		PermissionManageRoles,
+		PermissionLinkChats,
+	},
+	DungeonRoleModerator: {
+		PermissionCreateQuests,
+		PermissionEditShop,
+		PermissionInviteMembers,
+		PermissionRemoveMembers,
+	},
+	DungeonRoleMember: {},
+}
+
+// IsValid reports whether the role is one of the dungeon roles
+func (r DungeonRole) IsValid() bool {
+	_, ok := dungeonPermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants the permission
+func (r DungeonRole) Can(permission DungeonPermission) bool {
+	for _, granted := range dungeonPermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
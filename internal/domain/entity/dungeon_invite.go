@@ -0,0 +1,73 @@
+package entity
+
+import "time"
+
+// DungeonInvite lets users join a dungeon with its code, over HTTP or as a
+// Telegram deep link (/start join_<code>)
+type DungeonInvite struct {
+	Code             string
+	DungeonID        string
+	CreatedBy        int64
+	MaxUses          *int       // nil for unlimited uses
+	Uses             int        // Redemptions so far, including those awaiting approval
+	ExpiresAt        *time.Time // nil for an invite that does not expire
+	RequiresApproval bool       // Redeeming creates a JoinRequest instead of a membership
+	CreatedAt        time.Time
+}
+
+// Validate checks the invite's limits
+func (i *DungeonInvite) Validate() error {
+	if i.MaxUses != nil && *i.MaxUses <= 0 {
+		return ErrInvalidInviteMaxUses
+	}
+	return nil
+}
+
+// Redeem counts one use of the invite at the given time
+func (i *DungeonInvite) Redeem(at time.Time) error {
+	if i.ExpiresAt != nil && !at.Before(*i.ExpiresAt) {
+		return ErrInviteExpired
+	}
+	if i.MaxUses != nil && i.Uses >= *i.MaxUses {
+		return ErrInviteUsedUp
+	}
+	i.Uses++
+	return nil
+}
+
+// JoinRequestStatus is the state of a JoinRequest
+type JoinRequestStatus string
+
+// Join request states
+const (
+	JoinRequestPending  JoinRequestStatus = "pending"
+	JoinRequestApproved JoinRequestStatus = "approved"
+	JoinRequestDenied   JoinRequestStatus = "denied"
+)
+
+// JoinRequest is a redeemed invite waiting for the owner or a moderator
+type JoinRequest struct {
+	ID         int64
+	DungeonID  string
+	UserID     int64
+	InviteCode string
+	Status     JoinRequestStatus
+	DecidedBy  *int64 // Who approved or denied the request
+	CreatedAt  time.Time
+	DecidedAt  *time.Time
+}
+
+// Decide approves or denies a pending request
+func (r *JoinRequest) Decide(approve bool, deciderID int64, at time.Time) error {
+	if r.Status != JoinRequestPending {
+		return ErrJoinRequestDecided
+	}
+
+	r.Status = JoinRequestDenied
+	if approve {
+		r.Status = JoinRequestApproved
+	}
+	r.DecidedBy = &deciderID
+	r.DecidedAt = &at
+	return nil
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDungeonRole_Can(t *testing.T) {
+	all := []DungeonPermission{
+		PermissionCreateQuests,
+		PermissionEditShop,
+		PermissionAdjustBalances,
+		PermissionInviteMembers,
+		PermissionRemoveMembers,
+		PermissionManageRoles,
+		PermissionLinkChats,
+	}
+	for _, permission := range all {
+		assert.True(t, DungeonRoleOwner.Can(permission), permission)
+		assert.False(t, DungeonRoleMember.Can(permission), permission)
+	}
+
+	assert.True(t, DungeonRoleModerator.Can(PermissionCreateQuests))
+	assert.True(t, DungeonRoleModerator.Can(PermissionEditShop))
+	assert.True(t, DungeonRoleModerator.Can(PermissionInviteMembers))
+	assert.True(t, DungeonRoleModerator.Can(PermissionRemoveMembers))
+	assert.False(t, DungeonRoleModerator.Can(PermissionAdjustBalances))
+	assert.False(t, DungeonRoleModerator.Can(PermissionManageRoles))
+	assert.False(t, DungeonRoleModerator.Can(PermissionLinkChats))
+
+	assert.False(t, DungeonRole("admin").Can(PermissionCreateQuests))
+	assert.False(t, DungeonRole("admin").IsValid())
+	assert.True(t, DungeonRoleModerator.IsValid())
+}
+
+func TestDungeonInvite_Redeem(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	maxUses := 2
+	invite := &DungeonInvite{Code: "abc", MaxUses: &maxUses, ExpiresAt: &expires}
+	require.NoError(t, invite.Validate())
+
+	require.NoError(t, invite.Redeem(now))
+	require.NoError(t, invite.Redeem(now))
+	assert.Equal(t, 2, invite.Uses)
+	assert.ErrorIs(t, invite.Redeem(now), ErrInviteUsedUp)
+
+	invite = &DungeonInvite{Code: "abc", ExpiresAt: &expires}
+	assert.ErrorIs(t, invite.Redeem(expires), ErrInviteExpired)
+	assert.Zero(t, invite.Uses)
+
+	zero := 0
+	invite = &DungeonInvite{Code: "abc", MaxUses: &zero}
+	assert.ErrorIs(t, invite.Validate(), ErrInvalidInviteMaxUses)
+}
+
+func TestJoinRequest_Decide(t *testing.T) {
+	now := time.Now()
+	request := &JoinRequest{Status: JoinRequestPending}
+
+	require.NoError(t, request.Decide(true, 7, now))
+	assert.Equal(t, JoinRequestApproved, request.Status)
+	require.NotNil(t, request.DecidedBy)
+	assert.Equal(t, int64(7), *request.DecidedBy)
+
+	assert.ErrorIs(t, request.Decide(false, 7, now), ErrJoinRequestDecided)
+	assert.Equal(t, JoinRequestApproved, request.Status)
+}
@@ -1,6 +1,10 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrTaskNotFound         = errors.New("task not found")
@@ -8,3 +12,83 @@ var (
 	ErrExchangeRateNotFound = errors.New("exchange rate not found")
 	ErrCurrencyNotFound     = errors.New("currency not found")
 )
+
+// Quest scoring errors
+var (
+	ErrInvalidQuestMode        = errors.New("invalid quest mode")
+	ErrQuestNotActive          = errors.New("quest is not active")
+	ErrCompletionRatioRequired = errors.New("completion ratio is required for PARTIAL quests")
+	ErrInvalidCompletionRatio  = errors.New("completion ratio must be between 0 and 1")
+	ErrMinutesRequired         = errors.New("minutes are required for PER_MINUTE quests")
+	ErrInvalidMinutes          = errors.New("minutes must not be negative")
+	ErrMinutesBelowMinimum     = errors.New("minutes are below the quest minimum")
+	ErrRateNotConfigured       = errors.New("quest has no points-per-minute rate configured")
+)
+
+// Schedule errors
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrScheduleEnded   = errors.New("schedule has no upcoming occurrences")
+)
+
+// Timer errors
+var (
+	ErrInvalidTimerType       = errors.New("timer type must be countdown or stopwatch")
+	ErrInvalidTimerDuration   = errors.New("countdown duration must be positive")
+	ErrInvalidTimerTransition = errors.New("timer cannot change to that state")
+	ErrTimerAlreadyActive     = errors.New("a timer is already active for this quest")
+)
+
+// Ledger errors
+var (
+	ErrZeroLedgerAmount    = errors.New("ledger entry amount must not be zero")
+	ErrInvalidLedgerReason = errors.New("invalid ledger entry reason")
+)
+
+// Shop item errors
+var (
+	ErrItemCodeRequired   = errors.New("item code is required")
+	ErrItemNameRequired   = errors.New("item name is required")
+	ErrInvalidItemPrice   = errors.New("item price must not be negative")
+	ErrInvalidSalePrice   = errors.New("sale price must not be negative")
+	ErrInvalidItemStock   = errors.New("item stock must not be negative")
+	ErrInvalidRestock     = errors.New("restock quantity must be positive")
+	ErrItemStockUnlimited = errors.New("item has unlimited stock")
+)
+
+// Purchase errors
+var (
+	ErrPurchaseNotRefundable = errors.New("purchase has nothing left to refund")
+	ErrInvalidRefundQuantity = errors.New("refund quantity must be between 1 and the units not yet refunded")
+)
+
+// Loyalty tier errors
+var (
+	ErrTierNameRequired     = errors.New("tier name is required")
+	ErrInvalidTierDiscount  = errors.New("tier discount must be above 0 and at most 100 percent")
+	ErrInvalidTierThreshold = errors.New("tier purchase threshold must not be negative")
+)
+
+// Dungeon invite errors
+var (
+	ErrInvalidInviteMaxUses = errors.New("invite max uses must be positive")
+	ErrInviteExpired        = errors.New("invite has expired")
+	ErrInviteUsedUp         = errors.New("invite has no uses left")
+	ErrJoinRequestDecided   = errors.New("join request was already decided")
+)
+
+// ErrQuestOnCooldown is matched by QuestCooldownError via errors.Is
+var ErrQuestOnCooldown = errors.New("quest is on cooldown")
+
+// QuestCooldownError is returned when a quest is completed again before its CooldownSec elapsed
+type QuestCooldownError struct {
+	Remaining time.Duration // Time left until the quest can be completed again
+}
+
+func (e *QuestCooldownError) Error() string {
+	return fmt.Sprintf("%s: try again in %s", ErrQuestOnCooldown, e.Remaining.Round(time.Second))
+}
+
+func (e *QuestCooldownError) Is(target error) bool {
+	return target == ErrQuestOnCooldown
+}
@@ -25,3 +25,8 @@ func (k *IdempotencyKey) IsExpired() bool {
 func (k *IdempotencyKey) IsCompleted() bool {
 	return k.Status == "completed"
 }
+
+// IsFailed checks if the operation failed without taking effect
+func (k *IdempotencyKey) IsFailed() bool {
+	return k.Status == "failed"
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+// Ledger entry reasons
+const (
+	LedgerReasonQuestCompletion = "quest_completion"
+	LedgerReasonPurchase        = "purchase"
+	LedgerReasonRefund          = "refund"
+	LedgerReasonAdminAdjustment = "admin_adjustment"
+	LedgerReasonTransfer        = "transfer"
+	LedgerReasonOpeningBalance  = "opening_balance" // Balance carried over from before the ledger existed
+)
+
+// Ledger entry source types, i.e. what kind of entity SourceID refers to
+const (
+	LedgerSourceQuestCompletion = "quest_completion"
+	LedgerSourcePurchase        = "purchase"
+	LedgerSourceUser            = "user"
+)
+
+// LedgerEntry is one credit (positive Amount) or debit (negative Amount) of a
+// user's points. Entries are never updated or deleted; User.Balance is a cached
+// sum of them and each Wallet of those in its dungeon.
+type LedgerEntry struct {
+	ID           string
+	UserID       int64
+	DungeonID    string // Wallet the entry belongs to; empty for points outside any dungeon
+	Amount       valueobject.Decimal
+	BalanceAfter valueobject.Decimal // Wallet balance right after this entry, or the user's outside a dungeon
+	Reason       string
+	SourceType   string // e.g. "quest_completion", "purchase", "user"
+	SourceID     string // ID of the source entity, empty when there is none
+	Note         string
+	CreatedAt    time.Time
+}
+
+// Validate checks that the entry moves a non-zero amount for a known reason
+func (e *LedgerEntry) Validate() error {
+	if e.Amount.IsZero() {
+		return ErrZeroLedgerAmount
+	}
+	switch e.Reason {
+	case LedgerReasonQuestCompletion, LedgerReasonPurchase, LedgerReasonRefund,
+		LedgerReasonAdminAdjustment, LedgerReasonTransfer, LedgerReasonOpeningBalance:
+		return nil
+	default:
+		return ErrInvalidLedgerReason
+	}
+}
+
+// IsDebit reports whether the entry takes points away from the user
+func (e *LedgerEntry) IsDebit() bool {
+	return e.Amount.IsNegative()
+}
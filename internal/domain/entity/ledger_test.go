@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+func TestLedgerEntry_Validate(t *testing.T) {
+	entry := &LedgerEntry{Amount: valueobject.NewDecimal("-2.5"), Reason: LedgerReasonPurchase}
+	assert.NoError(t, entry.Validate())
+	assert.True(t, entry.IsDebit())
+
+	entry = &LedgerEntry{Amount: valueobject.NewDecimal("0"), Reason: LedgerReasonRefund}
+	assert.ErrorIs(t, entry.Validate(), ErrZeroLedgerAmount)
+
+	entry = &LedgerEntry{Amount: valueobject.NewDecimal("1"), Reason: "bonus"}
+	assert.ErrorIs(t, entry.Validate(), ErrInvalidLedgerReason)
+	assert.False(t, entry.IsDebit())
+}
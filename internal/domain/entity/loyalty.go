@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// LoyaltyStatus is the loyalty tier a user has reached in a dungeon
+type LoyaltyStatus struct {
+	UserID         int64
+	DungeonID      string
+	TierID         *int64 // Current tier; nil below the lowest tier
+	PurchaseCount  int    // Purchases that count towards tiers
+	NotifiedTierID *int64 // Tier the user was last told about
+	UpdatedAt      time.Time
+}
+
+// NotificationPending reports whether the user's tier changed since they were
+// last told about it
+func (s *LoyaltyStatus) NotificationPending() bool {
+	if s.TierID == nil || s.NotifiedTierID == nil {
+		return s.TierID != s.NotifiedTierID
+	}
+	return *s.NotifiedTierID != *s.TierID
+}
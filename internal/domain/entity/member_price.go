@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+// MemberPrice overrides the unit price of a shop item for one user
+type MemberPrice struct {
+	ItemID    int64
+	UserID    int64
+	Price     valueobject.Decimal
+	UpdatedAt time.Time
+}
@@ -6,6 +6,13 @@ import (
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
 )
 
+// Quest scoring modes
+const (
+	QuestModeBinary    = "BINARY"
+	QuestModePartial   = "PARTIAL"
+	QuestModePerMinute = "PER_MINUTE"
+)
+
 type Quest struct {
 	// Core identification
 	ID          string
@@ -26,13 +33,14 @@ type Quest struct {
 	DailyPointsCap   *valueobject.Decimal // Optional anti-abuse limit
 
 	// Behavioral Controls
-	CooldownSec   int // Minimum seconds between completions
-	StreakEnabled bool
+	CooldownSec        int // Minimum seconds between completions
+	StreakEnabled      bool
+	StreakGracePeriods int // Missed periods forgiven before a user's streak breaks
 
 	// Operational State
 	Status          string // "active" | "paused" | "archived"
 	LastCompletedAt *time.Time
-	StreakCount     int
+	StreakCount     int    // Deprecated: streaks are tracked per user, see QuestStreak
 	TimeZone        string // IANA timezone for streak boundaries
 
 	// Timestamps
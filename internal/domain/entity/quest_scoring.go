@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+// CalculateAward computes the points earned by a single completion of the quest.
+// completionRatio is only used by PARTIAL quests and minutes only by PER_MINUTE quests.
+func (q *Quest) CalculateAward(completionRatio *float64, minutes *int) (valueobject.Decimal, error) {
+	switch q.Mode {
+	case QuestModeBinary:
+		return q.PointsAward, nil
+
+	case QuestModePartial:
+		if completionRatio == nil {
+			return valueobject.Decimal{}, ErrCompletionRatioRequired
+		}
+		ratio := *completionRatio
+		if ratio < 0 || ratio > 1 {
+			return valueobject.Decimal{}, ErrInvalidCompletionRatio
+		}
+		return q.PointsAward.Mul(valueobject.NewDecimalFromFloat(ratio)), nil
+
+	case QuestModePerMinute:
+		if q.RatePointsPerMin == nil {
+			return valueobject.Decimal{}, ErrRateNotConfigured
+		}
+		if minutes == nil {
+			return valueobject.Decimal{}, ErrMinutesRequired
+		}
+		mins := *minutes
+		if mins < 0 {
+			return valueobject.Decimal{}, ErrInvalidMinutes
+		}
+		if q.MinMinutes != nil && mins < *q.MinMinutes {
+			return valueobject.Decimal{}, ErrMinutesBelowMinimum
+		}
+		// Minutes above the cap still count, but only up to the cap
+		if q.MaxMinutes != nil && mins > *q.MaxMinutes {
+			mins = *q.MaxMinutes
+		}
+		return q.RatePointsPerMin.Mul(valueobject.NewDecimalFromInt(int64(mins))), nil
+
+	default:
+		return valueobject.Decimal{}, ErrInvalidQuestMode
+	}
+}
+
+// CooldownRemaining returns how long the user still has to wait after their last
+// completion at lastCompletedAt. A zero duration means the quest can be completed now.
+func (q *Quest) CooldownRemaining(lastCompletedAt, now time.Time) time.Duration {
+	if q.CooldownSec <= 0 {
+		return 0
+	}
+	readyAt := lastCompletedAt.Add(time.Duration(q.CooldownSec) * time.Second)
+	if !now.Before(readyAt) {
+		return 0
+	}
+	return readyAt.Sub(now)
+}
+
+// CapAward clips award so that, together with what the user already earned from
+// this quest today, it never exceeds DailyPointsCap.
+func (q *Quest) CapAward(award, awardedToday valueobject.Decimal) valueobject.Decimal {
+	if q.DailyPointsCap == nil {
+		return award
+	}
+	remaining := q.DailyPointsCap.Sub(awardedToday)
+	if !remaining.IsPositive() {
+		return valueobject.NewDecimalFromInt(0)
+	}
+	if award.Cmp(remaining) > 0 {
+		return remaining
+	}
+	return award
+}
@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+func TestQuest_CalculateAward(t *testing.T) {
+	ratio := func(f float64) *float64 { return &f }
+	mins := func(i int) *int { return &i }
+	rate := valueobject.NewDecimal("1.5")
+
+	perMinute := &Quest{
+		Mode:             QuestModePerMinute,
+		RatePointsPerMin: &rate,
+		MinMinutes:       mins(5),
+		MaxMinutes:       mins(60),
+	}
+
+	tests := []struct {
+		name    string
+		quest   *Quest
+		ratio   *float64
+		minutes *int
+		want    string
+		wantErr error
+	}{
+		{"binary", &Quest{Mode: QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}, nil, nil, "10", nil},
+		{"binary ignores inputs", &Quest{Mode: QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}, ratio(0.1), mins(3), "10", nil},
+		{"partial half", &Quest{Mode: QuestModePartial, PointsAward: valueobject.NewDecimal("20")}, ratio(0.5), nil, "10", nil},
+		{"partial full", &Quest{Mode: QuestModePartial, PointsAward: valueobject.NewDecimal("20")}, ratio(1), nil, "20", nil},
+		{"partial missing ratio", &Quest{Mode: QuestModePartial}, nil, nil, "", ErrCompletionRatioRequired},
+		{"partial ratio too big", &Quest{Mode: QuestModePartial}, ratio(1.5), nil, "", ErrInvalidCompletionRatio},
+		{"partial ratio negative", &Quest{Mode: QuestModePartial}, ratio(-0.1), nil, "", ErrInvalidCompletionRatio},
+		{"per minute", perMinute, nil, mins(10), "15", nil},
+		{"per minute at floor", perMinute, nil, mins(5), "7.5", nil},
+		{"per minute capped", perMinute, nil, mins(90), "90", nil},
+		{"per minute below floor", perMinute, nil, mins(4), "", ErrMinutesBelowMinimum},
+		{"per minute missing minutes", perMinute, nil, nil, "", ErrMinutesRequired},
+		{"per minute negative minutes", perMinute, nil, mins(-1), "", ErrInvalidMinutes},
+		{"per minute without rate", &Quest{Mode: QuestModePerMinute}, nil, mins(10), "", ErrRateNotConfigured},
+		{"unknown mode", &Quest{Mode: "PROGRESSIVE"}, nil, nil, "", ErrInvalidQuestMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.quest.CalculateAward(tt.ratio, tt.minutes)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 0, got.Cmp(valueobject.NewDecimal(tt.want)), "got %s", got)
+		})
+	}
+}
+
+func TestQuest_CooldownRemaining(t *testing.T) {
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	quest := &Quest{CooldownSec: 3600}
+
+	assert.Equal(t, 30*time.Minute, quest.CooldownRemaining(last, last.Add(30*time.Minute)))
+	assert.Zero(t, quest.CooldownRemaining(last, last.Add(time.Hour)))
+	assert.Zero(t, quest.CooldownRemaining(last, last.Add(2*time.Hour)))
+	assert.Zero(t, (&Quest{}).CooldownRemaining(last, last))
+
+	err := error(&QuestCooldownError{Remaining: 90 * time.Second})
+	assert.ErrorIs(t, err, ErrQuestOnCooldown)
+	assert.Contains(t, err.Error(), "1m30s")
+}
+
+func TestQuest_CapAward(t *testing.T) {
+	capValue := valueobject.NewDecimal("50")
+	quest := &Quest{DailyPointsCap: &capValue}
+
+	tests := []struct {
+		name  string
+		award string
+		today string
+		want  string
+	}{
+		{"under cap", "10", "20", "10"},
+		{"exactly reaches cap", "30", "20", "30"},
+		{"clipped to cap", "40", "20", "30"},
+		{"cap already reached", "10", "50", "0"},
+		{"over cap from earlier", "10", "60", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quest.CapAward(valueobject.NewDecimal(tt.award), valueobject.NewDecimal(tt.today))
+			assert.Equal(t, 0, got.Cmp(valueobject.NewDecimal(tt.want)), "got %s", got)
+		})
+	}
+
+	uncapped := &Quest{}
+	assert.Equal(t, "100", uncapped.CapAward(valueobject.NewDecimal("100"), valueobject.NewDecimal("1000")).String())
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+// QuestStreak tracks one user's streak on one quest
+type QuestStreak struct {
+	UserID     int64
+	QuestID    string
+	Current    int   // Consecutive periods with at least one completion
+	Best       int   // Longest streak ever reached
+	LastPeriod int64 // Streak period of the last counted completion (see Quest.StreakPeriod)
+	UpdatedAt  time.Time
+}
+
+// StreakPeriod returns the number of the streak period containing t. Weekly quests
+// use ISO weeks (Monday to Sunday), every other quest uses calendar days; both are
+// evaluated in the quest's TimeZone.
+func (q *Quest) StreakPeriod(t time.Time) (int64, error) {
+	window, err := valueobject.NewDayWindow(t, q.TimeZone)
+	if err != nil {
+		return 0, err
+	}
+	day := window.DayNumber()
+	if q.Category == "weekly" {
+		// 1970-01-01 was a Thursday, shift so that weeks start on Monday
+		return floorDiv(day+3, 7), nil
+	}
+	return day, nil
+}
+
+// Advance records a completion made during period. A streak grows by at most one
+// per period and restarts at one when more than gracePeriods periods were missed
+// since the last counted completion. It reports whether the streak changed.
+func (s *QuestStreak) Advance(period int64, gracePeriods int) bool {
+	if s.Current > 0 && period <= s.LastPeriod {
+		return false
+	}
+
+	if s.Current == 0 || period-s.LastPeriod-1 > int64(gracePeriods) {
+		s.Current = 1
+	} else {
+		s.Current++
+	}
+
+	s.LastPeriod = period
+	if s.Current > s.Best {
+		s.Best = s.Current
+	}
+	return true
+}
+
+// CurrentAt returns the streak as seen during period: the stored value while the
+// streak can still be continued, or zero once too many periods were missed.
+func (s *QuestStreak) CurrentAt(period int64, gracePeriods int) int {
+	if period-s.LastPeriod-1 > int64(gracePeriods) {
+		return 0
+	}
+	return s.Current
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
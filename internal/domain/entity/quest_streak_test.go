@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuest_StreakPeriod(t *testing.T) {
+	daily := &Quest{Category: "daily", TimeZone: "America/New_York"}
+
+	// 23:30 and 00:30 local on consecutive days are consecutive periods
+	lateEvening := time.Date(2024, 3, 10, 3, 30, 0, 0, time.UTC) // 2024-03-09 22:30 EST
+	nextMorning := time.Date(2024, 3, 10, 5, 30, 0, 0, time.UTC) // 2024-03-10 01:30 EST
+	p1, err := daily.StreakPeriod(lateEvening)
+	require.NoError(t, err)
+	p2, err := daily.StreakPeriod(nextMorning)
+	require.NoError(t, err)
+	assert.Equal(t, p1+1, p2)
+
+	weekly := &Quest{Category: "weekly", TimeZone: "UTC"}
+	sunday, _ := weekly.StreakPeriod(time.Date(2024, 6, 9, 23, 0, 0, 0, time.UTC))
+	monday, _ := weekly.StreakPeriod(time.Date(2024, 6, 10, 1, 0, 0, 0, time.UTC))
+	nextSunday, _ := weekly.StreakPeriod(time.Date(2024, 6, 16, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, sunday+1, monday)
+	assert.Equal(t, monday, nextSunday)
+
+	_, err = (&Quest{TimeZone: "Not/AZone"}).StreakPeriod(time.Now())
+	assert.Error(t, err)
+}
+
+func TestQuestStreak_Advance(t *testing.T) {
+	t.Run("grows once per period", func(t *testing.T) {
+		s := &QuestStreak{}
+		assert.True(t, s.Advance(10, 0))
+		assert.False(t, s.Advance(10, 0))
+		assert.True(t, s.Advance(11, 0))
+		assert.True(t, s.Advance(12, 0))
+		assert.Equal(t, 3, s.Current)
+		assert.Equal(t, 3, s.Best)
+	})
+
+	t.Run("resets after a missed period", func(t *testing.T) {
+		s := &QuestStreak{Current: 5, Best: 7, LastPeriod: 10}
+		assert.True(t, s.Advance(12, 0))
+		assert.Equal(t, 1, s.Current)
+		assert.Equal(t, 7, s.Best)
+	})
+
+	t.Run("grace periods keep the streak alive", func(t *testing.T) {
+		s := &QuestStreak{Current: 5, Best: 5, LastPeriod: 10}
+		assert.True(t, s.Advance(12, 1))
+		assert.Equal(t, 6, s.Current)
+		assert.Equal(t, 6, s.Best)
+
+		assert.True(t, s.Advance(15, 1))
+		assert.Equal(t, 1, s.Current)
+	})
+
+	t.Run("current streak drops to zero once broken", func(t *testing.T) {
+		s := &QuestStreak{Current: 4, Best: 4, LastPeriod: 10}
+		assert.Equal(t, 4, s.CurrentAt(10, 0))
+		assert.Equal(t, 4, s.CurrentAt(11, 0))
+		assert.Equal(t, 0, s.CurrentAt(12, 0))
+		assert.Equal(t, 4, s.CurrentAt(12, 1))
+	})
+}
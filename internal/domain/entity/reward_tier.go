@@ -1,10 +1,16 @@
 package entity
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
-// RewardTier represents a discount tier that can be applied to shop items
+// RewardTier is a loyalty tier of a dungeon. Members reach it once they have
+// made MinPurchases purchases in the dungeon's shop and then get
+// DiscountPercent off there.
 type RewardTier struct {
 	ID              int64
+	DungeonID       string // Dungeon whose members can reach the tier
 	Name            string
 	Description     string
 	DiscountPercent float64 // Percentage discount (e.g. 10.0 for 10%)
@@ -12,3 +18,38 @@ type RewardTier struct {
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 }
+
+// Validate checks the tier can be offered to members
+func (t *RewardTier) Validate() error {
+	if t.Name == "" {
+		return ErrTierNameRequired
+	}
+	if t.DiscountPercent <= 0 || t.DiscountPercent > 100 {
+		return ErrInvalidTierDiscount
+	}
+	if t.MinPurchases < 0 {
+		return ErrInvalidTierThreshold
+	}
+	return nil
+}
+
+// TierFor returns the tier a member with purchaseCount purchases has reached,
+// or nil if they have not reached any. The tier with the highest threshold
+// wins; between tiers with the same threshold the bigger discount wins.
+func TierFor(tiers []*RewardTier, purchaseCount int) *RewardTier {
+	sorted := make([]*RewardTier, len(tiers))
+	copy(sorted, tiers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].MinPurchases != sorted[j].MinPurchases {
+			return sorted[i].MinPurchases > sorted[j].MinPurchases
+		}
+		return sorted[i].DiscountPercent > sorted[j].DiscountPercent
+	})
+
+	for _, tier := range sorted {
+		if tier.MinPurchases <= purchaseCount {
+			return tier
+		}
+	}
+	return nil
+}
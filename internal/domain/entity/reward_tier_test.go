@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTierFor(t *testing.T) {
+	bronze := &RewardTier{ID: 1, Name: "Bronze", MinPurchases: 3, DiscountPercent: 5}
+	silver := &RewardTier{ID: 2, Name: "Silver", MinPurchases: 10, DiscountPercent: 10}
+	silverPlus := &RewardTier{ID: 3, Name: "Silver+", MinPurchases: 10, DiscountPercent: 12}
+	tiers := []*RewardTier{silver, bronze, silverPlus}
+
+	assert.Nil(t, TierFor(tiers, 2))
+	assert.Equal(t, bronze, TierFor(tiers, 3))
+	assert.Equal(t, bronze, TierFor(tiers, 9))
+	assert.Equal(t, silverPlus, TierFor(tiers, 10), "same threshold goes to the bigger discount")
+	assert.Equal(t, silverPlus, TierFor(tiers, 100))
+	assert.Nil(t, TierFor(nil, 100))
+
+	// The input order is left alone
+	assert.Equal(t, []*RewardTier{silver, bronze, silverPlus}, tiers)
+}
+
+func TestRewardTier_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		tier RewardTier
+		err  error
+	}{
+		{"valid", RewardTier{Name: "Gold", DiscountPercent: 15, MinPurchases: 20}, nil},
+		{"zero threshold", RewardTier{Name: "Welcome", DiscountPercent: 1}, nil},
+		{"full discount", RewardTier{Name: "Free", DiscountPercent: 100, MinPurchases: 50}, nil},
+		{"missing name", RewardTier{DiscountPercent: 15, MinPurchases: 20}, ErrTierNameRequired},
+		{"zero discount", RewardTier{Name: "Gold", MinPurchases: 20}, ErrInvalidTierDiscount},
+		{"discount over 100", RewardTier{Name: "Gold", DiscountPercent: 101, MinPurchases: 20}, ErrInvalidTierDiscount},
+		{"negative threshold", RewardTier{Name: "Gold", DiscountPercent: 15, MinPurchases: -1}, ErrInvalidTierThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.tier.Validate())
+		})
+	}
+}
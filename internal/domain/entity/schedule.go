@@ -1,9 +1,27 @@
 package entity
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+// Schedule types
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
 )
 
+// scheduleScanDays bounds the search for the next matching calendar day. Four
+// years plus a day is enough to reach any valid day of month, including Feb 29.
+const scheduleScanDays = 4*366 + 1
+
+// scheduleCatchUpLimit caps how many overdue occurrences are materialized when a
+// schedule has not been advanced for a long time; older ones are dropped.
+const scheduleCatchUpLimit = 100
+
 // Schedule defines when and how often a task should recur
 type Schedule struct {
 	ID           string
@@ -29,3 +47,242 @@ type ScheduleOccurrence struct {
 	Skipped      bool
 	Missed       bool
 }
+
+// NewQuestSchedule builds the schedule of a recurring quest. Daily quests recur
+// at the start of every day and weekly quests at the start of every Monday, both
+// in the quest's timezone, beginning with the day containing now.
+func NewQuestSchedule(id string, quest *Quest, now time.Time) (*Schedule, error) {
+	window, err := valueobject.NewDayWindow(now, quest.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &Schedule{
+		ID:        id,
+		TaskID:    quest.ID,
+		StartDate: window.Start,
+		TimeOfDay: "00:00",
+		Timezone:  window.Start.Location().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch quest.Category {
+	case "daily":
+		schedule.Type = ScheduleDaily
+	case "weekly":
+		schedule.Type = ScheduleWeekly
+		schedule.DaysOfWeek = []int{int(time.Monday)}
+	default:
+		return nil, fmt.Errorf("%w: quest category %q does not recur", ErrInvalidSchedule, quest.Category)
+	}
+
+	return schedule, nil
+}
+
+// Validate checks that the schedule can produce occurrences
+func (s *Schedule) Validate() error {
+	switch s.Type {
+	case ScheduleDaily, ScheduleWeekly, ScheduleMonthly:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidSchedule, s.Type)
+	}
+	if s.StartDate.IsZero() {
+		return fmt.Errorf("%w: start date is required", ErrInvalidSchedule)
+	}
+	if _, _, err := s.clock(); err != nil {
+		return err
+	}
+	if _, err := s.location(); err != nil {
+		return err
+	}
+	for _, day := range s.DaysOfWeek {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("%w: day of week %d out of range", ErrInvalidSchedule, day)
+		}
+	}
+	for _, day := range s.DaysOfMonth {
+		if day < 1 || day > 31 {
+			return fmt.Errorf("%w: day of month %d out of range", ErrInvalidSchedule, day)
+		}
+	}
+	return nil
+}
+
+// NextOccurrence returns the first occurrence strictly after t. Occurrences fall
+// on TimeOfDay local time in the schedule's Timezone, so they keep their wall
+// clock time across DST changes and each matching day occurs exactly once; a time
+// skipped by a DST gap is moved forward by the length of the gap. Months without
+// one of the DaysOfMonth are skipped. It reports false once the schedule has ended.
+func (s *Schedule) NextOccurrence(t time.Time) (time.Time, bool, error) {
+	if err := s.Validate(); err != nil {
+		return time.Time{}, false, err
+	}
+	loc, _ := s.location()
+	hour, minute, _ := s.clock()
+
+	// Occurrences at the start date itself count
+	from := t
+	if s.StartDate.After(from) {
+		from = s.StartDate.Add(-time.Nanosecond)
+	}
+
+	year, month, day := from.In(loc).Date()
+	for i := 0; i < scheduleScanDays; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, time.UTC)
+		if !s.matches(date, loc) {
+			continue
+		}
+
+		candidate := wallClock(date, hour, minute, loc)
+		if !candidate.After(from) {
+			continue
+		}
+		if s.EndDate != nil && candidate.After(*s.EndDate) {
+			return time.Time{}, false, nil
+		}
+		return candidate, true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
+// ScheduleAdvance describes how to bring a schedule's persisted occurrences up to date
+type ScheduleAdvance struct {
+	New     []time.Time // Occurrence times to persist, oldest first
+	Current time.Time   // Latest occurrence due by now; open occurrences before it are closed
+	NextRun *time.Time  // When the schedule next needs advancing, nil once it has ended
+}
+
+// Advance computes the occurrences to persist after latest, the most recently
+// persisted occurrence (nil if there is none yet). It returns every occurrence
+// due by now, up to scheduleCatchUpLimit of the most recent ones, followed by the
+// first upcoming occurrence.
+func (s *Schedule) Advance(latest *time.Time, now time.Time) (ScheduleAdvance, error) {
+	var adv ScheduleAdvance
+	if latest != nil && latest.After(now) {
+		next := *latest
+		adv.NextRun = &next
+		return adv, nil
+	}
+
+	var after time.Time
+	if latest != nil {
+		after = *latest
+		adv.Current = *latest
+	}
+
+	var due []time.Time
+	for {
+		next, ok, err := s.NextOccurrence(after)
+		if err != nil {
+			return ScheduleAdvance{}, err
+		}
+		if !ok {
+			break
+		}
+		if next.After(now) {
+			adv.NextRun = &next
+			break
+		}
+		due = append(due, next)
+		after = next
+	}
+
+	if len(due) > scheduleCatchUpLimit {
+		due = due[len(due)-scheduleCatchUpLimit:]
+	}
+	if len(due) > 0 {
+		adv.Current = due[len(due)-1]
+	}
+
+	adv.New = due
+	if adv.NextRun != nil {
+		adv.New = append(adv.New, *adv.NextRun)
+	}
+	return adv, nil
+}
+
+// IsOpen reports whether the occurrence is neither completed nor closed out
+func (o *ScheduleOccurrence) IsOpen() bool {
+	return o.CompletedAt == nil && !o.Skipped && !o.Missed
+}
+
+// Close marks an open occurrence whose time has passed as skipped or missed
+func (o *ScheduleOccurrence) Close(skipIfMissed bool) {
+	if !o.IsOpen() {
+		return
+	}
+	if skipIfMissed {
+		o.Skipped = true
+	} else {
+		o.Missed = true
+	}
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return loc, nil
+}
+
+func (s *Schedule) clock() (int, int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(s.TimeOfDay, "%02d:%02d", &hour, &minute); err != nil || len(s.TimeOfDay) != 5 {
+		return 0, 0, fmt.Errorf("%w: time of day %q is not HH:MM", ErrInvalidSchedule, s.TimeOfDay)
+	}
+	if hour > 23 || minute > 59 || hour < 0 || minute < 0 {
+		return 0, 0, fmt.Errorf("%w: time of day %q is out of range", ErrInvalidSchedule, s.TimeOfDay)
+	}
+	return hour, minute, nil
+}
+
+// matches reports whether the calendar date (in UTC) is a day the schedule occurs on
+func (s *Schedule) matches(date time.Time, loc *time.Location) bool {
+	start := s.StartDate.In(loc)
+	switch s.Type {
+	case ScheduleWeekly:
+		days := s.DaysOfWeek
+		if len(days) == 0 {
+			days = []int{int(start.Weekday())}
+		}
+		return containsInt(days, int(date.Weekday()))
+	case ScheduleMonthly:
+		days := s.DaysOfMonth
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		return containsInt(days, date.Day())
+	default:
+		return true
+	}
+}
+
+// wallClock returns hour:minute local time on the calendar date. A wall clock
+// time that does not exist because of a DST gap is moved forward by the gap.
+func wallClock(date time.Time, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+	if local := t.In(loc); local.Hour() == hour && local.Minute() == minute {
+		return t
+	}
+
+	// time.Date resolved the missing time with the offset from after the gap,
+	// which lands before it; shift by the offset change to land after it
+	_, before := t.Zone()
+	_, after := t.Add(12 * time.Hour).Zone()
+	return t.Add(time.Duration(after-before) * time.Second)
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func collectOccurrences(t *testing.T, s *Schedule, from time.Time, n int) []time.Time {
+	t.Helper()
+	var out []time.Time
+	for len(out) < n {
+		next, ok, err := s.NextOccurrence(from)
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		out = append(out, next)
+		from = next
+	}
+	return out
+}
+
+func TestSchedule_NextOccurrence(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+
+	t.Run("daily keeps wall clock time across spring forward", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleDaily, TimeOfDay: "09:00", Timezone: "America/New_York",
+			StartDate: time.Date(2024, 3, 9, 0, 0, 0, 0, ny)}
+		got := collectOccurrences(t, s, s.StartDate, 3)
+		require.Len(t, got, 3)
+		for i, occ := range got {
+			local := occ.In(ny)
+			assert.Equal(t, 9+i, local.Day())
+			assert.Equal(t, 9, local.Hour())
+		}
+		assert.Equal(t, 23*time.Hour, got[1].Sub(got[0]))
+	})
+
+	t.Run("time inside a DST gap moves forward", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleDaily, TimeOfDay: "02:30", Timezone: "America/New_York",
+			StartDate: time.Date(2024, 3, 10, 0, 0, 0, 0, ny)}
+		next, ok, err := s.NextOccurrence(s.StartDate)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, 10, next.In(ny).Day())
+		assert.Equal(t, 3, next.In(ny).Hour())
+	})
+
+	t.Run("half hour DST gap", func(t *testing.T) {
+		lordHowe := mustLoad(t, "Australia/Lord_Howe")
+		s := &Schedule{Type: ScheduleDaily, TimeOfDay: "02:15", Timezone: "Australia/Lord_Howe",
+			StartDate: time.Date(2024, 10, 6, 0, 0, 0, 0, lordHowe)}
+		next, ok, err := s.NextOccurrence(s.StartDate)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, 2, next.In(lordHowe).Hour())
+		assert.Equal(t, 45, next.In(lordHowe).Minute())
+	})
+
+	t.Run("repeated hour occurs once on fall back", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleDaily, TimeOfDay: "01:30", Timezone: "America/New_York",
+			StartDate: time.Date(2024, 11, 2, 12, 0, 0, 0, ny)}
+		got := collectOccurrences(t, s, s.StartDate, 2)
+		require.Len(t, got, 2)
+		assert.Equal(t, 3, got[0].In(ny).Day())
+		assert.Equal(t, 4, got[1].In(ny).Day())
+	})
+
+	t.Run("weekly on selected days", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleWeekly, TimeOfDay: "18:00", Timezone: "UTC",
+			DaysOfWeek: []int{int(time.Monday), int(time.Thursday)},
+			StartDate:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)} // Saturday
+		got := collectOccurrences(t, s, s.StartDate, 3)
+		require.Len(t, got, 3)
+		assert.Equal(t, time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC), got[0])
+		assert.Equal(t, time.Date(2024, 6, 6, 18, 0, 0, 0, time.UTC), got[1])
+		assert.Equal(t, time.Date(2024, 6, 10, 18, 0, 0, 0, time.UTC), got[2])
+	})
+
+	t.Run("monthly skips months without the day", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleMonthly, TimeOfDay: "08:00", Timezone: "UTC",
+			DaysOfMonth: []int{31},
+			StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		got := collectOccurrences(t, s, s.StartDate, 3)
+		require.Len(t, got, 3)
+		assert.Equal(t, time.January, got[0].Month())
+		assert.Equal(t, time.March, got[1].Month())
+		assert.Equal(t, time.May, got[2].Month())
+	})
+
+	t.Run("monthly defaults to the start day", func(t *testing.T) {
+		s := &Schedule{Type: ScheduleMonthly, TimeOfDay: "08:00",
+			StartDate: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}
+		next, ok, err := s.NextOccurrence(time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("ends at end date", func(t *testing.T) {
+		end := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+		s := &Schedule{Type: ScheduleDaily, TimeOfDay: "10:00", EndDate: &end,
+			StartDate: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+		got := collectOccurrences(t, s, s.StartDate, 5)
+		assert.Len(t, got, 2)
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		for _, s := range []*Schedule{
+			{Type: "hourly", TimeOfDay: "10:00", StartDate: start},
+			{Type: ScheduleDaily, TimeOfDay: "25:00", StartDate: start},
+			{Type: ScheduleDaily, TimeOfDay: "9am", StartDate: start},
+			{Type: ScheduleDaily, TimeOfDay: "10:00"},
+			{Type: ScheduleDaily, TimeOfDay: "10:00", StartDate: start, Timezone: "Nowhere/Land"},
+			{Type: ScheduleWeekly, TimeOfDay: "10:00", StartDate: start, DaysOfWeek: []int{7}},
+		} {
+			_, _, err := s.NextOccurrence(start)
+			assert.True(t, errors.Is(err, ErrInvalidSchedule), "%+v", s)
+		}
+	})
+}
+
+func TestSchedule_Advance(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := &Schedule{Type: ScheduleDaily, TimeOfDay: "09:00", StartDate: start}
+
+	t.Run("first advance persists the current and upcoming occurrence", func(t *testing.T) {
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		adv, err := s.Advance(nil, now)
+		require.NoError(t, err)
+		require.Len(t, adv.New, 2)
+		assert.Equal(t, time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), adv.Current)
+		assert.Equal(t, time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC), *adv.NextRun)
+	})
+
+	t.Run("nothing to do before the upcoming occurrence", func(t *testing.T) {
+		latest := time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC)
+		adv, err := s.Advance(&latest, time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, adv.New)
+		assert.True(t, adv.Current.IsZero())
+		assert.Equal(t, latest, *adv.NextRun)
+	})
+
+	t.Run("catches up after downtime", func(t *testing.T) {
+		latest := time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC)
+		adv, err := s.Advance(&latest, time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, adv.New, 4) // 3rd, 4th, 5th and the upcoming 6th
+		assert.Equal(t, time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC), adv.Current)
+		assert.Equal(t, time.Date(2024, 6, 6, 9, 0, 0, 0, time.UTC), *adv.NextRun)
+	})
+
+	t.Run("catch up is bounded", func(t *testing.T) {
+		latest := time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC)
+		adv, err := s.Advance(&latest, latest.AddDate(2, 0, 0))
+		require.NoError(t, err)
+		assert.Len(t, adv.New, scheduleCatchUpLimit+1)
+	})
+
+	t.Run("ended schedule has no next run", func(t *testing.T) {
+		end := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+		ending := *s
+		ending.EndDate = &end
+		adv, err := ending.Advance(nil, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Len(t, adv.New, 2)
+		assert.Nil(t, adv.NextRun)
+	})
+}
+
+func TestScheduleOccurrence_Close(t *testing.T) {
+	skipped := &ScheduleOccurrence{}
+	skipped.Close(true)
+	assert.True(t, skipped.Skipped)
+	assert.False(t, skipped.Missed)
+
+	missed := &ScheduleOccurrence{}
+	missed.Close(false)
+	assert.True(t, missed.Missed)
+
+	now := time.Now()
+	done := &ScheduleOccurrence{CompletedAt: &now}
+	done.Close(false)
+	assert.False(t, done.Missed)
+}
+
+func TestNewQuestSchedule(t *testing.T) {
+	now := time.Date(2024, 6, 5, 15, 0, 0, 0, time.UTC)
+
+	daily, err := NewQuestSchedule("s1", &Quest{ID: "q1", Category: "daily", TimeZone: "Europe/Berlin"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleDaily, daily.Type)
+	assert.Equal(t, "Europe/Berlin", daily.Timezone)
+	assert.Equal(t, time.Date(2024, 6, 5, 0, 0, 0, 0, mustLoad(t, "Europe/Berlin")).Unix(), daily.StartDate.Unix())
+
+	weekly, err := NewQuestSchedule("s2", &Quest{ID: "q2", Category: "weekly"}, now)
+	require.NoError(t, err)
+	next, ok, err := weekly.NextOccurrence(now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), next)
+
+	_, err = NewQuestSchedule("s3", &Quest{ID: "q3", Category: "adhoc"}, now)
+	assert.ErrorIs(t, err, ErrInvalidSchedule)
+}
@@ -9,20 +9,61 @@ import (
 // ShopItem represents an item that can be purchased in the shop
 type ShopItem struct {
 	ID             int64
-	ChatID         int64   // 0 for global items, specific chat ID for chat-specific items
-	DungeonID      *string // nil for global items, specific dungeon ID for dungeon-specific items
-	Code           string  // Unique code for the item
+	ChatID         int64   // Legacy chat scope; shops are keyed by DungeonID
+	DungeonID      *string // Dungeon whose shop lists the item
+	Code           string  // Unique within the dungeon's shop
 	Name           string
 	Description    string
 	Price          valueobject.Decimal
 	Category       string // e.g., "rewards", "boosts", "cosmetics"
 	IsActive       bool
-	Stock          *int   // nil for unlimited stock, number for limited stock
-	DiscountTierID *int64 // Discount tier applied (if any)
+	Stock          *int                 // nil for unlimited stock, number for limited stock
+	DiscountTierID *int64               // Discount tier applied (if any)
+	SalePrice      *valueobject.Decimal // Unit price while a sale runs; nil when there is no sale
+	SaleEndsAt     *time.Time           // End of the sale; nil runs it until SalePrice is cleared
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
 
+// OnSale reports whether the item's sale price applies at the given time
+func (i *ShopItem) OnSale(at time.Time) bool {
+	return i.SalePrice != nil && (i.SaleEndsAt == nil || at.Before(*i.SaleEndsAt))
+}
+
+// Validate checks the item can be listed in a shop
+func (i *ShopItem) Validate() error {
+	if i.Code == "" {
+		return ErrItemCodeRequired
+	}
+	if i.Name == "" {
+		return ErrItemNameRequired
+	}
+	if i.Price.IsNegative() {
+		return ErrInvalidItemPrice
+	}
+	if i.SalePrice != nil && i.SalePrice.IsNegative() {
+		return ErrInvalidSalePrice
+	}
+	if i.Stock != nil && *i.Stock < 0 {
+		return ErrInvalidItemStock
+	}
+	return nil
+}
+
+// Restock adds quantity units to a limited stock
+func (i *ShopItem) Restock(quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidRestock
+	}
+	if i.Stock == nil {
+		return ErrItemStockUnlimited
+	}
+
+	stock := *i.Stock + quantity
+	i.Stock = &stock
+	return nil
+}
+
 // Purchase represents a user's purchase of a shop item
 type Purchase struct {
 	ID             int64
@@ -32,8 +73,69 @@ type Purchase struct {
 	ItemName       string              // Denormalized for history
 	ItemPrice      valueobject.Decimal // Price at time of purchase
 	Quantity       int
-	TotalCost      valueobject.Decimal
+	Subtotal       valueobject.Decimal // ItemPrice * Quantity, before any pricing rule
+	TotalCost      valueobject.Decimal // Subtotal plus every adjustment in PriceBreakdown
+	PriceBreakdown []PriceAdjustment
 	Status         string // "pending", "completed", "refunded"
 	DiscountTierID *int64 // Discount tier applied (if any)
 	PurchasedAt    time.Time
+
+	RefundedQuantity int                 // Units refunded so far; Status becomes "refunded" once all are
+	RefundedAmount   valueobject.Decimal // Points credited back so far
+	RefundedAt       *time.Time          // Time of the latest refund
+}
+
+// PriceAdjustment is one pricing rule's change to the price of a purchase
+type PriceAdjustment struct {
+	Rule        string              `json:"rule"`        // e.g. "discount_tier", "sale"
+	Description string              `json:"description"` // Human readable, e.g. "Summer sale"
+	Amount      valueobject.Decimal `json:"amount"`      // Negative for discounts
+}
+
+// amountScale is the number of decimal places amounts are stored with
+const amountScale = 8
+
+// Purchase statuses
+const (
+	PurchaseStatusPending   = "pending"
+	PurchaseStatusCompleted = "completed"
+	PurchaseStatusRefunded  = "refunded"
+)
+
+// RefundableQuantity returns how many units can still be refunded
+func (p *Purchase) RefundableQuantity() int {
+	if p.Status != PurchaseStatusCompleted {
+		return 0
+	}
+	return p.Quantity - p.RefundedQuantity
+}
+
+// Refund marks quantity more units as refunded and returns the amount to credit
+// back. Each unit is refunded at its share of TotalCost, so discounts are kept;
+// the last unit refunds whatever is left so the refunds add up to TotalCost.
+func (p *Purchase) Refund(quantity int, now time.Time) (valueobject.Decimal, error) {
+	refundable := p.RefundableQuantity()
+	if refundable == 0 {
+		return valueobject.Decimal{}, ErrPurchaseNotRefundable
+	}
+	if quantity <= 0 || quantity > refundable {
+		return valueobject.Decimal{}, ErrInvalidRefundQuantity
+	}
+
+	var amount valueobject.Decimal
+	if quantity == refundable {
+		amount = p.TotalCost.Sub(p.RefundedAmount)
+	} else {
+		perUnit := p.TotalCost.Div(valueobject.NewDecimalFromInt(int64(p.Quantity)))
+		amount = perUnit.Mul(valueobject.NewDecimalFromInt(int64(quantity))).Round(amountScale)
+	}
+
+	p.RefundedQuantity += quantity
+	p.RefundedAmount = p.RefundedAmount.Add(amount)
+	p.RefundedAt = &now
+	if p.RefundedQuantity == p.Quantity {
+		p.Status = PurchaseStatusRefunded
+	}
+
+	return amount, nil
 }
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+func TestPurchase_Refund(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("partial refunds add up to the total cost", func(t *testing.T) {
+		// Three units bought at a discount for 10 points in total
+		purchase := &Purchase{Quantity: 3, TotalCost: valueobject.NewDecimal("10"), Status: PurchaseStatusCompleted}
+
+		amount, err := purchase.Refund(1, now)
+		require.NoError(t, err)
+		assert.Equal(t, "3.33333333", amount.String())
+		assert.Equal(t, PurchaseStatusCompleted, purchase.Status)
+		assert.Equal(t, 2, purchase.RefundableQuantity())
+
+		amount, err = purchase.Refund(2, now)
+		require.NoError(t, err)
+		assert.Equal(t, "6.66666667", amount.String())
+		assert.Equal(t, PurchaseStatusRefunded, purchase.Status)
+		assert.Equal(t, "10", purchase.RefundedAmount.String())
+		assert.Equal(t, now, *purchase.RefundedAt)
+
+		_, err = purchase.Refund(1, now)
+		assert.ErrorIs(t, err, ErrPurchaseNotRefundable)
+	})
+
+	t.Run("invalid quantities", func(t *testing.T) {
+		purchase := &Purchase{Quantity: 2, TotalCost: valueobject.NewDecimal("4"), Status: PurchaseStatusCompleted}
+
+		_, err := purchase.Refund(0, now)
+		assert.ErrorIs(t, err, ErrInvalidRefundQuantity)
+		_, err = purchase.Refund(3, now)
+		assert.ErrorIs(t, err, ErrInvalidRefundQuantity)
+		assert.Equal(t, 0, purchase.RefundedQuantity)
+	})
+
+	t.Run("only completed purchases are refundable", func(t *testing.T) {
+		purchase := &Purchase{Quantity: 1, TotalCost: valueobject.NewDecimal("4"), Status: PurchaseStatusPending}
+
+		_, err := purchase.Refund(1, now)
+		assert.ErrorIs(t, err, ErrPurchaseNotRefundable)
+	})
+}
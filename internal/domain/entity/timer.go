@@ -14,18 +14,21 @@ type Timer struct {
 	Type           string     // "countdown" or "stopwatch"
 	StartTime      time.Time  // When the timer was started
 	Duration       int        // Duration in seconds (for countdown)
-	CurrentValue   int        // Current value in seconds
+	CurrentValue   int        // Seconds elapsed up to LastTick, excluding pauses
 	LastTick       *time.Time // Last time the timer was updated
-	Status         string     // "running", "paused", "completed"
+	Status         string     // "running", "paused", "completed", "cancelled"
 	Timezone       string     // IANA timezone for scheduling
 	NotificationID *string    // ID of scheduled notification
+
+	NotificationClaimedAt *time.Time // When the pending expiry notification was last claimed for sending
+	NotificationAttempts  int        // Expiry notifications tried since the countdown last expired
 }
 
 // TimerEvent represents a state change in a timer
 type TimerEvent struct {
 	ID        string
 	TimerID   string
-	EventType string // "start", "pause", "resume", "complete", "cancel"
+	EventType string // "start", "pause", "resume", "complete", "cancel", "extend", "expire"
 	Timestamp time.Time
 	Metadata  map[string]interface{}
 }
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"time"
+)
+
+// Timer types
+const (
+	TimerTypeCountdown = "countdown"
+	TimerTypeStopwatch = "stopwatch"
+)
+
+// Timer statuses
+const (
+	TimerStatusRunning   = "running"
+	TimerStatusPaused    = "paused"
+	TimerStatusCompleted = "completed"
+	TimerStatusCancelled = "cancelled"
+)
+
+// Timer event types
+const (
+	TimerEventStart    = "start"
+	TimerEventPause    = "pause"
+	TimerEventResume   = "resume"
+	TimerEventComplete = "complete"
+	TimerEventCancel   = "cancel"
+	TimerEventExtend   = "extend"
+	TimerEventExpire   = "expire"
+)
+
+// NewTimer validates the timer type and duration and returns a running timer
+// started at now. Countdowns need a positive Duration; stopwatches ignore it.
+func NewTimer(id string, userID int64, taskID, timerType string, durationSec int, timezone string, now time.Time) (*Timer, error) {
+	switch timerType {
+	case TimerTypeCountdown:
+		if durationSec <= 0 {
+			return nil, ErrInvalidTimerDuration
+		}
+	case TimerTypeStopwatch:
+		durationSec = 0
+	default:
+		return nil, ErrInvalidTimerType
+	}
+
+	return &Timer{
+		ID:        id,
+		TaskID:    taskID,
+		UserID:    userID,
+		Type:      timerType,
+		StartTime: now,
+		Duration:  durationSec,
+		LastTick:  &now,
+		Status:    TimerStatusRunning,
+		Timezone:  timezone,
+	}, nil
+}
+
+// IsActive reports whether the timer is running or paused
+func (t *Timer) IsActive() bool {
+	return t.Status == TimerStatusRunning || t.Status == TimerStatusPaused
+}
+
+// Elapsed returns the time the timer has been running, excluding pauses.
+// CurrentValue holds the seconds accumulated up to LastTick.
+func (t *Timer) Elapsed(now time.Time) time.Duration {
+	elapsed := time.Duration(t.CurrentValue) * time.Second
+	if t.Status == TimerStatusRunning && t.LastTick != nil && now.After(*t.LastTick) {
+		elapsed += now.Sub(*t.LastTick)
+	}
+	return elapsed
+}
+
+// Remaining returns how much of a countdown is left, never less than zero
+func (t *Timer) Remaining(now time.Time) time.Duration {
+	remaining := time.Duration(t.Duration)*time.Second - t.Elapsed(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// ExpiresAt returns when a running countdown reaches zero, or nil for stopwatches
+// and timers that are not running
+func (t *Timer) ExpiresAt(now time.Time) *time.Time {
+	if t.Type != TimerTypeCountdown || t.Status != TimerStatusRunning {
+		return nil
+	}
+	expiresAt := now.Add(t.Remaining(now))
+	return &expiresAt
+}
+
+// IsExpired reports whether a running countdown has reached zero
+func (t *Timer) IsExpired(now time.Time) bool {
+	return t.Type == TimerTypeCountdown && t.Status == TimerStatusRunning && t.Remaining(now) == 0
+}
+
+// Extend gives an active countdown extra time. An expired countdown gets the
+// extra time counted from now, and a new expiry notification will be due.
+func (t *Timer) Extend(now time.Time, extra time.Duration) error {
+	if t.Type != TimerTypeCountdown || !t.IsActive() || extra <= 0 {
+		return ErrInvalidTimerTransition
+	}
+	if elapsed := int(t.Elapsed(now) / time.Second); t.Duration < elapsed {
+		t.Duration = elapsed
+	}
+	t.Duration += int(extra / time.Second)
+	t.NotificationID = nil
+	t.NotificationClaimedAt = nil
+	t.NotificationAttempts = 0
+	return nil
+}
+
+// Pause stops the clock, keeping the elapsed time
+func (t *Timer) Pause(now time.Time) error {
+	if t.Status != TimerStatusRunning {
+		return ErrInvalidTimerTransition
+	}
+	t.tick(now)
+	t.Status = TimerStatusPaused
+	return nil
+}
+
+// Resume restarts a paused timer
+func (t *Timer) Resume(now time.Time) error {
+	if t.Status != TimerStatusPaused {
+		return ErrInvalidTimerTransition
+	}
+	t.LastTick = &now
+	t.Status = TimerStatusRunning
+	return nil
+}
+
+// Complete finishes an active timer
+func (t *Timer) Complete(now time.Time) error {
+	if !t.IsActive() {
+		return ErrInvalidTimerTransition
+	}
+	t.tick(now)
+	t.Status = TimerStatusCompleted
+	return nil
+}
+
+// Cancel abandons an active timer
+func (t *Timer) Cancel(now time.Time) error {
+	if !t.IsActive() {
+		return ErrInvalidTimerTransition
+	}
+	t.tick(now)
+	t.Status = TimerStatusCancelled
+	return nil
+}
+
+// tick folds the running time since LastTick into CurrentValue
+func (t *Timer) tick(now time.Time) {
+	t.CurrentValue = int(t.Elapsed(now) / time.Second)
+	t.LastTick = &now
+}
@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTimer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	countdown, err := NewTimer("t1", 1, "quest-1", TimerTypeCountdown, 1500, "UTC", now)
+	require.NoError(t, err)
+	assert.Equal(t, TimerStatusRunning, countdown.Status)
+	assert.Equal(t, now, *countdown.LastTick)
+
+	stopwatch, err := NewTimer("t2", 1, "quest-1", TimerTypeStopwatch, 99, "UTC", now)
+	require.NoError(t, err)
+	assert.Zero(t, stopwatch.Duration)
+
+	_, err = NewTimer("t3", 1, "quest-1", TimerTypeCountdown, 0, "UTC", now)
+	assert.ErrorIs(t, err, ErrInvalidTimerDuration)
+
+	_, err = NewTimer("t4", 1, "quest-1", "hourglass", 60, "UTC", now)
+	assert.ErrorIs(t, err, ErrInvalidTimerType)
+}
+
+func TestTimer_Lifecycle(t *testing.T) {
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	timer, err := NewTimer("t1", 1, "quest-1", TimerTypeCountdown, 25*60, "UTC", start)
+	require.NoError(t, err)
+
+	// Run 10 minutes, pause for 5, run 5 more
+	require.NoError(t, timer.Pause(start.Add(10*time.Minute)))
+	assert.Equal(t, 10*time.Minute, timer.Elapsed(start.Add(15*time.Minute)))
+	assert.Nil(t, timer.ExpiresAt(start.Add(15*time.Minute)))
+	assert.ErrorIs(t, timer.Pause(start.Add(15*time.Minute)), ErrInvalidTimerTransition)
+
+	require.NoError(t, timer.Resume(start.Add(15*time.Minute)))
+	assert.ErrorIs(t, timer.Resume(start.Add(15*time.Minute)), ErrInvalidTimerTransition)
+	assert.Equal(t, 15*time.Minute, timer.Elapsed(start.Add(20*time.Minute)))
+	assert.Equal(t, 10*time.Minute, timer.Remaining(start.Add(20*time.Minute)))
+	assert.Equal(t, start.Add(30*time.Minute), *timer.ExpiresAt(start.Add(20 * time.Minute)))
+	assert.Zero(t, timer.Remaining(start.Add(2*time.Hour)))
+
+	require.NoError(t, timer.Complete(start.Add(20*time.Minute)))
+	assert.Equal(t, TimerStatusCompleted, timer.Status)
+	assert.Equal(t, 15*60, timer.CurrentValue)
+	assert.Equal(t, 15*time.Minute, timer.Elapsed(start.Add(time.Hour)))
+	assert.ErrorIs(t, timer.Cancel(start.Add(time.Hour)), ErrInvalidTimerTransition)
+}
+
+func TestTimer_CancelPaused(t *testing.T) {
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	timer, err := NewTimer("t1", 1, "quest-1", TimerTypeStopwatch, 0, "UTC", start)
+	require.NoError(t, err)
+
+	require.NoError(t, timer.Pause(start.Add(time.Minute)))
+	require.NoError(t, timer.Cancel(start.Add(time.Hour)))
+	assert.Equal(t, TimerStatusCancelled, timer.Status)
+	assert.Equal(t, time.Minute, timer.Elapsed(start.Add(time.Hour)))
+	assert.False(t, timer.IsActive())
+	assert.ErrorIs(t, timer.Complete(start.Add(time.Hour)), ErrInvalidTimerTransition)
+}
+
+func TestTimer_ExpiryAndExtend(t *testing.T) {
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	timer, err := NewTimer("t1", 1, "quest-1", TimerTypeCountdown, 60, "UTC", start)
+	require.NoError(t, err)
+
+	assert.False(t, timer.IsExpired(start.Add(59*time.Second)))
+	assert.True(t, timer.IsExpired(start.Add(3*time.Minute)))
+
+	notificationID := "1:2"
+	timer.NotificationID = &notificationID
+	require.NoError(t, timer.Extend(start.Add(3*time.Minute), 5*time.Minute))
+	assert.Nil(t, timer.NotificationID)
+	assert.Equal(t, 5*time.Minute, timer.Remaining(start.Add(3*time.Minute)))
+	assert.False(t, timer.IsExpired(start.Add(7*time.Minute)))
+	assert.True(t, timer.IsExpired(start.Add(8*time.Minute)))
+
+	stopwatch, err := NewTimer("t2", 1, "quest-1", TimerTypeStopwatch, 0, "UTC", start)
+	require.NoError(t, err)
+	assert.False(t, stopwatch.IsExpired(start.Add(24*time.Hour)))
+	assert.ErrorIs(t, stopwatch.Extend(start, time.Minute), ErrInvalidTimerTransition)
+}
@@ -8,10 +8,22 @@ import (
 
 type User struct {
 	ID        int64
-	ChatID    int64  // Chat/group this user belongs to
-	Username  string // User's display name
-	Balance   valueobject.Decimal
-	TimeZone  string // IANA timezone (e.g. "America/New_York")
+	ChatID    int64               // Chat/group this user belongs to
+	Username  string              // User's display name
+	Balance   valueobject.Decimal // Total over the user's wallets and points outside any dungeon
+	Role      string              // "admin" or "member"; empty means member
+	TimeZone  string              // IANA timezone (e.g. "America/New_York")
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// User roles
+const (
+	UserRoleAdmin  = "admin"
+	UserRoleMember = "member"
+)
+
+// IsAdmin reports whether the user administers their chat
+func (u *User) IsAdmin() bool {
+	return u.Role == UserRoleAdmin
+}
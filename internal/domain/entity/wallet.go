@@ -0,0 +1,11 @@
+package entity
+
+import "github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+
+// Wallet holds a user's points in one dungeon. Balance is a cached sum of the
+// user's ledger entries in that dungeon.
+type Wallet struct {
+	UserID    int64
+	DungeonID string
+	Balance   valueobject.Decimal
+}
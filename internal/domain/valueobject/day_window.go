@@ -0,0 +1,90 @@
+package valueobject
+
+import "time"
+
+// DayWindow is the half-open interval [Start, End) covering one calendar day in
+// an IANA timezone. Days that contain a DST transition are 23 or 25 hours long
+// (or otherwise shifted by the zone's DST offset), so the window must never be
+// derived by adding 24 hours to a start instant.
+type DayWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewDayWindow returns the window of the calendar day containing t in timezone tz.
+// An empty tz means UTC. Start and End are expressed in the given location.
+func NewDayWindow(t time.Time, tz string) (DayWindow, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return DayWindow{}, err
+	}
+
+	local := t.In(loc)
+	year, month, day := local.Date()
+
+	return DayWindow{
+		Start: startOfDay(year, month, day, loc),
+		End:   startOfDay(year, month, day+1, loc),
+	}, nil
+}
+
+// Contains reports whether t falls inside the window.
+func (w DayWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Duration returns the real elapsed length of the day.
+func (w DayWindow) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// DayNumber returns the number of calendar days between 1970-01-01 and the
+// window's local date. Consecutive local days always differ by exactly one,
+// regardless of how long each day actually is.
+func (w DayWindow) DayNumber() int64 {
+	year, month, day := w.Start.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// Next returns the window of the following calendar day.
+func (w DayWindow) Next() DayWindow {
+	year, month, day := w.Start.Date()
+	return DayWindow{
+		Start: w.End,
+		End:   startOfDay(year, month, day+2, w.Start.Location()),
+	}
+}
+
+// startOfDay returns the first instant whose local date is year-month-day.
+// Midnight itself may be skipped (DST gap at 00:00) or occur twice (fall-back
+// across midnight), in which case time.Date does not guarantee which instant it
+// picks, so the result is corrected with a search around the candidate.
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	candidate := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	target := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	onOrAfterTarget := func(t time.Time) bool {
+		y, m, d := t.In(loc).Date()
+		return !time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Before(target)
+	}
+
+	if onOrAfterTarget(candidate) && !onOrAfterTarget(candidate.Add(-time.Nanosecond)) {
+		return candidate
+	}
+
+	// No DST offset in use today is larger than a few hours, so the true start
+	// of the day lies within this range.
+	lo := candidate.Add(-4 * time.Hour)
+	hi := candidate.Add(4 * time.Hour)
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if onOrAfterTarget(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	// Transitions happen on whole seconds, so hi is the exact start
+	return hi.Truncate(time.Second).In(loc)
+}
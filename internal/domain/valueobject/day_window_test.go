@@ -0,0 +1,48 @@
+package valueobject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		tz        string
+		at        time.Time
+		wantStart string
+		wantLen   time.Duration
+	}{
+		{"utc", "UTC", time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC), "2024-05-01T00:00:00Z", 24 * time.Hour},
+		{"empty tz is utc", "", time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC), "2024-05-01T00:00:00Z", 24 * time.Hour},
+		{"local day differs from utc day", "America/New_York", time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC), "2024-05-01T00:00:00-04:00", 24 * time.Hour},
+		{"spring forward", "America/New_York", time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), "2024-03-10T00:00:00-05:00", 23 * time.Hour},
+		{"fall back", "America/New_York", time.Date(2024, 11, 3, 12, 0, 0, 0, time.UTC), "2024-11-03T00:00:00-04:00", 25 * time.Hour},
+		{"europe spring forward", "Europe/Berlin", time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), "2024-03-31T00:00:00+01:00", 23 * time.Hour},
+		{"half hour dst", "Australia/Lord_Howe", time.Date(2024, 4, 7, 2, 0, 0, 0, time.UTC), "2024-04-07T00:00:00+11:00", 24*time.Hour + 30*time.Minute},
+		{"midnight skipped", "America/Santiago", time.Date(2024, 9, 8, 12, 0, 0, 0, time.UTC), "2024-09-08T01:00:00-03:00", 23 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewDayWindow(tt.at, tt.tz)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStart, w.Start.Format(time.RFC3339))
+			assert.Equal(t, tt.wantLen, w.Duration())
+			assert.True(t, w.Contains(tt.at))
+			assert.False(t, w.Contains(w.End))
+			assert.Equal(t, w.End, w.Next().Start)
+			assert.Equal(t, w.DayNumber()+1, w.Next().DayNumber())
+		})
+	}
+
+	epoch, err := NewDayWindow(time.Date(1970, 1, 1, 12, 0, 0, 0, time.UTC), "Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), epoch.DayNumber())
+
+	_, err = NewDayWindow(time.Now(), "Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
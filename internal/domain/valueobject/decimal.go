@@ -1,6 +1,8 @@
 package valueobject
 
 import (
+	"database/sql/driver"
+
 	"github.com/shopspring/decimal"
 )
 
@@ -20,6 +22,17 @@ func NewDecimal(s string) Decimal {
 	return Decimal{value: d}
 }
 
+// NewDecimalFromInt creates a new Decimal from an integer.
+func NewDecimalFromInt(i int64) Decimal {
+	return Decimal{value: decimal.NewFromInt(i)}
+}
+
+// NewDecimalFromFloat creates a new Decimal from a float64.
+// Note: The result is only as precise as the float it was built from.
+func NewDecimalFromFloat(f float64) Decimal {
+	return Decimal{value: decimal.NewFromFloat(f)}
+}
+
 // Add returns a new Decimal that is the sum of d and other.
 func (d Decimal) Add(other Decimal) Decimal {
 	return Decimal{value: d.value.Add(other.value)}
@@ -41,6 +54,11 @@ func (d Decimal) Div(other Decimal) Decimal {
 	return Decimal{value: d.value.Div(other.value).Round(16)}
 }
 
+// Round returns d rounded to the given number of decimal places.
+func (d Decimal) Round(places int32) Decimal {
+	return Decimal{value: d.value.Round(places)}
+}
+
 // Cmp compares d and other and returns:
 // -1 if d < other
 // 0 if d == other
@@ -85,3 +103,13 @@ func (d Decimal) MarshalJSON() ([]byte, error) {
 func (d *Decimal) UnmarshalJSON(data []byte) error {
 	return d.value.UnmarshalJSON(data)
 }
+
+// Value implements driver.Valuer so decimals can be stored in NUMERIC columns.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.value.String(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns.
+func (d *Decimal) Scan(src interface{}) error {
+	return d.value.Scan(src)
+}
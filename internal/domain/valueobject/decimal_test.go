@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecimal_New(t *testing.T) {
@@ -105,3 +106,22 @@ func TestDecimal_Sign(t *testing.T) {
 		})
 	}
 }
+
+func TestDecimal_Constructors(t *testing.T) {
+	assert.Equal(t, "42", NewDecimalFromInt(42).String())
+	assert.Equal(t, "-7", NewDecimalFromInt(-7).String())
+	assert.Equal(t, "0.75", NewDecimalFromFloat(0.75).String())
+	assert.Equal(t, 0, NewDecimalFromFloat(2.5).Cmp(NewDecimal("2.5")))
+}
+
+func TestDecimal_SQL(t *testing.T) {
+	value, err := NewDecimal("12.5").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "12.5", value)
+
+	for _, src := range []interface{}{[]byte("3.25"), "3.25", 3.25} {
+		var d Decimal
+		require.NoError(t, d.Scan(src))
+		assert.Equal(t, "3.25", d.String())
+	}
+}
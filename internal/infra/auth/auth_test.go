@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const botToken = "123456:test-token"
+
+// signWebApp builds initData the way Telegram does
+func signWebApp(t *testing.T, values url.Values) string {
+	t.Helper()
+	fields := make(map[string]string)
+	for key := range values {
+		fields[key] = values.Get(key)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(dataCheckString(fields)))
+
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values.Encode()
+}
+
+func TestVerifyWebAppInitData(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	values := url.Values{
+		"query_id":  {"AAH"},
+		"user":      {`{"id":42,"first_name":"Ada","username":"ada"}`},
+		"auth_date": {strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)},
+	}
+	initData := signWebApp(t, values)
+
+	user, err := VerifyWebAppInitData(initData, botToken, DefaultLoginMaxAge, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), user.ID)
+	assert.Equal(t, "ada", user.DisplayName())
+
+	_, err = VerifyWebAppInitData(initData, "654321:other-token", DefaultLoginMaxAge, now)
+	assert.ErrorIs(t, err, ErrLoginHash)
+
+	_, err = VerifyWebAppInitData(initData, botToken, DefaultLoginMaxAge, now.Add(24*time.Hour))
+	assert.ErrorIs(t, err, ErrLoginExpired)
+
+	tampered, _ := url.ParseQuery(initData)
+	tampered.Set("user", `{"id":43,"first_name":"Eve"}`)
+	_, err = VerifyWebAppInitData(tampered.Encode(), botToken, DefaultLoginMaxAge, now)
+	assert.ErrorIs(t, err, ErrLoginHash)
+
+	_, err = VerifyWebAppInitData("user=%7B%7D", botToken, DefaultLoginMaxAge, now)
+	assert.ErrorIs(t, err, ErrInvalidLogin)
+}
+
+func TestVerifyLoginWidget(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	fields := map[string]string{
+		"id":         "42",
+		"first_name": "Ada",
+		"auth_date":  strconv.FormatInt(now.Unix(), 10),
+	}
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte("auth_date=" + fields["auth_date"] + "\nfirst_name=Ada\nid=42"))
+	fields["hash"] = hex.EncodeToString(mac.Sum(nil))
+
+	user, err := VerifyLoginWidget(fields, botToken, DefaultLoginMaxAge, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), user.ID)
+	assert.Equal(t, "Ada", user.DisplayName())
+
+	fields["id"] = "43"
+	_, err = VerifyLoginWidget(fields, botToken, DefaultLoginMaxAge, now)
+	assert.ErrorIs(t, err, ErrLoginHash)
+}
+
+func TestSessions(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	sessions := NewSessions([]byte("secret"), time.Hour)
+
+	token, expiresAt := sessions.Issue(42, now)
+	assert.Equal(t, now.Add(time.Hour), expiresAt)
+
+	userID, err := sessions.Verify(token, now.Add(59*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), userID)
+
+	_, err = sessions.Verify(token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	_, err = NewSessions([]byte("other"), time.Hour).Verify(token, now)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+
+	for _, bad := range []string{"", "abc", token + "x", "e30." + token[len(token)-10:]} {
+		_, err = sessions.Verify(bad, now)
+		assert.ErrorIs(t, err, ErrInvalidSession, bad)
+	}
+}
@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// SystemClock implements ports.Clock with the real wall clock
+type SystemClock struct{}
+
+func NewSystemClock() *SystemClock {
+	return &SystemClock{}
+}
+
+func (c *SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (c *SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (c *SystemClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+// Compile-time check that SystemClock implements ports.Clock
+var _ ports.Clock = (*SystemClock)(nil)
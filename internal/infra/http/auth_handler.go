@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/auth"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// AuthConfig is what the server needs to log users in with Telegram
+type AuthConfig struct {
+	BotToken    string         // Token of the bot the logins are issued for
+	LoginMaxAge time.Duration  // How old a Telegram login may be
+	Sessions    *auth.Sessions // Issues the bearer tokens
+	Users       ports.UserRepository
+}
+
+// WebAppLoginRequest carries the initData of a Telegram Mini App
+type WebAppLoginRequest struct {
+	InitData string `json:"init_data"`
+}
+
+// SessionResponse represents the JSON response of a successful login
+type SessionResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	UserID    int64  `json:"user_id"`
+}
+
+func (s *Server) webAppLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req WebAppLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	user, err := auth.VerifyWebAppInitData(req.InitData, s.Auth.BotToken, s.Auth.LoginMaxAge, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	s.startSession(w, r, user)
+}
+
+// loginWidgetHandler accepts the object the Telegram Login Widget passes to
+// its onauth callback, posted as is
+func (s *Server) loginWidgetHandler(w http.ResponseWriter, r *http.Request) {
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// The hash covers the fields as Telegram sent them, so numbers are
+	// formatted back exactly as they were received
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		fields[key] = fmt.Sprint(value)
+	}
+
+	user, err := auth.VerifyLoginWidget(fields, s.Auth.BotToken, s.Auth.LoginMaxAge, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	s.startSession(w, r, user)
+}
+
+// startSession registers the Telegram user on first login and responds with a session token
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, tgUser *auth.TelegramUser) {
+	if err := s.ensureUser(r.Context(), tgUser); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, expiresAt := s.Auth.Sessions.Issue(tgUser.ID, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SessionResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		UserID:    tgUser.ID,
+	})
+}
+
+// ensureUser creates the user the way the bot's /start does
+func (s *Server) ensureUser(ctx context.Context, tgUser *auth.TelegramUser) error {
+	_, err := s.Auth.Users.FindByID(ctx, tgUser.ID)
+	if !errors.Is(err, ports.ErrUserNotFound) {
+		return err
+	}
+
+	err = s.Auth.Users.Create(ctx, &entity.User{
+		ID:       tgUser.ID,
+		ChatID:   tgUser.ID, // A user's private chat with the bot has their ID
+		Username: tgUser.DisplayName(),
+		Balance:  valueobject.NewDecimal("0.00"),
+		Role:     entity.UserRoleMember,
+		TimeZone: "UTC",
+	})
+	if errors.Is(err, ports.ErrUserAlreadyExists) {
+		// Registered concurrently, e.g. by the bot
+		return nil
+	}
+	return err
+}
+
+// authenticate rejects requests without a valid session token and puts the
+// user it was issued to into the request context
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Authorization header with a bearer token is required", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := s.Auth.Sessions.Verify(token, time.Now())
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.ContextWithUserID(r.Context(), userID)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// userIDFromContext returns the authenticated user, writing a 401 response
+// when the request did not pass through authenticate
+func userIDFromContext(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/auth"
+)
+
+// signTelegramFields returns the hash Telegram puts next to fields, keyed with secret
+func signTelegramFields(secret []byte, fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + fields[key]
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webAppInitData(fields map[string]string) string {
+	key := hmac.New(sha256.New, []byte("WebAppData"))
+	key.Write([]byte(testBotToken))
+
+	values := url.Values{}
+	for name, value := range fields {
+		values.Set(name, value)
+	}
+	values.Set("hash", signTelegramFields(key.Sum(nil), fields))
+	return values.Encode()
+}
+
+// withToken serves a GET of path with the raw Authorization header value
+func (s *testServer) withToken(path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := newTestServer(t)
+	server.ensureTestUser(t, 1)
+
+	valid, _ := server.Auth.Sessions.Issue(1, time.Now())
+	expired, _ := server.Auth.Sessions.Issue(1, time.Now().Add(-2*auth.DefaultSessionTTL))
+	foreign, _ := auth.NewSessions([]byte("other-secret"), auth.DefaultSessionTTL).Issue(1, time.Now())
+	other, _ := server.Auth.Sessions.Issue(2, time.Now())
+	payload, _, _ := strings.Cut(other, ".")
+	_, signature, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{"valid token", "Bearer " + valid, http.StatusOK, ""},
+		{"scheme is case insensitive", "bearer " + valid, http.StatusOK, ""},
+		{"missing header", "", http.StatusUnauthorized, "Bearer"},
+		{"other scheme", "Basic " + valid, http.StatusUnauthorized, "Bearer"},
+		{"empty token", "Bearer  ", http.StatusUnauthorized, "Bearer"},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"token of another secret", "Bearer " + foreign, http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"tampered claims", "Bearer " + payload + "." + signature, http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"garbage", "Bearer not-a-token", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := server.withToken("/api/v1/streaks", tt.authorization)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.challenge, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestWebAppLoginHandler(t *testing.T) {
+	server := newTestServer(t)
+	fields := map[string]string{
+		"query_id":  "AAH",
+		"user":      `{"id":42,"first_name":"Ada","username":"ada"}`,
+		"auth_date": strconv.FormatInt(time.Now().Unix(), 10),
+	}
+
+	rec := server.request(t, http.MethodPost, "/api/v1/auth/telegram/webapp", 0, WebAppLoginRequest{InitData: webAppInitData(fields)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var session SessionResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&session))
+	assert.Equal(t, int64(42), session.UserID)
+
+	// The first login registers the user and the token opens the API
+	user, err := server.store.Users.FindByID(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, "ada", user.Username)
+	assert.Equal(t, http.StatusOK, server.withToken("/api/v1/streaks", "Bearer "+session.Token).Code)
+
+	// Logging in again keeps the user
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/webapp", 0, WebAppLoginRequest{InitData: webAppInitData(fields)})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	tampered, err := url.ParseQuery(webAppInitData(fields))
+	require.NoError(t, err)
+	tampered.Set("user", `{"id":43,"first_name":"Eve"}`)
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/webapp", 0, WebAppLoginRequest{InitData: tampered.Encode()})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	fields["auth_date"] = strconv.FormatInt(time.Now().Add(-2*auth.DefaultLoginMaxAge).Unix(), 10)
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/webapp", 0, WebAppLoginRequest{InitData: webAppInitData(fields)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "stale logins are rejected")
+
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/webapp", 0, "not an object")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLoginWidgetHandler(t *testing.T) {
+	server := newTestServer(t)
+	authDate := time.Now().Unix()
+	secret := sha256.Sum256([]byte(testBotToken))
+	hash := signTelegramFields(secret[:], map[string]string{
+		"id":         "42",
+		"first_name": "Ada",
+		"auth_date":  strconv.FormatInt(authDate, 10),
+	})
+
+	// The widget posts numbers as JSON numbers
+	body := map[string]interface{}{"id": 42, "first_name": "Ada", "auth_date": authDate, "hash": hash}
+	rec := server.request(t, http.MethodPost, "/api/v1/auth/telegram/login", 0, body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var session SessionResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&session))
+	assert.Equal(t, int64(42), session.UserID)
+	userID, err := server.Auth.Sessions.Verify(session.Token, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), userID)
+
+	body["first_name"] = "Eve"
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/login", 0, body)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	delete(body, "hash")
+	rec = server.request(t, http.MethodPost, "/api/v1/auth/telegram/login", 0, body)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -1,12 +1,18 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 )
 
 // CreateDungeonRequest represents the JSON request for creating a dungeon
@@ -21,14 +27,76 @@ type CreateDungeonResponse struct {
 	Title          string `json:"title"`
 	AdminUserID    int64  `json:"admin_user_id"`
 	TelegramChatID *int64 `json:"telegram_chat_id,omitempty"`
+	TimeZone       string `json:"time_zone"`
 	CreatedAt      string `json:"created_at"`
 }
 
+// UserDungeonResponse represents the JSON response for one of the caller's
+// dungeons, with their role and balance in it
+type UserDungeonResponse struct {
+	CreateDungeonResponse
+	Role    string `json:"role"`
+	Balance string `json:"balance"`
+}
+
 // AddMemberRequest represents the JSON request for adding a member to a dungeon
 type AddMemberRequest struct {
 	UserID int64 `json:"user_id"`
 }
 
+// SetMemberRoleRequest represents the JSON request for changing a member's role
+type SetMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// TransferOwnershipRequest represents the JSON request for handing a dungeon to another member
+type TransferOwnershipRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+// CreateInviteRequest represents the JSON request for creating an invite
+type CreateInviteRequest struct {
+	MaxUses          *int `json:"max_uses,omitempty"`
+	ExpiresInHours   int  `json:"expires_in_hours,omitempty"`
+	RequiresApproval bool `json:"requires_approval"`
+}
+
+// InviteResponse represents the JSON response for one invite
+type InviteResponse struct {
+	Code             string  `json:"code"`
+	DungeonID        string  `json:"dungeon_id"`
+	CreatedBy        int64   `json:"created_by"`
+	MaxUses          *int    `json:"max_uses,omitempty"`
+	Uses             int     `json:"uses"`
+	ExpiresAt        *string `json:"expires_at,omitempty"`
+	RequiresApproval bool    `json:"requires_approval"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// JoinResponse represents the JSON response for redeeming an invite
+type JoinResponse struct {
+	Status        string `json:"status"` // "joined" or "pending"
+	DungeonID     string `json:"dungeon_id"`
+	JoinRequestID int64  `json:"join_request_id,omitempty"`
+}
+
+// JoinRequestResponse represents the JSON response for one join request
+type JoinRequestResponse struct {
+	ID         int64  `json:"id"`
+	DungeonID  string `json:"dungeon_id"`
+	UserID     int64  `json:"user_id"`
+	InviteCode string `json:"invite_code"`
+	Status     string `json:"status"`
+	DecidedBy  *int64 `json:"decided_by,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// AdjustBalanceRequest represents the JSON request for crediting or debiting a member
+type AdjustBalanceRequest struct {
+	Amount valueobject.Decimal `json:"amount"`
+	Note   string              `json:"note,omitempty"`
+}
+
 func (s *Server) createDungeonHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateDungeonRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -36,23 +104,15 @@ func (s *Server) createDungeonHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get admin user ID from query parameter or request context
-	adminUserIDStr := r.URL.Query().Get("admin_user_id")
-	if adminUserIDStr == "" {
-		http.Error(w, "admin_user_id query parameter is required", http.StatusBadRequest)
-		return
-	}
-
-	adminUserID, err := strconv.ParseInt(adminUserIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid admin_user_id", http.StatusBadRequest)
+	adminUserID, ok := userIDFromContext(w, r)
+	if !ok {
 		return
 	}
 
 	// Call the use case
 	createdDungeon, err := s.DungeonService.CreateDungeon(r.Context(), adminUserID, req.Title, req.TelegramChatID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
@@ -64,6 +124,31 @@ func (s *Server) createDungeonHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+func (s *Server) listUserDungeonsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	dungeons, err := s.DungeonService.ListUserDungeons(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	response := make([]UserDungeonResponse, len(dungeons))
+	for i, dungeon := range dungeons {
+		response[i] = UserDungeonResponse{
+			CreateDungeonResponse: s.dungeonToResponse(dungeon.Dungeon),
+			Role:                  string(dungeon.Role),
+			Balance:               dungeon.Balance.String(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (s *Server) addMemberHandler(w http.ResponseWriter, r *http.Request) {
 	// Get dungeon ID from URL parameter
 	dungeonID := chi.URLParam(r, "dungeonId")
@@ -78,23 +163,15 @@ func (s *Server) addMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get admin user ID from query parameter or request context
-	adminUserIDStr := r.URL.Query().Get("admin_user_id")
-	if adminUserIDStr == "" {
-		http.Error(w, "admin_user_id query parameter is required", http.StatusBadRequest)
-		return
-	}
-
-	adminUserID, err := strconv.ParseInt(adminUserIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid admin_user_id", http.StatusBadRequest)
+	adminUserID, ok := userIDFromContext(w, r)
+	if !ok {
 		return
 	}
 
 	// Call the use case
-	err = s.DungeonService.AddMember(r.Context(), adminUserID, dungeonID, req.UserID)
+	err := s.DungeonService.AddMember(r.Context(), adminUserID, dungeonID, req.UserID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
@@ -111,28 +188,339 @@ func (s *Server) listMembersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get admin user ID from query parameter or request context
-	adminUserIDStr := r.URL.Query().Get("admin_user_id")
-	if adminUserIDStr == "" {
-		http.Error(w, "admin_user_id query parameter is required", http.StatusBadRequest)
+	adminUserID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the use case
+	members, err := s.DungeonService.ListMembers(r.Context(), adminUserID, dungeonID)
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
-	adminUserID, err := strconv.ParseInt(adminUserIDStr, 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]int64{"members": members})
+}
+
+func (s *Server) setMemberRoleHandler(w http.ResponseWriter, r *http.Request) {
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid admin_user_id", http.StatusBadRequest)
+		http.Error(w, "Invalid userId", http.StatusBadRequest)
 		return
 	}
 
-	// Call the use case
-	members, err := s.DungeonService.ListMembers(r.Context(), adminUserID, dungeonID)
+	var req SetMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	err = s.DungeonService.SetMemberRole(r.Context(), userID, chi.URLParam(r, "dungeonId"), memberID, entity.DungeonRole(req.Role))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string][]int64{"members": members})
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "role": req.Role})
+}
+
+func (s *Server) transferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	var req TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	dungeon, err := s.DungeonService.TransferOwnership(r.Context(), userID, chi.URLParam(r, "dungeonId"), req.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.dungeonToResponse(dungeon))
+}
+
+func (s *Server) adjustBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	var req AdjustBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := s.DungeonService.AdjustBalance(r.Context(), userID, chi.URLParam(r, "dungeonId"), memberID, req.Amount, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LedgerEntryResponse{
+		ID:           entry.ID,
+		Amount:       entry.Amount.String(),
+		BalanceAfter: entry.BalanceAfter.String(),
+		Reason:       entry.Reason,
+		Note:         entry.Note,
+		CreatedAt:    entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+func (s *Server) removeMemberHandler(w http.ResponseWriter, r *http.Request) {
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.DungeonService.RemoveMember(r.Context(), userID, chi.URLParam(r, "dungeonId"), memberID); err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) leaveDungeonHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.DungeonService.LeaveDungeon(r.Context(), userID, chi.URLParam(r, "dungeonId")); err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createInviteHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInHours < 0 {
+		http.Error(w, "expires_in_hours must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	invite, err := s.DungeonService.CreateInvite(r.Context(), userID, chi.URLParam(r, "dungeonId"), usecase.InviteOptions{
+		MaxUses:          req.MaxUses,
+		ValidFor:         time.Duration(req.ExpiresInHours) * time.Hour,
+		RequiresApproval: req.RequiresApproval,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inviteToResponse(invite))
+}
+
+func (s *Server) listInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	invites, err := s.DungeonService.ListInvites(r.Context(), userID, chi.URLParam(r, "dungeonId"))
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	response := make([]InviteResponse, len(invites))
+	for i, invite := range invites {
+		response[i] = inviteToResponse(invite)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) revokeInviteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.DungeonService.RevokeInvite(r.Context(), userID, chi.URLParam(r, "dungeonId"), chi.URLParam(r, "code"))
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) joinWithInviteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.DungeonService.JoinWithInvite(r.Context(), userID, chi.URLParam(r, "code"))
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	response := JoinResponse{Status: "joined", DungeonID: result.Dungeon.ID}
+	if !result.Joined() {
+		response.Status = "pending"
+		response.JoinRequestID = result.Request.ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) listJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := s.DungeonService.ListJoinRequests(r.Context(), userID, chi.URLParam(r, "dungeonId"))
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	response := make([]JoinRequestResponse, len(requests))
+	for i, request := range requests {
+		response[i] = joinRequestToResponse(request)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) approveJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	s.decideJoinRequest(w, r, s.DungeonService.ApproveJoinRequest)
+}
+
+func (s *Server) denyJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	s.decideJoinRequest(w, r, s.DungeonService.DenyJoinRequest)
+}
+
+// decideJoinRequest applies decide to the join request in the URL
+func (s *Server) decideJoinRequest(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, actorID, requestID int64) (*entity.JoinRequest, error)) {
+	requestID, err := strconv.ParseInt(chi.URLParam(r, "requestId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid requestId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := decide(r.Context(), userID, requestID)
+	if err != nil {
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(joinRequestToResponse(request))
+}
+
+func inviteToResponse(invite *entity.DungeonInvite) InviteResponse {
+	response := InviteResponse{
+		Code:             invite.Code,
+		DungeonID:        invite.DungeonID,
+		CreatedBy:        invite.CreatedBy,
+		MaxUses:          invite.MaxUses,
+		Uses:             invite.Uses,
+		RequiresApproval: invite.RequiresApproval,
+		CreatedAt:        invite.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if invite.ExpiresAt != nil {
+		expiresAt := invite.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+func joinRequestToResponse(request *entity.JoinRequest) JoinRequestResponse {
+	return JoinRequestResponse{
+		ID:         request.ID,
+		DungeonID:  request.DungeonID,
+		UserID:     request.UserID,
+		InviteCode: request.InviteCode,
+		Status:     string(request.Status),
+		DecidedBy:  request.DecidedBy,
+		CreatedAt:  request.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// dungeonErrorStatus maps dungeon membership errors to HTTP status codes
+func dungeonErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidDungeonRole),
+		errors.Is(err, entity.ErrInvalidInviteMaxUses):
+		return http.StatusBadRequest
+	case errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrDungeonNotFound),
+		errors.Is(err, ports.ErrDungeonMemberNotFound),
+		errors.Is(err, ports.ErrDungeonInviteNotFound),
+		errors.Is(err, ports.ErrJoinRequestNotFound),
+		errors.Is(err, ports.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrOwnerRoleFixed),
+		errors.Is(err, usecase.ErrAlreadyOwner),
+		errors.Is(err, usecase.ErrAlreadyMember),
+		errors.Is(err, usecase.ErrOwnerCannotLeave),
+		errors.Is(err, usecase.ErrChatAlreadyLinked),
+		errors.Is(err, entity.ErrJoinRequestDecided):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrInviteExpired),
+		errors.Is(err, entity.ErrInviteUsedUp):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // Helper method to convert entity.Dungeon to CreateDungeonResponse
@@ -142,6 +530,7 @@ func (s *Server) dungeonToResponse(dungeon *entity.Dungeon) CreateDungeonRespons
 		Title:          dungeon.Title,
 		AdminUserID:    dungeon.AdminUserID,
 		TelegramChatID: dungeon.TelegramChatID,
+		TimeZone:       dungeon.TimeZone,
 		CreatedAt:      dungeon.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// LedgerEntryResponse represents the JSON response for one ledger entry
+type LedgerEntryResponse struct {
+	ID           string `json:"id"`
+	DungeonID    string `json:"dungeon_id,omitempty"`
+	Amount       string `json:"amount"`
+	BalanceAfter string `json:"balance_after"`
+	Reason       string `json:"reason"`
+	SourceType   string `json:"source_type,omitempty"`
+	SourceID     string `json:"source_id,omitempty"`
+	Note         string `json:"note,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func (s *Server) listLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var entries []*entity.LedgerEntry
+	var err error
+	if dungeonID := r.URL.Query().Get("dungeon_id"); dungeonID != "" {
+		entries, err = s.LedgerService.WalletHistory(r.Context(), userID, dungeonID, limit)
+	} else {
+		entries, err = s.LedgerService.History(r.Context(), userID, limit)
+	}
+	if err != nil {
+		if errors.Is(err, ports.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]LedgerEntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = LedgerEntryResponse{
+			ID:           entry.ID,
+			DungeonID:    entry.DungeonID,
+			Amount:       entry.Amount.String(),
+			BalanceAfter: entry.BalanceAfter.String(),
+			Reason:       entry.Reason,
+			SourceType:   entry.SourceType,
+			SourceID:     entry.SourceID,
+			Note:         entry.Note,
+			CreatedAt:    entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
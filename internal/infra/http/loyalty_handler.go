@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// LoyaltyTierRequest represents the JSON request for creating or updating a loyalty tier
+type LoyaltyTierRequest struct {
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	DiscountPercent float64 `json:"discount_percent"`
+	MinPurchases    int     `json:"min_purchases"`
+}
+
+// LoyaltyTierResponse represents the JSON response for a loyalty tier
+type LoyaltyTierResponse struct {
+	ID              int64   `json:"id"`
+	DungeonID       string  `json:"dungeon_id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description,omitempty"`
+	DiscountPercent float64 `json:"discount_percent"`
+	MinPurchases    int     `json:"min_purchases"`
+}
+
+// LoyaltyStandingResponse represents the JSON response for a user's loyalty standing
+type LoyaltyStandingResponse struct {
+	PurchaseCount int                  `json:"purchase_count"`
+	Tier          *LoyaltyTierResponse `json:"tier,omitempty"`
+	NextTier      *LoyaltyTierResponse `json:"next_tier,omitempty"`
+}
+
+func (s *Server) listLoyaltyTiersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	tiers, err := s.LoyaltyService.ListTiers(r.Context(), userID, chi.URLParam(r, "dungeonId"))
+	if err != nil {
+		http.Error(w, err.Error(), loyaltyErrorStatus(err))
+		return
+	}
+
+	response := make([]LoyaltyTierResponse, len(tiers))
+	for i, tier := range tiers {
+		response[i] = *loyaltyTierToResponse(tier)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) createLoyaltyTierHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req LoyaltyTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tier := &entity.RewardTier{
+		DungeonID:       chi.URLParam(r, "dungeonId"),
+		Name:            req.Name,
+		Description:     req.Description,
+		DiscountPercent: req.DiscountPercent,
+		MinPurchases:    req.MinPurchases,
+	}
+	if err := s.LoyaltyService.CreateTier(r.Context(), userID, tier); err != nil {
+		http.Error(w, err.Error(), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(loyaltyTierToResponse(tier))
+}
+
+func (s *Server) updateLoyaltyTierHandler(w http.ResponseWriter, r *http.Request) {
+	tierID, err := strconv.ParseInt(chi.URLParam(r, "tierId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tierId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req LoyaltyTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tier := &entity.RewardTier{
+		ID:              tierID,
+		Name:            req.Name,
+		Description:     req.Description,
+		DiscountPercent: req.DiscountPercent,
+		MinPurchases:    req.MinPurchases,
+	}
+	if err := s.LoyaltyService.UpdateTier(r.Context(), userID, tier); err != nil {
+		http.Error(w, err.Error(), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loyaltyTierToResponse(tier))
+}
+
+func (s *Server) deleteLoyaltyTierHandler(w http.ResponseWriter, r *http.Request) {
+	tierID, err := strconv.ParseInt(chi.URLParam(r, "tierId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tierId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.LoyaltyService.DeleteTier(r.Context(), userID, tierID); err != nil {
+		http.Error(w, err.Error(), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getLoyaltyStandingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	standing, err := s.LoyaltyService.Standing(r.Context(), userID, chi.URLParam(r, "dungeonId"))
+	if err != nil {
+		http.Error(w, err.Error(), loyaltyErrorStatus(err))
+		return
+	}
+
+	response := LoyaltyStandingResponse{PurchaseCount: standing.PurchaseCount}
+	if standing.Tier != nil {
+		response.Tier = loyaltyTierToResponse(standing.Tier)
+	}
+	if standing.Next != nil {
+		response.NextTier = loyaltyTierToResponse(standing.Next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// loyaltyErrorStatus maps loyalty errors to HTTP status codes
+func loyaltyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrTierNameRequired),
+		errors.Is(err, entity.ErrInvalidTierDiscount),
+		errors.Is(err, entity.ErrInvalidTierThreshold):
+		return http.StatusBadRequest
+	case errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrRewardTierNotFound),
+		errors.Is(err, ports.ErrDungeonNotFound),
+		errors.Is(err, ports.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func loyaltyTierToResponse(tier *entity.RewardTier) *LoyaltyTierResponse {
+	return &LoyaltyTierResponse{
+		ID:              tier.ID,
+		DungeonID:       tier.DungeonID,
+		Name:            tier.Name,
+		Description:     tier.Description,
+		DiscountPercent: tier.DiscountPercent,
+		MinPurchases:    tier.MinPurchases,
+	}
+}
@@ -2,12 +2,15 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 )
 
@@ -26,6 +29,7 @@ type QuestResponse struct {
 	DailyPointsCap   *string `json:"daily_points_cap,omitempty"`
 	CooldownSec      int     `json:"cooldown_sec"`
 	StreakEnabled    bool    `json:"streak_enabled"`
+	StreakGrace      int     `json:"streak_grace_periods"`
 	Status           string  `json:"status"`
 }
 
@@ -43,6 +47,7 @@ type CreateQuestRequest struct {
 	DailyPointsCap   *string `json:"daily_points_cap,omitempty"`
 	CooldownSec      *int    `json:"cooldown_sec,omitempty"`
 	StreakEnabled    *bool   `json:"streak_enabled,omitempty"`
+	StreakGrace      *int    `json:"streak_grace_periods,omitempty"`
 	Status           *string `json:"status,omitempty"`
 }
 
@@ -58,6 +63,15 @@ type CompleteQuestResponse struct {
 	AwardedPoints string `json:"awarded_points"`
 	SubmittedAt   string `json:"submitted_at"`
 	StreakCount   *int   `json:"streak_count,omitempty"`
+	BestStreak    *int   `json:"best_streak,omitempty"`
+}
+
+// StreakResponse represents the JSON response for a user's streak on a quest
+type StreakResponse struct {
+	QuestID       string `json:"quest_id"`
+	QuestTitle    string `json:"quest_title"`
+	CurrentStreak int    `json:"current_streak"`
+	BestStreak    int    `json:"best_streak"`
 }
 
 func (s *Server) createQuestHandler(w http.ResponseWriter, r *http.Request) {
@@ -74,16 +88,8 @@ func (s *Server) createQuestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from query parameter or request context
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
 		return
 	}
 
@@ -128,6 +134,10 @@ func (s *Server) createQuestHandler(w http.ResponseWriter, r *http.Request) {
 		input.StreakEnabled = *req.StreakEnabled
 	}
 
+	if req.StreakGrace != nil {
+		input.StreakGracePeriods = *req.StreakGrace
+	}
+
 	if req.Status != nil {
 		input.Status = *req.Status
 	}
@@ -135,7 +145,7 @@ func (s *Server) createQuestHandler(w http.ResponseWriter, r *http.Request) {
 	// Call the use case
 	createdQuest, err := s.QuestService.CreateQuest(r.Context(), userID, dungeonID, input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
@@ -155,22 +165,14 @@ func (s *Server) listQuestsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from query parameter or request context
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
 		return
 	}
 
 	quests, err := s.QuestService.ListQuests(r.Context(), userID, dungeonID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), dungeonErrorStatus(err))
 		return
 	}
 
@@ -196,16 +198,8 @@ func (s *Server) completeQuestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from query parameter or request context
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
 		return
 	}
 
@@ -216,15 +210,81 @@ func (s *Server) completeQuestHandler(w http.ResponseWriter, r *http.Request) {
 		Minutes:         req.Minutes,
 	}
 
-	err = s.QuestService.CompleteQuest(r.Context(), userID, questID, input)
+	completion, err := s.QuestService.CompleteQuest(r.Context(), userID, questID, input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		var cooldownErr *entity.QuestCooldownError
+		if errors.As(err, &cooldownErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(cooldownErr.Remaining.Seconds()))))
+		}
+		http.Error(w, err.Error(), completeQuestErrorStatus(err))
 		return
 	}
 
+	response := CompleteQuestResponse{
+		AwardedPoints: completion.AwardedPoints.String(),
+		SubmittedAt:   completion.SubmittedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	// The completion is already recorded, so a failed lookup only omits the streak
+	if streak, err := s.QuestService.GetStreak(r.Context(), userID, questID); err == nil {
+		response.StreakCount = &streak.Current
+		response.BestStreak = &streak.Best
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Quest completed"})
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) listStreaksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	streaks, err := s.QuestService.ListStreaks(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]StreakResponse, len(streaks))
+	for i, streak := range streaks {
+		response[i] = StreakResponse{
+			QuestID:       streak.QuestID,
+			QuestTitle:    streak.QuestTitle,
+			CurrentStreak: streak.Current,
+			BestStreak:    streak.Best,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// completeQuestErrorStatus maps quest completion errors to HTTP status codes
+func completeQuestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrInvalidQuestMode),
+		errors.Is(err, entity.ErrCompletionRatioRequired),
+		errors.Is(err, entity.ErrInvalidCompletionRatio),
+		errors.Is(err, entity.ErrMinutesRequired),
+		errors.Is(err, entity.ErrInvalidMinutes),
+		errors.Is(err, entity.ErrMinutesBelowMinimum),
+		errors.Is(err, entity.ErrRateNotConfigured):
+		return http.StatusBadRequest
+	case errors.Is(err, entity.ErrQuestOnCooldown):
+		return http.StatusTooManyRequests
+	case errors.Is(err, entity.ErrQuestNotActive),
+		errors.Is(err, usecase.ErrCompletionInProgress):
+		return http.StatusConflict
+	case errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrQuestNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // Helper method to convert entity.Quest to QuestResponse
@@ -255,6 +315,7 @@ func (s *Server) questToResponse(quest *entity.Quest) QuestResponse {
 		DailyPointsCap:   dailyPointsCap,
 		CooldownSec:      quest.CooldownSec,
 		StreakEnabled:    quest.StreakEnabled,
+		StreakGrace:      quest.StreakGracePeriods,
 		Status:           quest.Status,
 	}
 }
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteQuestHandler_UnknownQuestIsNotFound(t *testing.T) {
+	server := newTestServer(t)
+	server.ensureTestUser(t, 1)
+
+	rec := server.request(t, http.MethodPost, "/api/v1/quests/missing/complete", 1, CompleteQuestRequest{})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
@@ -7,12 +7,18 @@ import (
 )
 
 type Server struct {
-	Router         *chi.Mux
-	QuestService   *usecase.QuestService
-	DungeonService *usecase.DungeonService
+	Router           *chi.Mux
+	QuestService     *usecase.QuestService
+	DungeonService   *usecase.DungeonService
+	TimerService     *usecase.TimerService
+	LedgerService    *usecase.LedgerService
+	ShopService      *usecase.ShopService
+	ShopAdminService *usecase.ShopAdminService
+	LoyaltyService   *usecase.LoyaltyService
+	Auth             AuthConfig
 }
 
-func NewServer(questService *usecase.QuestService, dungeonService *usecase.DungeonService) *Server {
+func NewServer(questService *usecase.QuestService, dungeonService *usecase.DungeonService, timerService *usecase.TimerService, ledgerService *usecase.LedgerService, shopService *usecase.ShopService, shopAdminService *usecase.ShopAdminService, loyaltyService *usecase.LoyaltyService, authConfig AuthConfig) *Server {
 	r := chi.NewRouter()
 
 	// Add middleware
@@ -21,9 +27,15 @@ func NewServer(questService *usecase.QuestService, dungeonService *usecase.Dunge
 	r.Use(middleware.RequestID)
 
 	server := &Server{
-		Router:         r,
-		QuestService:   questService,
-		DungeonService: dungeonService,
+		Router:           r,
+		QuestService:     questService,
+		DungeonService:   dungeonService,
+		TimerService:     timerService,
+		LedgerService:    ledgerService,
+		ShopService:      shopService,
+		ShopAdminService: shopAdminService,
+		LoyaltyService:   loyaltyService,
+		Auth:             authConfig,
 	}
 
 	server.setupRoutes()
@@ -32,22 +44,105 @@ func NewServer(questService *usecase.QuestService, dungeonService *usecase.Dunge
 
 func (s *Server) setupRoutes() {
 	s.Router.Route("/api/v1", func(r chi.Router) {
-		// Quest routes
-		r.Route("/dungeons/{dungeonId}/quests", func(r chi.Router) {
-			r.Get("/", s.listQuestsHandler)
+		// Login routes exchange a Telegram login for a session token
+		r.Route("/auth/telegram", func(r chi.Router) {
+			r.Post("/webapp", s.webAppLoginHandler)
+			r.Post("/login", s.loginWidgetHandler)
 		})
 
-		r.Route("/quests/{questId}", func(r chi.Router) {
-			r.Post("/complete", s.completeQuestHandler)
+		// Every other route acts as the user of the session
+		r.Group(func(r chi.Router) {
+			r.Use(s.authenticate)
+			s.setupAuthenticatedRoutes(r)
 		})
+	})
+}
+
+func (s *Server) setupAuthenticatedRoutes(r chi.Router) {
+	// Quest routes
+	r.Route("/dungeons/{dungeonId}/quests", func(r chi.Router) {
+		r.Get("/", s.listQuestsHandler)
+	})
+
+	r.Route("/quests/{questId}", func(r chi.Router) {
+		r.Post("/complete", s.completeQuestHandler)
+		r.Post("/timers", s.startTimerHandler)
+	})
+
+	// Timer routes
+	r.Route("/timers", func(r chi.Router) {
+		r.Get("/", s.listActiveTimersHandler)
+		r.Route("/{timerId}", func(r chi.Router) {
+			r.Get("/", s.getTimerHandler)
+			r.Post("/pause", s.pauseTimerHandler)
+			r.Post("/resume", s.resumeTimerHandler)
+			r.Post("/cancel", s.cancelTimerHandler)
+			r.Post("/complete", s.completeTimerHandler)
+		})
+	})
+
+	r.Get("/streaks", s.listStreaksHandler)
+	r.Get("/ledger", s.listLedgerHandler)
+
+	// Shop routes
+	r.Post("/purchases/{purchaseId}/refund", s.refundPurchaseHandler)
+	r.Get("/discount-tiers", s.listDiscountTiersHandler)
+
+	// Loyalty tier routes
+	r.Get("/dungeons/{dungeonId}/loyalty", s.getLoyaltyStandingHandler)
+	r.Route("/dungeons/{dungeonId}/loyalty-tiers", func(r chi.Router) {
+		r.Get("/", s.listLoyaltyTiersHandler)
+		r.Post("/", s.createLoyaltyTierHandler)
+	})
+	r.Route("/loyalty-tiers/{tierId}", func(r chi.Router) {
+		r.Put("/", s.updateLoyaltyTierHandler)
+		r.Delete("/", s.deleteLoyaltyTierHandler)
+	})
+
+	// Joining dungeons
+	r.Post("/invites/{code}/join", s.joinWithInviteHandler)
+	r.Route("/join-requests/{requestId}", func(r chi.Router) {
+		r.Post("/approve", s.approveJoinRequestHandler)
+		r.Post("/deny", s.denyJoinRequestHandler)
+	})
+
+	// Dungeon routes
+	r.Route("/dungeons", func(r chi.Router) {
+		r.Get("/", s.listUserDungeonsHandler)
+		r.Post("/", s.createDungeonHandler)
+		r.Route("/{dungeonId}", func(r chi.Router) {
+			r.Post("/quests", s.createQuestHandler)
+			r.Post("/members", s.addMemberHandler)
+			r.Get("/members", s.listMembersHandler)
+			r.Put("/members/{userId}/role", s.setMemberRoleHandler)
+			r.Post("/members/{userId}/adjustments", s.adjustBalanceHandler)
+			r.Delete("/members/{userId}", s.removeMemberHandler)
+			r.Post("/leave", s.leaveDungeonHandler)
+			r.Post("/transfer", s.transferOwnershipHandler)
+
+			// Invite and join request routes
+			r.Route("/invites", func(r chi.Router) {
+				r.Get("/", s.listInvitesHandler)
+				r.Post("/", s.createInviteHandler)
+				r.Delete("/{code}", s.revokeInviteHandler)
+			})
+			r.Get("/join-requests", s.listJoinRequestsHandler)
+			r.Get("/members/{userId}/purchases", s.listMemberPurchasesHandler)
 
-		// Dungeon routes
-		r.Route("/dungeons", func(r chi.Router) {
-			r.Post("/", s.createDungeonHandler)
-			r.Route("/{dungeonId}", func(r chi.Router) {
-				r.Post("/quests", s.createQuestHandler)
-				r.Post("/members", s.addMemberHandler)
-				r.Get("/members", s.listMembersHandler)
+			// Dungeon shop routes
+			r.Route("/shop/items", func(r chi.Router) {
+				r.Get("/", s.listShopItemsHandler)
+				r.Post("/", s.createShopItemHandler)
+				r.Route("/{itemId}", func(r chi.Router) {
+					r.Get("/", s.getShopItemHandler)
+					r.Put("/", s.updateShopItemHandler)
+					r.Delete("/", s.deleteShopItemHandler)
+					r.Post("/activate", s.activateShopItemHandler)
+					r.Post("/deactivate", s.deactivateShopItemHandler)
+					r.Post("/restock", s.restockShopItemHandler)
+					r.Put("/discount-tier", s.assignDiscountTierHandler)
+					r.Get("/purchases", s.listItemPurchasesHandler)
+				})
 			})
 		})
 	})
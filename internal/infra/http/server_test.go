@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/auth"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/clock"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+const testBotToken = "123456:test-token"
+
+// testServer is a Server on in-memory storage, wired the way cmd/api wires it
+type testServer struct {
+	*Server
+	store *storage.Storage
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := storage.NewInMemory()
+
+	ledgerService := usecase.NewLedgerService(store.Ledger, store.Wallets, store.Users, store.UUIDGen, store.TxManager)
+	questService := usecase.NewQuestService(store.Quests, store.Completions, store.Streaks, store.Users, store.Dungeons, store.DungeonMembers, ledgerService, store.UUIDGen, store.Scheduler, store.Idempotency, store.TxManager)
+	dungeonService := usecase.NewDungeonService(store.Dungeons, store.DungeonMembers, store.Invites, store.JoinRequests, store.Users, ledgerService, nil, store.UUIDGen, store.TxManager)
+	loyaltyService := usecase.NewLoyaltyService(store.RewardTiers, store.LoyaltyStatus, store.Purchases, store.Users, store.Dungeons, store.DungeonMembers, nil, store.TxManager)
+	pricing := usecase.NewPricingPipeline(
+		usecase.MemberPriceRule(store.MemberPrices),
+		usecase.SaleRule(),
+		usecase.DiscountTierRule(store.DiscountTiers),
+		usecase.LoyaltyTierRule(loyaltyService),
+	)
+	shopService := usecase.NewShopService(store.ShopItems, store.Purchases, store.Users, ledgerService, store.ChatConfigs, pricing, loyaltyService, store.UUIDGen, store.TxManager, store.Idempotency, store.Dungeons, store.DungeonMembers)
+	shopAdminService := usecase.NewShopAdminService(store.Dungeons, store.DungeonMembers, store.ShopItems, store.Purchases, store.DiscountTiers, store.TxManager)
+	timerService := usecase.NewTimerService(store.Timers, store.TimerEvents, store.Quests, questService, nil, store.UUIDGen, clock.NewSystemClock(), store.TxManager)
+
+	server := NewServer(questService, dungeonService, timerService, ledgerService, shopService, shopAdminService, loyaltyService, AuthConfig{
+		BotToken:    testBotToken,
+		LoginMaxAge: auth.DefaultLoginMaxAge,
+		Sessions:    auth.NewSessions([]byte("test-secret"), auth.DefaultSessionTTL),
+		Users:       store.Users,
+	})
+	return &testServer{Server: server, store: store}
+}
+
+// request serves a request with a JSON body, authenticated as userID unless
+// userID is zero
+func (s *testServer) request(t *testing.T, method, path string, userID int64, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, jsonBody(t, body))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != 0 {
+		token, _ := s.Auth.Sessions.Issue(userID, time.Now())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func jsonBody(t *testing.T, body interface{}) *bytes.Reader {
+	t.Helper()
+	if body == nil {
+		return bytes.NewReader(nil)
+	}
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	return bytes.NewReader(data)
+}
+
+// createDungeon registers ownerID and creates a dungeon they own through the API
+func (s *testServer) createDungeon(t *testing.T, ownerID int64, title string) string {
+	t.Helper()
+	s.ensureTestUser(t, ownerID)
+	rec := s.request(t, http.MethodPost, "/api/v1/dungeons", ownerID, CreateDungeonRequest{Title: title})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var response CreateDungeonResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	return response.ID
+}
+
+// ensureTestUser registers userID the way a first login does
+func (s *testServer) ensureTestUser(t *testing.T, userID int64) {
+	t.Helper()
+	require.NoError(t, s.ensureUser(context.Background(), &auth.TelegramUser{ID: userID, FirstName: "User"}))
+}
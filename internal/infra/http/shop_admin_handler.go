@@ -0,0 +1,410 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// ShopItemRequest represents the JSON request for creating or updating a shop item.
+// Prices accept both JSON numbers and strings.
+type ShopItemRequest struct {
+	Code           string               `json:"code"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	Category       string               `json:"category"`
+	Price          valueobject.Decimal  `json:"price"`
+	SalePrice      *valueobject.Decimal `json:"sale_price,omitempty"`
+	SaleEndsAt     *time.Time           `json:"sale_ends_at,omitempty"`
+	Stock          *int                 `json:"stock,omitempty"`            // Omitted for unlimited stock
+	IsActive       *bool                `json:"is_active,omitempty"`        // Create only; defaults to true
+	DiscountTierID *int64               `json:"discount_tier_id,omitempty"` // Create only
+}
+
+// ShopItemResponse represents the JSON response for a shop item
+type ShopItemResponse struct {
+	ID             int64   `json:"id"`
+	DungeonID      string  `json:"dungeon_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	Category       string  `json:"category"`
+	Price          string  `json:"price"`
+	SalePrice      *string `json:"sale_price,omitempty"`
+	SaleEndsAt     *string `json:"sale_ends_at,omitempty"`
+	Stock          *int    `json:"stock,omitempty"`
+	IsActive       bool    `json:"is_active"`
+	DiscountTierID *int64  `json:"discount_tier_id,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// RestockRequest represents the JSON request for restocking a shop item
+type RestockRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// AssignDiscountTierRequest represents the JSON request for assigning a discount tier;
+// a null discount_tier_id removes the tier
+type AssignDiscountTierRequest struct {
+	DiscountTierID *int64 `json:"discount_tier_id"`
+}
+
+// DiscountTierResponse represents the JSON response for a discount tier
+type DiscountTierResponse struct {
+	ID              int64   `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description,omitempty"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+func (s *Server) listShopItemsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := s.ShopAdminService.ListItems(r.Context(), userID, chi.URLParam(r, "dungeonId"))
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	response := make([]ShopItemResponse, len(items))
+	for i, item := range items {
+		response[i] = shopItemToResponse(item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) getShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := s.ShopAdminService.GetItem(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) createShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShopItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	item := req.toShopItem()
+	item.IsActive = req.IsActive == nil || *req.IsActive
+	item.DiscountTierID = req.DiscountTierID
+	if err := s.ShopAdminService.CreateItem(r.Context(), userID, chi.URLParam(r, "dungeonId"), item); err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) updateShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShopItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	item := req.toShopItem()
+	item.ID = itemID
+	if err := s.ShopAdminService.UpdateItem(r.Context(), userID, chi.URLParam(r, "dungeonId"), item); err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) deleteShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.ShopAdminService.DeleteItem(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID); err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) activateShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	s.setShopItemActive(w, r, true)
+}
+
+func (s *Server) deactivateShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	s.setShopItemActive(w, r, false)
+}
+
+func (s *Server) setShopItemActive(w http.ResponseWriter, r *http.Request, active bool) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := s.ShopAdminService.SetItemActive(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID, active)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) restockShopItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req RestockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	item, err := s.ShopAdminService.RestockItem(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID, req.Quantity)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) assignDiscountTierHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req AssignDiscountTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	item, err := s.ShopAdminService.AssignDiscountTier(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID, req.DiscountTierID)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shopItemToResponse(item))
+}
+
+func (s *Server) listItemPurchasesHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := itemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	purchases, err := s.ShopAdminService.ItemPurchases(r.Context(), userID, chi.URLParam(r, "dungeonId"), itemID)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	writePurchases(w, purchases)
+}
+
+func (s *Server) listMemberPurchasesHandler(w http.ResponseWriter, r *http.Request) {
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	purchases, err := s.ShopAdminService.MemberPurchases(r.Context(), userID, chi.URLParam(r, "dungeonId"), memberID)
+	if err != nil {
+		http.Error(w, err.Error(), shopAdminErrorStatus(err))
+		return
+	}
+
+	writePurchases(w, purchases)
+}
+
+func (s *Server) listDiscountTiersHandler(w http.ResponseWriter, r *http.Request) {
+	tiers, err := s.ShopAdminService.DiscountTiers(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]DiscountTierResponse, len(tiers))
+	for i, tier := range tiers {
+		response[i] = DiscountTierResponse{
+			ID:              tier.ID,
+			Name:            tier.Name,
+			Description:     tier.Description,
+			DiscountPercent: tier.DiscountPercent,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// shopAdminErrorStatus maps shop management errors to HTTP status codes
+func shopAdminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrItemCodeRequired),
+		errors.Is(err, entity.ErrItemNameRequired),
+		errors.Is(err, entity.ErrInvalidItemPrice),
+		errors.Is(err, entity.ErrInvalidSalePrice),
+		errors.Is(err, entity.ErrInvalidItemStock),
+		errors.Is(err, entity.ErrInvalidRestock),
+		errors.Is(err, ports.ErrDiscountTierNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrDungeonNotFound),
+		errors.Is(err, ports.ErrShopItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrItemStockUnlimited),
+		errors.Is(err, ports.ErrShopItemCodeExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// itemIDFromURL parses the itemId URL parameter, writing a 400 response if it is invalid
+func itemIDFromURL(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid itemId", http.StatusBadRequest)
+		return 0, false
+	}
+	return itemID, true
+}
+
+func writePurchases(w http.ResponseWriter, purchases []*entity.Purchase) {
+	response := make([]PurchaseResponse, len(purchases))
+	for i, purchase := range purchases {
+		response[i] = purchaseToResponse(purchase)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (req *ShopItemRequest) toShopItem() *entity.ShopItem {
+	return &entity.ShopItem{
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		Category:    req.Category,
+		Price:       req.Price,
+		SalePrice:   req.SalePrice,
+		SaleEndsAt:  req.SaleEndsAt,
+		Stock:       req.Stock,
+	}
+}
+
+func shopItemToResponse(item *entity.ShopItem) ShopItemResponse {
+	response := ShopItemResponse{
+		ID:             item.ID,
+		Code:           item.Code,
+		Name:           item.Name,
+		Description:    item.Description,
+		Category:       item.Category,
+		Price:          item.Price.String(),
+		Stock:          item.Stock,
+		IsActive:       item.IsActive,
+		DiscountTierID: item.DiscountTierID,
+		CreatedAt:      item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if item.DungeonID != nil {
+		response.DungeonID = *item.DungeonID
+	}
+	if item.SalePrice != nil {
+		str := item.SalePrice.String()
+		response.SalePrice = &str
+	}
+	if item.SaleEndsAt != nil {
+		str := item.SaleEndsAt.Format("2006-01-02T15:04:05Z07:00")
+		response.SaleEndsAt = &str
+	}
+	return response
+}
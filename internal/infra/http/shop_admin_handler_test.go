@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+func (s *testServer) createShopItem(t *testing.T, ownerID int64, dungeonID, code string) int64 {
+	t.Helper()
+	stock := 5
+	rec := s.request(t, http.MethodPost, "/api/v1/dungeons/"+dungeonID+"/shop/items", ownerID, ShopItemRequest{
+		Code:  code,
+		Name:  "Item " + code,
+		Price: valueobject.NewDecimal("10"),
+		Stock: &stock,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var item ShopItemResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&item))
+	return item.ID
+}
+
+func TestShopAdminHandlers_Authorization(t *testing.T) {
+	server := newTestServer(t)
+	dungeonID := server.createDungeon(t, 1, "Guild")
+	otherDungeonID := server.createDungeon(t, 3, "Other guild")
+	server.ensureTestUser(t, 2)
+	rec := server.request(t, http.MethodPost, "/api/v1/dungeons/"+dungeonID+"/members", 1, AddMemberRequest{UserID: 2})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	itemID := server.createShopItem(t, 1, dungeonID, "tea")
+	otherItemID := server.createShopItem(t, 3, otherDungeonID, "tea")
+
+	// Routes of one item; members may only use the first
+	item := ShopItemRequest{Code: "tea", Name: "Tea", Price: valueobject.NewDecimal("8")}
+	routes := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodGet, "", nil},
+		{http.MethodPut, "", item},
+		{http.MethodDelete, "", nil},
+		{http.MethodPost, "/activate", nil},
+		{http.MethodPost, "/deactivate", nil},
+		{http.MethodPost, "/restock", RestockRequest{Quantity: 3}},
+		{http.MethodPut, "/discount-tier", AssignDiscountTierRequest{}},
+		{http.MethodGet, "/purchases", nil},
+	}
+
+	t.Run("members cannot manage the shop", func(t *testing.T) {
+		rec := server.request(t, http.MethodPost, "/api/v1/dungeons/"+dungeonID+"/shop/items", 2, item)
+		assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+		rec = server.request(t, http.MethodGet, "/api/v1/dungeons/"+dungeonID+"/members/1/purchases", 2, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+		for i, route := range routes {
+			want := http.StatusForbidden
+			if i == 0 {
+				want = http.StatusOK
+			}
+			path := fmt.Sprintf("/api/v1/dungeons/%s/shop/items/%d%s", dungeonID, itemID, route.path)
+			rec := server.request(t, route.method, path, 2, route.body)
+			assert.Equal(t, want, rec.Code, "%s %s: %s", route.method, path, rec.Body.String())
+		}
+	})
+
+	t.Run("items of another dungeon are not found", func(t *testing.T) {
+		for _, route := range routes {
+			path := fmt.Sprintf("/api/v1/dungeons/%s/shop/items/%d%s", dungeonID, otherItemID, route.path)
+			rec := server.request(t, route.method, path, 1, route.body)
+			assert.Equal(t, http.StatusNotFound, rec.Code, "%s %s: %s", route.method, path, rec.Body.String())
+		}
+
+		rec := server.request(t, http.MethodGet, fmt.Sprintf("/api/v1/dungeons/%s/shop/items/%d", otherDungeonID, otherItemID), 3, nil)
+		assert.Equal(t, http.StatusOK, rec.Code, "the item itself is untouched")
+	})
+
+	t.Run("outsiders are forbidden", func(t *testing.T) {
+		rec := server.request(t, http.MethodGet, "/api/v1/dungeons/"+dungeonID+"/shop/items", 3, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	})
+}
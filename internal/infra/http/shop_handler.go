@@ -0,0 +1,126 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// RefundPurchaseRequest represents the JSON request for refunding a purchase
+type RefundPurchaseRequest struct {
+	Quantity int    `json:"quantity,omitempty"` // 0 or omitted refunds everything left
+	Reason   string `json:"reason,omitempty"`
+}
+
+// PriceAdjustmentResponse is one line of a purchase's price breakdown
+type PriceAdjustmentResponse struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Amount      string `json:"amount"`
+}
+
+// PurchaseResponse represents the JSON response for a purchase
+type PurchaseResponse struct {
+	ID               int64                     `json:"id"`
+	UserID           int64                     `json:"user_id"`
+	ItemID           int64                     `json:"item_id"`
+	ItemName         string                    `json:"item_name"`
+	Quantity         int                       `json:"quantity"`
+	ItemPrice        string                    `json:"item_price"`
+	Subtotal         string                    `json:"subtotal"`
+	TotalCost        string                    `json:"total_cost"`
+	PriceBreakdown   []PriceAdjustmentResponse `json:"price_breakdown"`
+	Status           string                    `json:"status"`
+	PurchasedAt      string                    `json:"purchased_at"`
+	RefundedQuantity int                       `json:"refunded_quantity"`
+	RefundedAmount   string                    `json:"refunded_amount"`
+	RefundedAt       *string                   `json:"refunded_at,omitempty"`
+}
+
+func (s *Server) refundPurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	purchaseID, err := strconv.ParseInt(chi.URLParam(r, "purchaseId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid purchaseId", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; without one the whole purchase is refunded
+	var req RefundPurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := s.ShopService.RefundPurchase(r.Context(), userID, purchaseID, usecase.RefundInput{
+		Quantity: req.Quantity,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), refundErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(purchaseToResponse(purchase))
+}
+
+// refundErrorStatus maps refund errors to HTTP status codes
+func refundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrInvalidRefundQuantity):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrRefundNotAllowed),
+		errors.Is(err, usecase.ErrRefundWindowExpired),
+		errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrPurchaseNotFound),
+		errors.Is(err, ports.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrPurchaseNotRefundable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func purchaseToResponse(purchase *entity.Purchase) PurchaseResponse {
+	response := PurchaseResponse{
+		ID:               purchase.ID,
+		UserID:           purchase.UserID,
+		ItemID:           purchase.ItemID,
+		ItemName:         purchase.ItemName,
+		Quantity:         purchase.Quantity,
+		ItemPrice:        purchase.ItemPrice.String(),
+		Subtotal:         purchase.Subtotal.String(),
+		TotalCost:        purchase.TotalCost.String(),
+		PriceBreakdown:   make([]PriceAdjustmentResponse, 0, len(purchase.PriceBreakdown)),
+		Status:           purchase.Status,
+		PurchasedAt:      purchase.PurchasedAt.Format("2006-01-02T15:04:05Z07:00"),
+		RefundedQuantity: purchase.RefundedQuantity,
+		RefundedAmount:   purchase.RefundedAmount.String(),
+	}
+	for _, adjustment := range purchase.PriceBreakdown {
+		response.PriceBreakdown = append(response.PriceBreakdown, PriceAdjustmentResponse{
+			Rule:        adjustment.Rule,
+			Description: adjustment.Description,
+			Amount:      adjustment.Amount.String(),
+		})
+	}
+	if purchase.RefundedAt != nil {
+		str := purchase.RefundedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.RefundedAt = &str
+	}
+	return response
+}
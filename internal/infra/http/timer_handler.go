@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// StartTimerRequest represents the JSON request for starting a timer
+type StartTimerRequest struct {
+	Type        string `json:"type"`
+	DurationSec int    `json:"duration_sec,omitempty"`
+}
+
+// TimerResponse represents the JSON response for a timer
+type TimerResponse struct {
+	ID           string  `json:"id"`
+	QuestID      string  `json:"quest_id"`
+	Type         string  `json:"type"`
+	Status       string  `json:"status"`
+	StartedAt    string  `json:"started_at"`
+	DurationSec  int     `json:"duration_sec,omitempty"`
+	ElapsedSec   int     `json:"elapsed_sec"`
+	RemainingSec *int    `json:"remaining_sec,omitempty"`
+	ExpiresAt    *string `json:"expires_at,omitempty"`
+}
+
+// CompleteTimerResponse represents the JSON response for completing a timer
+type CompleteTimerResponse struct {
+	Timer      TimerResponse          `json:"timer"`
+	Completion *CompleteQuestResponse `json:"completion,omitempty"`
+}
+
+func (s *Server) startTimerHandler(w http.ResponseWriter, r *http.Request) {
+	questID := chi.URLParam(r, "questId")
+	if questID == "" {
+		http.Error(w, "questId is required", http.StatusBadRequest)
+		return
+	}
+
+	var req StartTimerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	timer, err := s.TimerService.StartTimer(r.Context(), userID, usecase.StartTimerInput{
+		QuestID:     questID,
+		Type:        req.Type,
+		DurationSec: req.DurationSec,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), timerErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(timerToResponse(timer, time.Now()))
+}
+
+func (s *Server) listActiveTimersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	timers, err := s.TimerService.ListActiveTimers(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	response := make([]TimerResponse, len(timers))
+	for i, timer := range timers {
+		response[i] = timerToResponse(timer, now)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) getTimerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	timer, err := s.TimerService.GetTimer(r.Context(), userID, chi.URLParam(r, "timerId"))
+	if err != nil {
+		http.Error(w, err.Error(), timerErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timerToResponse(timer, time.Now()))
+}
+
+func (s *Server) pauseTimerHandler(w http.ResponseWriter, r *http.Request) {
+	s.timerTransitionHandler(w, r, s.TimerService.PauseTimer)
+}
+
+func (s *Server) resumeTimerHandler(w http.ResponseWriter, r *http.Request) {
+	s.timerTransitionHandler(w, r, s.TimerService.ResumeTimer)
+}
+
+func (s *Server) cancelTimerHandler(w http.ResponseWriter, r *http.Request) {
+	s.timerTransitionHandler(w, r, s.TimerService.CancelTimer)
+}
+
+func (s *Server) completeTimerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.TimerService.CompleteTimer(r.Context(), userID, chi.URLParam(r, "timerId"))
+	if err != nil {
+		var cooldownErr *entity.QuestCooldownError
+		if errors.As(err, &cooldownErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(cooldownErr.Remaining.Seconds()))))
+		}
+		http.Error(w, err.Error(), timerErrorStatus(err))
+		return
+	}
+
+	response := CompleteTimerResponse{Timer: timerToResponse(result.Timer, time.Now())}
+	if result.Completion != nil {
+		response.Completion = &CompleteQuestResponse{
+			AwardedPoints: result.Completion.AwardedPoints.String(),
+			SubmittedAt:   result.Completion.SubmittedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) timerTransitionHandler(w http.ResponseWriter, r *http.Request, transition func(ctx context.Context, userID int64, timerID string) (*entity.Timer, error)) {
+	userID, ok := userIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	timer, err := transition(r.Context(), userID, chi.URLParam(r, "timerId"))
+	if err != nil {
+		http.Error(w, err.Error(), timerErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timerToResponse(timer, time.Now()))
+}
+
+// timerErrorStatus maps timer errors to HTTP status codes, falling back to the
+// quest completion mapping for completions submitted by stopwatches
+func timerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrInvalidTimerType),
+		errors.Is(err, entity.ErrInvalidTimerDuration):
+		return http.StatusBadRequest
+	case errors.Is(err, ports.ErrTimerNotFound),
+		errors.Is(err, ports.ErrQuestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrInvalidTimerTransition),
+		errors.Is(err, entity.ErrTimerAlreadyActive):
+		return http.StatusConflict
+	default:
+		return completeQuestErrorStatus(err)
+	}
+}
+
+func timerToResponse(timer *entity.Timer, now time.Time) TimerResponse {
+	response := TimerResponse{
+		ID:          timer.ID,
+		QuestID:     timer.TaskID,
+		Type:        timer.Type,
+		Status:      timer.Status,
+		StartedAt:   timer.StartTime.Format("2006-01-02T15:04:05Z07:00"),
+		DurationSec: timer.Duration,
+		ElapsedSec:  int(timer.Elapsed(now) / time.Second),
+	}
+
+	if timer.Type == entity.TimerTypeCountdown {
+		remaining := int(timer.Remaining(now) / time.Second)
+		response.RemainingSec = &remaining
+	}
+	if expiresAt := timer.ExpiresAt(now); expiresAt != nil {
+		str := expiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ExpiresAt = &str
+	}
+
+	return response
+}
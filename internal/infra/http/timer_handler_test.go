@@ -0,0 +1,18 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+)
+
+func TestStartTimerHandler_UnknownQuestIsNotFound(t *testing.T) {
+	server := newTestServer(t)
+	server.ensureTestUser(t, 1)
+
+	rec := server.request(t, http.MethodPost, "/api/v1/quests/missing/timers", 1, StartTimerRequest{Type: entity.TimerTypeCountdown, DurationSec: 60})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
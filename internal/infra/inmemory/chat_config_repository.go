@@ -57,3 +57,16 @@ func (r *ChatConfigRepository) Update(ctx context.Context, config *entity.ChatCo
 	r.configs[config.ChatID] = config
 	return nil
 }
+
+// Snapshot implements Snapshotter
+func (r *ChatConfigRepository) Snapshot() func() {
+	r.mu.RLock()
+	configs := cloneMap(r.configs)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.configs = configs
+	}
+}
@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
@@ -9,13 +10,15 @@ import (
 )
 
 type InMemoryDiscountTierRepository struct {
-	mu    sync.RWMutex
-	tiers map[int64]*entity.DiscountTier
+	mu     sync.RWMutex
+	tiers  map[int64]*entity.DiscountTier
+	nextID int64
 }
 
 func NewDiscountTierRepository() *InMemoryDiscountTierRepository {
 	return &InMemoryDiscountTierRepository{
-		tiers: make(map[int64]*entity.DiscountTier),
+		tiers:  make(map[int64]*entity.DiscountTier),
+		nextID: 1,
 	}
 }
 
@@ -27,6 +30,14 @@ func (r *InMemoryDiscountTierRepository) Create(ctx context.Context, tier *entit
 		return ports.ErrDiscountTierExists
 	}
 
+	// A zero ID is assigned the next free one, like a serial column
+	if tier.ID == 0 {
+		tier.ID = r.nextID
+	}
+	if tier.ID >= r.nextID {
+		r.nextID = tier.ID + 1
+	}
+
 	r.tiers[tier.ID] = tier
 	return nil
 }
@@ -50,6 +61,7 @@ func (r *InMemoryDiscountTierRepository) FindAll(ctx context.Context) ([]*entity
 	for _, t := range r.tiers {
 		tiers = append(tiers, t)
 	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].DiscountPercent < tiers[j].DiscountPercent })
 	return tiers, nil
 }
 
@@ -69,6 +81,23 @@ func (r *InMemoryDiscountTierRepository) Delete(ctx context.Context, id int64) e
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if _, exists := r.tiers[id]; !exists {
+		return ports.ErrDiscountTierNotFound
+	}
+
 	delete(r.tiers, id)
 	return nil
 }
+
+// Snapshot implements Snapshotter
+func (r *InMemoryDiscountTierRepository) Snapshot() func() {
+	r.mu.RLock()
+	tiers, nextID := cloneMap(r.tiers), r.nextID
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.tiers, r.nextID = tiers, nextID
+	}
+}
@@ -0,0 +1,107 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type DungeonInviteRepository struct {
+	mu      sync.RWMutex
+	invites map[string]*entity.DungeonInvite
+}
+
+func NewDungeonInviteRepository() *DungeonInviteRepository {
+	return &DungeonInviteRepository{
+		invites: make(map[string]*entity.DungeonInvite),
+	}
+}
+
+func (r *DungeonInviteRepository) Create(ctx context.Context, invite *entity.DungeonInvite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.invites[invite.Code]; exists {
+		return fmt.Errorf("invite %s already exists", invite.Code)
+	}
+
+	inviteCopy := *invite
+	r.invites[invite.Code] = &inviteCopy
+	return nil
+}
+
+func (r *DungeonInviteRepository) GetByCode(ctx context.Context, code string) (*entity.DungeonInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invite, exists := r.invites[code]
+	if !exists {
+		return nil, ports.ErrDungeonInviteNotFound
+	}
+
+	inviteCopy := *invite
+	return &inviteCopy, nil
+}
+
+// ListByDungeon returns copies of the dungeon's invites, newest first
+func (r *DungeonInviteRepository) ListByDungeon(ctx context.Context, dungeonID string) ([]*entity.DungeonInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var invites []*entity.DungeonInvite
+	for _, invite := range r.invites {
+		if invite.DungeonID == dungeonID {
+			inviteCopy := *invite
+			invites = append(invites, &inviteCopy)
+		}
+	}
+
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreatedAt.Equal(invites[j].CreatedAt) {
+			return invites[i].CreatedAt.After(invites[j].CreatedAt)
+		}
+		return invites[i].Code < invites[j].Code
+	})
+	return invites, nil
+}
+
+// Update saves the invite's use count
+func (r *DungeonInviteRepository) Update(ctx context.Context, invite *entity.DungeonInvite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.invites[invite.Code]
+	if !exists {
+		return ports.ErrDungeonInviteNotFound
+	}
+	existing.Uses = invite.Uses
+	return nil
+}
+
+func (r *DungeonInviteRepository) Delete(ctx context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.invites[code]; !exists {
+		return ports.ErrDungeonInviteNotFound
+	}
+	delete(r.invites, code)
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *DungeonInviteRepository) Snapshot() func() {
+	r.mu.RLock()
+	invites := cloneMap(r.invites)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.invites = invites
+	}
+}
@@ -0,0 +1,151 @@
+package inmemory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type dungeonMemberKey struct {
+	dungeonID string
+	userID    int64
+}
+
+type DungeonMemberRepository struct {
+	mu      sync.RWMutex
+	members map[dungeonMemberKey]entity.DungeonMember
+}
+
+func NewDungeonMemberRepository() *DungeonMemberRepository {
+	return &DungeonMemberRepository{
+		members: make(map[dungeonMemberKey]entity.DungeonMember),
+	}
+}
+
+// Add makes the user a member of the dungeon. Adding an existing member
+// keeps their original role and join time.
+func (r *DungeonMemberRepository) Add(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := dungeonMemberKey{dungeonID, userID}
+	if _, exists := r.members[key]; !exists {
+		r.members[key] = entity.DungeonMember{DungeonID: dungeonID, UserID: userID, Role: role, JoinedAt: time.Now()}
+	}
+	return nil
+}
+
+func (r *DungeonMemberRepository) Get(ctx context.Context, dungeonID string, userID int64) (*entity.DungeonMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, exists := r.members[dungeonMemberKey{dungeonID, userID}]
+	if !exists {
+		return nil, ports.ErrDungeonMemberNotFound
+	}
+	return &member, nil
+}
+
+func (r *DungeonMemberRepository) SetRole(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := dungeonMemberKey{dungeonID, userID}
+	member, exists := r.members[key]
+	if !exists {
+		return ports.ErrDungeonMemberNotFound
+	}
+	member.Role = role
+	r.members[key] = member
+	return nil
+}
+
+func (r *DungeonMemberRepository) Remove(ctx context.Context, dungeonID string, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := dungeonMemberKey{dungeonID, userID}
+	if _, exists := r.members[key]; !exists {
+		return ports.ErrDungeonMemberNotFound
+	}
+	delete(r.members, key)
+	return nil
+}
+
+// List returns copies of the dungeon's memberships in ascending user order
+func (r *DungeonMemberRepository) List(ctx context.Context, dungeonID string) ([]*entity.DungeonMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var members []*entity.DungeonMember
+	for key, member := range r.members {
+		if key.dungeonID == dungeonID {
+			memberCopy := member
+			members = append(members, &memberCopy)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members, nil
+}
+
+// ListByUser returns copies of the user's memberships, longest-standing first
+func (r *DungeonMemberRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.DungeonMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var members []*entity.DungeonMember
+	for key, member := range r.members {
+		if key.userID == userID {
+			memberCopy := member
+			members = append(members, &memberCopy)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].JoinedAt.Equal(members[j].JoinedAt) {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		}
+		return members[i].DungeonID < members[j].DungeonID
+	})
+	return members, nil
+}
+
+// ListUsers returns the IDs of the dungeon's members in ascending order
+func (r *DungeonMemberRepository) ListUsers(ctx context.Context, dungeonID string) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var userIDs []int64
+	for key := range r.members {
+		if key.dungeonID == dungeonID {
+			userIDs = append(userIDs, key.userID)
+		}
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return userIDs, nil
+}
+
+func (r *DungeonMemberRepository) IsMember(ctx context.Context, dungeonID string, userID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.members[dungeonMemberKey{dungeonID, userID}]
+	return exists, nil
+}
+
+// Snapshot implements Snapshotter
+func (r *DungeonMemberRepository) Snapshot() func() {
+	r.mu.RLock()
+	members := maps.Clone(r.members)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.members = members
+	}
+}
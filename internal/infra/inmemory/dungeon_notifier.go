@@ -0,0 +1,60 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// JoinNotification is a notification recorded by DungeonNotifier. Requests
+// sent to reviewers are pending; decisions sent to requesters are not.
+type JoinNotification struct {
+	UserID    int64
+	RequestID int64
+	Status    entity.JoinRequestStatus
+}
+
+// DungeonNotifier records join notifications instead of sending them
+type DungeonNotifier struct {
+	mu   sync.Mutex
+	sent []JoinNotification
+	Err  error // Returned by every method when set
+}
+
+func NewDungeonNotifier() *DungeonNotifier {
+	return &DungeonNotifier{}
+}
+
+func (n *DungeonNotifier) NotifyJoinRequest(ctx context.Context, reviewerID int64, dungeon *entity.Dungeon, request *entity.JoinRequest) error {
+	return n.record(JoinNotification{UserID: reviewerID, RequestID: request.ID, Status: request.Status})
+}
+
+func (n *DungeonNotifier) NotifyJoinDecided(ctx context.Context, dungeon *entity.Dungeon, request *entity.JoinRequest) error {
+	return n.record(JoinNotification{UserID: request.UserID, RequestID: request.ID, Status: request.Status})
+}
+
+func (n *DungeonNotifier) record(notification JoinNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// Sent returns the notifications recorded so far
+func (n *DungeonNotifier) Sent() []JoinNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sent := make([]JoinNotification, len(n.sent))
+	copy(sent, n.sent)
+	return sent
+}
+
+// Compile-time check that DungeonNotifier implements ports.DungeonNotifier
+var _ ports.DungeonNotifier = (*DungeonNotifier)(nil)
@@ -0,0 +1,140 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type DungeonRepository struct {
+	mu       sync.RWMutex
+	dungeons map[string]*entity.Dungeon
+}
+
+func NewDungeonRepository() *DungeonRepository {
+	return &DungeonRepository{
+		dungeons: make(map[string]*entity.Dungeon),
+	}
+}
+
+func (r *DungeonRepository) Create(ctx context.Context, dungeon *entity.Dungeon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.dungeons[dungeon.ID]; exists {
+		return fmt.Errorf("dungeon %s already exists", dungeon.ID)
+	}
+	// A Telegram chat is linked to at most one dungeon
+	if dungeon.TelegramChatID != nil {
+		if linked := r.findByChat(*dungeon.TelegramChatID); linked != nil {
+			return fmt.Errorf("chat %d is already linked to dungeon %s", *dungeon.TelegramChatID, linked.ID)
+		}
+	}
+
+	r.dungeons[dungeon.ID] = copyDungeon(dungeon)
+	return nil
+}
+
+func (r *DungeonRepository) GetByID(ctx context.Context, dungeonID string) (*entity.Dungeon, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dungeon, exists := r.dungeons[dungeonID]
+	if !exists {
+		return nil, ports.ErrDungeonNotFound
+	}
+	return copyDungeon(dungeon), nil
+}
+
+func (r *DungeonRepository) FindByTelegramChatID(ctx context.Context, chatID int64) (*entity.Dungeon, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dungeon := r.findByChat(chatID)
+	if dungeon == nil {
+		return nil, ports.ErrDungeonNotFound
+	}
+	return copyDungeon(dungeon), nil
+}
+
+// ListByAdmin returns copies of the dungeons the user administers, oldest first
+func (r *DungeonRepository) ListByAdmin(ctx context.Context, userID int64) ([]*entity.Dungeon, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dungeons []*entity.Dungeon
+	for _, dungeon := range r.dungeons {
+		if dungeon.AdminUserID == userID {
+			dungeons = append(dungeons, copyDungeon(dungeon))
+		}
+	}
+
+	sort.Slice(dungeons, func(i, j int) bool {
+		if !dungeons[i].CreatedAt.Equal(dungeons[j].CreatedAt) {
+			return dungeons[i].CreatedAt.Before(dungeons[j].CreatedAt)
+		}
+		return dungeons[i].ID < dungeons[j].ID
+	})
+	return dungeons, nil
+}
+
+// Update saves the dungeon's title, admin, chat link and time zone
+func (r *DungeonRepository) Update(ctx context.Context, dungeon *entity.Dungeon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.dungeons[dungeon.ID]
+	if !exists {
+		return ports.ErrDungeonNotFound
+	}
+	if dungeon.TelegramChatID != nil {
+		if linked := r.findByChat(*dungeon.TelegramChatID); linked != nil && linked.ID != dungeon.ID {
+			return fmt.Errorf("chat %d is already linked to dungeon %s", *dungeon.TelegramChatID, linked.ID)
+		}
+	}
+
+	updated := copyDungeon(dungeon)
+	updated.CreatedAt = existing.CreatedAt
+	r.dungeons[dungeon.ID] = updated
+	return nil
+}
+
+// findByChat must be called with r.mu held
+func (r *DungeonRepository) findByChat(chatID int64) *entity.Dungeon {
+	for _, dungeon := range r.dungeons {
+		if dungeon.TelegramChatID != nil && *dungeon.TelegramChatID == chatID {
+			return dungeon
+		}
+	}
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *DungeonRepository) Snapshot() func() {
+	r.mu.RLock()
+	dungeons := make(map[string]*entity.Dungeon, len(r.dungeons))
+	for id, dungeon := range r.dungeons {
+		dungeons[id] = copyDungeon(dungeon)
+	}
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.dungeons = dungeons
+	}
+}
+
+// copyDungeon copies the dungeon including its chat link
+func copyDungeon(dungeon *entity.Dungeon) *entity.Dungeon {
+	dungeonCopy := *dungeon
+	if dungeon.TelegramChatID != nil {
+		chatID := *dungeon.TelegramChatID
+		dungeonCopy.TelegramChatID = &chatID
+	}
+	return &dungeonCopy
+}
@@ -32,12 +32,25 @@ func (r *InMemoryIdempotencyRepository) Create(ctx context.Context, key *entity.
 	return nil
 }
 
+// Claim records key as pending if it is new or its earlier attempt failed or expired
+func (r *InMemoryIdempotencyRepository) Claim(ctx context.Context, key *entity.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.keys[key.Key]; exists && !existing.IsFailed() && !existing.IsExpired() {
+		return ports.ErrIdempotencyKeyExists
+	}
+
+	r.keys[key.Key] = key
+	return nil
+}
+
 func (r *InMemoryIdempotencyRepository) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	idempKey, exists := r.keys[key]
-	if !exists {
+	if !exists || idempKey.IsExpired() {
 		return nil, ports.ErrIdempotencyKeyNotFound
 	}
 
@@ -57,15 +70,20 @@ func (r *InMemoryIdempotencyRepository) Update(ctx context.Context, key *entity.
 }
 
 func (r *InMemoryIdempotencyRepository) DeleteExpired(ctx context.Context) error {
-	return r.Purge(ctx, time.Now())
+	now := time.Now()
+	return r.deleteWhere(func(key *entity.IdempotencyKey) bool { return !key.ExpiresAt.After(now) })
 }
 
 func (r *InMemoryIdempotencyRepository) Purge(ctx context.Context, olderThan time.Time) error {
+	return r.deleteWhere(func(key *entity.IdempotencyKey) bool { return key.CreatedAt.Before(olderThan) })
+}
+
+func (r *InMemoryIdempotencyRepository) deleteWhere(match func(*entity.IdempotencyKey) bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	for k, v := range r.keys {
-		if v.ExpiresAt.Before(olderThan) {
+		if match(v) {
 			delete(r.keys, k)
 		}
 	}
@@ -78,3 +96,16 @@ func (r *InMemoryIdempotencyRepository) Count() int {
 	defer r.mu.RUnlock()
 	return len(r.keys)
 }
+
+// Snapshot implements Snapshotter
+func (r *InMemoryIdempotencyRepository) Snapshot() func() {
+	r.mu.RLock()
+	keys := cloneMap(r.keys)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.keys = keys
+	}
+}
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type JoinRequestRepository struct {
+	mu       sync.RWMutex
+	requests map[int64]*entity.JoinRequest
+	nextID   int64
+}
+
+func NewJoinRequestRepository() *JoinRequestRepository {
+	return &JoinRequestRepository{
+		requests: make(map[int64]*entity.JoinRequest),
+		nextID:   1,
+	}
+}
+
+func (r *JoinRequestRepository) Create(ctx context.Context, request *entity.JoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request.ID = r.nextID
+	r.nextID++
+
+	requestCopy := *request
+	r.requests[request.ID] = &requestCopy
+	return nil
+}
+
+func (r *JoinRequestRepository) GetByID(ctx context.Context, id int64) (*entity.JoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, exists := r.requests[id]
+	if !exists {
+		return nil, ports.ErrJoinRequestNotFound
+	}
+
+	requestCopy := *request
+	return &requestCopy, nil
+}
+
+func (r *JoinRequestRepository) FindPending(ctx context.Context, dungeonID string, userID int64) (*entity.JoinRequest, error) {
+	for _, request := range r.pending(dungeonID) {
+		if request.UserID == userID {
+			return request, nil
+		}
+	}
+	return nil, ports.ErrJoinRequestNotFound
+}
+
+func (r *JoinRequestRepository) ListPending(ctx context.Context, dungeonID string) ([]*entity.JoinRequest, error) {
+	return r.pending(dungeonID), nil
+}
+
+// pending returns copies of the dungeon's undecided requests, oldest first
+func (r *JoinRequestRepository) pending(dungeonID string) []*entity.JoinRequest {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var requests []*entity.JoinRequest
+	for _, request := range r.requests {
+		if request.DungeonID == dungeonID && request.Status == entity.JoinRequestPending {
+			requestCopy := *request
+			requests = append(requests, &requestCopy)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+	return requests
+}
+
+func (r *JoinRequestRepository) Update(ctx context.Context, request *entity.JoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.requests[request.ID]; !exists {
+		return ports.ErrJoinRequestNotFound
+	}
+
+	requestCopy := *request
+	r.requests[request.ID] = &requestCopy
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *JoinRequestRepository) Snapshot() func() {
+	r.mu.RLock()
+	requests, nextID := cloneMap(r.requests), r.nextID
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests, r.nextID = requests, nextID
+	}
+}
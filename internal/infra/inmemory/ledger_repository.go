@@ -0,0 +1,113 @@
+package inmemory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+type LedgerRepository struct {
+	mu      sync.RWMutex
+	entries []entity.LedgerEntry // In append order
+}
+
+func NewLedgerRepository() *LedgerRepository {
+	return &LedgerRepository{}
+}
+
+func (r *LedgerRepository) Append(ctx context.Context, entry *entity.LedgerEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *LedgerRepository) ListByUser(ctx context.Context, userID int64, limit int) ([]*entity.LedgerEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*entity.LedgerEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if r.entries[i].UserID == userID {
+			entry := r.entries[i]
+			entries = append(entries, &entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *LedgerRepository) ListByWallet(ctx context.Context, userID int64, dungeonID string, limit int) ([]*entity.LedgerEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*entity.LedgerEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if r.entries[i].UserID == userID && r.entries[i].DungeonID == dungeonID {
+			entry := r.entries[i]
+			entries = append(entries, &entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *LedgerRepository) Balances(ctx context.Context) (map[int64]valueobject.Decimal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	balances := make(map[int64]valueobject.Decimal)
+	for _, entry := range r.entries {
+		sum, ok := balances[entry.UserID]
+		if !ok {
+			sum = valueobject.NewDecimal("0")
+		}
+		balances[entry.UserID] = sum.Add(entry.Amount)
+	}
+
+	return balances, nil
+}
+
+// WalletBalances sums the entries that belong to a dungeon, ordered by user
+// and dungeon
+func (r *LedgerRepository) WalletBalances(ctx context.Context) ([]*entity.Wallet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sums := make(map[walletKey]valueobject.Decimal)
+	for _, entry := range r.entries {
+		if entry.DungeonID == "" {
+			continue
+		}
+		key := walletKey{entry.UserID, entry.DungeonID}
+		sum, ok := sums[key]
+		if !ok {
+			sum = valueobject.NewDecimal("0")
+		}
+		sums[key] = sum.Add(entry.Amount)
+	}
+
+	var wallets []*entity.Wallet
+	for key, sum := range sums {
+		wallets = append(wallets, &entity.Wallet{UserID: key.userID, DungeonID: key.dungeonID, Balance: sum})
+	}
+	sortWallets(wallets)
+	return wallets, nil
+}
+
+// Snapshot implements Snapshotter
+func (r *LedgerRepository) Snapshot() func() {
+	r.mu.RLock()
+	entries := slices.Clone(r.entries)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.entries = entries
+	}
+}
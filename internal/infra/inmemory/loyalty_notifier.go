@@ -0,0 +1,51 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// TierNotification is a notification recorded by LoyaltyNotifier
+type TierNotification struct {
+	UserID int64
+	TierID int64
+}
+
+// LoyaltyNotifier records tier notifications instead of sending them
+type LoyaltyNotifier struct {
+	mu   sync.Mutex
+	sent []TierNotification
+	Err  error // Returned by NotifyTierReached when set
+}
+
+func NewLoyaltyNotifier() *LoyaltyNotifier {
+	return &LoyaltyNotifier{}
+}
+
+func (n *LoyaltyNotifier) NotifyTierReached(ctx context.Context, user *entity.User, tier *entity.RewardTier) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+
+	n.sent = append(n.sent, TierNotification{UserID: user.ID, TierID: tier.ID})
+	return nil
+}
+
+// Sent returns the notifications recorded so far
+func (n *LoyaltyNotifier) Sent() []TierNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sent := make([]TierNotification, len(n.sent))
+	copy(sent, n.sent)
+	return sent
+}
+
+// Compile-time check that LoyaltyNotifier implements ports.LoyaltyNotifier
+var _ ports.LoyaltyNotifier = (*LoyaltyNotifier)(nil)
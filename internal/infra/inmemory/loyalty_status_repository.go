@@ -0,0 +1,58 @@
+package inmemory
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type loyaltyStatusKey struct {
+	userID    int64
+	dungeonID string
+}
+
+type LoyaltyStatusRepository struct {
+	mu       sync.RWMutex
+	statuses map[loyaltyStatusKey]entity.LoyaltyStatus
+}
+
+func NewLoyaltyStatusRepository() *LoyaltyStatusRepository {
+	return &LoyaltyStatusRepository{
+		statuses: make(map[loyaltyStatusKey]entity.LoyaltyStatus),
+	}
+}
+
+func (r *LoyaltyStatusRepository) Find(ctx context.Context, userID int64, dungeonID string) (*entity.LoyaltyStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status, exists := r.statuses[loyaltyStatusKey{userID, dungeonID}]
+	if !exists {
+		return nil, ports.ErrLoyaltyStatusNotFound
+	}
+	return &status, nil
+}
+
+func (r *LoyaltyStatusRepository) Save(ctx context.Context, status *entity.LoyaltyStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statuses[loyaltyStatusKey{status.UserID, status.DungeonID}] = *status
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *LoyaltyStatusRepository) Snapshot() func() {
+	r.mu.RLock()
+	statuses := maps.Clone(r.statuses)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.statuses = statuses
+	}
+}
@@ -0,0 +1,66 @@
+package inmemory
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type memberPriceKey struct {
+	itemID int64
+	userID int64
+}
+
+type MemberPriceRepository struct {
+	mu     sync.RWMutex
+	prices map[memberPriceKey]entity.MemberPrice
+}
+
+func NewMemberPriceRepository() *MemberPriceRepository {
+	return &MemberPriceRepository{
+		prices: make(map[memberPriceKey]entity.MemberPrice),
+	}
+}
+
+func (r *MemberPriceRepository) Set(ctx context.Context, price *entity.MemberPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prices[memberPriceKey{price.ItemID, price.UserID}] = *price
+	return nil
+}
+
+func (r *MemberPriceRepository) Find(ctx context.Context, itemID, userID int64) (*entity.MemberPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	price, exists := r.prices[memberPriceKey{itemID, userID}]
+	if !exists {
+		return nil, ports.ErrMemberPriceNotFound
+	}
+	return &price, nil
+}
+
+func (r *MemberPriceRepository) Delete(ctx context.Context, itemID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.prices, memberPriceKey{itemID, userID})
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *MemberPriceRepository) Snapshot() func() {
+	r.mu.RLock()
+	prices := maps.Clone(r.prices)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.prices = prices
+	}
+}
@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
@@ -49,33 +50,56 @@ func (r *PurchaseRepository) FindByID(ctx context.Context, id int64) (*entity.Pu
 }
 
 func (r *PurchaseRepository) FindByUserID(ctx context.Context, userID int64) ([]*entity.Purchase, error) {
+	return r.filter(func(purchase *entity.Purchase) bool { return purchase.UserID == userID }), nil
+}
+
+func (r *PurchaseRepository) FindByItemID(ctx context.Context, itemID int64) ([]*entity.Purchase, error) {
+	return r.filter(func(purchase *entity.Purchase) bool { return purchase.ItemID == itemID }), nil
+}
+
+// filter returns copies of matching purchases, newest first
+func (r *PurchaseRepository) filter(match func(*entity.Purchase) bool) []*entity.Purchase {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var purchases []*entity.Purchase
 	for _, purchase := range r.purchases {
-		if purchase.UserID == userID {
-			// Create a copy
+		if match(purchase) {
 			purchaseCopy := *purchase
 			purchases = append(purchases, &purchaseCopy)
 		}
 	}
+	sort.Slice(purchases, func(i, j int) bool {
+		if !purchases[i].PurchasedAt.Equal(purchases[j].PurchasedAt) {
+			return purchases[i].PurchasedAt.After(purchases[j].PurchasedAt)
+		}
+		return purchases[i].ID > purchases[j].ID
+	})
+	return purchases
+}
+
+func (r *PurchaseRepository) Update(ctx context.Context, purchase *entity.Purchase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.purchases[purchase.ID]; !exists {
+		return ports.ErrPurchaseNotFound
+	}
 
-	return purchases, nil
+	purchaseCopy := *purchase
+	r.purchases[purchase.ID] = &purchaseCopy
+	return nil
 }
 
-func (r *PurchaseRepository) FindByItemID(ctx context.Context, itemID int64) ([]*entity.Purchase, error) {
+// Snapshot implements Snapshotter
+func (r *PurchaseRepository) Snapshot() func() {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	purchases, nextID := cloneMap(r.purchases), r.nextID
+	r.mu.RUnlock()
 
-	var purchases []*entity.Purchase
-	for _, purchase := range r.purchases {
-		if purchase.ItemID == itemID {
-			// Create a copy
-			purchaseCopy := *purchase
-			purchases = append(purchases, &purchaseCopy)
-		}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.purchases, r.nextID = purchases, nextID
 	}
-
-	return purchases, nil
 }
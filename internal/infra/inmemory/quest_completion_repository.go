@@ -0,0 +1,91 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type QuestCompletionRepository struct {
+	mu          sync.RWMutex
+	completions []*entity.QuestCompletion // In insert order
+}
+
+func NewQuestCompletionRepository() *QuestCompletionRepository {
+	return &QuestCompletionRepository{}
+}
+
+func (r *QuestCompletionRepository) Insert(ctx context.Context, completion *entity.QuestCompletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if completion.IdempotencyKey != "" {
+		for _, existing := range r.completions {
+			if existing.IdempotencyKey == completion.IdempotencyKey {
+				return ports.ErrIdempotencyKeyExists
+			}
+		}
+	}
+
+	completionCopy := *completion
+	r.completions = append(r.completions, &completionCopy)
+	return nil
+}
+
+func (r *QuestCompletionRepository) LastForUser(ctx context.Context, userID int64, questID string) (*entity.QuestCompletion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var last *entity.QuestCompletion
+	for _, completion := range r.completions {
+		if completion.UserID != userID || completion.QuestID != questID {
+			continue
+		}
+		if last == nil || !completion.SubmittedAt.Before(last.SubmittedAt) {
+			last = completion
+		}
+	}
+	if last == nil {
+		return nil, ports.ErrQuestCompletionNotFound
+	}
+
+	completionCopy := *last
+	return &completionCopy, nil
+}
+
+func (r *QuestCompletionRepository) SumAwardedForUserOnDay(ctx context.Context, userID int64, questID string, day time.Time, tz string) (valueobject.Decimal, error) {
+	sum := valueobject.NewDecimal("0")
+
+	window, err := valueobject.NewDayWindow(day, tz)
+	if err != nil {
+		return sum, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, completion := range r.completions {
+		if completion.UserID == userID && completion.QuestID == questID && window.Contains(completion.SubmittedAt) {
+			sum = sum.Add(completion.AwardedPoints)
+		}
+	}
+	return sum, nil
+}
+
+// Snapshot implements Snapshotter
+func (r *QuestCompletionRepository) Snapshot() func() {
+	r.mu.RLock()
+	completions := cloneSlice(r.completions)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.completions = completions
+	}
+}
@@ -0,0 +1,104 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type QuestRepository struct {
+	mu     sync.RWMutex
+	quests map[string]*entity.Quest
+}
+
+func NewQuestRepository() *QuestRepository {
+	return &QuestRepository{
+		quests: make(map[string]*entity.Quest),
+	}
+}
+
+func (r *QuestRepository) Create(ctx context.Context, quest *entity.Quest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.quests[quest.ID]; exists {
+		return fmt.Errorf("quest %s already exists", quest.ID)
+	}
+
+	questCopy := *quest
+	r.quests[quest.ID] = &questCopy
+	return nil
+}
+
+func (r *QuestRepository) GetByID(ctx context.Context, questID string) (*entity.Quest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quest, exists := r.quests[questID]
+	if !exists {
+		return nil, ports.ErrQuestNotFound
+	}
+
+	questCopy := *quest
+	return &questCopy, nil
+}
+
+// ListByDungeon returns copies of the dungeon's quests, oldest first
+func (r *QuestRepository) ListByDungeon(ctx context.Context, dungeonID string) ([]*entity.Quest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var quests []*entity.Quest
+	for _, quest := range r.quests {
+		if quest.DungeonID == dungeonID {
+			questCopy := *quest
+			quests = append(quests, &questCopy)
+		}
+	}
+
+	sort.Slice(quests, func(i, j int) bool {
+		if !quests[i].CreatedAt.Equal(quests[j].CreatedAt) {
+			return quests[i].CreatedAt.Before(quests[j].CreatedAt)
+		}
+		return quests[i].ID < quests[j].ID
+	})
+	return quests, nil
+}
+
+func (r *QuestRepository) Update(ctx context.Context, quest *entity.Quest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.quests[quest.ID]; !exists {
+		return ports.ErrQuestNotFound
+	}
+
+	questCopy := *quest
+	r.quests[quest.ID] = &questCopy
+	return nil
+}
+
+func (r *QuestRepository) Delete(ctx context.Context, questID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.quests, questID)
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *QuestRepository) Snapshot() func() {
+	r.mu.RLock()
+	quests := cloneMap(r.quests)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.quests = quests
+	}
+}
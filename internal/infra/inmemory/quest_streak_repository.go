@@ -0,0 +1,81 @@
+package inmemory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type questStreakKey struct {
+	userID  int64
+	questID string
+}
+
+type QuestStreakRepository struct {
+	mu      sync.RWMutex
+	streaks map[questStreakKey]entity.QuestStreak
+}
+
+func NewQuestStreakRepository() *QuestStreakRepository {
+	return &QuestStreakRepository{
+		streaks: make(map[questStreakKey]entity.QuestStreak),
+	}
+}
+
+func (r *QuestStreakRepository) Get(ctx context.Context, userID int64, questID string) (*entity.QuestStreak, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	streak, exists := r.streaks[questStreakKey{userID, questID}]
+	if !exists {
+		return nil, ports.ErrQuestStreakNotFound
+	}
+	return &streak, nil
+}
+
+func (r *QuestStreakRepository) Upsert(ctx context.Context, streak *entity.QuestStreak) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.streaks[questStreakKey{streak.UserID, streak.QuestID}] = *streak
+	return nil
+}
+
+// ListByUser returns the user's streaks, longest best streak first
+func (r *QuestStreakRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.QuestStreak, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var streaks []*entity.QuestStreak
+	for key, streak := range r.streaks {
+		if key.userID == userID {
+			streakCopy := streak
+			streaks = append(streaks, &streakCopy)
+		}
+	}
+
+	sort.Slice(streaks, func(i, j int) bool {
+		if streaks[i].Best != streaks[j].Best {
+			return streaks[i].Best > streaks[j].Best
+		}
+		return streaks[i].QuestID < streaks[j].QuestID
+	})
+	return streaks, nil
+}
+
+// Snapshot implements Snapshotter
+func (r *QuestStreakRepository) Snapshot() func() {
+	r.mu.RLock()
+	streaks := maps.Clone(r.streaks)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.streaks = streaks
+	}
+}
@@ -0,0 +1,116 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type RewardTierRepository struct {
+	mu     sync.RWMutex
+	tiers  map[int64]*entity.RewardTier
+	nextID int64
+}
+
+func NewRewardTierRepository() *RewardTierRepository {
+	return &RewardTierRepository{
+		tiers:  make(map[int64]*entity.RewardTier),
+		nextID: 1,
+	}
+}
+
+func (r *RewardTierRepository) Create(ctx context.Context, tier *entity.RewardTier) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tier.ID == 0 {
+		tier.ID = r.nextID
+	}
+	if tier.ID >= r.nextID {
+		r.nextID = tier.ID + 1
+	}
+
+	tierCopy := *tier
+	r.tiers[tier.ID] = &tierCopy
+	return nil
+}
+
+func (r *RewardTierRepository) FindByID(ctx context.Context, id int64) (*entity.RewardTier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tier, exists := r.tiers[id]
+	if !exists {
+		return nil, ports.ErrRewardTierNotFound
+	}
+
+	tierCopy := *tier
+	return &tierCopy, nil
+}
+
+func (r *RewardTierRepository) FindAll(ctx context.Context) ([]*entity.RewardTier, error) {
+	return r.find(func(*entity.RewardTier) bool { return true }), nil
+}
+
+func (r *RewardTierRepository) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.RewardTier, error) {
+	return r.find(func(tier *entity.RewardTier) bool { return tier.DungeonID == dungeonID }), nil
+}
+
+// find returns copies of the matching tiers ordered by threshold
+func (r *RewardTierRepository) find(match func(*entity.RewardTier) bool) []*entity.RewardTier {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tiers []*entity.RewardTier
+	for _, tier := range r.tiers {
+		if match(tier) {
+			tierCopy := *tier
+			tiers = append(tiers, &tierCopy)
+		}
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		if tiers[i].MinPurchases != tiers[j].MinPurchases {
+			return tiers[i].MinPurchases < tiers[j].MinPurchases
+		}
+		return tiers[i].ID < tiers[j].ID
+	})
+	return tiers
+}
+
+func (r *RewardTierRepository) Update(ctx context.Context, tier *entity.RewardTier) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tiers[tier.ID]; !exists {
+		return ports.ErrRewardTierNotFound
+	}
+
+	tierCopy := *tier
+	r.tiers[tier.ID] = &tierCopy
+	return nil
+}
+
+func (r *RewardTierRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tiers, id)
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (r *RewardTierRepository) Snapshot() func() {
+	r.mu.RLock()
+	tiers, nextID := cloneMap(r.tiers), r.nextID
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.tiers, r.nextID = tiers, nextID
+	}
+}
@@ -0,0 +1,149 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// ScheduleRepository stores schedules by ID. Schedules do not record a user,
+// so FindByUser looks up the quest each schedule belongs to and returns the
+// ones in dungeons the user is a member of.
+type ScheduleRepository struct {
+	mu        sync.RWMutex
+	schedules map[string]*entity.Schedule
+	quests    ports.QuestRepository
+	members   ports.DungeonMemberRepository
+}
+
+func NewScheduleRepository(quests ports.QuestRepository, members ports.DungeonMemberRepository) *ScheduleRepository {
+	return &ScheduleRepository{
+		schedules: make(map[string]*entity.Schedule),
+		quests:    quests,
+		members:   members,
+	}
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, schedule *entity.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schedules[schedule.ID]; exists {
+		return fmt.Errorf("schedule %s already exists", schedule.ID)
+	}
+
+	r.schedules[schedule.ID] = copySchedule(schedule)
+	return nil
+}
+
+func (r *ScheduleRepository) FindByID(ctx context.Context, id string) (*entity.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedule, exists := r.schedules[id]
+	if !exists {
+		return nil, ports.ErrTaskNotFound
+	}
+	return copySchedule(schedule), nil
+}
+
+func (r *ScheduleRepository) FindByTask(ctx context.Context, taskID string) ([]*entity.Schedule, error) {
+	return r.find(func(schedule *entity.Schedule) bool { return schedule.TaskID == taskID }), nil
+}
+
+func (r *ScheduleRepository) FindByUser(ctx context.Context, userID int64) ([]*entity.Schedule, error) {
+	var schedules []*entity.Schedule
+	for _, schedule := range r.find(func(*entity.Schedule) bool { return true }) {
+		quest, err := r.quests.GetByID(ctx, schedule.TaskID)
+		if errors.Is(err, ports.ErrQuestNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		isMember, err := r.members.IsMember(ctx, quest.DungeonID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if isMember {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, schedule *entity.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schedules[schedule.ID]; !exists {
+		return ports.ErrTaskNotFound
+	}
+
+	r.schedules[schedule.ID] = copySchedule(schedule)
+	return nil
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.schedules, id)
+	return nil
+}
+
+// find returns copies of the matching schedules, oldest first
+func (r *ScheduleRepository) find(match func(*entity.Schedule) bool) []*entity.Schedule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var schedules []*entity.Schedule
+	for _, schedule := range r.schedules {
+		if match(schedule) {
+			schedules = append(schedules, copySchedule(schedule))
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+// Snapshot implements Snapshotter
+func (r *ScheduleRepository) Snapshot() func() {
+	r.mu.RLock()
+	schedules := make(map[string]*entity.Schedule, len(r.schedules))
+	for id, schedule := range r.schedules {
+		schedules[id] = copySchedule(schedule)
+	}
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.schedules = schedules
+	}
+}
+
+// copySchedule copies the schedule including its end date and day lists
+func copySchedule(schedule *entity.Schedule) *entity.Schedule {
+	scheduleCopy := *schedule
+	if schedule.EndDate != nil {
+		endDate := *schedule.EndDate
+		scheduleCopy.EndDate = &endDate
+	}
+	scheduleCopy.DaysOfWeek = slices.Clone(schedule.DaysOfWeek)
+	scheduleCopy.DaysOfMonth = slices.Clone(schedule.DaysOfMonth)
+	return &scheduleCopy
+}
@@ -2,38 +2,203 @@ package inmemory
 
 import (
 	"context"
+	"maps"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
 type InMemoryScheduler struct {
-	scheduledTasks map[string]*entity.Quest
+	mu          sync.Mutex
+	schedules   map[string]*entity.Schedule             // keyed by task ID
+	occurrences map[string][]*entity.ScheduleOccurrence // keyed by schedule ID, oldest first
+	nextRun     map[string]*time.Time                   // keyed by schedule ID
 }
 
 func NewInMemoryScheduler() *InMemoryScheduler {
 	return &InMemoryScheduler{
-		scheduledTasks: make(map[string]*entity.Quest),
+		schedules:   make(map[string]*entity.Schedule),
+		occurrences: make(map[string][]*entity.ScheduleOccurrence),
+		nextRun:     make(map[string]*time.Time),
 	}
 }
 
+// ScheduleRecurringTask creates or refreshes the quest's schedule. When the quest
+// was completed, the occurrence that was due at that time is marked completed.
 func (s *InMemoryScheduler) ScheduleRecurringTask(ctx context.Context, quest *entity.Quest) error {
-	s.scheduledTasks[quest.ID] = quest
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	schedule, err := entity.NewQuestSchedule(uuid.New().String(), quest, now)
+	if err != nil {
+		return err
+	}
+
+	if existing, ok := s.schedules[quest.ID]; ok {
+		existing.Type = schedule.Type
+		existing.DaysOfWeek = schedule.DaysOfWeek
+		existing.Timezone = schedule.Timezone
+		existing.EndDate = nil
+		existing.UpdatedAt = now
+		schedule = existing
+	} else {
+		s.schedules[quest.ID] = schedule
+	}
+
+	if err := s.advance(schedule, now); err != nil {
+		return err
+	}
+
+	if quest.LastCompletedAt != nil {
+		occurrences := s.occurrences[schedule.ID]
+		for i := len(occurrences) - 1; i >= 0; i-- {
+			if occurrences[i].ScheduledFor.After(*quest.LastCompletedAt) {
+				continue
+			}
+			if occurrences[i].IsOpen() {
+				completedAt := *quest.LastCompletedAt
+				occurrences[i].CompletedAt = &completedAt
+			}
+			break
+		}
+	}
+
 	return nil
 }
 
 func (s *InMemoryScheduler) CancelScheduledTask(ctx context.Context, questID string) error {
-	delete(s.scheduledTasks, questID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, exists := s.schedules[questID]
+	if !exists {
+		return ports.ErrTaskNotFound
+	}
+
+	// Keep past occurrences as history, drop the upcoming ones
+	now := time.Now()
+	var kept []*entity.ScheduleOccurrence
+	for _, occurrence := range s.occurrences[schedule.ID] {
+		if !occurrence.ScheduledFor.After(now) {
+			kept = append(kept, occurrence)
+		}
+	}
+	s.occurrences[schedule.ID] = kept
+	schedule.EndDate = &now
+	s.nextRun[schedule.ID] = nil
 	return nil
 }
 
 func (s *InMemoryScheduler) GetNextOccurrence(ctx context.Context, questID string) (time.Time, error) {
-	if _, exists := s.scheduledTasks[questID]; exists {
-		// Simple implementation - assumes daily recurrence
-		now := time.Now()
-		next := now.Add(24 * time.Hour)
-		return next, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, exists := s.schedules[questID]
+	if !exists {
+		return time.Time{}, ports.ErrTaskNotFound
+	}
+
+	now := time.Now()
+	for _, occurrence := range s.occurrences[schedule.ID] {
+		if occurrence.ScheduledFor.After(now) {
+			return occurrence.ScheduledFor, nil
+		}
+	}
+
+	next, ok, err := schedule.NextOccurrence(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return time.Time{}, entity.ErrScheduleEnded
+	}
+	return next, nil
+}
+
+func (s *InMemoryScheduler) Tick(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, schedule := range s.schedules {
+		nextRun := s.nextRun[schedule.ID]
+		if nextRun == nil || nextRun.After(now) {
+			continue
+		}
+		if err := s.advance(schedule, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Occurrences returns copies of the persisted occurrences of a task's schedule, oldest first
+func (s *InMemoryScheduler) Occurrences(ctx context.Context, questID string) ([]entity.ScheduleOccurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, exists := s.schedules[questID]
+	if !exists {
+		return nil, ports.ErrTaskNotFound
+	}
+
+	occurrences := make([]entity.ScheduleOccurrence, len(s.occurrences[schedule.ID]))
+	for i, occurrence := range s.occurrences[schedule.ID] {
+		occurrences[i] = *occurrence
+	}
+	return occurrences, nil
+}
+
+// advance must be called with s.mu held
+func (s *InMemoryScheduler) advance(schedule *entity.Schedule, now time.Time) error {
+	occurrences := s.occurrences[schedule.ID]
+
+	var latest *time.Time
+	if len(occurrences) > 0 {
+		latest = &occurrences[len(occurrences)-1].ScheduledFor
+	}
+
+	adv, err := schedule.Advance(latest, now)
+	if err != nil {
+		return err
+	}
+
+	for _, scheduledFor := range adv.New {
+		occurrences = append(occurrences, &entity.ScheduleOccurrence{
+			ID:           uuid.New().String(),
+			ScheduleID:   schedule.ID,
+			ScheduledFor: scheduledFor,
+		})
+	}
+
+	for _, occurrence := range occurrences {
+		if occurrence.ScheduledFor.Before(adv.Current) {
+			occurrence.Close(schedule.SkipIfMissed)
+		}
+	}
+
+	s.occurrences[schedule.ID] = occurrences
+	s.nextRun[schedule.ID] = adv.NextRun
+	return nil
+}
+
+// Snapshot implements Snapshotter
+func (s *InMemoryScheduler) Snapshot() func() {
+	s.mu.Lock()
+	schedules := cloneMap(s.schedules)
+	occurrences := make(map[string][]*entity.ScheduleOccurrence, len(s.occurrences))
+	for id, list := range s.occurrences {
+		occurrences[id] = cloneSlice(list)
+	}
+	nextRun := maps.Clone(s.nextRun) // Advancing replaces these times rather than changing them
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.schedules, s.occurrences, s.nextRun = schedules, occurrences, nextRun
 	}
-	return time.Time{}, ports.ErrTaskNotFound
 }
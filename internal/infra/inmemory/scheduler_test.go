@@ -0,0 +1,93 @@
+package inmemory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+func TestInMemoryScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("schedules the current and next day", func(t *testing.T) {
+		scheduler := inmemory.NewInMemoryScheduler()
+		quest := &entity.Quest{ID: "quest-1", Category: "daily", TimeZone: "UTC"}
+		require.NoError(t, scheduler.ScheduleRecurringTask(ctx, quest))
+
+		occurrences, err := scheduler.Occurrences(ctx, "quest-1")
+		require.NoError(t, err)
+		require.Len(t, occurrences, 2)
+
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		assert.True(t, occurrences[0].ScheduledFor.Equal(today))
+		assert.True(t, occurrences[1].ScheduledFor.Equal(today.Add(24*time.Hour)))
+
+		next, err := scheduler.GetNextOccurrence(ctx, "quest-1")
+		require.NoError(t, err)
+		assert.True(t, next.Equal(today.Add(24*time.Hour)))
+	})
+
+	t.Run("completion marks the due occurrence", func(t *testing.T) {
+		scheduler := inmemory.NewInMemoryScheduler()
+		quest := &entity.Quest{ID: "quest-1", Category: "daily"}
+		require.NoError(t, scheduler.ScheduleRecurringTask(ctx, quest))
+
+		completedAt := time.Now()
+		quest.LastCompletedAt = &completedAt
+		require.NoError(t, scheduler.ScheduleRecurringTask(ctx, quest))
+
+		occurrences, err := scheduler.Occurrences(ctx, "quest-1")
+		require.NoError(t, err)
+		require.Len(t, occurrences, 2)
+		require.NotNil(t, occurrences[0].CompletedAt)
+		assert.True(t, occurrences[1].IsOpen())
+	})
+
+	t.Run("tick marks uncompleted occurrences missed", func(t *testing.T) {
+		scheduler := inmemory.NewInMemoryScheduler()
+		require.NoError(t, scheduler.ScheduleRecurringTask(ctx, &entity.Quest{ID: "quest-1", Category: "daily"}))
+
+		// Nothing is due yet
+		require.NoError(t, scheduler.Tick(ctx, time.Now()))
+		occurrences, _ := scheduler.Occurrences(ctx, "quest-1")
+		require.Len(t, occurrences, 2)
+
+		// Three days later
+		require.NoError(t, scheduler.Tick(ctx, time.Now().Add(72*time.Hour)))
+		occurrences, _ = scheduler.Occurrences(ctx, "quest-1")
+		require.Len(t, occurrences, 5)
+		for _, occurrence := range occurrences[:3] {
+			assert.True(t, occurrence.Missed)
+			assert.False(t, occurrence.Skipped)
+		}
+		assert.True(t, occurrences[3].IsOpen())
+		assert.True(t, occurrences[4].IsOpen())
+	})
+
+	t.Run("cancel drops upcoming occurrences", func(t *testing.T) {
+		scheduler := inmemory.NewInMemoryScheduler()
+		require.NoError(t, scheduler.ScheduleRecurringTask(ctx, &entity.Quest{ID: "quest-1", Category: "weekly"}))
+		require.NoError(t, scheduler.CancelScheduledTask(ctx, "quest-1"))
+
+		_, err := scheduler.GetNextOccurrence(ctx, "quest-1")
+		assert.ErrorIs(t, err, entity.ErrScheduleEnded)
+
+		require.NoError(t, scheduler.Tick(ctx, time.Now().Add(30*24*time.Hour)))
+		occurrences, _ := scheduler.Occurrences(ctx, "quest-1")
+		for _, occurrence := range occurrences {
+			assert.False(t, occurrence.ScheduledFor.After(time.Now()))
+		}
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		scheduler := inmemory.NewInMemoryScheduler()
+		_, err := scheduler.GetNextOccurrence(ctx, "missing")
+		assert.ErrorIs(t, err, ports.ErrTaskNotFound)
+	})
+}
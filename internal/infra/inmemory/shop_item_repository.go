@@ -2,7 +2,9 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
@@ -25,6 +27,10 @@ func (r *ShopItemRepository) Create(ctx context.Context, item *entity.ShopItem)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.codeTaken(item) {
+		return ports.ErrShopItemCodeExists
+	}
+
 	if item.ID == 0 {
 		item.ID = r.nextID
 		r.nextID++
@@ -52,12 +58,12 @@ func (r *ShopItemRepository) FindByID(ctx context.Context, id int64) (*entity.Sh
 	return &itemCopy, nil
 }
 
-func (r *ShopItemRepository) FindByCode(ctx context.Context, chatID int64, code string) (*entity.ShopItem, error) {
+func (r *ShopItemRepository) FindByCode(ctx context.Context, dungeonID string, code string) (*entity.ShopItem, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, item := range r.items {
-		if item.ChatID == chatID && item.Code == code {
+		if item.DungeonID != nil && *item.DungeonID == dungeonID && item.Code == code {
 			// Return a copy
 			itemCopy := *item
 			if item.Stock != nil {
@@ -71,14 +77,13 @@ func (r *ShopItemRepository) FindByCode(ctx context.Context, chatID int64, code
 	return nil, ports.ErrShopItemNotFound
 }
 
-func (r *ShopItemRepository) FindByChatID(ctx context.Context, chatID int64) ([]*entity.ShopItem, error) {
+func (r *ShopItemRepository) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var items []*entity.ShopItem
 	for _, item := range r.items {
-		if item.ChatID == chatID {
-			// Create a copy
+		if item.DungeonID != nil && *item.DungeonID == dungeonID {
 			itemCopy := *item
 			if item.Stock != nil {
 				stockCopy := *item.Stock
@@ -88,6 +93,7 @@ func (r *ShopItemRepository) FindByChatID(ctx context.Context, chatID int64) ([]
 		}
 	}
 
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
 	return items, nil
 }
 
@@ -99,6 +105,9 @@ func (r *ShopItemRepository) Update(ctx context.Context, item *entity.ShopItem)
 	if !exists {
 		return ports.ErrShopItemNotFound
 	}
+	if r.codeTaken(item) {
+		return ports.ErrShopItemCodeExists
+	}
 
 	// Check stock constraints
 	if item.Stock != nil && existing.Stock != nil {
@@ -117,6 +126,30 @@ func (r *ShopItemRepository) Update(ctx context.Context, item *entity.ShopItem)
 	return nil
 }
 
+func (r *ShopItemRepository) AdjustStock(ctx context.Context, id int64, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, exists := r.items[id]
+	if !exists {
+		return ports.ErrShopItemNotFound
+	}
+	if item.Stock == nil {
+		return nil
+	}
+	if *item.Stock+delta < 0 {
+		return ports.ErrInsufficientStock
+	}
+
+	// Store a fresh copy so items handed out earlier keep their stock
+	itemCopy := *item
+	stock := *item.Stock + delta
+	itemCopy.Stock = &stock
+	itemCopy.UpdatedAt = time.Now()
+	r.items[id] = &itemCopy
+	return nil
+}
+
 func (r *ShopItemRepository) Delete(ctx context.Context, id int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -128,3 +161,37 @@ func (r *ShopItemRepository) Delete(ctx context.Context, id int64) error {
 	delete(r.items, id)
 	return nil
 }
+
+// codeTaken reports whether another item of the same dungeon uses item's code
+func (r *ShopItemRepository) codeTaken(item *entity.ShopItem) bool {
+	if item.DungeonID == nil {
+		return false
+	}
+	for _, other := range r.items {
+		if other.ID != item.ID && other.DungeonID != nil && *other.DungeonID == *item.DungeonID && other.Code == item.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot implements Snapshotter
+func (r *ShopItemRepository) Snapshot() func() {
+	r.mu.RLock()
+	items, nextID := cloneMap(r.items), r.nextID
+	r.mu.RUnlock()
+
+	// Copy stock too so the snapshot shares nothing with the stored items
+	for _, item := range items {
+		if item.Stock != nil {
+			stockCopy := *item.Stock
+			item.Stock = &stockCopy
+		}
+	}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.items, r.nextID = items, nextID
+	}
+}
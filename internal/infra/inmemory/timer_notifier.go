@@ -0,0 +1,60 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// TimerNotification is a notification recorded by TimerNotifier
+type TimerNotification struct {
+	ID      string
+	TimerID string
+	UserID  int64
+	QuestID string
+}
+
+// TimerNotifier records expiry notifications instead of sending them
+type TimerNotifier struct {
+	mu   sync.Mutex
+	sent []TimerNotification
+	Err  error // Returned by NotifyTimerExpired when set
+}
+
+func NewTimerNotifier() *TimerNotifier {
+	return &TimerNotifier{}
+}
+
+func (n *TimerNotifier) NotifyTimerExpired(ctx context.Context, timer *entity.Timer, quest *entity.Quest) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return "", n.Err
+	}
+
+	notification := TimerNotification{
+		ID:      fmt.Sprintf("notification-%d", len(n.sent)+1),
+		TimerID: timer.ID,
+		UserID:  timer.UserID,
+		QuestID: quest.ID,
+	}
+	n.sent = append(n.sent, notification)
+	return notification.ID, nil
+}
+
+// Sent returns the notifications recorded so far
+func (n *TimerNotifier) Sent() []TimerNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sent := make([]TimerNotification, len(n.sent))
+	copy(sent, n.sent)
+	return sent
+}
+
+// Compile-time check that TimerNotifier implements ports.TimerNotifier
+var _ ports.TimerNotifier = (*TimerNotifier)(nil)
@@ -0,0 +1,248 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type TimerRepository struct {
+	mu     sync.RWMutex
+	timers map[string]*entity.Timer
+}
+
+func NewTimerRepository() *TimerRepository {
+	return &TimerRepository{
+		timers: make(map[string]*entity.Timer),
+	}
+}
+
+func (r *TimerRepository) Create(ctx context.Context, timer *entity.Timer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timerCopy := *timer
+	r.timers[timer.ID] = &timerCopy
+	return nil
+}
+
+func (r *TimerRepository) FindByID(ctx context.Context, id string) (*entity.Timer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	timer, exists := r.timers[id]
+	if !exists {
+		return nil, ports.ErrTimerNotFound
+	}
+
+	// Return a copy to prevent external modifications
+	timerCopy := *timer
+	return &timerCopy, nil
+}
+
+func (r *TimerRepository) FindByUser(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	return r.filter(func(timer *entity.Timer) bool { return timer.UserID == userID }), nil
+}
+
+func (r *TimerRepository) FindByTask(ctx context.Context, taskID string) ([]*entity.Timer, error) {
+	return r.filter(func(timer *entity.Timer) bool { return timer.TaskID == taskID }), nil
+}
+
+func (r *TimerRepository) FindActiveByUser(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	return r.filter(func(timer *entity.Timer) bool { return timer.UserID == userID && timer.IsActive() }), nil
+}
+
+func (r *TimerRepository) FindUsersWithActiveTimers(ctx context.Context) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[int64]bool)
+	var userIDs []int64
+	for _, timer := range r.timers {
+		if timer.IsActive() && !seen[timer.UserID] {
+			seen[timer.UserID] = true
+			userIDs = append(userIDs, timer.UserID)
+		}
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return userIDs, nil
+}
+
+func (r *TimerRepository) Update(ctx context.Context, timer *entity.Timer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.timers[timer.ID]; !exists {
+		return ports.ErrTimerNotFound
+	}
+
+	timerCopy := *timer
+	r.timers[timer.ID] = &timerCopy
+	return nil
+}
+
+func (r *TimerRepository) ClaimExpiry(ctx context.Context, timerID, claim string, now, retryBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timer, exists := r.timers[timerID]
+	if !exists || timer.Status != entity.TimerStatusRunning {
+		return 0, ports.ErrTimerNotificationTaken
+	}
+	unclaimed := timer.NotificationID == nil && timer.NotificationClaimedAt == nil
+	if !unclaimed && (timer.NotificationClaimedAt == nil || timer.NotificationClaimedAt.After(retryBefore)) {
+		return 0, ports.ErrTimerNotificationTaken
+	}
+
+	timerCopy := *timer
+	timerCopy.NotificationID = &claim
+	timerCopy.NotificationClaimedAt = &now
+	timerCopy.NotificationAttempts++
+	r.timers[timerID] = &timerCopy
+	return timerCopy.NotificationAttempts, nil
+}
+
+func (r *TimerRepository) SettleExpiry(ctx context.Context, timerID, claim string, notificationID *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timer, exists := r.timers[timerID]
+	if !exists || timer.Status != entity.TimerStatusRunning || timer.NotificationID == nil || *timer.NotificationID != claim {
+		return ports.ErrTimerNotificationTaken
+	}
+
+	timerCopy := *timer
+	timerCopy.NotificationID = notificationID
+	if notificationID != nil {
+		timerCopy.NotificationClaimedAt = nil
+	}
+	r.timers[timerID] = &timerCopy
+	return nil
+}
+
+func (r *TimerRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.timers, id)
+	return nil
+}
+
+func (r *TimerRepository) BulkUpdate(ctx context.Context, timers []*entity.Timer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, timer := range timers {
+		if _, exists := r.timers[timer.ID]; !exists {
+			return ports.ErrTimerNotFound
+		}
+	}
+	for _, timer := range timers {
+		timerCopy := *timer
+		r.timers[timer.ID] = &timerCopy
+	}
+	return nil
+}
+
+// filter returns copies of matching timers, most recently started first
+func (r *TimerRepository) filter(match func(*entity.Timer) bool) []*entity.Timer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var timers []*entity.Timer
+	for _, timer := range r.timers {
+		if match(timer) {
+			timerCopy := *timer
+			timers = append(timers, &timerCopy)
+		}
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].StartTime.After(timers[j].StartTime)
+	})
+	return timers
+}
+
+type TimerEventRepository struct {
+	mu     sync.RWMutex
+	events []*entity.TimerEvent
+}
+
+func NewTimerEventRepository() *TimerEventRepository {
+	return &TimerEventRepository{}
+}
+
+func (r *TimerEventRepository) Create(ctx context.Context, event *entity.TimerEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eventCopy := *event
+	r.events = append(r.events, &eventCopy)
+	return nil
+}
+
+func (r *TimerEventRepository) FindByTimer(ctx context.Context, timerID string) ([]*entity.TimerEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*entity.TimerEvent
+	for _, event := range r.events {
+		if event.TimerID == timerID {
+			eventCopy := *event
+			events = append(events, &eventCopy)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+func (r *TimerEventRepository) DeleteOldEvents(ctx context.Context, olderThan time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.Timestamp.Before(olderThan) {
+			kept = append(kept, event)
+		}
+	}
+	r.events = kept
+	return nil
+}
+
+// Compile-time checks that the repositories implement their ports
+var (
+	_ ports.TimerRepository      = (*TimerRepository)(nil)
+	_ ports.TimerEventRepository = (*TimerEventRepository)(nil)
+)
+
+// Snapshot implements Snapshotter
+func (r *TimerRepository) Snapshot() func() {
+	r.mu.RLock()
+	timers := cloneMap(r.timers)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.timers = timers
+	}
+}
+
+// Snapshot implements Snapshotter
+func (r *TimerEventRepository) Snapshot() func() {
+	r.mu.RLock()
+	events := cloneSlice(r.events)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = events
+	}
+}
@@ -2,19 +2,80 @@ package inmemory
 
 import (
 	"context"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
-// TxManager is a no-op transaction manager for in-memory repositories
-type TxManager struct{}
+// Snapshotter is a repository whose contents a TxManager saves before a
+// transaction and restores when it rolls back
+type Snapshotter interface {
+	// Snapshot copies the current contents and returns a function that puts
+	// them back
+	Snapshot() (restore func())
+}
+
+// TxManager gives in-memory repositories transaction semantics: it snapshots
+// the repositories it was created with and restores them when fn fails.
+// Transactions run one at a time. Writes made outside a transaction while
+// one is running are lost if it rolls back.
+type TxManager struct {
+	mu    sync.Mutex
+	repos []Snapshotter
+}
 
-// NewTxManager creates a new in-memory transaction manager
-func NewTxManager() *TxManager {
-	return &TxManager{}
+// NewTxManager creates a transaction manager over repos. Repositories not
+// passed here keep their writes when a transaction rolls back.
+func NewTxManager(repos ...Snapshotter) *TxManager {
+	return &TxManager{repos: repos}
 }
 
-// WithTx executes the given function without any actual transaction
-// This is suitable for in-memory repositories that don't need transactions
+// WithTx executes the given function within a transaction
 func (m *TxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
-	// Simply execute the function without any transaction
-	return fn(ctx)
+	// Check if we're already in a transaction
+	if tx := ports.TxFromContext(ctx); tx != nil {
+		// Already in a transaction, just execute the function
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	restores := make([]func(), len(m.repos))
+	for i, repo := range m.repos {
+		restores[i] = repo.Snapshot()
+	}
+
+	if err := fn(ports.ContextWithTx(ctx, m)); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// cloneMap copies m along with the values it points to, so changes made to
+// the stored entities after the snapshot do not leak into it
+func cloneMap[K comparable, V any](m map[K]*V) map[K]*V {
+	out := make(map[K]*V, len(m))
+	for k, v := range m {
+		valueCopy := *v
+		out[k] = &valueCopy
+	}
+	return out
 }
+
+// cloneSlice copies s along with the values it points to
+func cloneSlice[V any](s []*V) []*V {
+	out := make([]*V, len(s))
+	for i, v := range s {
+		valueCopy := *v
+		out[i] = &valueCopy
+	}
+	return out
+}
+
+// Compile-time check that TxManager implements ports.TxManager
+var _ ports.TxManager = (*TxManager)(nil)
@@ -0,0 +1,76 @@
+package inmemory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+func TestTxManager(t *testing.T) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	setup := func(t *testing.T) (*inmemory.TxManager, *inmemory.UserRepository, *inmemory.QuestRepository) {
+		userRepo := inmemory.NewUserRepository()
+		questRepo := inmemory.NewQuestRepository()
+		require.NoError(t, userRepo.Create(ctx, &entity.User{ID: 1, Balance: valueobject.NewDecimal("10")}))
+		return inmemory.NewTxManager(userRepo, questRepo), userRepo, questRepo
+	}
+
+	t.Run("Rollback restores every repository", func(t *testing.T) {
+		txManager, userRepo, questRepo := setup(t)
+
+		err := txManager.WithTx(ctx, func(ctx context.Context) error {
+			_, err := userRepo.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+			require.NoError(t, err)
+			require.NoError(t, questRepo.Create(ctx, &entity.Quest{ID: "q1", DungeonID: "d1"}))
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		user, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "10", user.Balance.String())
+		_, err = questRepo.GetByID(ctx, "q1")
+		assert.ErrorIs(t, err, ports.ErrQuestNotFound)
+	})
+
+	t.Run("Commit keeps writes", func(t *testing.T) {
+		txManager, userRepo, _ := setup(t)
+
+		err := txManager.WithTx(ctx, func(ctx context.Context) error {
+			_, err := userRepo.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+			return err
+		})
+		require.NoError(t, err)
+
+		user, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "15", user.Balance.String())
+	})
+
+	t.Run("Nested transaction rolls back with the outer one", func(t *testing.T) {
+		txManager, userRepo, _ := setup(t)
+
+		err := txManager.WithTx(ctx, func(ctx context.Context) error {
+			err := txManager.WithTx(ctx, func(ctx context.Context) error {
+				_, err := userRepo.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+				return err
+			})
+			require.NoError(t, err)
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		user, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "10", user.Balance.String())
+	})
+}
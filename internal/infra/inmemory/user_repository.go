@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -53,6 +54,17 @@ func (r *UserRepository) FindByID(ctx context.Context, id int64) (*entity.User,
 	return user, nil
 }
 
+// Lock only checks the user exists: the TxManager runs one transaction at a time
+func (r *UserRepository) Lock(ctx context.Context, id int64) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.users[id]; !exists {
+		return ports.ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *UserRepository) FindByChatID(ctx context.Context, chatID int64) ([]*entity.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -63,22 +75,36 @@ func (r *UserRepository) FindByChatID(ctx context.Context, chatID int64) ([]*ent
 			users = append(users, u)
 		}
 	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
 
 	return users, nil
 }
 
-func (r *UserRepository) UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) error {
+func (r *UserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*entity.User, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users, nil
+}
+
+func (r *UserRepository) UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) (valueobject.Decimal, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	user, exists := r.users[userID]
 	if !exists {
-		return ports.ErrUserNotFound
+		return valueobject.Decimal{}, ports.ErrUserNotFound
 	}
 
 	user.Balance = user.Balance.Add(delta)
 	user.UpdatedAt = time.Now()
-	return nil
+	return user.Balance, nil
 }
 
 func (r *UserRepository) Delete(ctx context.Context, id int64) error {
@@ -92,3 +118,16 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 	delete(r.users, id)
 	return nil
 }
+
+// Snapshot implements Snapshotter
+func (r *UserRepository) Snapshot() func() {
+	r.mu.RLock()
+	users := cloneMap(r.users)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.users = users
+	}
+}
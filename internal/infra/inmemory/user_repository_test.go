@@ -57,8 +57,9 @@ func TestUserRepository(t *testing.T) {
 
 	// Test UpdateBalance
 	t.Run("Update balance", func(t *testing.T) {
-		err := repo.UpdateBalance(ctx, 1, valueobject.NewDecimal("10.50"))
+		balance, err := repo.UpdateBalance(ctx, 1, valueobject.NewDecimal("10.50"))
 		assert.NoError(t, err)
+		assert.Equal(t, 10.5, balance.Float64())
 
 		user, err := repo.FindByID(ctx, 1)
 		assert.NoError(t, err)
@@ -68,7 +69,7 @@ func TestUserRepository(t *testing.T) {
 	})
 
 	t.Run("Update balance nonexistent user", func(t *testing.T) {
-		err := repo.UpdateBalance(ctx, 999, valueobject.NewDecimal("10.50"))
+		_, err := repo.UpdateBalance(ctx, 999, valueobject.NewDecimal("10.50"))
 		assert.Error(t, err)
 	})
 
@@ -0,0 +1,102 @@
+package inmemory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+type walletKey struct {
+	userID    int64
+	dungeonID string
+}
+
+type WalletRepository struct {
+	mu       sync.RWMutex
+	balances map[walletKey]valueobject.Decimal
+}
+
+func NewWalletRepository() *WalletRepository {
+	return &WalletRepository{
+		balances: make(map[walletKey]valueobject.Decimal),
+	}
+}
+
+func (r *WalletRepository) Get(ctx context.Context, userID int64, dungeonID string) (*entity.Wallet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	balance, exists := r.balances[walletKey{userID, dungeonID}]
+	if !exists {
+		balance = valueobject.NewDecimal("0")
+	}
+	return &entity.Wallet{UserID: userID, DungeonID: dungeonID, Balance: balance}, nil
+}
+
+// ListByUser returns the user's wallets ordered by dungeon ID
+func (r *WalletRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.Wallet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var wallets []*entity.Wallet
+	for key, balance := range r.balances {
+		if key.userID == userID {
+			wallets = append(wallets, &entity.Wallet{UserID: key.userID, DungeonID: key.dungeonID, Balance: balance})
+		}
+	}
+	sortWallets(wallets)
+	return wallets, nil
+}
+
+// FindAll returns every wallet ordered by user and dungeon
+func (r *WalletRepository) FindAll(ctx context.Context) ([]*entity.Wallet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var wallets []*entity.Wallet
+	for key, balance := range r.balances {
+		wallets = append(wallets, &entity.Wallet{UserID: key.userID, DungeonID: key.dungeonID, Balance: balance})
+	}
+	sortWallets(wallets)
+	return wallets, nil
+}
+
+func (r *WalletRepository) UpdateBalance(ctx context.Context, userID int64, dungeonID string, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := walletKey{userID, dungeonID}
+	balance, exists := r.balances[key]
+	if !exists {
+		balance = valueobject.NewDecimal("0")
+	}
+	balance = balance.Add(delta)
+	r.balances[key] = balance
+	return balance, nil
+}
+
+// Snapshot implements Snapshotter
+func (r *WalletRepository) Snapshot() func() {
+	r.mu.RLock()
+	balances := maps.Clone(r.balances)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.balances = balances
+	}
+}
+
+func sortWallets(wallets []*entity.Wallet) {
+	sort.Slice(wallets, func(i, j int) bool {
+		if wallets[i].UserID != wallets[j].UserID {
+			return wallets[i].UserID < wallets[j].UserID
+		}
+		return wallets[i].DungeonID < wallets[j].DungeonID
+	})
+}
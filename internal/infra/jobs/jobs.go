@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// LeaderLockID is the advisory lock key replicas compete for to run LeaderOnly jobs
+const LeaderLockID int64 = 0x61646864 // "adhd"
+
+// SchedulerTickJob advances recurring schedules. Only the leader ticks; the
+// scheduler itself is also safe to tick from several replicas at once.
+func SchedulerTickJob(scheduler ports.Scheduler, interval time.Duration) Job {
+	return Job{
+		Name:       "scheduler_tick",
+		Interval:   interval,
+		Jitter:     interval / 10,
+		LeaderOnly: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			return scheduler.Tick(ctx, now)
+		},
+	}
+}
+
+// IdempotencyPurgeJob deletes expired idempotency keys and any key older than retention
+func IdempotencyPurgeJob(repo ports.IdempotencyRepository, interval, retention time.Duration) Job {
+	return Job{
+		Name:       "idempotency_purge",
+		Interval:   interval,
+		Jitter:     interval / 10,
+		LeaderOnly: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			if err := repo.DeleteExpired(ctx); err != nil {
+				return err
+			}
+			return repo.Purge(ctx, now.Add(-retention))
+		},
+	}
+}
+
+// TimerEventCleanupJob deletes timer events older than retention
+func TimerEventCleanupJob(repo ports.TimerEventRepository, interval, retention time.Duration) Job {
+	return Job{
+		Name:       "timer_event_cleanup",
+		Interval:   interval,
+		Jitter:     interval / 10,
+		LeaderOnly: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			return repo.DeleteOldEvents(ctx, now.Add(-retention))
+		},
+	}
+}
+
+// TimerExpiryJob notifies users whose countdown timers have run out. Pending
+// timers are read from the database on every run, so nothing is lost when the
+// bot restarts. Only the bot sends notifications, so the job runs on every bot
+// replica instead of on the leader, which may be the API; each expiry is
+// claimed before it is sent, so users are still notified once.
+func TimerExpiryJob(timers *usecase.TimerService, interval time.Duration) Job {
+	return Job{
+		Name:     "timer_expiry",
+		Interval: interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			_, err := timers.DispatchExpired(ctx, now)
+			return err
+		},
+	}
+}
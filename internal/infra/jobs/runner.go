@@ -0,0 +1,240 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// Job is a unit of periodic background work
+type Job struct {
+	Name       string
+	Interval   time.Duration // Time between the end of one run and the start of the next
+	Jitter     time.Duration // Up to this much random delay is added to every wait
+	LeaderOnly bool          // Run only on the replica holding leadership
+	Run        func(ctx context.Context, now time.Time) error
+}
+
+// Stats reports the outcome of a job's runs so far
+type Stats struct {
+	Runs          int
+	Successes     int
+	Failures      int
+	LastRunAt     time.Time
+	LastSuccessAt time.Time
+	LastError     string
+	LastDuration  time.Duration
+	NextRunAt     time.Time
+}
+
+type jobState struct {
+	job   Job
+	stats Stats
+}
+
+// Runner runs registered jobs on their intervals. Jobs marked LeaderOnly run on
+// one replica at a time, the one the LeaderElector picks; every other job runs
+// on every replica. All timing goes through ports.Clock.
+type Runner struct {
+	clock   ports.Clock
+	elector ports.LeaderElector
+	jitter  func(max time.Duration) time.Duration
+
+	mu   sync.Mutex
+	jobs []*jobState
+}
+
+// NewRunner creates a runner. With a nil elector this process is always the leader.
+func NewRunner(clock ports.Clock, elector ports.LeaderElector) *Runner {
+	return &Runner{
+		clock:   clock,
+		elector: elector,
+		jitter: func(max time.Duration) time.Duration {
+			if max <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(max)))
+		},
+	}
+}
+
+// Register adds a job. Its first run is due immediately, plus jitter.
+func (r *Runner) Register(job Job) error {
+	if job.Name == "" {
+		return errors.New("job name is required")
+	}
+	if job.Interval <= 0 {
+		return fmt.Errorf("job %s: interval must be positive", job.Name)
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job %s: run function is required", job.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range r.jobs {
+		if state.job.Name == job.Name {
+			return fmt.Errorf("job %s is already registered", job.Name)
+		}
+	}
+
+	r.jobs = append(r.jobs, &jobState{
+		job:   job,
+		stats: Stats{NextRunAt: r.clock.Now().Add(r.jitter(job.Jitter))},
+	})
+	return nil
+}
+
+// Run executes due jobs until ctx is cancelled, then releases leadership
+func (r *Runner) Run(ctx context.Context) error {
+	defer func() {
+		if r.elector != nil {
+			// ctx is already cancelled, give the release its own deadline
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := r.elector.Release(releaseCtx); err != nil {
+				log.Printf("jobs: failed to release leadership: %v", err)
+			}
+		}
+	}()
+
+	for {
+		r.RunDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.clock.After(r.untilNextRun()):
+		}
+	}
+}
+
+// RunDue runs every job whose next run is due at the clock's current time, one
+// after another, and schedules each job's following run.
+func (r *Runner) RunDue(ctx context.Context) {
+	now := r.clock.Now()
+
+	r.mu.Lock()
+	var due []*jobState
+	for _, state := range r.jobs {
+		if !state.stats.NextRunAt.After(now) {
+			due = append(due, state)
+		}
+	}
+	r.mu.Unlock()
+
+	leader, checked := false, false
+	for _, state := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if state.job.LeaderOnly {
+			if !checked {
+				leader = r.isLeader(ctx)
+				checked = true
+			}
+			if !leader {
+				r.reschedule(state, now)
+				continue
+			}
+		}
+
+		r.runJob(ctx, state)
+	}
+}
+
+// Stats returns a snapshot of every job's statistics keyed by job name
+func (r *Runner) Stats() map[string]Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]Stats, len(r.jobs))
+	for _, state := range r.jobs {
+		stats[state.job.Name] = state.stats
+	}
+	return stats
+}
+
+func (r *Runner) runJob(ctx context.Context, state *jobState) {
+	started := r.clock.Now()
+	err := safeRun(ctx, state.job, started)
+	finished := r.clock.Now()
+
+	r.mu.Lock()
+	state.stats.Runs++
+	state.stats.LastRunAt = started
+	state.stats.LastDuration = finished.Sub(started)
+	if err != nil {
+		state.stats.Failures++
+		state.stats.LastError = err.Error()
+	} else {
+		state.stats.Successes++
+		state.stats.LastSuccessAt = finished
+		state.stats.LastError = ""
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		log.Printf("jobs: %s failed after %s: %v", state.job.Name, finished.Sub(started), err)
+	}
+
+	r.reschedule(state, finished)
+}
+
+func (r *Runner) reschedule(state *jobState, from time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.stats.NextRunAt = from.Add(state.job.Interval + r.jitter(state.job.Jitter))
+}
+
+func (r *Runner) isLeader(ctx context.Context) bool {
+	if r.elector == nil {
+		return true
+	}
+	leader, err := r.elector.TryAcquire(ctx)
+	if err != nil {
+		log.Printf("jobs: leader election failed: %v", err)
+		return false
+	}
+	return leader
+}
+
+func (r *Runner) untilNextRun() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.jobs) == 0 {
+		return time.Minute
+	}
+
+	next := r.jobs[0].stats.NextRunAt
+	for _, state := range r.jobs[1:] {
+		if state.stats.NextRunAt.Before(next) {
+			next = state.stats.NextRunAt
+		}
+	}
+
+	wait := next.Sub(r.clock.Now())
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// safeRun turns a panicking job into a failed run
+func safeRun(ctx context.Context, job Job, now time.Time) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx, now)
+}
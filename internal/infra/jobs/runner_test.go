@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Sleep(d time.Duration) { c.Advance(d) }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+type fakeElector struct {
+	leader   bool
+	err      error
+	released bool
+}
+
+func (e *fakeElector) TryAcquire(ctx context.Context) (bool, error) { return e.leader, e.err }
+
+func (e *fakeElector) Release(ctx context.Context) error {
+	e.released = true
+	return nil
+}
+
+func newTestRunner(elector *fakeElector) (*Runner, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	var runner *Runner
+	if elector != nil {
+		runner = NewRunner(clock, elector)
+	} else {
+		runner = NewRunner(clock, nil)
+	}
+	runner.jitter = func(max time.Duration) time.Duration { return max / 2 }
+	return runner, clock
+}
+
+func TestRunner_RunsJobsOnTheirIntervals(t *testing.T) {
+	runner, clock := newTestRunner(nil)
+	ctx := context.Background()
+
+	var runsAt []time.Time
+	require.NoError(t, runner.Register(Job{
+		Name:     "tick",
+		Interval: time.Minute,
+		Run: func(ctx context.Context, now time.Time) error {
+			runsAt = append(runsAt, now)
+			return nil
+		},
+	}))
+
+	runner.RunDue(ctx)
+	clock.Advance(30 * time.Second)
+	runner.RunDue(ctx)
+	clock.Advance(30 * time.Second)
+	runner.RunDue(ctx)
+
+	require.Len(t, runsAt, 2)
+	assert.Equal(t, time.Minute, runsAt[1].Sub(runsAt[0]))
+
+	stats := runner.Stats()["tick"]
+	assert.Equal(t, 2, stats.Runs)
+	assert.Equal(t, 2, stats.Successes)
+	assert.Equal(t, runsAt[1].Add(time.Minute), stats.NextRunAt)
+}
+
+func TestRunner_AppliesJitter(t *testing.T) {
+	runner, clock := newTestRunner(nil)
+	start := clock.Now()
+
+	require.NoError(t, runner.Register(Job{
+		Name:     "jittery",
+		Interval: time.Minute,
+		Jitter:   20 * time.Second,
+		Run:      func(ctx context.Context, now time.Time) error { return nil },
+	}))
+	assert.Equal(t, start.Add(10*time.Second), runner.Stats()["jittery"].NextRunAt)
+
+	clock.Advance(10 * time.Second)
+	runner.RunDue(context.Background())
+	assert.Equal(t, start.Add(80*time.Second), runner.Stats()["jittery"].NextRunAt)
+}
+
+func TestRunner_RecordsFailures(t *testing.T) {
+	runner, clock := newTestRunner(nil)
+	ctx := context.Background()
+
+	fail := true
+	require.NoError(t, runner.Register(Job{
+		Name:     "flaky",
+		Interval: time.Minute,
+		Run: func(ctx context.Context, now time.Time) error {
+			if fail {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}))
+	require.NoError(t, runner.Register(Job{
+		Name:     "panicky",
+		Interval: time.Minute,
+		Run:      func(ctx context.Context, now time.Time) error { panic("oops") },
+	}))
+
+	runner.RunDue(ctx)
+	stats := runner.Stats()
+	assert.Equal(t, 1, stats["flaky"].Failures)
+	assert.Equal(t, "boom", stats["flaky"].LastError)
+	assert.Equal(t, 1, stats["panicky"].Failures)
+	assert.Contains(t, stats["panicky"].LastError, "oops")
+
+	fail = false
+	clock.Advance(time.Minute)
+	runner.RunDue(ctx)
+	stats = runner.Stats()
+	assert.Equal(t, 1, stats["flaky"].Successes)
+	assert.Empty(t, stats["flaky"].LastError)
+	assert.Equal(t, clock.Now(), stats["flaky"].LastSuccessAt)
+}
+
+func TestRunner_LeaderOnlyJobs(t *testing.T) {
+	elector := &fakeElector{}
+	runner, clock := newTestRunner(elector)
+	ctx := context.Background()
+
+	var leaderRuns, everywhereRuns int
+	require.NoError(t, runner.Register(Job{
+		Name: "singleton", Interval: time.Minute, LeaderOnly: true,
+		Run: func(ctx context.Context, now time.Time) error { leaderRuns++; return nil },
+	}))
+	require.NoError(t, runner.Register(Job{
+		Name: "local", Interval: time.Minute,
+		Run: func(ctx context.Context, now time.Time) error { everywhereRuns++; return nil },
+	}))
+
+	runner.RunDue(ctx)
+	assert.Equal(t, 0, leaderRuns)
+	assert.Equal(t, 1, everywhereRuns)
+	assert.Equal(t, clock.Now().Add(time.Minute), runner.Stats()["singleton"].NextRunAt)
+
+	elector.err = errors.New("connection refused")
+	clock.Advance(time.Minute)
+	runner.RunDue(ctx)
+	assert.Equal(t, 0, leaderRuns)
+
+	elector.leader, elector.err = true, nil
+	clock.Advance(time.Minute)
+	runner.RunDue(ctx)
+	assert.Equal(t, 1, leaderRuns)
+	assert.Equal(t, 3, everywhereRuns)
+}
+
+func TestRunner_RegisterValidation(t *testing.T) {
+	runner, _ := newTestRunner(nil)
+	noop := func(ctx context.Context, now time.Time) error { return nil }
+
+	assert.Error(t, runner.Register(Job{Interval: time.Minute, Run: noop}))
+	assert.Error(t, runner.Register(Job{Name: "a", Run: noop}))
+	assert.Error(t, runner.Register(Job{Name: "a", Interval: time.Minute}))
+	require.NoError(t, runner.Register(Job{Name: "a", Interval: time.Minute, Run: noop}))
+	assert.Error(t, runner.Register(Job{Name: "a", Interval: time.Minute, Run: noop}))
+}
+
+func TestRunner_RunStopsAndReleasesLeadership(t *testing.T) {
+	elector := &fakeElector{leader: true}
+	runner, _ := newTestRunner(elector)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := 0
+	require.NoError(t, runner.Register(Job{
+		Name: "tick", Interval: time.Minute, LeaderOnly: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			return nil
+		},
+	}))
+
+	err := runner.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, runs)
+	assert.True(t, elector.released)
+}
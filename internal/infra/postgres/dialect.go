@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlstore"
+)
+
+// Dialect adapts the shared repositories to PostgreSQL
+var Dialect = sqlstore.Dialect{
+	IsUniqueViolation: isUniqueViolation,
+	NextID: func(table string) string {
+		return `nextval(pg_get_serial_sequence('` + table + `', 'id'))`
+	},
+	IntList:      func(values *[]int) sqlstore.IntList { return intArray{values} },
+	LocksRows:    true,
+	ExactNumeric: true,
+	InsertOrder:  "seq",
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE constraint
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// intArray stores a list of ints in an INTEGER[] column
+type intArray struct {
+	values *[]int
+}
+
+func (a intArray) Value() (driver.Value, error) {
+	out := make([]int64, len(*a.values))
+	for i, v := range *a.values {
+		out[i] = int64(v)
+	}
+	return pq.Array(out).Value()
+}
+
+func (a intArray) Scan(src interface{}) error {
+	var values []int64
+	if err := pq.Array(&values).Scan(src); err != nil {
+		return err
+	}
+	*a.values = make([]int, len(values))
+	for i, v := range values {
+		(*a.values)[i] = int(v)
+	}
+	return nil
+}
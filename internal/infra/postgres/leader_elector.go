@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// AdvisoryLockElector elects a leader among replicas with a session-level Postgres
+// advisory lock. The lock lives as long as the dedicated connection holding it, so
+// leadership moves to another replica when the leader exits or loses its connection.
+type AdvisoryLockElector struct {
+	db     *sql.DB
+	lockID int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func NewAdvisoryLockElector(db *sql.DB, lockID int64) *AdvisoryLockElector {
+	return &AdvisoryLockElector{db: db, lockID: lockID}
+}
+
+func (e *AdvisoryLockElector) TryAcquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		// Still the leader as long as the session holding the lock is alive
+		if err := e.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		e.conn.Close()
+		e.conn = nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to open leader election connection: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.lockID).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	e.conn = conn
+	return true, nil
+}
+
+func (e *AdvisoryLockElector) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	defer func() {
+		e.conn.Close()
+		e.conn = nil
+	}()
+
+	_, err := e.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.lockID)
+	if err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
+
+// Compile-time check that AdvisoryLockElector implements ports.LeaderElector
+var _ ports.LeaderElector = (*AdvisoryLockElector)(nil)
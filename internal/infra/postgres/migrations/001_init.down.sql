@@ -0,0 +1,5 @@
+-- Revert migration 001: Initial schema setup for PostgreSQL
+DROP TABLE tasks;
+DROP TABLE currencies;
+DROP TABLE user_balances;
+DROP TABLE users;
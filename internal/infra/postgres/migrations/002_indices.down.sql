@@ -0,0 +1,10 @@
+-- Revert migration 002: Add indices and constraints for PostgreSQL
+DROP TRIGGER update_user_balances_timestamp ON user_balances;
+DROP TRIGGER update_tasks_timestamp ON tasks;
+DROP TRIGGER update_users_timestamp ON users;
+
+DROP FUNCTION update_timestamp();
+
+DROP INDEX idx_tasks_last_completed;
+DROP INDEX idx_tasks_category_status;
+DROP INDEX idx_user_balances_user;
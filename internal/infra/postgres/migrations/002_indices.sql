@@ -1,6 +1,4 @@
 -- Migration 002: Add indices and constraints for PostgreSQL
-BEGIN;
-
 -- Create indices for performance
 CREATE INDEX idx_user_balances_user ON user_balances(user_id);
 CREATE INDEX idx_tasks_category_status ON tasks(category, status);
@@ -27,5 +25,3 @@ FOR EACH ROW EXECUTE FUNCTION update_timestamp();
 CREATE TRIGGER update_user_balances_timestamp
 BEFORE UPDATE ON user_balances
 FOR EACH ROW EXECUTE FUNCTION update_timestamp();
-
-COMMIT;
\ No newline at end of file
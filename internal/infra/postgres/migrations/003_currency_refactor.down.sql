@@ -0,0 +1,20 @@
+-- Revert migration 003: Refactor currency system for multi-chat support
+-- Balances go back to being keyed by currency code
+ALTER TABLE user_balances DROP CONSTRAINT fk_currency;
+ALTER TABLE user_balances DROP CONSTRAINT user_balances_pkey;
+ALTER TABLE user_balances DROP COLUMN currency_id;
+ALTER TABLE user_balances ADD CONSTRAINT user_balances_pkey PRIMARY KEY (user_id, currency_code);
+
+ALTER TABLE users DROP COLUMN chat_id;
+
+-- Per-chat currencies cannot be mapped back to global ones, so the original
+-- table comes back empty
+DROP TABLE currencies;
+
+CREATE TABLE currencies (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    decimals INTEGER NOT NULL DEFAULT 2,
+    conversion_rates_json JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
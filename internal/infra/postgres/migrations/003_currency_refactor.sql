@@ -1,6 +1,4 @@
 -- Migration 003: Refactor currency system for multi-chat support
-BEGIN;
-
 -- Drop old currency table if exists
 DROP TABLE IF EXISTS currencies CASCADE;
 
@@ -32,5 +30,3 @@ CREATE INDEX idx_currencies_base ON currencies(chat_id, is_base_currency) WHERE
 
 -- Ensure only one base currency per chat
 CREATE UNIQUE INDEX idx_one_base_currency_per_chat ON currencies(chat_id) WHERE is_base_currency = TRUE;
-
-COMMIT;
\ No newline at end of file
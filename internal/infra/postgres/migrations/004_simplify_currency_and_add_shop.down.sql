@@ -0,0 +1,40 @@
+-- Revert migration 004: Simplify to single currency per chat and add shop
+DROP TABLE purchases;
+DROP TABLE shop_items;
+DROP TABLE chat_configs;
+
+ALTER TABLE users DROP COLUMN balance;
+
+-- The multi-currency tables come back empty; balances are not split back out
+CREATE TABLE currencies (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    code VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    decimals INTEGER NOT NULL DEFAULT 2,
+    is_base_currency BOOLEAN NOT NULL DEFAULT FALSE,
+    exchange_rates_json JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, code)
+);
+
+CREATE INDEX idx_currencies_base ON currencies(chat_id, is_base_currency) WHERE is_base_currency = TRUE;
+CREATE UNIQUE INDEX idx_one_base_currency_per_chat ON currencies(chat_id) WHERE is_base_currency = TRUE;
+
+CREATE TABLE user_balances (
+    user_id BIGINT NOT NULL,
+    currency_code VARCHAR(10) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    currency_id BIGINT,
+    CONSTRAINT user_balances_pkey PRIMARY KEY (user_id, currency_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_currency FOREIGN KEY (currency_id) REFERENCES currencies(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_user_balances_user ON user_balances(user_id);
+
+CREATE TRIGGER update_user_balances_timestamp
+BEFORE UPDATE ON user_balances
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
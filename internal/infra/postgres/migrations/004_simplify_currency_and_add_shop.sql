@@ -1,6 +1,4 @@
 -- Migration 004: Simplify to single currency per chat and add shop
-BEGIN;
-
 -- Drop old currency-related tables
 DROP TABLE IF EXISTS user_balances CASCADE;
 DROP TABLE IF EXISTS currencies CASCADE;
@@ -60,5 +58,3 @@ FOR EACH ROW EXECUTE FUNCTION update_timestamp();
 CREATE TRIGGER update_shop_items_timestamp
 BEFORE UPDATE ON shop_items
 FOR EACH ROW EXECUTE FUNCTION update_timestamp();
-
-COMMIT;
\ No newline at end of file
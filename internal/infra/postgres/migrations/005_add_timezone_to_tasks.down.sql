@@ -0,0 +1,2 @@
+-- Revert migration 005: Add timezone support to tasks
+ALTER TABLE tasks DROP COLUMN time_zone;
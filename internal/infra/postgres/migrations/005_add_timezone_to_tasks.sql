@@ -1,7 +1,3 @@
 -- Migration 005: Add timezone support to tasks
-BEGIN;
-
 ALTER TABLE tasks
 ADD COLUMN time_zone VARCHAR(50) NOT NULL DEFAULT 'UTC';
-
-COMMIT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Revert migration 006: Add idempotency support
+DROP TABLE idempotency_keys;
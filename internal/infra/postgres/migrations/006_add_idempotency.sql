@@ -1,6 +1,4 @@
 -- Migration 006: Add idempotency support
-BEGIN;
-
 CREATE TABLE idempotency_keys (
     key VARCHAR(255) PRIMARY KEY,
     operation VARCHAR(50) NOT NULL,
@@ -17,5 +15,3 @@ CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
 
 -- Index for user lookups
 CREATE INDEX idx_idempotency_keys_user_id ON idempotency_keys(user_id);
-
-COMMIT;
\ No newline at end of file
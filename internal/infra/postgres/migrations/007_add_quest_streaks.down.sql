@@ -0,0 +1,4 @@
+-- Revert migration 007: Track streaks per user and quest
+ALTER TABLE IF EXISTS quests DROP COLUMN IF EXISTS streak_grace_periods;
+
+DROP TABLE quest_streaks;
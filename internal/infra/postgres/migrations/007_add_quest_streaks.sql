@@ -0,0 +1,13 @@
+-- Migration 007: Track streaks per user and quest
+CREATE TABLE quest_streaks (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id VARCHAR(36) NOT NULL,
+    current_streak INTEGER NOT NULL DEFAULT 0 CHECK (current_streak >= 0),
+    best_streak INTEGER NOT NULL DEFAULT 0 CHECK (best_streak >= current_streak),
+    last_period BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, quest_id)
+);
+
+-- Number of missed periods a streak survives, configured per quest
+ALTER TABLE IF EXISTS quests ADD COLUMN IF NOT EXISTS streak_grace_periods INTEGER NOT NULL DEFAULT 0;
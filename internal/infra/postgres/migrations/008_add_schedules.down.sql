@@ -0,0 +1,3 @@
+-- Revert migration 008: Persist recurring schedules and their occurrences
+DROP TABLE schedule_occurrences;
+DROP TABLE schedules;
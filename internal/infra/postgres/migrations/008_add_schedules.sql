@@ -0,0 +1,30 @@
+-- Migration 008: Persist recurring schedules and their occurrences
+CREATE TABLE schedules (
+    id VARCHAR(36) PRIMARY KEY,
+    task_id VARCHAR(36) NOT NULL UNIQUE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('daily', 'weekly', 'monthly')),
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE,
+    time_of_day VARCHAR(5) NOT NULL,
+    days_of_week INTEGER[] NOT NULL DEFAULT '{}',
+    days_of_month INTEGER[] NOT NULL DEFAULT '{}',
+    skip_if_missed BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for claiming due schedules
+CREATE INDEX idx_schedules_next_run_at ON schedules(next_run_at) WHERE next_run_at IS NOT NULL;
+
+CREATE TABLE schedule_occurrences (
+    id VARCHAR(36) PRIMARY KEY,
+    schedule_id VARCHAR(36) NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    missed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (schedule_id, scheduled_for),
+    CHECK (NOT (skipped AND missed))
+);
@@ -0,0 +1,3 @@
+-- Revert migration 009: Add focus timers and their event log
+DROP TABLE timer_events;
+DROP TABLE timers;
@@ -0,0 +1,29 @@
+-- Migration 009: Add focus timers and their event log
+CREATE TABLE timers (
+    id VARCHAR(36) PRIMARY KEY,
+    task_id VARCHAR(36) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('countdown', 'stopwatch')),
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_sec INTEGER NOT NULL DEFAULT 0 CHECK (duration_sec >= 0),
+    current_value_sec INTEGER NOT NULL DEFAULT 0 CHECK (current_value_sec >= 0),
+    last_tick TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('running', 'paused', 'completed', 'cancelled')),
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    notification_id VARCHAR(255)
+);
+
+-- Index for a user's active timers
+CREATE INDEX idx_timers_user_status ON timers(user_id, status);
+CREATE INDEX idx_timers_task_id ON timers(task_id);
+
+CREATE TABLE timer_events (
+    id VARCHAR(36) PRIMARY KEY,
+    timer_id VARCHAR(36) NOT NULL REFERENCES timers(id) ON DELETE CASCADE,
+    event_type VARCHAR(10) NOT NULL CHECK (event_type IN ('start', 'pause', 'resume', 'complete', 'cancel')),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_timer_events_timer_id ON timer_events(timer_id);
+CREATE INDEX idx_timer_events_occurred_at ON timer_events(occurred_at);
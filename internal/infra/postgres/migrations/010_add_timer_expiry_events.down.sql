@@ -0,0 +1,9 @@
+-- Revert migration 010: Record countdown extensions and expiry notifications
+DROP INDEX idx_timers_pending_notification;
+
+-- The old check does not allow the new event types
+DELETE FROM timer_events WHERE event_type IN ('extend', 'expire');
+
+ALTER TABLE timer_events DROP CONSTRAINT timer_events_event_type_check;
+ALTER TABLE timer_events ADD CONSTRAINT timer_events_event_type_check
+    CHECK (event_type IN ('start', 'pause', 'resume', 'complete', 'cancel'));
@@ -0,0 +1,8 @@
+-- Migration 010: Record countdown extensions and expiry notifications
+ALTER TABLE timer_events DROP CONSTRAINT IF EXISTS timer_events_event_type_check;
+ALTER TABLE timer_events ADD CONSTRAINT timer_events_event_type_check
+    CHECK (event_type IN ('start', 'pause', 'resume', 'complete', 'cancel', 'extend', 'expire'));
+
+-- Index for finding running countdowns that still need an expiry notification
+CREATE INDEX idx_timers_pending_notification ON timers(user_id)
+    WHERE status = 'running' AND type = 'countdown' AND notification_id IS NULL;
@@ -0,0 +1,4 @@
+-- Revert migration 011: Append-only points ledger
+-- users.balance is kept up to date alongside the ledger, so it stays as is
+DROP TABLE ledger_entries;
+DROP FUNCTION reject_ledger_update();
@@ -0,0 +1,34 @@
+-- Migration 011: Append-only points ledger; users.balance becomes a cached projection
+CREATE TABLE ledger_entries (
+    seq BIGSERIAL UNIQUE, -- Insertion order, used to list entries
+    id VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount NUMERIC(20, 8) NOT NULL CHECK (amount <> 0),
+    balance_after NUMERIC(20, 8) NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN (
+        'quest_completion', 'purchase', 'refund', 'admin_adjustment', 'transfer', 'opening_balance')),
+    source_type VARCHAR(20) NOT NULL DEFAULT '',
+    source_id VARCHAR(64) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_entries_user ON ledger_entries(user_id, seq DESC);
+CREATE INDEX idx_ledger_entries_source ON ledger_entries(source_type, source_id);
+
+-- Entries are immutable
+CREATE FUNCTION reject_ledger_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+BEFORE UPDATE ON ledger_entries
+FOR EACH ROW EXECUTE FUNCTION reject_ledger_update();
+
+-- Carry existing balances over so the ledger and users.balance agree
+INSERT INTO ledger_entries (id, user_id, amount, balance_after, reason, note)
+SELECT md5('opening_balance:' || id)::uuid::text, id, balance, balance, 'opening_balance', 'Balance before the ledger was introduced'
+FROM users
+WHERE balance <> 0;
@@ -0,0 +1,10 @@
+-- Revert migration 012: Purchase refunds and per-chat refund policy
+ALTER TABLE chat_configs
+    DROP COLUMN refunds_admin_only,
+    DROP COLUMN refund_window_sec;
+
+ALTER TABLE purchases
+    DROP CONSTRAINT purchases_refunded_quantity_check,
+    DROP COLUMN refunded_at,
+    DROP COLUMN refunded_amount,
+    DROP COLUMN refunded_quantity;
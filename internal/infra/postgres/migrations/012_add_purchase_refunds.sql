@@ -0,0 +1,10 @@
+-- Migration 012: Purchase refunds and per-chat refund policy
+ALTER TABLE purchases
+    ADD COLUMN refunded_quantity INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_amount NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_at TIMESTAMP WITH TIME ZONE,
+    ADD CONSTRAINT purchases_refunded_quantity_check CHECK (refunded_quantity BETWEEN 0 AND quantity);
+
+ALTER TABLE chat_configs
+    ADD COLUMN refund_window_sec INTEGER NOT NULL DEFAULT 86400 CHECK (refund_window_sec >= 0),
+    ADD COLUMN refunds_admin_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- Revert migration 013: Pricing rules and purchase price breakdowns
+ALTER TABLE purchases
+    DROP COLUMN price_breakdown,
+    DROP COLUMN subtotal;
+
+DROP TABLE member_prices;
+
+ALTER TABLE shop_items
+    DROP COLUMN sale_ends_at,
+    DROP COLUMN sale_price;
@@ -0,0 +1,28 @@
+-- Migration 013: Pricing rules and purchase price breakdowns
+ALTER TABLE shop_items
+    ADD COLUMN sale_price NUMERIC(20, 8) CHECK (sale_price >= 0),
+    ADD COLUMN sale_ends_at TIMESTAMP WITH TIME ZONE;
+
+-- Per-user price overrides
+CREATE TABLE member_prices (
+    item_id BIGINT NOT NULL REFERENCES shop_items(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    price NUMERIC(20, 8) NOT NULL CHECK (price >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (item_id, user_id)
+);
+
+ALTER TABLE purchases
+    ADD COLUMN subtotal NUMERIC(20, 8),
+    ADD COLUMN price_breakdown JSONB NOT NULL DEFAULT '[]';
+
+-- Older purchases only record the list price and what was charged
+UPDATE purchases SET subtotal = item_price * quantity;
+UPDATE purchases
+SET price_breakdown = jsonb_build_array(jsonb_build_object(
+    'rule', 'discount_tier',
+    'description', 'Discount',
+    'amount', (total_cost - subtotal)::TEXT))
+WHERE total_cost <> subtotal;
+
+ALTER TABLE purchases ALTER COLUMN subtotal SET NOT NULL;
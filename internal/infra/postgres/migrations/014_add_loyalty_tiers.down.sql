@@ -0,0 +1,3 @@
+-- Revert migration 014: Loyalty tiers per chat
+DROP TABLE loyalty_statuses;
+DROP TABLE reward_tiers;
@@ -0,0 +1,24 @@
+-- Migration 014: Loyalty tiers per chat
+CREATE TABLE reward_tiers (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_percent NUMERIC(5, 2) NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    min_purchases INTEGER NOT NULL CHECK (min_purchases >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reward_tiers_chat ON reward_tiers(chat_id, min_purchases);
+
+-- The tier each user has reached in a chat
+CREATE TABLE loyalty_statuses (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_id BIGINT NOT NULL,
+    tier_id BIGINT REFERENCES reward_tiers(id) ON DELETE SET NULL,
+    purchase_count INTEGER NOT NULL DEFAULT 0 CHECK (purchase_count >= 0),
+    notified_tier_id BIGINT REFERENCES reward_tiers(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, chat_id)
+);
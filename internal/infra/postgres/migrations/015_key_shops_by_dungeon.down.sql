@@ -0,0 +1,17 @@
+-- Revert migration 015: Key shops by dungeon instead of Telegram chat
+DROP INDEX idx_purchases_dungeon;
+ALTER TABLE purchases DROP COLUMN dungeon_id;
+
+-- Items go back to the chat their dungeon is linked to. Items of dungeons
+-- without a chat stay global, so adding the chat and code constraint back
+-- fails, and the rollback with it, if their codes clash.
+UPDATE shop_items i
+SET chat_id = d.telegram_chat_id
+FROM dungeons d
+WHERE i.dungeon_id = d.id AND d.telegram_chat_id IS NOT NULL;
+
+DROP INDEX idx_shop_items_dungeon_code;
+ALTER TABLE shop_items DROP COLUMN dungeon_id;
+ALTER TABLE shop_items ADD CONSTRAINT shop_items_chat_id_code_key UNIQUE (chat_id, code);
+
+DROP TABLE dungeons;
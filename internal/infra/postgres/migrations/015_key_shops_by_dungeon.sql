@@ -0,0 +1,42 @@
+-- Migration 015: Key shops by dungeon instead of Telegram chat
+CREATE TABLE IF NOT EXISTS dungeons (
+    id TEXT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    admin_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    telegram_chat_id BIGINT UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE shop_items ADD COLUMN IF NOT EXISTS dungeon_id TEXT REFERENCES dungeons(id) ON DELETE CASCADE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS dungeon_id TEXT;
+
+-- Codes are unique per dungeon from now on
+ALTER TABLE shop_items DROP CONSTRAINT IF EXISTS shop_items_chat_id_code_key;
+
+-- Chat items move to the dungeon linked to their chat
+UPDATE shop_items i
+SET dungeon_id = d.id
+FROM dungeons d
+WHERE i.dungeon_id IS NULL AND i.chat_id <> 0 AND d.telegram_chat_id = i.chat_id;
+
+-- Global items are copied into every linked dungeon that has no item with the
+-- same code. The originals stay unlisted so their purchase history is kept.
+INSERT INTO shop_items (chat_id, dungeon_id, code, name, description, price, category, is_active, stock,
+    sale_price, sale_ends_at, created_at, updated_at)
+SELECT d.telegram_chat_id, d.id, g.code, g.name, g.description, g.price, g.category, g.is_active, g.stock,
+    g.sale_price, g.sale_ends_at, g.created_at, g.updated_at
+FROM shop_items g
+CROSS JOIN dungeons d
+WHERE g.chat_id = 0 AND g.dungeon_id IS NULL AND d.telegram_chat_id IS NOT NULL
+    AND NOT EXISTS (SELECT 1 FROM shop_items i WHERE i.dungeon_id = d.id AND i.code = g.code);
+
+-- Items of chats without a dungeon keep a NULL dungeon_id and are not listed
+-- until the chat is linked to one
+CREATE UNIQUE INDEX idx_shop_items_dungeon_code ON shop_items(dungeon_id, code) WHERE dungeon_id IS NOT NULL;
+
+UPDATE purchases p
+SET dungeon_id = i.dungeon_id
+FROM shop_items i
+WHERE p.dungeon_id IS NULL AND p.item_id = i.id AND i.dungeon_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_purchases_dungeon ON purchases(dungeon_id, purchased_at DESC);
@@ -0,0 +1,17 @@
+-- Revert migration 016: Dungeon membership, quests, quest completions and discount tiers
+ALTER TABLE purchases
+    DROP CONSTRAINT purchases_dungeon_id_fkey,
+    DROP COLUMN discount_tier_id;
+
+ALTER TABLE shop_items DROP COLUMN discount_tier_id;
+
+DROP TABLE discount_tiers;
+
+ALTER TABLE quest_streaks DROP CONSTRAINT quest_streaks_quest_id_fkey;
+
+DROP TABLE quest_completions;
+DROP TABLE quests;
+DROP TABLE dungeon_members;
+
+DROP INDEX idx_dungeons_admin;
+DROP INDEX IF EXISTS idx_users_chat_id;
@@ -0,0 +1,87 @@
+-- Migration 016: Dungeon membership, quests, quest completions and discount tiers
+--
+-- User.Username is kept in users.display_name until migration 020 gives it
+-- its own column.
+-- Members of a chat are looked up for loyalty tiers and refunds
+CREATE INDEX IF NOT EXISTS idx_users_chat_id ON users(chat_id);
+
+CREATE INDEX idx_dungeons_admin ON dungeons(admin_user_id);
+
+CREATE TABLE dungeon_members (
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (dungeon_id, user_id)
+);
+
+CREATE INDEX idx_dungeon_members_user ON dungeon_members(user_id);
+
+CREATE TABLE quests (
+    id VARCHAR(36) PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category VARCHAR(10) NOT NULL CHECK (category IN ('daily', 'weekly', 'adhoc')),
+    difficulty VARCHAR(10) NOT NULL DEFAULT '',
+    mode VARCHAR(10) NOT NULL CHECK (mode IN ('BINARY', 'PARTIAL', 'PER_MINUTE')),
+    points_award NUMERIC(20, 8) NOT NULL DEFAULT 0 CHECK (points_award >= 0),
+    rate_points_per_min NUMERIC(20, 8) CHECK (rate_points_per_min >= 0),
+    min_minutes INTEGER CHECK (min_minutes >= 0),
+    max_minutes INTEGER CHECK (max_minutes >= min_minutes),
+    daily_points_cap NUMERIC(20, 8) CHECK (daily_points_cap >= 0),
+    cooldown_sec INTEGER NOT NULL DEFAULT 0 CHECK (cooldown_sec >= 0),
+    streak_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    streak_grace_periods INTEGER NOT NULL DEFAULT 0 CHECK (streak_grace_periods >= 0),
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'archived')),
+    last_completed_at TIMESTAMP WITH TIME ZONE,
+    streak_count INTEGER NOT NULL DEFAULT 0,
+    time_zone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quests_dungeon ON quests(dungeon_id);
+
+CREATE TABLE quest_completions (
+    id VARCHAR(36) PRIMARY KEY,
+    quest_id VARCHAR(36) NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completion_ratio DOUBLE PRECISION CHECK (completion_ratio BETWEEN 0 AND 1),
+    minutes INTEGER CHECK (minutes >= 0),
+    awarded_points NUMERIC(20, 8) NOT NULL CHECK (awarded_points >= 0),
+    idempotency_key VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- Serves both the latest completion and the daily cap lookups
+CREATE INDEX idx_quest_completions_user_quest ON quest_completions(user_id, quest_id, submitted_at DESC);
+
+-- Streaks were recorded before quests were stored, so older rows are not checked
+ALTER TABLE quest_streaks ADD CONSTRAINT quest_streaks_quest_id_fkey
+    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE NOT VALID;
+
+CREATE TABLE discount_tiers (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_percent NUMERIC(5, 2) NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    min_purchases INTEGER NOT NULL DEFAULT 0 CHECK (min_purchases >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE shop_items
+    ADD COLUMN discount_tier_id BIGINT REFERENCES discount_tiers(id) ON DELETE SET NULL;
+
+ALTER TABLE purchases
+    ADD COLUMN discount_tier_id BIGINT REFERENCES discount_tiers(id) ON DELETE SET NULL,
+    ADD CONSTRAINT purchases_dungeon_id_fkey FOREIGN KEY (dungeon_id) REFERENCES dungeons(id) ON DELETE SET NULL;
+
+CREATE TRIGGER update_quests_timestamp
+BEFORE UPDATE ON quests
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+CREATE TRIGGER update_discount_tiers_timestamp
+BEFORE UPDATE ON discount_tiers
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
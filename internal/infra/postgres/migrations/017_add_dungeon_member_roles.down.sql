@@ -0,0 +1,2 @@
+-- Revert migration 017: Roles on dungeon membership
+ALTER TABLE dungeon_members DROP COLUMN role;
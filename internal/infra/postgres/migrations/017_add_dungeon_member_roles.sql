@@ -0,0 +1,9 @@
+-- Migration 017: Roles on dungeon membership
+ALTER TABLE dungeon_members
+    ADD COLUMN role VARCHAR(10) NOT NULL DEFAULT 'member'
+        CHECK (role IN ('owner', 'moderator', 'member'));
+
+-- Each admin becomes the owner of their dungeon, joining it if they had not
+INSERT INTO dungeon_members (dungeon_id, user_id, role)
+SELECT id, admin_user_id, 'owner' FROM dungeons
+ON CONFLICT (dungeon_id, user_id) DO UPDATE SET role = 'owner';
@@ -0,0 +1,3 @@
+-- Revert migration 018: Dungeon invites and join requests
+DROP TABLE join_requests;
+DROP TABLE dungeon_invites;
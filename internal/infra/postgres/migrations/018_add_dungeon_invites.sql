@@ -0,0 +1,28 @@
+-- Migration 018: Dungeon invites and join requests
+CREATE TABLE dungeon_invites (
+    code VARCHAR(64) PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_dungeon_invites_dungeon ON dungeon_invites(dungeon_id, created_at DESC);
+
+CREATE TABLE join_requests (
+    id BIGSERIAL PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Revoking the invite keeps the requests made with it
+    invite_code VARCHAR(64) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+    decided_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+-- A user has at most one undecided request per dungeon
+CREATE UNIQUE INDEX idx_join_requests_pending ON join_requests(dungeon_id, user_id) WHERE status = 'pending';
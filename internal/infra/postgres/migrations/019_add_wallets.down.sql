@@ -0,0 +1,5 @@
+-- Revert migration 019: Per-dungeon wallets
+-- users.balance is kept up to date alongside the wallets, so it stays as is
+DROP TABLE wallets;
+DROP INDEX idx_ledger_entries_wallet;
+ALTER TABLE ledger_entries DROP COLUMN dungeon_id;
@@ -0,0 +1,43 @@
+-- Migration 019: Per-dungeon wallets; users.balance stays the user's total
+ALTER TABLE ledger_entries ADD COLUMN dungeon_id TEXT REFERENCES dungeons(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_ledger_entries_wallet ON ledger_entries(user_id, dungeon_id, seq DESC);
+
+CREATE TABLE wallets (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    balance NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, dungeon_id)
+);
+
+-- Assigning existing entries to a dungeon is the one update they ever get
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_append_only;
+
+-- Quest awards, purchases and refunds belong to the dungeon they happened in
+UPDATE ledger_entries e
+SET dungeon_id = c.dungeon_id
+FROM quest_completions c
+WHERE e.source_type = 'quest_completion' AND e.source_id = c.id;
+
+UPDATE ledger_entries e
+SET dungeon_id = p.dungeon_id
+FROM purchases p
+WHERE e.source_type = 'purchase' AND e.source_id = p.id::text
+  AND p.dungeon_id IN (SELECT id FROM dungeons);
+
+-- Everything else goes to the user's home dungeon: the one linked to their
+-- chat, or else the one they joined first. Users in no dungeon keep their
+-- points outside any wallet.
+UPDATE ledger_entries e
+SET dungeon_id = COALESCE(
+    (SELECT d.id FROM users u JOIN dungeons d ON d.telegram_chat_id = u.chat_id WHERE u.id = e.user_id),
+    (SELECT m.dungeon_id FROM dungeon_members m WHERE m.user_id = e.user_id ORDER BY m.joined_at, m.dungeon_id LIMIT 1))
+WHERE e.dungeon_id IS NULL;
+
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_append_only;
+
+INSERT INTO wallets (user_id, dungeon_id, balance)
+SELECT user_id, dungeon_id, SUM(amount)
+FROM ledger_entries
+WHERE dungeon_id IS NOT NULL
+GROUP BY user_id, dungeon_id;
@@ -0,0 +1,2 @@
+-- Revert migration 020: users.username
+ALTER TABLE users DROP COLUMN username;
@@ -0,0 +1,6 @@
+-- Migration 020: users.username
+-- The user repositories kept User.Username in display_name until now, so
+-- existing users start with their display name as username.
+ALTER TABLE users ADD COLUMN username VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE users SET username = display_name;
@@ -0,0 +1,32 @@
+-- Revert migration 021: Key loyalty tiers and statuses by dungeon
+-- Tiers and statuses go back to the chat their dungeon is linked to; those of
+-- dungeons without a chat are dropped. Purchase counts are not restored and
+-- catch up on each member's next purchase.
+ALTER TABLE loyalty_statuses ADD COLUMN chat_id BIGINT;
+
+UPDATE loyalty_statuses s
+SET chat_id = d.telegram_chat_id
+FROM dungeons d
+WHERE d.id = s.dungeon_id;
+
+DELETE FROM loyalty_statuses WHERE chat_id IS NULL;
+
+ALTER TABLE loyalty_statuses DROP CONSTRAINT loyalty_statuses_pkey;
+ALTER TABLE loyalty_statuses DROP COLUMN dungeon_id;
+ALTER TABLE loyalty_statuses ALTER COLUMN chat_id SET NOT NULL;
+ALTER TABLE loyalty_statuses ADD PRIMARY KEY (user_id, chat_id);
+
+ALTER TABLE reward_tiers ADD COLUMN chat_id BIGINT;
+
+UPDATE reward_tiers t
+SET chat_id = d.telegram_chat_id
+FROM dungeons d
+WHERE d.id = t.dungeon_id;
+
+DELETE FROM reward_tiers WHERE chat_id IS NULL;
+
+DROP INDEX idx_reward_tiers_dungeon;
+ALTER TABLE reward_tiers DROP COLUMN dungeon_id;
+ALTER TABLE reward_tiers ALTER COLUMN chat_id SET NOT NULL;
+
+CREATE INDEX idx_reward_tiers_chat ON reward_tiers(chat_id, min_purchases);
@@ -0,0 +1,44 @@
+-- Migration 021: Key loyalty tiers and statuses by dungeon instead of Telegram chat
+ALTER TABLE reward_tiers ADD COLUMN dungeon_id TEXT REFERENCES dungeons(id) ON DELETE CASCADE;
+
+-- Tiers move to the dungeon linked to their chat. Tiers of chats without a
+-- dungeon could only discount shops that no longer exist and are dropped.
+UPDATE reward_tiers t
+SET dungeon_id = d.id
+FROM dungeons d
+WHERE d.telegram_chat_id = t.chat_id;
+
+DELETE FROM reward_tiers WHERE dungeon_id IS NULL;
+
+DROP INDEX idx_reward_tiers_chat;
+ALTER TABLE reward_tiers DROP COLUMN chat_id;
+ALTER TABLE reward_tiers ALTER COLUMN dungeon_id SET NOT NULL;
+
+CREATE INDEX idx_reward_tiers_dungeon ON reward_tiers(dungeon_id, min_purchases);
+
+ALTER TABLE loyalty_statuses ADD COLUMN dungeon_id TEXT REFERENCES dungeons(id) ON DELETE CASCADE;
+
+UPDATE loyalty_statuses s
+SET dungeon_id = d.id
+FROM dungeons d
+WHERE d.telegram_chat_id = s.chat_id;
+
+DELETE FROM loyalty_statuses WHERE dungeon_id IS NULL;
+
+ALTER TABLE loyalty_statuses DROP CONSTRAINT loyalty_statuses_pkey;
+ALTER TABLE loyalty_statuses DROP COLUMN chat_id;
+ALTER TABLE loyalty_statuses ALTER COLUMN dungeon_id SET NOT NULL;
+ALTER TABLE loyalty_statuses ADD PRIMARY KEY (user_id, dungeon_id);
+
+-- Statuses counted purchases in every shop; only the dungeon's own count now
+UPDATE loyalty_statuses s
+SET purchase_count = (
+    SELECT COUNT(*) FROM purchases p
+    WHERE p.user_id = s.user_id AND p.dungeon_id = s.dungeon_id AND p.status = 'completed');
+
+UPDATE loyalty_statuses s
+SET tier_id = (
+    SELECT t.id FROM reward_tiers t
+    WHERE t.dungeon_id = s.dungeon_id AND t.min_purchases <= s.purchase_count
+    ORDER BY t.min_purchases DESC, t.discount_percent DESC
+    LIMIT 1);
@@ -0,0 +1,2 @@
+-- Revert migration 022: dungeons.time_zone
+ALTER TABLE dungeons DROP COLUMN time_zone;
@@ -0,0 +1,3 @@
+-- Migration 022: dungeons.time_zone
+-- New quests of a dungeon start in its zone; existing dungeons use UTC
+ALTER TABLE dungeons ADD COLUMN time_zone VARCHAR(50) NOT NULL DEFAULT 'UTC';
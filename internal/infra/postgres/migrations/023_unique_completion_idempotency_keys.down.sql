@@ -0,0 +1,2 @@
+-- Revert migration 023: one quest completion per idempotency key
+DROP INDEX idx_quest_completions_idempotency_key;
@@ -0,0 +1,12 @@
+-- Migration 023: one quest completion per idempotency key
+-- Concurrent presses of one Done button could record a key twice; the later
+-- duplicates keep their points but give up the key.
+UPDATE quest_completions c SET idempotency_key = ''
+WHERE c.idempotency_key <> '' AND EXISTS (
+    SELECT 1 FROM quest_completions d
+    WHERE d.idempotency_key = c.idempotency_key
+      AND (d.submitted_at < c.submitted_at OR (d.submitted_at = c.submitted_at AND d.id < c.id))
+);
+
+CREATE UNIQUE INDEX idx_quest_completions_idempotency_key ON quest_completions(idempotency_key)
+    WHERE idempotency_key <> '';
@@ -0,0 +1,3 @@
+-- Revert migration 024: timers.notification_claimed_at and notification_attempts
+ALTER TABLE timers DROP COLUMN notification_attempts;
+ALTER TABLE timers DROP COLUMN notification_claimed_at;
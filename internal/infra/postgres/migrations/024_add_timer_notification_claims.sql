@@ -0,0 +1,8 @@
+-- Migration 024: timers.notification_claimed_at and notification_attempts
+-- Expiry notifications whose send failed or whose process stopped are claimed
+-- again after a delay, and given up after a few attempts
+ALTER TABLE timers ADD COLUMN notification_claimed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE timers ADD COLUMN notification_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Claims left behind by processes that stopped mid-send are due again
+UPDATE timers SET notification_id = NULL WHERE notification_id = 'sending';
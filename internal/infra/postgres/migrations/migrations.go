@@ -0,0 +1,138 @@
+// Package migrations holds the PostgreSQL schema migrations and the runner
+// that applies them. The SQLite backend applies its own migrations with the
+// same runner.
+//
+// Each migration is a pair of files: NNN_name.sql applies it and
+// NNN_name.down.sql rolls it back. The runner wraps every file in a
+// transaction, so the files themselves must not contain BEGIN or COMMIT.
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Files returns the migrations built into the binary
+func Files() fs.FS {
+	return files
+}
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // Empty when the migration has no down file
+	Checksum string // SHA-256 of Up, used to detect edits after it was applied
+}
+
+// VersionString formats the version the way it is stored in schema_migrations
+func (m *Migration) VersionString() string {
+	return fmt.Sprintf("%03d", m.Version)
+}
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(\.down)?\.sql$`)
+
+// Load reads the migrations in fsys, ordered by version
+func Load(fsys fs.FS) ([]*Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	downs := make(map[int]string)
+	for _, path := range paths {
+		match := fileNamePattern.FindStringSubmatch(path)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named NNN_name.sql or NNN_name.down.sql", path)
+		}
+		version, _ := strconv.Atoi(match[1])
+		name, isDown := match[2], match[3] != ""
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", path, err)
+		}
+
+		if isDown {
+			if _, ok := downs[version]; ok {
+				return nil, fmt.Errorf("migration version %03d has more than one down file", version)
+			}
+			downs[version] = string(content)
+			continue
+		}
+
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %03d", existing.Name, name, version)
+		}
+		sum := sha256.Sum256(content)
+		byVersion[version] = &Migration{
+			Version:  version,
+			Name:     name,
+			Up:       string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+	}
+
+	for version, down := range downs {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("down migration %03d has no up migration", version)
+		}
+		migration.Down = down
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Create writes empty up and down files for a new migration to dir, numbered
+// after the latest migration there, and returns their paths
+func Create(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !namePattern.MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q may only contain letters, digits and underscores", name)
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	version := 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%03d_%s", version, name)
+	title := strings.ReplaceAll(name, "_", " ")
+	upPath := filepath.Join(dir, base+".sql")
+	downPath := filepath.Join(dir, base+".down.sql")
+
+	if err := os.WriteFile(upPath, []byte(fmt.Sprintf("-- Migration %03d: %s\n\n", version, title)), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte(fmt.Sprintf("-- Revert migration %03d: %s\n\n", version, title)), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", downPath, err)
+	}
+
+	return upPath, downPath, nil
+}
@@ -0,0 +1,146 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_add_b.sql":      {Data: []byte("CREATE TABLE b ();")},
+		"002_add_a.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"002_add_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, 2, migrations[0].Version)
+	assert.Equal(t, "add_a", migrations[0].Name)
+	assert.Equal(t, "002", migrations[0].VersionString())
+	assert.Equal(t, "DROP TABLE a;", migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+
+	assert.Equal(t, 10, migrations[1].Version)
+	assert.Empty(t, migrations[1].Down)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"duplicate version", fstest.MapFS{
+			"001_one.sql": {Data: []byte("")},
+			"001_two.sql": {Data: []byte("")},
+		}},
+		{"down without up", fstest.MapFS{
+			"001_one.down.sql": {Data: []byte("")},
+		}},
+		{"bad name", fstest.MapFS{
+			"one.sql": {Data: []byte("")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	upPath, downPath, err := Create(dir, "Add Widgets")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "001_add_widgets.sql"), upPath)
+	assert.Equal(t, filepath.Join(dir, "001_add_widgets.down.sql"), downPath)
+
+	upPath, _, err = Create(dir, "add_gadgets")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "002_add_gadgets.sql"), upPath)
+
+	content, err := os.ReadFile(upPath)
+	require.NoError(t, err)
+	assert.Equal(t, "-- Migration 002: add gadgets\n\n", string(content))
+
+	_, _, err = Create(dir, "drop-widgets")
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load(Files())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// The runner wraps every file in its own transaction
+	transaction := regexp.MustCompile(`(?im)^\s*(BEGIN|COMMIT)\s*;`)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration versions should have no gaps")
+		assert.NotEmpty(t, m.Down, "migration %s_%s has no down file", m.VersionString(), m.Name)
+		assert.False(t, transaction.MatchString(m.Up), "migration %s_%s manages its own transaction", m.VersionString(), m.Name)
+		assert.False(t, transaction.MatchString(m.Down), "down migration %s_%s manages its own transaction", m.VersionString(), m.Name)
+	}
+}
+
+func TestRunner_AcceptsEditedChecksums(t *testing.T) {
+	ctx := context.Background()
+	all, err := Load(Files())
+	require.NoError(t, err)
+
+	var edited []*Migration
+	for _, m := range all {
+		if m.Version == 16 {
+			edited = append(edited, m)
+		}
+	}
+	require.Len(t, edited, 1)
+
+	// A database that applied migration 016 before its comment was corrected
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "migrations.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			name TEXT NOT NULL DEFAULT '',
+			checksum TEXT NOT NULL DEFAULT ''
+		);
+		INSERT INTO schema_migrations (version, name, checksum)
+		VALUES ('016', 'add_dungeons_and_quests', '399c9136ca738586af81cb06ec603aa282c98ff6e07338e2a1c4bd0cc973fae9')`)
+	require.NoError(t, err)
+
+	runner := NewSQLiteRunner(db, edited)
+	statuses, err := runner.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Modified)
+
+	done, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, done)
+
+	var checksum string
+	require.NoError(t, db.QueryRow(`SELECT checksum FROM schema_migrations WHERE version = '016'`).Scan(&checksum))
+	assert.Equal(t, edited[0].Checksum, checksum)
+
+	// Any other change is still refused
+	_, err = db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = '016'`)
+	require.NoError(t, err)
+	_, err = runner.Up(ctx)
+	assert.ErrorContains(t, err, "016_add_dungeons_and_quests was changed after it was applied")
+}
@@ -0,0 +1,351 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// lockKey identifies the advisory lock held while migrations run, so two
+// deploys starting at once do not apply the same migration twice
+const lockKey int64 = 4_815_162_342
+
+// editedChecksums maps the checksums of migration files as first released to
+// the migration they belong to, for files edited later without changing what
+// they do. Databases that applied the earlier file get the current checksum
+// recorded instead of refusing to migrate.
+var editedChecksums = map[string]string{
+	// Corrected the comment about users.username
+	"399c9136ca738586af81cb06ec603aa282c98ff6e07338e2a1c4bd0cc973fae9": "016_add_dungeons_and_quests",
+}
+
+var (
+	ErrNoDownMigration = errors.New("migration has no down file")
+	ErrNothingApplied  = errors.New("no migrations have been applied")
+)
+
+// Status describes one migration known to the files or the database
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Modified  bool       // The file changed after the migration was applied
+	Missing   bool       // Applied, but there is no file for it any more
+}
+
+// Runner applies and rolls back migrations, recording them in schema_migrations
+type Runner struct {
+	db         *sql.DB
+	migrations []*Migration
+	sqlite     bool
+}
+
+func NewRunner(db *sql.DB, migrations []*Migration) *Runner {
+	return &Runner{db: db, migrations: migrations}
+}
+
+// NewSQLiteRunner returns a runner for a SQLite database. SQLite has no
+// advisory locks; each migration's transaction holds the database's write
+// lock, so a second process applying the same migration fails to record it
+// and rolls back instead.
+func NewSQLiteRunner(db *sql.DB, migrations []*Migration) *Runner {
+	return &Runner{db: db, migrations: migrations, sqlite: true}
+}
+
+// applied is a row of schema_migrations
+type applied struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies every pending migration in order and returns them. It refuses to
+// run when an applied migration was edited afterwards.
+func (r *Runner) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := r.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.adopt(ctx, conn, history); err != nil {
+			return err
+		}
+		if err := r.checkChecksums(history); err != nil {
+			return err
+		}
+
+		for _, migration := range r.migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+			if err := r.run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the n most recently applied migrations, newest first
+func (r *Runner) Down(ctx context.Context, n int) ([]*Migration, error) {
+	var done []*Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		done, err = r.down(ctx, conn, n)
+		return err
+	})
+	return done, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again
+func (r *Runner) Redo(ctx context.Context) (*Migration, error) {
+	var migration *Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := r.down(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			return ErrNothingApplied
+		}
+		migration = done[0]
+		return r.run(ctx, conn, migration, true)
+	})
+	return migration, err
+}
+
+// Status lists every migration with whether and when it was applied
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	history, err := r.history(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	known := make(map[int]bool, len(r.migrations))
+	for _, migration := range r.migrations {
+		known[migration.Version] = true
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := history[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = row.checksum != "" && row.checksum != migration.Checksum && !superseded(migration, row.checksum)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, row := range history {
+		if known[version] {
+			continue
+		}
+		appliedAt := row.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: row.name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// down rolls back the n most recently applied migrations; the caller holds the lock
+func (r *Runner) down(ctx context.Context, conn *sql.Conn, n int) ([]*Migration, error) {
+	history, err := r.history(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for i := len(r.migrations) - 1; i >= 0 && len(done) < n; i-- {
+		migration := r.migrations[i]
+		if _, ok := history[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("cannot roll back migration %s_%s: %w", migration.VersionString(), migration.Name, ErrNoDownMigration)
+		}
+		if err := r.run(ctx, conn, migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// run applies or rolls back one migration and records it in one transaction
+func (r *Runner) run(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	script, record := migration.Down, `DELETE FROM schema_migrations WHERE version = $1`
+	args := []interface{}{migration.VersionString()}
+	if up {
+		script = migration.Up
+		record = `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`
+		args = append(args, migration.Name, migration.Checksum)
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to run migration %s_%s: %w", migration.VersionString(), migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %s_%s: %w", migration.VersionString(), migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// adopt records the name and checksum of migrations applied before they were
+// tracked, trusting the current files, and of migrations applied from an
+// earlier version of an edited file
+func (r *Runner) adopt(ctx context.Context, conn *sql.Conn, history map[int]applied) error {
+	for _, migration := range r.migrations {
+		row, ok := history[migration.Version]
+		if !ok || (row.checksum != "" && !superseded(migration, row.checksum)) {
+			continue
+		}
+		_, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET name = $2, checksum = $3 WHERE version = $1`,
+			migration.VersionString(), migration.Name, migration.Checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of migration %s: %w", migration.VersionString(), err)
+		}
+		row.name, row.checksum = migration.Name, migration.Checksum
+		history[migration.Version] = row
+	}
+	return nil
+}
+
+// superseded reports whether checksum is of an earlier version of the
+// migration's file, listed in editedChecksums
+func superseded(migration *Migration, checksum string) bool {
+	return editedChecksums[checksum] == migration.VersionString()+"_"+migration.Name
+}
+
+// checkChecksums fails if an applied migration's file has changed
+func (r *Runner) checkChecksums(history map[int]applied) error {
+	for _, migration := range r.migrations {
+		row, ok := history[migration.Version]
+		if !ok {
+			continue
+		}
+		if row.checksum != migration.Checksum {
+			return fmt.Errorf("migration %s_%s was changed after it was applied", migration.VersionString(), migration.Name)
+		}
+	}
+	return nil
+}
+
+// history returns the applied migrations by version, creating the bookkeeping
+// table if needed
+func (r *Runner) history(ctx context.Context, conn *sql.Conn) (map[int]applied, error) {
+	create := r.createTable
+	if r.sqlite {
+		create = r.createSQLiteTable
+	}
+	if err := create(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[int]applied)
+	for rows.Next() {
+		var versionStr string
+		var row applied
+		if err := rows.Scan(&versionStr, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("applied migration has invalid version %q", versionStr)
+		}
+		history[version] = row
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over applied migrations: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *Runner) createTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		ALTER TABLE schema_migrations
+			ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// createSQLiteTable is createTable for SQLite, which cannot add a column only
+// if it is missing, so the columns are looked up first
+func (r *Runner) createSQLiteTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range []string{"name", "checksum"} {
+		var exists bool
+		err := conn.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM pragma_table_info('schema_migrations') WHERE name = $1)`, column).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN `+column+` TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on one connection while holding the migrations advisory
+// lock. SQLite runners only take the connection.
+func (r *Runner) withLock(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	if r.sqlite {
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	return fn(conn)
+}
@@ -1,32 +0,0 @@
-package postgres
-
-import (
-	"context"
-	"database/sql"
-	"time"
-
-	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
-)
-
-type PgScheduler struct {
-	db *sql.DB
-}
-
-func NewPgScheduler(db *sql.DB) *PgScheduler {
-	return &PgScheduler{db: db}
-}
-
-func (s *PgScheduler) ScheduleRecurringTask(ctx context.Context, quest *entity.Quest) error {
-	// TODO: Implement actual database scheduling logic
-	return nil
-}
-
-func (s *PgScheduler) CancelScheduledTask(ctx context.Context, taskID string) error {
-	// TODO: Implement actual database cancellation logic
-	return nil
-}
-
-func (s *PgScheduler) GetNextOccurrence(ctx context.Context, taskID string) (time.Time, error) {
-	// TODO: Query next occurrence from database
-	return time.Time{}, nil
-}
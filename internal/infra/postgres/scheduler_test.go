@@ -0,0 +1,87 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlstore"
+)
+
+func TestPgScheduler(t *testing.T) {
+	// Get connection string from env or use default
+	connStr := os.Getenv("TEST_DB_CONN")
+	if connStr == "" {
+		connStr = "user=postgres dbname=adhd_bot_test sslmode=disable"
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Skip("Skipping test: database not available:", err)
+	}
+
+	// Clean database before tests and apply the schedules migration
+	_, err = db.Exec("DROP TABLE IF EXISTS schedule_occurrences, schedules CASCADE")
+	require.NoError(t, err)
+	migration, err := os.ReadFile("migrations/008_add_schedules.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(migration))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	scheduler := sqlstore.NewScheduler(db, postgres.Dialect)
+
+	quest := &entity.Quest{ID: "quest-1", Category: "daily", TimeZone: "Europe/Berlin"}
+	require.NoError(t, scheduler.ScheduleRecurringTask(ctx, quest))
+
+	next, err := scheduler.GetNextOccurrence(ctx, "quest-1")
+	require.NoError(t, err)
+	assert.True(t, next.After(time.Now()))
+
+	t.Run("concurrent ticks from several replicas", func(t *testing.T) {
+		later := time.Now().Add(72 * time.Hour)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- sqlstore.NewScheduler(db, postgres.Dialect).Tick(ctx, later)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		var total, missed, open int
+		err := db.QueryRow(`
+			SELECT COUNT(*),
+				COUNT(*) FILTER (WHERE missed),
+				COUNT(*) FILTER (WHERE completed_at IS NULL AND NOT skipped AND NOT missed)
+			FROM schedule_occurrences`).Scan(&total, &missed, &open)
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Equal(t, 3, missed)
+		assert.Equal(t, 2, open)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		require.NoError(t, scheduler.CancelScheduledTask(ctx, "quest-1"))
+		_, err := scheduler.GetNextOccurrence(ctx, "quest-1")
+		assert.ErrorIs(t, err, entity.ErrScheduleEnded)
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
 	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlstore"
 )
 
 func TestUserRepository(t *testing.T) {
@@ -49,7 +50,7 @@ func TestUserRepository(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	repo := postgres.NewUserRepository(db)
+	repo := sqlstore.NewUserRepository(db, postgres.Dialect)
 
 	t.Run("Create and find user", func(t *testing.T) {
 		user := &entity.User{
@@ -72,8 +73,9 @@ func TestUserRepository(t *testing.T) {
 	})
 
 	t.Run("Update balance", func(t *testing.T) {
-		err := repo.UpdateBalance(context.Background(), 1, valueobject.NewDecimal("5.25"))
+		balance, err := repo.UpdateBalance(context.Background(), 1, valueobject.NewDecimal("5.25"))
 		require.NoError(t, err)
+		require.Equal(t, 15.75, balance.Float64())
 
 		found, err := repo.FindByID(context.Background(), 1)
 		require.NoError(t, err)
@@ -81,8 +83,9 @@ func TestUserRepository(t *testing.T) {
 	})
 
 	t.Run("Update balance negative", func(t *testing.T) {
-		err := repo.UpdateBalance(context.Background(), 1, valueobject.NewDecimal("-5.25"))
+		balance, err := repo.UpdateBalance(context.Background(), 1, valueobject.NewDecimal("-5.25"))
 		require.NoError(t, err)
+		require.Equal(t, 10.5, balance.Float64())
 
 		found, err := repo.FindByID(context.Background(), 1)
 		require.NoError(t, err)
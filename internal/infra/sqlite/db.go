@@ -0,0 +1,56 @@
+// Package sqlite implements the repository ports on a single SQLite file, for
+// deployments that run on one host without a database server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres/migrations"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// connParams configures every connection. Times are written in UTC with a
+// fixed layout so they compare correctly as text, and transactions take the
+// write lock up front so two writers never deadlock upgrading their locks.
+const connParams = "_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate" +
+	"&_time_format=sqlite&_timezone=UTC&_texttotime=1"
+
+// Open opens the database file at path, creating it if needed, and applies
+// pending migrations
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?"+connParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate applies the migrations that have not been applied yet, each in its
+// own transaction. It refuses to run when an applied migration was edited
+// afterwards.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	all, err := migrations.Load(files)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrations.NewSQLiteRunner(db, all).Up(ctx)
+	return err
+}
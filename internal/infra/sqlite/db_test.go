@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "bot.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrate_RecordsChecksums(t *testing.T) {
+	db := openTestDB(t)
+
+	var untracked int
+	err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE name = '' OR checksum = ''`).Scan(&untracked)
+	require.NoError(t, err)
+	assert.Zero(t, untracked)
+
+	// Migrating again applies nothing and still passes the checksum check
+	require.NoError(t, Migrate(context.Background(), db))
+}
+
+func TestMigrate_AdoptsUntrackedMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	// Databases migrated before checksums were tracked only have versions
+	_, err := db.Exec(`ALTER TABLE schema_migrations DROP COLUMN name`)
+	require.NoError(t, err)
+	_, err = db.Exec(`ALTER TABLE schema_migrations DROP COLUMN checksum`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(context.Background(), db))
+
+	var untracked int
+	err = db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE name = '' OR checksum = ''`).Scan(&untracked)
+	require.NoError(t, err)
+	assert.Zero(t, untracked)
+}
+
+func TestMigrate_RejectsEditedMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	_, err := db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = '001'`)
+	require.NoError(t, err)
+
+	err = Migrate(context.Background(), db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "001_init was changed after it was applied")
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlstore"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect adapts the shared repositories to SQLite. Rows get their ID from
+// rowid when it is NULL, and transactions begin immediately, holding the
+// database's write lock instead of row locks.
+var Dialect = sqlstore.Dialect{
+	IsUniqueViolation: isUniqueViolation,
+	NextID:            func(table string) string { return `NULL` },
+	IntList:           func(values *[]int) sqlstore.IntList { return jsonList{values} },
+	InsertOrder:       "rowid",
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE or PRIMARY KEY constraint
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// jsonList stores a list of ints as a JSON array
+type jsonList struct {
+	values *[]int
+}
+
+func (l jsonList) Value() (driver.Value, error) {
+	values := *l.values
+	if values == nil {
+		values = []int{}
+	}
+	encoded, err := json.Marshal(values)
+	return string(encoded), err
+}
+
+func (l jsonList) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), l.values)
+	case []byte:
+		return json.Unmarshal(src, l.values)
+	default:
+		return fmt.Errorf("cannot scan %T into a list of ints", src)
+	}
+}
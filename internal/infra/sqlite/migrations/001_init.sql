@@ -1,6 +1,4 @@
 -- Migration 001: Initial schema setup
-BEGIN TRANSACTION;
-
 -- Users table
 CREATE TABLE users (
     id INTEGER PRIMARY KEY,
@@ -51,5 +49,3 @@ CREATE TABLE tasks (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
-
-COMMIT;
\ No newline at end of file
@@ -1,6 +1,4 @@
 -- Migration 002: Add indices and constraints
-BEGIN TRANSACTION;
-
 -- Create indices for performance
 CREATE INDEX idx_user_balances_user ON user_balances(user_id);
 CREATE INDEX idx_tasks_category_status ON tasks(category, status);
@@ -29,5 +27,3 @@ BEGIN
     SET updated_at = CURRENT_TIMESTAMP 
     WHERE user_id = NEW.user_id AND currency_code = NEW.currency_code;
 END;
-
-COMMIT;
\ No newline at end of file
@@ -0,0 +1,280 @@
+-- Migration 003: Bring the schema up to the PostgreSQL one as of its migration 016
+--
+-- Decimal amounts are stored as TEXT so they keep their exact value; SQLite
+-- would turn NUMERIC columns into floating point. Sums over them are done by
+-- the repositories.
+
+-- Balances live on users, one currency per chat
+DROP TABLE user_balances;
+DROP TABLE currencies;
+
+ALTER TABLE users ADD COLUMN chat_id INTEGER;
+ALTER TABLE users ADD COLUMN balance TEXT NOT NULL DEFAULT '0';
+
+CREATE INDEX idx_users_chat_id ON users(chat_id);
+
+ALTER TABLE tasks ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+CREATE TABLE chat_configs (
+    chat_id INTEGER PRIMARY KEY,
+    currency_name TEXT NOT NULL DEFAULT 'Points',
+    refund_window_sec INTEGER NOT NULL DEFAULT 86400 CHECK (refund_window_sec >= 0),
+    refunds_admin_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    operation TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'completed', 'failed')),
+    result TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+CREATE INDEX idx_idempotency_keys_user_id ON idempotency_keys(user_id);
+
+-- Dungeons and quests
+CREATE TABLE dungeons (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    admin_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    telegram_chat_id INTEGER UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_dungeons_admin ON dungeons(admin_user_id);
+
+CREATE TABLE dungeon_members (
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (dungeon_id, user_id)
+);
+
+CREATE INDEX idx_dungeon_members_user ON dungeon_members(user_id);
+
+CREATE TABLE quests (
+    id TEXT PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL CHECK (category IN ('daily', 'weekly', 'adhoc')),
+    difficulty TEXT NOT NULL DEFAULT '',
+    mode TEXT NOT NULL CHECK (mode IN ('BINARY', 'PARTIAL', 'PER_MINUTE')),
+    points_award TEXT NOT NULL DEFAULT '0',
+    rate_points_per_min TEXT,
+    min_minutes INTEGER CHECK (min_minutes >= 0),
+    max_minutes INTEGER CHECK (max_minutes >= min_minutes),
+    daily_points_cap TEXT,
+    cooldown_sec INTEGER NOT NULL DEFAULT 0 CHECK (cooldown_sec >= 0),
+    streak_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    streak_grace_periods INTEGER NOT NULL DEFAULT 0 CHECK (streak_grace_periods >= 0),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'archived')),
+    last_completed_at TIMESTAMP,
+    streak_count INTEGER NOT NULL DEFAULT 0,
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quests_dungeon ON quests(dungeon_id);
+
+CREATE TABLE quest_completions (
+    id TEXT PRIMARY KEY,
+    quest_id TEXT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    submitted_at TIMESTAMP NOT NULL,
+    completion_ratio REAL CHECK (completion_ratio BETWEEN 0 AND 1),
+    minutes INTEGER CHECK (minutes >= 0),
+    awarded_points TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_quest_completions_user_quest ON quest_completions(user_id, quest_id, submitted_at DESC);
+
+CREATE TABLE quest_streaks (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id TEXT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    current_streak INTEGER NOT NULL DEFAULT 0 CHECK (current_streak >= 0),
+    best_streak INTEGER NOT NULL DEFAULT 0 CHECK (best_streak >= current_streak),
+    last_period INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, quest_id)
+);
+
+-- Recurring schedules; the day lists are JSON arrays
+CREATE TABLE schedules (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('daily', 'weekly', 'monthly')),
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    time_of_day TEXT NOT NULL,
+    days_of_week TEXT NOT NULL DEFAULT '[]',
+    days_of_month TEXT NOT NULL DEFAULT '[]',
+    skip_if_missed BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    next_run_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_schedules_next_run_at ON schedules(next_run_at) WHERE next_run_at IS NOT NULL;
+
+CREATE TABLE schedule_occurrences (
+    id TEXT PRIMARY KEY,
+    schedule_id TEXT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    missed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (schedule_id, scheduled_for),
+    CHECK (NOT (skipped AND missed))
+);
+
+-- Focus timers
+CREATE TABLE timers (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('countdown', 'stopwatch')),
+    start_time TIMESTAMP NOT NULL,
+    duration_sec INTEGER NOT NULL DEFAULT 0 CHECK (duration_sec >= 0),
+    current_value_sec INTEGER NOT NULL DEFAULT 0 CHECK (current_value_sec >= 0),
+    last_tick TIMESTAMP,
+    status TEXT NOT NULL CHECK (status IN ('running', 'paused', 'completed', 'cancelled')),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    notification_id TEXT
+);
+
+CREATE INDEX idx_timers_user_status ON timers(user_id, status);
+CREATE INDEX idx_timers_task_id ON timers(task_id);
+CREATE INDEX idx_timers_pending_notification ON timers(user_id)
+    WHERE status = 'running' AND type = 'countdown' AND notification_id IS NULL;
+
+CREATE TABLE timer_events (
+    id TEXT PRIMARY KEY,
+    timer_id TEXT NOT NULL REFERENCES timers(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL CHECK (event_type IN ('start', 'pause', 'resume', 'complete', 'cancel', 'extend', 'expire')),
+    occurred_at TIMESTAMP NOT NULL,
+    metadata TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_timer_events_timer_id ON timer_events(timer_id);
+CREATE INDEX idx_timer_events_occurred_at ON timer_events(occurred_at);
+
+-- Append-only points ledger; its rowid keeps insertion order
+CREATE TABLE ledger_entries (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount TEXT NOT NULL,
+    balance_after TEXT NOT NULL,
+    reason TEXT NOT NULL CHECK (reason IN (
+        'quest_completion', 'purchase', 'refund', 'admin_adjustment', 'transfer', 'opening_balance')),
+    source_type TEXT NOT NULL DEFAULT '',
+    source_id TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_entries_user ON ledger_entries(user_id);
+CREATE INDEX idx_ledger_entries_source ON ledger_entries(source_type, source_id);
+
+CREATE TRIGGER ledger_entries_append_only
+BEFORE UPDATE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are append-only');
+END;
+
+-- Shop
+CREATE TABLE discount_tiers (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_percent REAL NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    min_purchases INTEGER NOT NULL DEFAULT 0 CHECK (min_purchases >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE shop_items (
+    id INTEGER PRIMARY KEY,
+    chat_id INTEGER NOT NULL DEFAULT 0, -- Legacy chat scope
+    dungeon_id TEXT REFERENCES dungeons(id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    stock INTEGER, -- NULL for unlimited
+    discount_tier_id INTEGER REFERENCES discount_tiers(id) ON DELETE SET NULL,
+    sale_price TEXT,
+    sale_ends_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_shop_items_dungeon_code ON shop_items(dungeon_id, code) WHERE dungeon_id IS NOT NULL;
+
+CREATE TABLE purchases (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    item_id INTEGER NOT NULL REFERENCES shop_items(id) ON DELETE RESTRICT,
+    dungeon_id TEXT REFERENCES dungeons(id) ON DELETE SET NULL,
+    item_name TEXT NOT NULL, -- Denormalized for history
+    item_price TEXT NOT NULL, -- Price at time of purchase
+    quantity INTEGER NOT NULL DEFAULT 1,
+    subtotal TEXT NOT NULL,
+    total_cost TEXT NOT NULL,
+    price_breakdown TEXT NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL DEFAULT 'completed',
+    discount_tier_id INTEGER REFERENCES discount_tiers(id) ON DELETE SET NULL,
+    purchased_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    refunded_quantity INTEGER NOT NULL DEFAULT 0 CHECK (refunded_quantity BETWEEN 0 AND quantity),
+    refunded_amount TEXT NOT NULL DEFAULT '0',
+    refunded_at TIMESTAMP
+);
+
+CREATE INDEX idx_purchases_user ON purchases(user_id, purchased_at DESC);
+CREATE INDEX idx_purchases_item ON purchases(item_id);
+CREATE INDEX idx_purchases_dungeon ON purchases(dungeon_id, purchased_at DESC);
+
+-- Per-user price overrides
+CREATE TABLE member_prices (
+    item_id INTEGER NOT NULL REFERENCES shop_items(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    price TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (item_id, user_id)
+);
+
+-- Loyalty tiers per chat
+CREATE TABLE reward_tiers (
+    id INTEGER PRIMARY KEY,
+    chat_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_percent REAL NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    min_purchases INTEGER NOT NULL CHECK (min_purchases >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reward_tiers_chat ON reward_tiers(chat_id, min_purchases);
+
+CREATE TABLE loyalty_statuses (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_id INTEGER NOT NULL,
+    tier_id INTEGER REFERENCES reward_tiers(id) ON DELETE SET NULL,
+    purchase_count INTEGER NOT NULL DEFAULT 0 CHECK (purchase_count >= 0),
+    notified_tier_id INTEGER REFERENCES reward_tiers(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, chat_id)
+);
@@ -0,0 +1,10 @@
+-- Migration 004: Roles on dungeon membership, as PostgreSQL migration 017
+ALTER TABLE dungeon_members
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+        CHECK (role IN ('owner', 'moderator', 'member'));
+
+-- Each admin becomes the owner of their dungeon. The WHERE keeps SQLite from
+-- reading ON CONFLICT as a join constraint.
+INSERT INTO dungeon_members (dungeon_id, user_id, role)
+SELECT id, admin_user_id, 'owner' FROM dungeons WHERE true
+ON CONFLICT (dungeon_id, user_id) DO UPDATE SET role = 'owner';
@@ -0,0 +1,26 @@
+-- Migration 005: Dungeon invites and join requests, as PostgreSQL migration 018
+CREATE TABLE dungeon_invites (
+    code TEXT PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+    expires_at TIMESTAMP,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_dungeon_invites_dungeon ON dungeon_invites(dungeon_id, created_at DESC);
+
+CREATE TABLE join_requests (
+    id INTEGER PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+    decided_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_join_requests_pending ON join_requests(dungeon_id, user_id) WHERE status = 'pending';
@@ -0,0 +1,43 @@
+-- Migration 006: Per-dungeon wallets, as PostgreSQL migration 019
+ALTER TABLE ledger_entries ADD COLUMN dungeon_id TEXT REFERENCES dungeons(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_ledger_entries_wallet ON ledger_entries(user_id, dungeon_id);
+
+CREATE TABLE wallets (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    balance TEXT NOT NULL DEFAULT '0',
+    PRIMARY KEY (user_id, dungeon_id)
+);
+
+-- Assigning existing entries to a dungeon is the one update they ever get
+DROP TRIGGER ledger_entries_append_only;
+
+UPDATE ledger_entries
+SET dungeon_id = (SELECT c.dungeon_id FROM quest_completions c WHERE c.id = ledger_entries.source_id)
+WHERE source_type = 'quest_completion';
+
+UPDATE ledger_entries
+SET dungeon_id = (SELECT p.dungeon_id FROM purchases p WHERE CAST(p.id AS TEXT) = ledger_entries.source_id
+                  AND p.dungeon_id IN (SELECT id FROM dungeons))
+WHERE source_type = 'purchase';
+
+UPDATE ledger_entries
+SET dungeon_id = COALESCE(
+    (SELECT d.id FROM users u JOIN dungeons d ON d.telegram_chat_id = u.chat_id WHERE u.id = ledger_entries.user_id),
+    (SELECT m.dungeon_id FROM dungeon_members m WHERE m.user_id = ledger_entries.user_id ORDER BY m.joined_at, m.dungeon_id LIMIT 1))
+WHERE dungeon_id IS NULL;
+
+CREATE TRIGGER ledger_entries_append_only
+BEFORE UPDATE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are append-only');
+END;
+
+-- Amounts are text, so SQLite sums them as floating point. Run cmd/reconcile
+-- with -fix afterwards if balances use many decimal places.
+INSERT INTO wallets (user_id, dungeon_id, balance)
+SELECT user_id, dungeon_id, CAST(SUM(CAST(amount AS REAL)) AS TEXT)
+FROM ledger_entries
+WHERE dungeon_id IS NOT NULL
+GROUP BY user_id, dungeon_id;
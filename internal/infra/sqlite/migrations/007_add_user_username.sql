@@ -0,0 +1,4 @@
+-- Migration 007: users.username, as PostgreSQL migration 020
+ALTER TABLE users ADD COLUMN username TEXT NOT NULL DEFAULT '';
+
+UPDATE users SET username = display_name;
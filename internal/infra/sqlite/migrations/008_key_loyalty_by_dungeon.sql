@@ -0,0 +1,53 @@
+-- Migration 008: Key loyalty tiers and statuses by dungeon, as PostgreSQL
+-- migration 021. SQLite cannot drop key columns, so both tables are rebuilt;
+-- tiers keep their IDs.
+CREATE TABLE dungeon_reward_tiers (
+    id INTEGER PRIMARY KEY,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_percent REAL NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    min_purchases INTEGER NOT NULL CHECK (min_purchases >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO dungeon_reward_tiers (id, dungeon_id, name, description, discount_percent, min_purchases, created_at, updated_at)
+SELECT t.id, d.id, t.name, t.description, t.discount_percent, t.min_purchases, t.created_at, t.updated_at
+FROM reward_tiers t
+JOIN dungeons d ON d.telegram_chat_id = t.chat_id;
+
+CREATE TABLE dungeon_loyalty_statuses (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dungeon_id TEXT NOT NULL REFERENCES dungeons(id) ON DELETE CASCADE,
+    tier_id INTEGER REFERENCES dungeon_reward_tiers(id) ON DELETE SET NULL,
+    purchase_count INTEGER NOT NULL DEFAULT 0 CHECK (purchase_count >= 0),
+    notified_tier_id INTEGER REFERENCES dungeon_reward_tiers(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, dungeon_id)
+);
+
+INSERT INTO dungeon_loyalty_statuses (user_id, dungeon_id, tier_id, purchase_count, notified_tier_id, updated_at)
+SELECT s.user_id, d.id, s.tier_id, s.purchase_count, s.notified_tier_id, s.updated_at
+FROM loyalty_statuses s
+JOIN dungeons d ON d.telegram_chat_id = s.chat_id;
+
+DROP TABLE loyalty_statuses;
+DROP TABLE reward_tiers;
+
+ALTER TABLE dungeon_reward_tiers RENAME TO reward_tiers;
+ALTER TABLE dungeon_loyalty_statuses RENAME TO loyalty_statuses;
+
+CREATE INDEX idx_reward_tiers_dungeon ON reward_tiers(dungeon_id, min_purchases);
+
+UPDATE loyalty_statuses
+SET purchase_count = (
+    SELECT COUNT(*) FROM purchases p
+    WHERE p.user_id = loyalty_statuses.user_id AND p.dungeon_id = loyalty_statuses.dungeon_id AND p.status = 'completed');
+
+UPDATE loyalty_statuses
+SET tier_id = (
+    SELECT t.id FROM reward_tiers t
+    WHERE t.dungeon_id = loyalty_statuses.dungeon_id AND t.min_purchases <= loyalty_statuses.purchase_count
+    ORDER BY t.min_purchases DESC, t.discount_percent DESC
+    LIMIT 1);
@@ -0,0 +1,2 @@
+-- Migration 009: dungeons.time_zone, as PostgreSQL migration 022
+ALTER TABLE dungeons ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,11 @@
+-- Migration 010: one quest completion per idempotency key, as PostgreSQL migration 023
+UPDATE quest_completions SET idempotency_key = ''
+WHERE idempotency_key <> '' AND EXISTS (
+    SELECT 1 FROM quest_completions d
+    WHERE d.idempotency_key = quest_completions.idempotency_key
+      AND (d.submitted_at < quest_completions.submitted_at
+           OR (d.submitted_at = quest_completions.submitted_at AND d.id < quest_completions.id))
+);
+
+CREATE UNIQUE INDEX idx_quest_completions_idempotency_key ON quest_completions(idempotency_key)
+    WHERE idempotency_key <> '';
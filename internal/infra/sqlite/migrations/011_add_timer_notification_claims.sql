@@ -0,0 +1,5 @@
+-- Migration 011: timers.notification_claimed_at and notification_attempts, as PostgreSQL migration 024
+ALTER TABLE timers ADD COLUMN notification_claimed_at TIMESTAMP;
+ALTER TABLE timers ADD COLUMN notification_attempts INTEGER NOT NULL DEFAULT 0;
+
+UPDATE timers SET notification_id = NULL WHERE notification_id = 'sending';
@@ -0,0 +1,48 @@
+// Package sqlstore implements the repository ports on database/sql. PostgreSQL
+// and SQLite share these repositories; each backend describes what differs in
+// a Dialect.
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Dialect holds what differs between the databases the repositories run on
+type Dialect struct {
+	// IsUniqueViolation reports whether err comes from a UNIQUE or PRIMARY KEY constraint
+	IsUniqueViolation func(err error) bool
+	// NextID is the SQL expression for the generated ID of a new row of table
+	NextID func(table string) string
+	// IntList wraps a list of ints to be written to or scanned from a column
+	IntList func(values *[]int) IntList
+	// LocksRows reports whether SELECT ... FOR UPDATE locks rows. Databases
+	// without row locks take their write lock when a transaction begins, so
+	// rows read inside one cannot change until it ends.
+	LocksRows bool
+	// ExactNumeric reports whether the database adds NUMERIC values exactly.
+	// Otherwise decimals are stored as text and added up in Go.
+	ExactNumeric bool
+	// InsertOrder is the column listing ledger entries in insertion order
+	InsertOrder string
+}
+
+// IntList is a list of ints stored in one column
+type IntList interface {
+	driver.Valuer
+	sql.Scanner
+}
+
+// forUpdate returns the clause locking the rows a SELECT inside a transaction reads
+func (d Dialect) forUpdate() string {
+	if d.LocksRows {
+		return ` FOR UPDATE`
+	}
+	return ``
+}
+
+// insertID returns the VALUES expression for a row ID given as $1, generated
+// when it is zero
+func (d Dialect) insertID(table string) string {
+	return `COALESCE(NULLIF(CAST($1 AS BIGINT), 0), ` + d.NextID(table) + `)`
+}
@@ -1,4 +1,4 @@
-package postgres
+package sqlstore
 
 import (
 	"context"
@@ -7,34 +7,39 @@ import (
 	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
 type DiscountTierRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
-func NewDiscountTierRepository(db *sql.DB) *DiscountTierRepository {
-	return &DiscountTierRepository{db: db}
+func NewDiscountTierRepository(db *sql.DB, dialect Dialect) *DiscountTierRepository {
+	return &DiscountTierRepository{db: db, dialect: dialect}
 }
 
 func (r *DiscountTierRepository) Create(ctx context.Context, tier *entity.DiscountTier) error {
-	// Check if we're in a transaction
+	// A zero ID lets the database assign one
+	query := `
+		INSERT INTO discount_tiers (id, name, description, discount_percent, min_purchases, created_at, updated_at)
+		VALUES (` + r.dialect.insertID("discount_tiers") + `, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	var row *sql.Row
 	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `
-			INSERT INTO discount_tiers (id, name, description, discount_percent, min_purchases, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		row = tx.QueryRowContext(ctx, query,
 			tier.ID, tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.CreatedAt, tier.UpdatedAt)
-		if err != nil {
-			return fmt.Errorf("failed to create discount tier: %w", err)
-		}
 	} else {
-		_, err := r.db.ExecContext(ctx, `
-			INSERT INTO discount_tiers (id, name, description, discount_percent, min_purchases, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		row = r.db.QueryRowContext(ctx, query,
 			tier.ID, tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.CreatedAt, tier.UpdatedAt)
-		if err != nil {
-			return fmt.Errorf("failed to create discount tier: %w", err)
+	}
+
+	if err := row.Scan(&tier.ID); err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ports.ErrDiscountTierExists
 		}
+		return fmt.Errorf("failed to create discount tier: %w", err)
 	}
 
 	return nil
@@ -59,7 +64,7 @@ func (r *DiscountTierRepository) FindByID(ctx context.Context, id int64) (*entit
 	err := row.Scan(&tier.ID, &tier.Name, &tier.Description, &tier.DiscountPercent, &tier.MinPurchases, &createdAt, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("discount tier not found: %w", ErrDiscountTierNotFound)
+			return nil, fmt.Errorf("discount tier not found: %w", ports.ErrDiscountTierNotFound)
 		}
 		return nil, fmt.Errorf("failed to query discount tier: %w", err)
 	}
@@ -71,9 +76,17 @@ func (r *DiscountTierRepository) FindByID(ctx context.Context, id int64) (*entit
 }
 
 func (r *DiscountTierRepository) FindAll(ctx context.Context) ([]*entity.DiscountTier, error) {
-	rows, err := r.db.QueryContext(ctx, `
+	query := `
 		SELECT id, name, description, discount_percent, min_purchases, created_at, updated_at
-		FROM discount_tiers ORDER BY discount_percent ASC`)
+		FROM discount_tiers ORDER BY discount_percent ASC`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query discount tiers: %w", err)
 	}
@@ -104,42 +117,55 @@ func (r *DiscountTierRepository) FindAll(ctx context.Context) ([]*entity.Discoun
 }
 
 func (r *DiscountTierRepository) Update(ctx context.Context, tier *entity.DiscountTier) error {
-	// Check if we're in a transaction
-	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `
+	query := `
 			UPDATE discount_tiers 
 			SET name = $1, description = $2, discount_percent = $3, min_purchases = $4, updated_at = $5
-			WHERE id = $6`,
+			WHERE id = $6`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
 			tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.UpdatedAt, tier.ID)
-		if err != nil {
-			return fmt.Errorf("failed to update discount tier: %w", err)
-		}
 	} else {
-		_, err := r.db.ExecContext(ctx, `
-			UPDATE discount_tiers 
-			SET name = $1, description = $2, discount_percent = $3, min_purchases = $4, updated_at = $5
-			WHERE id = $6`,
+		result, err = r.db.ExecContext(ctx, query,
 			tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.UpdatedAt, tier.ID)
-		if err != nil {
-			return fmt.Errorf("failed to update discount tier: %w", err)
-		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update discount tier: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDiscountTierNotFound
 	}
 
 	return nil
 }
 
 func (r *DiscountTierRepository) Delete(ctx context.Context, id int64) error {
-	// Check if we're in a transaction
+	query := `DELETE FROM discount_tiers WHERE id = $1`
+
+	var result sql.Result
+	var err error
 	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `DELETE FROM discount_tiers WHERE id = $1`, id)
-		if err != nil {
-			return fmt.Errorf("failed to delete discount tier: %w", err)
-		}
+		result, err = tx.ExecContext(ctx, query, id)
 	} else {
-		_, err := r.db.ExecContext(ctx, `DELETE FROM discount_tiers WHERE id = $1`, id)
-		if err != nil {
-			return fmt.Errorf("failed to delete discount tier: %w", err)
-		}
+		result, err = r.db.ExecContext(ctx, query, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete discount tier: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDiscountTierNotFound
 	}
 
 	return nil
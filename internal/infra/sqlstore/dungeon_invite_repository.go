@@ -0,0 +1,156 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type DungeonInviteRepository struct {
+	db *sql.DB
+}
+
+func NewDungeonInviteRepository(db *sql.DB) *DungeonInviteRepository {
+	return &DungeonInviteRepository{db: db}
+}
+
+func (r *DungeonInviteRepository) Create(ctx context.Context, invite *entity.DungeonInvite) error {
+	query := `
+		INSERT INTO dungeon_invites (code, dungeon_id, created_by, max_uses, uses, expires_at, requires_approval, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query,
+			invite.Code, invite.DungeonID, invite.CreatedBy, invite.MaxUses, invite.Uses, invite.ExpiresAt,
+			invite.RequiresApproval, invite.CreatedAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, query,
+			invite.Code, invite.DungeonID, invite.CreatedBy, invite.MaxUses, invite.Uses, invite.ExpiresAt,
+			invite.RequiresApproval, invite.CreatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create dungeon invite: %w", err)
+	}
+
+	return nil
+}
+
+const dungeonInviteColumns = `code, dungeon_id, created_by, max_uses, uses, expires_at, requires_approval, created_at`
+
+func scanDungeonInvite(row rowScanner) (*entity.DungeonInvite, error) {
+	var invite entity.DungeonInvite
+	var expiresAt sql.NullTime
+
+	err := row.Scan(&invite.Code, &invite.DungeonID, &invite.CreatedBy, &invite.MaxUses, &invite.Uses, &expiresAt,
+		&invite.RequiresApproval, &invite.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		invite.ExpiresAt = &expiresAt.Time
+	}
+	return &invite, nil
+}
+
+func (r *DungeonInviteRepository) GetByCode(ctx context.Context, code string) (*entity.DungeonInvite, error) {
+	query := `SELECT ` + dungeonInviteColumns + ` FROM dungeon_invites WHERE code = $1`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, code)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, code)
+	}
+
+	invite, err := scanDungeonInvite(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("dungeon invite not found: %w", ports.ErrDungeonInviteNotFound)
+		}
+		return nil, fmt.Errorf("failed to query dungeon invite: %w", err)
+	}
+
+	return invite, nil
+}
+
+func (r *DungeonInviteRepository) ListByDungeon(ctx context.Context, dungeonID string) ([]*entity.DungeonInvite, error) {
+	query := `SELECT ` + dungeonInviteColumns + ` FROM dungeon_invites WHERE dungeon_id = $1 ORDER BY created_at DESC, code`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, dungeonID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, dungeonID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dungeon invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []*entity.DungeonInvite
+	for rows.Next() {
+		invite, err := scanDungeonInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dungeon invite: %w", err)
+		}
+		invites = append(invites, invite)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dungeon invite rows: %w", err)
+	}
+
+	return invites, nil
+}
+
+func (r *DungeonInviteRepository) Update(ctx context.Context, invite *entity.DungeonInvite) error {
+	query := `UPDATE dungeon_invites SET uses = $1 WHERE code = $2`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, invite.Uses, invite.Code)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, invite.Uses, invite.Code)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update dungeon invite: %w", err)
+	}
+
+	return inviteAffected(result)
+}
+
+func (r *DungeonInviteRepository) Delete(ctx context.Context, code string) error {
+	query := `DELETE FROM dungeon_invites WHERE code = $1`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, code)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, code)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete dungeon invite: %w", err)
+	}
+
+	return inviteAffected(result)
+}
+
+// inviteAffected returns ErrDungeonInviteNotFound if result touched no invite
+func inviteAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDungeonInviteNotFound
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type DungeonMemberRepository struct {
+	db *sql.DB
+}
+
+func NewDungeonMemberRepository(db *sql.DB) *DungeonMemberRepository {
+	return &DungeonMemberRepository{db: db}
+}
+
+func (r *DungeonMemberRepository) Add(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error {
+	// Check if we're in a transaction
+	if tx, ok := GetTx(ctx); ok {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO dungeon_members (dungeon_id, user_id, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (dungeon_id, user_id) DO NOTHING`,
+			dungeonID, userID, role)
+		if err != nil {
+			return fmt.Errorf("failed to add dungeon member: %w", err)
+		}
+	} else {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO dungeon_members (dungeon_id, user_id, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (dungeon_id, user_id) DO NOTHING`,
+			dungeonID, userID, role)
+		if err != nil {
+			return fmt.Errorf("failed to add dungeon member: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *DungeonMemberRepository) Get(ctx context.Context, dungeonID string, userID int64) (*entity.DungeonMember, error) {
+	query := `
+		SELECT dungeon_id, user_id, role, joined_at
+		FROM dungeon_members
+		WHERE dungeon_id = $1 AND user_id = $2`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, dungeonID, userID)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, dungeonID, userID)
+	}
+
+	var member entity.DungeonMember
+	err := row.Scan(&member.DungeonID, &member.UserID, &member.Role, &member.JoinedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("dungeon member not found: %w", ports.ErrDungeonMemberNotFound)
+		}
+		return nil, fmt.Errorf("failed to query dungeon member: %w", err)
+	}
+
+	return &member, nil
+}
+
+func (r *DungeonMemberRepository) SetRole(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error {
+	query := `
+		UPDATE dungeon_members
+		SET role = $1
+		WHERE dungeon_id = $2 AND user_id = $3`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, role, dungeonID, userID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, role, dungeonID, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set dungeon member role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDungeonMemberNotFound
+	}
+
+	return nil
+}
+
+func (r *DungeonMemberRepository) Remove(ctx context.Context, dungeonID string, userID int64) error {
+	query := `DELETE FROM dungeon_members WHERE dungeon_id = $1 AND user_id = $2`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, dungeonID, userID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, dungeonID, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove dungeon member: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDungeonMemberNotFound
+	}
+
+	return nil
+}
+
+func (r *DungeonMemberRepository) List(ctx context.Context, dungeonID string) ([]*entity.DungeonMember, error) {
+	return r.list(ctx, `
+		SELECT dungeon_id, user_id, role, joined_at
+		FROM dungeon_members
+		WHERE dungeon_id = $1
+		ORDER BY user_id`, dungeonID)
+}
+
+func (r *DungeonMemberRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.DungeonMember, error) {
+	return r.list(ctx, `
+		SELECT dungeon_id, user_id, role, joined_at
+		FROM dungeon_members
+		WHERE user_id = $1
+		ORDER BY joined_at, dungeon_id`, userID)
+}
+
+func (r *DungeonMemberRepository) list(ctx context.Context, query string, arg interface{}) ([]*entity.DungeonMember, error) {
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, arg)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, arg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dungeon members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*entity.DungeonMember
+	for rows.Next() {
+		var member entity.DungeonMember
+		if err := rows.Scan(&member.DungeonID, &member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dungeon member: %w", err)
+		}
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dungeon member rows: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *DungeonMemberRepository) ListUsers(ctx context.Context, dungeonID string) ([]int64, error) {
+	query := `
+		SELECT user_id
+		FROM dungeon_members 
+		WHERE dungeon_id = $1
+		ORDER BY user_id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, dungeonID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, dungeonID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dungeon members: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		err := rows.Scan(&userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dungeon member rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (r *DungeonMemberRepository) IsMember(ctx context.Context, dungeonID string, userID int64) (bool, error) {
+	var count int
+	var row *sql.Row
+
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) 
+			FROM dungeon_members 
+			WHERE dungeon_id = $1 AND user_id = $2`, dungeonID, userID)
+	} else {
+		row = r.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) 
+			FROM dungeon_members 
+			WHERE dungeon_id = $1 AND user_id = $2`, dungeonID, userID)
+	}
+
+	err := row.Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check dungeon membership: %w", err)
+	}
+
+	return count > 0, nil
+}
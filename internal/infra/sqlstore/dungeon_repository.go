@@ -0,0 +1,169 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type DungeonRepository struct {
+	db *sql.DB
+}
+
+func NewDungeonRepository(db *sql.DB) *DungeonRepository {
+	return &DungeonRepository{db: db}
+}
+
+func (r *DungeonRepository) Create(ctx context.Context, dungeon *entity.Dungeon) error {
+	// Check if we're in a transaction
+	if tx, ok := GetTx(ctx); ok {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO dungeons (id, title, admin_user_id, telegram_chat_id, time_zone, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			dungeon.ID, dungeon.Title, dungeon.AdminUserID, dungeon.TelegramChatID, dungeon.TimeZone, dungeon.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create dungeon: %w", err)
+		}
+	} else {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO dungeons (id, title, admin_user_id, telegram_chat_id, time_zone, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			dungeon.ID, dungeon.Title, dungeon.AdminUserID, dungeon.TelegramChatID, dungeon.TimeZone, dungeon.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create dungeon: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *DungeonRepository) GetByID(ctx context.Context, dungeonID string) (*entity.Dungeon, error) {
+	var dungeon entity.Dungeon
+	var telegramChatID *int64
+	var createdAt time.Time
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `
+			SELECT id, title, admin_user_id, telegram_chat_id, time_zone, created_at
+			FROM dungeons WHERE id = $1`, dungeonID)
+	} else {
+		row = r.db.QueryRowContext(ctx, `
+			SELECT id, title, admin_user_id, telegram_chat_id, time_zone, created_at
+			FROM dungeons WHERE id = $1`, dungeonID)
+	}
+
+	err := row.Scan(&dungeon.ID, &dungeon.Title, &dungeon.AdminUserID, &telegramChatID, &dungeon.TimeZone, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("dungeon not found: %w", ports.ErrDungeonNotFound)
+		}
+		return nil, fmt.Errorf("failed to query dungeon: %w", err)
+	}
+
+	dungeon.TelegramChatID = telegramChatID
+	dungeon.CreatedAt = createdAt
+
+	return &dungeon, nil
+}
+
+// FindByTelegramChatID returns the dungeon linked to a Telegram chat
+func (r *DungeonRepository) FindByTelegramChatID(ctx context.Context, chatID int64) (*entity.Dungeon, error) {
+	var dungeon entity.Dungeon
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `
+			SELECT id, title, admin_user_id, telegram_chat_id, time_zone, created_at
+			FROM dungeons WHERE telegram_chat_id = $1`, chatID)
+	} else {
+		row = r.db.QueryRowContext(ctx, `
+			SELECT id, title, admin_user_id, telegram_chat_id, time_zone, created_at
+			FROM dungeons WHERE telegram_chat_id = $1`, chatID)
+	}
+
+	err := row.Scan(&dungeon.ID, &dungeon.Title, &dungeon.AdminUserID, &dungeon.TelegramChatID, &dungeon.TimeZone, &dungeon.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("dungeon not found: %w", ports.ErrDungeonNotFound)
+		}
+		return nil, fmt.Errorf("failed to query dungeon: %w", err)
+	}
+
+	return &dungeon, nil
+}
+
+func (r *DungeonRepository) ListByAdmin(ctx context.Context, userID int64) ([]*entity.Dungeon, error) {
+	query := `
+		SELECT id, title, admin_user_id, telegram_chat_id, time_zone, created_at
+		FROM dungeons WHERE admin_user_id = $1
+		ORDER BY created_at, id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, userID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dungeons: %w", err)
+	}
+	defer rows.Close()
+
+	var dungeons []*entity.Dungeon
+	for rows.Next() {
+		var dungeon entity.Dungeon
+		var telegramChatID *int64
+		var createdAt time.Time
+
+		err := rows.Scan(&dungeon.ID, &dungeon.Title, &dungeon.AdminUserID, &telegramChatID, &dungeon.TimeZone, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dungeon: %w", err)
+		}
+
+		dungeon.TelegramChatID = telegramChatID
+		dungeon.CreatedAt = createdAt
+
+		dungeons = append(dungeons, &dungeon)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dungeon rows: %w", err)
+	}
+
+	return dungeons, nil
+}
+
+// Update saves the dungeon's title, admin, chat link and time zone
+func (r *DungeonRepository) Update(ctx context.Context, dungeon *entity.Dungeon) error {
+	query := `
+		UPDATE dungeons
+		SET title = $1, admin_user_id = $2, telegram_chat_id = $3, time_zone = $4
+		WHERE id = $5`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, dungeon.Title, dungeon.AdminUserID, dungeon.TelegramChatID, dungeon.TimeZone, dungeon.ID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, dungeon.Title, dungeon.AdminUserID, dungeon.TelegramChatID, dungeon.TimeZone, dungeon.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update dungeon: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrDungeonNotFound
+	}
+
+	return nil
+}
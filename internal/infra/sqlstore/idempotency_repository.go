@@ -1,4 +1,4 @@
-package postgres
+package sqlstore
 
 import (
 	"context"
@@ -7,14 +7,16 @@ import (
 	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
 type IdempotencyRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
-func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
-	return &IdempotencyRepository{db: db}
+func NewIdempotencyRepository(db *sql.DB, dialect Dialect) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db, dialect: dialect}
 }
 
 func (r *IdempotencyRepository) Create(ctx context.Context, key *entity.IdempotencyKey) error {
@@ -25,6 +27,9 @@ func (r *IdempotencyRepository) Create(ctx context.Context, key *entity.Idempote
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 			key.Key, key.Operation, key.UserID, key.Status, key.Result, key.CreatedAt, key.CompletedAt, key.ExpiresAt)
 		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrIdempotencyKeyExists
+			}
 			return fmt.Errorf("failed to create idempotency key: %w", err)
 		}
 	} else {
@@ -33,6 +38,9 @@ func (r *IdempotencyRepository) Create(ctx context.Context, key *entity.Idempote
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 			key.Key, key.Operation, key.UserID, key.Status, key.Result, key.CreatedAt, key.CompletedAt, key.ExpiresAt)
 		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrIdempotencyKeyExists
+			}
 			return fmt.Errorf("failed to create idempotency key: %w", err)
 		}
 	}
@@ -40,6 +48,41 @@ func (r *IdempotencyRepository) Create(ctx context.Context, key *entity.Idempote
 	return nil
 }
 
+// Claim records key as pending if it is new or its earlier attempt failed or
+// expired, in a single statement so concurrent attempts cannot both claim it
+func (r *IdempotencyRepository) Claim(ctx context.Context, key *entity.IdempotencyKey) error {
+	query := `
+		INSERT INTO idempotency_keys (key, operation, user_id, status, result, created_at, completed_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (key) DO UPDATE
+		SET operation = EXCLUDED.operation, user_id = EXCLUDED.user_id, status = EXCLUDED.status,
+			result = EXCLUDED.result, created_at = EXCLUDED.created_at, completed_at = EXCLUDED.completed_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.status = 'failed' OR idempotency_keys.expires_at <= $9`
+
+	args := []interface{}{key.Key, key.Operation, key.UserID, key.Status, key.Result, key.CreatedAt, key.CompletedAt, key.ExpiresAt, time.Now()}
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrIdempotencyKeyExists
+	}
+
+	return nil
+}
+
 func (r *IdempotencyRepository) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
 	var idempotencyKey entity.IdempotencyKey
 	var createdAt time.Time
@@ -50,18 +93,18 @@ func (r *IdempotencyRepository) FindByKey(ctx context.Context, key string) (*ent
 	if tx, ok := GetTx(ctx); ok {
 		row = tx.QueryRowContext(ctx, `
 			SELECT key, operation, user_id, status, result, created_at, completed_at, expires_at
-			FROM idempotency_keys WHERE key = $1 AND expires_at > NOW()`, key)
+			FROM idempotency_keys WHERE key = $1 AND expires_at > $2`, key, time.Now())
 	} else {
 		row = r.db.QueryRowContext(ctx, `
 			SELECT key, operation, user_id, status, result, created_at, completed_at, expires_at
-			FROM idempotency_keys WHERE key = $1 AND expires_at > NOW()`, key)
+			FROM idempotency_keys WHERE key = $1 AND expires_at > $2`, key, time.Now())
 	}
 
 	err := row.Scan(&idempotencyKey.Key, &idempotencyKey.Operation, &idempotencyKey.UserID, &idempotencyKey.Status,
 		&idempotencyKey.Result, &createdAt, &completedAt, &expiresAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil // No key found or expired
+			return nil, ports.ErrIdempotencyKeyNotFound // Missing or expired
 		}
 		return nil, fmt.Errorf("failed to query idempotency key: %w", err)
 	}
@@ -74,25 +117,30 @@ func (r *IdempotencyRepository) FindByKey(ctx context.Context, key string) (*ent
 }
 
 func (r *IdempotencyRepository) Update(ctx context.Context, key *entity.IdempotencyKey) error {
-	// Check if we're in a transaction
-	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `
+	query := `
 			UPDATE idempotency_keys 
 			SET status = $1, result = $2, completed_at = $3, expires_at = $4
-			WHERE key = $5`,
+			WHERE key = $5`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
 			key.Status, key.Result, key.CompletedAt, key.ExpiresAt, key.Key)
-		if err != nil {
-			return fmt.Errorf("failed to update idempotency key: %w", err)
-		}
 	} else {
-		_, err := r.db.ExecContext(ctx, `
-			UPDATE idempotency_keys 
-			SET status = $1, result = $2, completed_at = $3, expires_at = $4
-			WHERE key = $5`,
+		result, err = r.db.ExecContext(ctx, query,
 			key.Status, key.Result, key.CompletedAt, key.ExpiresAt, key.Key)
-		if err != nil {
-			return fmt.Errorf("failed to update idempotency key: %w", err)
-		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrIdempotencyKeyNotFound
 	}
 
 	return nil
@@ -101,12 +149,12 @@ func (r *IdempotencyRepository) Update(ctx context.Context, key *entity.Idempote
 func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) error {
 	// Check if we're in a transaction
 	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+		_, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
 		}
 	} else {
-		_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+		_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
 		}
@@ -0,0 +1,150 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type JoinRequestRepository struct {
+	db *sql.DB
+}
+
+func NewJoinRequestRepository(db *sql.DB) *JoinRequestRepository {
+	return &JoinRequestRepository{db: db}
+}
+
+func (r *JoinRequestRepository) Create(ctx context.Context, request *entity.JoinRequest) error {
+	query := `
+		INSERT INTO join_requests (dungeon_id, user_id, invite_code, status, decided_by, created_at, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query,
+			request.DungeonID, request.UserID, request.InviteCode, request.Status, request.DecidedBy,
+			request.CreatedAt, request.DecidedAt)
+	} else {
+		row = r.db.QueryRowContext(ctx, query,
+			request.DungeonID, request.UserID, request.InviteCode, request.Status, request.DecidedBy,
+			request.CreatedAt, request.DecidedAt)
+	}
+
+	if err := row.Scan(&request.ID); err != nil {
+		return fmt.Errorf("failed to create join request: %w", err)
+	}
+
+	return nil
+}
+
+const joinRequestColumns = `id, dungeon_id, user_id, invite_code, status, decided_by, created_at, decided_at`
+
+func scanJoinRequest(row rowScanner) (*entity.JoinRequest, error) {
+	var request entity.JoinRequest
+	var decidedAt sql.NullTime
+
+	err := row.Scan(&request.ID, &request.DungeonID, &request.UserID, &request.InviteCode, &request.Status,
+		&request.DecidedBy, &request.CreatedAt, &decidedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if decidedAt.Valid {
+		request.DecidedAt = &decidedAt.Time
+	}
+	return &request, nil
+}
+
+func (r *JoinRequestRepository) GetByID(ctx context.Context, id int64) (*entity.JoinRequest, error) {
+	return r.findOne(ctx, `id = $1`, id)
+}
+
+func (r *JoinRequestRepository) FindPending(ctx context.Context, dungeonID string, userID int64) (*entity.JoinRequest, error) {
+	return r.findOne(ctx, `dungeon_id = $1 AND user_id = $2 AND status = 'pending'`, dungeonID, userID)
+}
+
+func (r *JoinRequestRepository) findOne(ctx context.Context, condition string, args ...interface{}) (*entity.JoinRequest, error) {
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests WHERE ` + condition
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, args...)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, args...)
+	}
+
+	request, err := scanJoinRequest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("join request not found: %w", ports.ErrJoinRequestNotFound)
+		}
+		return nil, fmt.Errorf("failed to query join request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (r *JoinRequestRepository) ListPending(ctx context.Context, dungeonID string) ([]*entity.JoinRequest, error) {
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests
+		WHERE dungeon_id = $1 AND status = 'pending'
+		ORDER BY created_at ASC, id ASC`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, dungeonID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, dungeonID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query join requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*entity.JoinRequest
+	for rows.Next() {
+		request, err := scanJoinRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan join request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over join request rows: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *JoinRequestRepository) Update(ctx context.Context, request *entity.JoinRequest) error {
+	query := `
+		UPDATE join_requests
+		SET status = $1, decided_by = $2, decided_at = $3
+		WHERE id = $4`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, request.Status, request.DecidedBy, request.DecidedAt, request.ID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, request.Status, request.DecidedBy, request.DecidedAt, request.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update join request: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrJoinRequestNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+type LedgerRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewLedgerRepository(db *sql.DB, dialect Dialect) *LedgerRepository {
+	return &LedgerRepository{db: db, dialect: dialect}
+}
+
+func (r *LedgerRepository) Append(ctx context.Context, entry *entity.LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (id, user_id, dungeon_id, amount, balance_after, reason, source_type, source_id, note, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10)`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query,
+			entry.ID, entry.UserID, entry.DungeonID, entry.Amount.String(), entry.BalanceAfter.String(), entry.Reason,
+			entry.SourceType, entry.SourceID, entry.Note, entry.CreatedAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, query,
+			entry.ID, entry.UserID, entry.DungeonID, entry.Amount.String(), entry.BalanceAfter.String(), entry.Reason,
+			entry.SourceType, entry.SourceID, entry.Note, entry.CreatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LedgerRepository) ListByUser(ctx context.Context, userID int64, limit int) ([]*entity.LedgerEntry, error) {
+	return r.list(ctx, `
+		SELECT id, user_id, dungeon_id, amount, balance_after, reason, source_type, source_id, note, created_at
+		FROM ledger_entries WHERE user_id = $1
+		ORDER BY `+r.dialect.InsertOrder+` DESC
+		LIMIT $2`, userID, limit)
+}
+
+func (r *LedgerRepository) ListByWallet(ctx context.Context, userID int64, dungeonID string, limit int) ([]*entity.LedgerEntry, error) {
+	return r.list(ctx, `
+		SELECT id, user_id, dungeon_id, amount, balance_after, reason, source_type, source_id, note, created_at
+		FROM ledger_entries WHERE user_id = $1 AND dungeon_id = $2
+		ORDER BY `+r.dialect.InsertOrder+` DESC
+		LIMIT $3`, userID, dungeonID, limit)
+}
+
+func (r *LedgerRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.LedgerEntry, error) {
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.LedgerEntry
+	for rows.Next() {
+		var entry entity.LedgerEntry
+		var dungeonID sql.NullString
+		var amountStr, balanceStr string
+		if err := rows.Scan(&entry.ID, &entry.UserID, &dungeonID, &amountStr, &balanceStr, &entry.Reason,
+			&entry.SourceType, &entry.SourceID, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entry.DungeonID = dungeonID.String
+		entry.Amount = valueobject.NewDecimal(amountStr)
+		entry.BalanceAfter = valueobject.NewDecimal(balanceStr)
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over ledger entry rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *LedgerRepository) Balances(ctx context.Context) (map[int64]valueobject.Decimal, error) {
+	// Without an exact numeric type amounts are stored as text and summed
+	// here rather than with SUM; the loop below adds up either kind of row
+	query := `SELECT user_id, amount FROM ledger_entries`
+	if r.dialect.ExactNumeric {
+		query = `SELECT user_id, SUM(amount) FROM ledger_entries GROUP BY user_id`
+	}
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[int64]valueobject.Decimal)
+	for rows.Next() {
+		var userID int64
+		var amount valueobject.Decimal
+		if err := rows.Scan(&userID, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger balance: %w", err)
+		}
+		if balance, ok := balances[userID]; ok {
+			amount = balance.Add(amount)
+		}
+		balances[userID] = amount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over ledger balance rows: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *LedgerRepository) WalletBalances(ctx context.Context) ([]*entity.Wallet, error) {
+	// Summed like in Balances; the ordering makes each wallet's rows adjacent
+	query := `
+		SELECT user_id, dungeon_id, amount
+		FROM ledger_entries
+		WHERE dungeon_id IS NOT NULL
+		ORDER BY user_id, dungeon_id`
+	if r.dialect.ExactNumeric {
+		query = `
+		SELECT user_id, dungeon_id, SUM(amount)
+		FROM ledger_entries
+		WHERE dungeon_id IS NOT NULL
+		GROUP BY user_id, dungeon_id
+		ORDER BY user_id, dungeon_id`
+	}
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*entity.Wallet
+	for rows.Next() {
+		var userID int64
+		var dungeonID string
+		var amount valueobject.Decimal
+		if err := rows.Scan(&userID, &dungeonID, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet balance: %w", err)
+		}
+		if n := len(wallets); n > 0 && wallets[n-1].UserID == userID && wallets[n-1].DungeonID == dungeonID {
+			wallets[n-1].Balance = wallets[n-1].Balance.Add(amount)
+			continue
+		}
+		wallets = append(wallets, &entity.Wallet{UserID: userID, DungeonID: dungeonID, Balance: amount})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over wallet balance rows: %w", err)
+	}
+
+	return wallets, nil
+}
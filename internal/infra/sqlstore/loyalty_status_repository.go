@@ -0,0 +1,70 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type LoyaltyStatusRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewLoyaltyStatusRepository(db *sql.DB, dialect Dialect) *LoyaltyStatusRepository {
+	return &LoyaltyStatusRepository{db: db, dialect: dialect}
+}
+
+// Find returns a user's status in a dungeon. Inside a transaction the row stays
+// locked (or, on SQLite, the whole database) until the transaction ends.
+func (r *LoyaltyStatusRepository) Find(ctx context.Context, userID int64, dungeonID string) (*entity.LoyaltyStatus, error) {
+	query := `
+		SELECT user_id, dungeon_id, tier_id, purchase_count, notified_tier_id, updated_at
+		FROM loyalty_statuses WHERE user_id = $1 AND dungeon_id = $2`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query+r.dialect.forUpdate(), userID, dungeonID)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, userID, dungeonID)
+	}
+
+	var status entity.LoyaltyStatus
+	err := row.Scan(&status.UserID, &status.DungeonID, &status.TierID, &status.PurchaseCount, &status.NotifiedTierID, &status.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrLoyaltyStatusNotFound
+		}
+		return nil, fmt.Errorf("failed to query loyalty status: %w", err)
+	}
+
+	return &status, nil
+}
+
+func (r *LoyaltyStatusRepository) Save(ctx context.Context, status *entity.LoyaltyStatus) error {
+	query := `
+		INSERT INTO loyalty_statuses (user_id, dungeon_id, tier_id, purchase_count, notified_tier_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, dungeon_id) DO UPDATE SET
+			tier_id = EXCLUDED.tier_id,
+			purchase_count = EXCLUDED.purchase_count,
+			notified_tier_id = EXCLUDED.notified_tier_id,
+			updated_at = EXCLUDED.updated_at`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query,
+			status.UserID, status.DungeonID, status.TierID, status.PurchaseCount, status.NotifiedTierID, status.UpdatedAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, query,
+			status.UserID, status.DungeonID, status.TierID, status.PurchaseCount, status.NotifiedTierID, status.UpdatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save loyalty status: %w", err)
+	}
+
+	return nil
+}
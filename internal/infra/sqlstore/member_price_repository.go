@@ -0,0 +1,77 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type MemberPriceRepository struct {
+	db *sql.DB
+}
+
+func NewMemberPriceRepository(db *sql.DB) *MemberPriceRepository {
+	return &MemberPriceRepository{db: db}
+}
+
+func (r *MemberPriceRepository) Set(ctx context.Context, price *entity.MemberPrice) error {
+	query := `
+		INSERT INTO member_prices (item_id, user_id, price, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, user_id) DO UPDATE SET price = EXCLUDED.price, updated_at = EXCLUDED.updated_at`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, price.ItemID, price.UserID, price.Price.String(), price.UpdatedAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, price.ItemID, price.UserID, price.Price.String(), price.UpdatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set member price: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MemberPriceRepository) Find(ctx context.Context, itemID, userID int64) (*entity.MemberPrice, error) {
+	query := `SELECT item_id, user_id, price, updated_at FROM member_prices WHERE item_id = $1 AND user_id = $2`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, itemID, userID)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, itemID, userID)
+	}
+
+	var price entity.MemberPrice
+	var priceStr string
+	if err := row.Scan(&price.ItemID, &price.UserID, &priceStr, &price.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrMemberPriceNotFound
+		}
+		return nil, fmt.Errorf("failed to query member price: %w", err)
+	}
+	price.Price = valueobject.NewDecimal(priceStr)
+
+	return &price, nil
+}
+
+func (r *MemberPriceRepository) Delete(ctx context.Context, itemID, userID int64) error {
+	query := `DELETE FROM member_prices WHERE item_id = $1 AND user_id = $2`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, itemID, userID)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, itemID, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete member price: %w", err)
+	}
+
+	return nil
+}
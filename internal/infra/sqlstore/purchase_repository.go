@@ -0,0 +1,190 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type PurchaseRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewPurchaseRepository(db *sql.DB, dialect Dialect) *PurchaseRepository {
+	return &PurchaseRepository{db: db, dialect: dialect}
+}
+
+func (r *PurchaseRepository) Create(ctx context.Context, purchase *entity.Purchase) error {
+	breakdown, err := marshalBreakdown(purchase.PriceBreakdown)
+	if err != nil {
+		return err
+	}
+
+	// A zero ID lets the database assign one
+	query := `
+		INSERT INTO purchases (id, user_id, item_id, dungeon_id, item_name, item_price, quantity, subtotal, total_cost,
+			price_breakdown, status, discount_tier_id, purchased_at)
+		VALUES (` + r.dialect.insertID("purchases") + `, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query,
+			purchase.ID, purchase.UserID, purchase.ItemID, purchase.DungeonID, purchase.ItemName,
+			purchase.ItemPrice.String(), purchase.Quantity, purchase.Subtotal.String(), purchase.TotalCost.String(),
+			breakdown, purchase.Status, purchase.DiscountTierID, purchase.PurchasedAt)
+	} else {
+		row = r.db.QueryRowContext(ctx, query,
+			purchase.ID, purchase.UserID, purchase.ItemID, purchase.DungeonID, purchase.ItemName,
+			purchase.ItemPrice.String(), purchase.Quantity, purchase.Subtotal.String(), purchase.TotalCost.String(),
+			breakdown, purchase.Status, purchase.DiscountTierID, purchase.PurchasedAt)
+	}
+
+	if err := row.Scan(&purchase.ID); err != nil {
+		return fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	return nil
+}
+
+// marshalBreakdown encodes a price breakdown for the price_breakdown column
+func marshalBreakdown(breakdown []entity.PriceAdjustment) ([]byte, error) {
+	if breakdown == nil {
+		breakdown = []entity.PriceAdjustment{}
+	}
+	data, err := json.Marshal(breakdown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode price breakdown: %w", err)
+	}
+	return data, nil
+}
+
+const purchaseColumns = `id, user_id, item_id, dungeon_id, item_name, item_price, quantity, subtotal, total_cost,
+	price_breakdown, status, discount_tier_id, purchased_at, refunded_quantity, refunded_amount, refunded_at`
+
+func scanPurchase(row rowScanner) (*entity.Purchase, error) {
+	var purchase entity.Purchase
+	var itemPriceStr, subtotalStr, totalCostStr, refundedAmountStr string
+	var breakdown []byte
+	var dungeonID sql.NullString
+	var refundedAt sql.NullTime
+
+	err := row.Scan(&purchase.ID, &purchase.UserID, &purchase.ItemID, &dungeonID, &purchase.ItemName,
+		&itemPriceStr, &purchase.Quantity, &subtotalStr, &totalCostStr, &breakdown, &purchase.Status,
+		&purchase.DiscountTierID, &purchase.PurchasedAt, &purchase.RefundedQuantity, &refundedAmountStr, &refundedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(breakdown, &purchase.PriceBreakdown); err != nil {
+		return nil, fmt.Errorf("failed to decode price breakdown: %w", err)
+	}
+	// Purchases made before shops were keyed by dungeon have none
+	purchase.DungeonID = dungeonID.String
+	purchase.ItemPrice = valueobject.NewDecimal(itemPriceStr)
+	purchase.Subtotal = valueobject.NewDecimal(subtotalStr)
+	purchase.TotalCost = valueobject.NewDecimal(totalCostStr)
+	purchase.RefundedAmount = valueobject.NewDecimal(refundedAmountStr)
+	if refundedAt.Valid {
+		purchase.RefundedAt = &refundedAt.Time
+	}
+
+	return &purchase, nil
+}
+
+// FindByID returns a purchase. Inside a transaction the row stays locked (or,
+// on SQLite, the whole database) until the transaction ends, so concurrent
+// refunds of one purchase are serialized.
+func (r *PurchaseRepository) FindByID(ctx context.Context, id int64) (*entity.Purchase, error) {
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `SELECT `+purchaseColumns+` FROM purchases WHERE id = $1`+r.dialect.forUpdate(), id)
+	} else {
+		row = r.db.QueryRowContext(ctx, `SELECT `+purchaseColumns+` FROM purchases WHERE id = $1`, id)
+	}
+
+	purchase, err := scanPurchase(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("purchase not found: %w", ports.ErrPurchaseNotFound)
+		}
+		return nil, fmt.Errorf("failed to query purchase: %w", err)
+	}
+
+	return purchase, nil
+}
+
+func (r *PurchaseRepository) FindByUserID(ctx context.Context, userID int64) ([]*entity.Purchase, error) {
+	return r.findWhere(ctx, `user_id = $1`, userID)
+}
+
+func (r *PurchaseRepository) FindByItemID(ctx context.Context, itemID int64) ([]*entity.Purchase, error) {
+	return r.findWhere(ctx, `item_id = $1`, itemID)
+}
+
+func (r *PurchaseRepository) findWhere(ctx context.Context, condition string, args ...interface{}) ([]*entity.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE ` + condition + ` ORDER BY purchased_at DESC, id DESC`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query purchases: %w", err)
+	}
+	defer rows.Close()
+
+	var purchases []*entity.Purchase
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan purchase: %w", err)
+		}
+		purchases = append(purchases, purchase)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over purchase rows: %w", err)
+	}
+
+	return purchases, nil
+}
+
+func (r *PurchaseRepository) Update(ctx context.Context, purchase *entity.Purchase) error {
+	query := `
+		UPDATE purchases
+		SET status = $1, refunded_quantity = $2, refunded_amount = $3, refunded_at = $4
+		WHERE id = $5`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
+			purchase.Status, purchase.RefundedQuantity, purchase.RefundedAmount.String(), purchase.RefundedAt, purchase.ID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query,
+			purchase.Status, purchase.RefundedQuantity, purchase.RefundedAmount.String(), purchase.RefundedAt, purchase.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update purchase: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrPurchaseNotFound
+	}
+
+	return nil
+}
@@ -1,4 +1,4 @@
-package postgres
+package sqlstore
 
 import (
 	"context"
@@ -8,14 +8,16 @@ import (
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
 type QuestCompletionRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
-func NewQuestCompletionRepository(db *sql.DB) *QuestCompletionRepository {
-	return &QuestCompletionRepository{db: db}
+func NewQuestCompletionRepository(db *sql.DB, dialect Dialect) *QuestCompletionRepository {
+	return &QuestCompletionRepository{db: db, dialect: dialect}
 }
 
 func (r *QuestCompletionRepository) Insert(ctx context.Context, completion *entity.QuestCompletion) error {
@@ -27,6 +29,9 @@ func (r *QuestCompletionRepository) Insert(ctx context.Context, completion *enti
 			completion.ID, completion.QuestID, completion.UserID, completion.DungeonID, completion.SubmittedAt,
 			completion.CompletionRatio, completion.Minutes, completion.AwardedPoints.String(), completion.IdempotencyKey)
 		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrIdempotencyKeyExists
+			}
 			return fmt.Errorf("failed to insert quest completion: %w", err)
 		}
 	} else {
@@ -36,6 +41,9 @@ func (r *QuestCompletionRepository) Insert(ctx context.Context, completion *enti
 			completion.ID, completion.QuestID, completion.UserID, completion.DungeonID, completion.SubmittedAt,
 			completion.CompletionRatio, completion.Minutes, completion.AwardedPoints.String(), completion.IdempotencyKey)
 		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrIdempotencyKeyExists
+			}
 			return fmt.Errorf("failed to insert quest completion: %w", err)
 		}
 	}
@@ -70,7 +78,7 @@ func (r *QuestCompletionRepository) LastForUser(ctx context.Context, userID int6
 	err := row.Scan(&completion.ID, &completion.QuestID, &completion.UserID, &completion.DungeonID, &submittedAt, &completionRatio, &minutes, &awardedPointsStr, &completion.IdempotencyKey)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil // No completion found
+			return nil, ports.ErrQuestCompletionNotFound
 		}
 		return nil, fmt.Errorf("failed to query quest completion: %w", err)
 	}
@@ -87,34 +95,43 @@ func (r *QuestCompletionRepository) LastForUser(ctx context.Context, userID int6
 }
 
 func (r *QuestCompletionRepository) SumAwardedForUserOnDay(ctx context.Context, userID int64, questID string, day time.Time, tz string) (valueobject.Decimal, error) {
-	var sumStr string
+	sum := valueobject.NewDecimal("0")
 
 	// Calculate the start and end of the day in the given timezone
-	startOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
+	window, err := valueobject.NewDayWindow(day, tz)
+	if err != nil {
+		return sum, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
 
-	var row *sql.Row
+	// A day has few completions per quest, so they are summed here rather than
+	// with SUM, which SQLite would compute in floating point over its text points
+	query := `
+		SELECT awarded_points
+		FROM quest_completions
+		WHERE user_id = $1 AND quest_id = $2 AND submitted_at >= $3 AND submitted_at < $4`
+
+	var rows *sql.Rows
 	if tx, ok := GetTx(ctx); ok {
-		row = tx.QueryRowContext(ctx, `
-			SELECT COALESCE(SUM(awarded_points), '0') as total
-			FROM quest_completions 
-			WHERE user_id = $1 AND quest_id = $2 AND submitted_at >= $3 AND submitted_at <= $4`,
-			userID, questID, startOfDay, endOfDay)
+		rows, err = tx.QueryContext(ctx, query, userID, questID, window.Start, window.End)
 	} else {
-		row = r.db.QueryRowContext(ctx, `
-			SELECT COALESCE(SUM(awarded_points), '0') as total
-			FROM quest_completions 
-			WHERE user_id = $1 AND quest_id = $2 AND submitted_at >= $3 AND submitted_at <= $4`,
-			userID, questID, startOfDay, endOfDay)
+		rows, err = r.db.QueryContext(ctx, query, userID, questID, window.Start, window.End)
 	}
-
-	err := row.Scan(&sumStr)
 	if err != nil {
-		return valueobject.NewDecimal("0"), fmt.Errorf("failed to sum awarded points: %w", err)
+		return sum, fmt.Errorf("failed to sum awarded points: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var points valueobject.Decimal
+		if err := rows.Scan(&points); err != nil {
+			return valueobject.NewDecimal("0"), fmt.Errorf("failed to scan awarded points: %w", err)
+		}
+		sum = sum.Add(points)
 	}
 
-	// Parse the sum
-	sum := valueobject.NewDecimal(sumStr)
+	if err := rows.Err(); err != nil {
+		return valueobject.NewDecimal("0"), fmt.Errorf("error iterating over awarded points: %w", err)
+	}
 
 	return sum, nil
 }
@@ -1,4 +1,4 @@
-package postgres
+package sqlstore
 
 import (
 	"context"
@@ -8,6 +8,7 @@ import (
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
 type QuestRepository struct {
@@ -24,12 +25,12 @@ func (r *QuestRepository) Create(ctx context.Context, quest *entity.Quest) error
 		_, err := tx.ExecContext(ctx, `
 			INSERT INTO quests (id, dungeon_id, title, description, category, difficulty, mode, points_award, 
 				rate_points_per_min, min_minutes, max_minutes, daily_points_cap, cooldown_sec, streak_enabled, 
-				status, last_completed_at, streak_count, time_zone, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
+				streak_grace_periods, status, last_completed_at, streak_count, time_zone, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
 			quest.ID, quest.DungeonID, quest.Title, quest.Description, quest.Category, quest.Difficulty, quest.Mode,
 			quest.PointsAward.String(), quest.RatePointsPerMin, quest.MinMinutes, quest.MaxMinutes, quest.DailyPointsCap,
-			quest.CooldownSec, quest.StreakEnabled, quest.Status, quest.LastCompletedAt, quest.StreakCount,
-			quest.TimeZone, quest.CreatedAt, quest.UpdatedAt)
+			quest.CooldownSec, quest.StreakEnabled, quest.StreakGracePeriods, quest.Status, quest.LastCompletedAt,
+			quest.StreakCount, quest.TimeZone, quest.CreatedAt, quest.UpdatedAt)
 		if err != nil {
 			return fmt.Errorf("failed to create quest: %w", err)
 		}
@@ -37,12 +38,12 @@ func (r *QuestRepository) Create(ctx context.Context, quest *entity.Quest) error
 		_, err := r.db.ExecContext(ctx, `
 			INSERT INTO quests (id, dungeon_id, title, description, category, difficulty, mode, points_award, 
 				rate_points_per_min, min_minutes, max_minutes, daily_points_cap, cooldown_sec, streak_enabled, 
-				status, last_completed_at, streak_count, time_zone, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
+				streak_grace_periods, status, last_completed_at, streak_count, time_zone, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
 			quest.ID, quest.DungeonID, quest.Title, quest.Description, quest.Category, quest.Difficulty, quest.Mode,
 			quest.PointsAward.String(), quest.RatePointsPerMin, quest.MinMinutes, quest.MaxMinutes, quest.DailyPointsCap,
-			quest.CooldownSec, quest.StreakEnabled, quest.Status, quest.LastCompletedAt, quest.StreakCount,
-			quest.TimeZone, quest.CreatedAt, quest.UpdatedAt)
+			quest.CooldownSec, quest.StreakEnabled, quest.StreakGracePeriods, quest.Status, quest.LastCompletedAt,
+			quest.StreakCount, quest.TimeZone, quest.CreatedAt, quest.UpdatedAt)
 		if err != nil {
 			return fmt.Errorf("failed to create quest: %w", err)
 		}
@@ -63,23 +64,23 @@ func (r *QuestRepository) GetByID(ctx context.Context, questID string) (*entity.
 		row = tx.QueryRowContext(ctx, `
 			SELECT id, dungeon_id, title, description, category, difficulty, mode, points_award, 
 				rate_points_per_min, min_minutes, max_minutes, daily_points_cap, cooldown_sec, streak_enabled, 
-				status, last_completed_at, streak_count, time_zone, created_at, updated_at
+				streak_grace_periods, status, last_completed_at, streak_count, time_zone, created_at, updated_at
 			FROM quests WHERE id = $1`, questID)
 	} else {
 		row = r.db.QueryRowContext(ctx, `
 			SELECT id, dungeon_id, title, description, category, difficulty, mode, points_award, 
 				rate_points_per_min, min_minutes, max_minutes, daily_points_cap, cooldown_sec, streak_enabled, 
-				status, last_completed_at, streak_count, time_zone, created_at, updated_at
+				streak_grace_periods, status, last_completed_at, streak_count, time_zone, created_at, updated_at
 			FROM quests WHERE id = $1`, questID)
 	}
 
 	err := row.Scan(&quest.ID, &quest.DungeonID, &quest.Title, &quest.Description, &quest.Category, &quest.Difficulty,
 		&quest.Mode, &pointsAwardStr, &quest.RatePointsPerMin, &quest.MinMinutes, &quest.MaxMinutes, &quest.DailyPointsCap,
-		&quest.CooldownSec, &quest.StreakEnabled, &quest.Status, &lastCompletedAt, &quest.StreakCount,
+		&quest.CooldownSec, &quest.StreakEnabled, &quest.StreakGracePeriods, &quest.Status, &lastCompletedAt, &quest.StreakCount,
 		&quest.TimeZone, &createdAt, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("quest not found: %w", ErrQuestNotFound)
+			return nil, fmt.Errorf("quest not found: %w", ports.ErrQuestNotFound)
 		}
 		return nil, fmt.Errorf("failed to query quest: %w", err)
 	}
@@ -96,11 +97,20 @@ func (r *QuestRepository) GetByID(ctx context.Context, questID string) (*entity.
 }
 
 func (r *QuestRepository) ListByDungeon(ctx context.Context, dungeonID string) ([]*entity.Quest, error) {
-	rows, err := r.db.QueryContext(ctx, `
+	query := `
 		SELECT id, dungeon_id, title, description, category, difficulty, mode, points_award, 
 			rate_points_per_min, min_minutes, max_minutes, daily_points_cap, cooldown_sec, streak_enabled, 
-			status, last_completed_at, streak_count, time_zone, created_at, updated_at
-		FROM quests WHERE dungeon_id = $1`, dungeonID)
+			streak_grace_periods, status, last_completed_at, streak_count, time_zone, created_at, updated_at
+		FROM quests WHERE dungeon_id = $1
+		ORDER BY created_at, id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, dungeonID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, dungeonID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query quests: %w", err)
 	}
@@ -116,7 +126,7 @@ func (r *QuestRepository) ListByDungeon(ctx context.Context, dungeonID string) (
 
 		err := rows.Scan(&quest.ID, &quest.DungeonID, &quest.Title, &quest.Description, &quest.Category, &quest.Difficulty,
 			&quest.Mode, &pointsAwardStr, &quest.RatePointsPerMin, &quest.MinMinutes, &quest.MaxMinutes, &quest.DailyPointsCap,
-			&quest.CooldownSec, &quest.StreakEnabled, &quest.Status, &lastCompletedAt, &quest.StreakCount,
+			&quest.CooldownSec, &quest.StreakEnabled, &quest.StreakGracePeriods, &quest.Status, &lastCompletedAt, &quest.StreakCount,
 			&quest.TimeZone, &createdAt, &updatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan quest: %w", err)
@@ -141,37 +151,39 @@ func (r *QuestRepository) ListByDungeon(ctx context.Context, dungeonID string) (
 }
 
 func (r *QuestRepository) Update(ctx context.Context, quest *entity.Quest) error {
-	// Check if we're in a transaction
-	if tx, ok := GetTx(ctx); ok {
-		_, err := tx.ExecContext(ctx, `
+	query := `
 			UPDATE quests 
 			SET title = $1, description = $2, category = $3, difficulty = $4, mode = $5, points_award = $6,
 				rate_points_per_min = $7, min_minutes = $8, max_minutes = $9, daily_points_cap = $10,
-				cooldown_sec = $11, streak_enabled = $12, status = $13, last_completed_at = $14,
-				streak_count = $15, time_zone = $16, updated_at = $17
-			WHERE id = $18`,
+				cooldown_sec = $11, streak_enabled = $12, streak_grace_periods = $13, status = $14,
+				last_completed_at = $15, streak_count = $16, time_zone = $17, updated_at = $18
+			WHERE id = $19`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
 			quest.Title, quest.Description, quest.Category, quest.Difficulty, quest.Mode,
 			quest.PointsAward.String(), quest.RatePointsPerMin, quest.MinMinutes, quest.MaxMinutes, quest.DailyPointsCap,
-			quest.CooldownSec, quest.StreakEnabled, quest.Status, quest.LastCompletedAt,
+			quest.CooldownSec, quest.StreakEnabled, quest.StreakGracePeriods, quest.Status, quest.LastCompletedAt,
 			quest.StreakCount, quest.TimeZone, quest.UpdatedAt, quest.ID)
-		if err != nil {
-			return fmt.Errorf("failed to update quest: %w", err)
-		}
 	} else {
-		_, err := r.db.ExecContext(ctx, `
-			UPDATE quests 
-			SET title = $1, description = $2, category = $3, difficulty = $4, mode = $5, points_award = $6,
-				rate_points_per_min = $7, min_minutes = $8, max_minutes = $9, daily_points_cap = $10,
-				cooldown_sec = $11, streak_enabled = $12, status = $13, last_completed_at = $14,
-				streak_count = $15, time_zone = $16, updated_at = $17
-			WHERE id = $18`,
+		result, err = r.db.ExecContext(ctx, query,
 			quest.Title, quest.Description, quest.Category, quest.Difficulty, quest.Mode,
 			quest.PointsAward.String(), quest.RatePointsPerMin, quest.MinMinutes, quest.MaxMinutes, quest.DailyPointsCap,
-			quest.CooldownSec, quest.StreakEnabled, quest.Status, quest.LastCompletedAt,
+			quest.CooldownSec, quest.StreakEnabled, quest.StreakGracePeriods, quest.Status, quest.LastCompletedAt,
 			quest.StreakCount, quest.TimeZone, quest.UpdatedAt, quest.ID)
-		if err != nil {
-			return fmt.Errorf("failed to update quest: %w", err)
-		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update quest: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrQuestNotFound
 	}
 
 	return nil
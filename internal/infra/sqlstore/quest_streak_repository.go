@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type QuestStreakRepository struct {
+	db *sql.DB
+}
+
+func NewQuestStreakRepository(db *sql.DB) *QuestStreakRepository {
+	return &QuestStreakRepository{db: db}
+}
+
+func (r *QuestStreakRepository) Get(ctx context.Context, userID int64, questID string) (*entity.QuestStreak, error) {
+	var streak entity.QuestStreak
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `
+			SELECT user_id, quest_id, current_streak, best_streak, last_period, updated_at
+			FROM quest_streaks WHERE user_id = $1 AND quest_id = $2`, userID, questID)
+	} else {
+		row = r.db.QueryRowContext(ctx, `
+			SELECT user_id, quest_id, current_streak, best_streak, last_period, updated_at
+			FROM quest_streaks WHERE user_id = $1 AND quest_id = $2`, userID, questID)
+	}
+
+	err := row.Scan(&streak.UserID, &streak.QuestID, &streak.Current, &streak.Best, &streak.LastPeriod, &streak.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrQuestStreakNotFound
+		}
+		return nil, fmt.Errorf("failed to query quest streak: %w", err)
+	}
+
+	return &streak, nil
+}
+
+func (r *QuestStreakRepository) Upsert(ctx context.Context, streak *entity.QuestStreak) error {
+	query := `
+		INSERT INTO quest_streaks (user_id, quest_id, current_streak, best_streak, last_period, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, quest_id) DO UPDATE
+		SET current_streak = EXCLUDED.current_streak, best_streak = EXCLUDED.best_streak,
+			last_period = EXCLUDED.last_period, updated_at = EXCLUDED.updated_at`
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query,
+			streak.UserID, streak.QuestID, streak.Current, streak.Best, streak.LastPeriod, streak.UpdatedAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, query,
+			streak.UserID, streak.QuestID, streak.Current, streak.Best, streak.LastPeriod, streak.UpdatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upsert quest streak: %w", err)
+	}
+
+	return nil
+}
+
+func (r *QuestStreakRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.QuestStreak, error) {
+	query := `
+		SELECT user_id, quest_id, current_streak, best_streak, last_period, updated_at
+		FROM quest_streaks WHERE user_id = $1
+		ORDER BY best_streak DESC, quest_id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, userID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quest streaks: %w", err)
+	}
+	defer rows.Close()
+
+	var streaks []*entity.QuestStreak
+	for rows.Next() {
+		var streak entity.QuestStreak
+		if err := rows.Scan(&streak.UserID, &streak.QuestID, &streak.Current, &streak.Best, &streak.LastPeriod, &streak.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan quest streak: %w", err)
+		}
+		streaks = append(streaks, &streak)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over quest streak rows: %w", err)
+	}
+
+	return streaks, nil
+}
@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type RewardTierRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewRewardTierRepository(db *sql.DB, dialect Dialect) *RewardTierRepository {
+	return &RewardTierRepository{db: db, dialect: dialect}
+}
+
+func (r *RewardTierRepository) Create(ctx context.Context, tier *entity.RewardTier) error {
+	// A zero ID lets the database assign one
+	query := `
+		INSERT INTO reward_tiers (id, dungeon_id, name, description, discount_percent, min_purchases, created_at, updated_at)
+		VALUES (` + r.dialect.insertID("reward_tiers") + `, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query,
+			tier.ID, tier.DungeonID, tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.CreatedAt, tier.UpdatedAt)
+	} else {
+		row = r.db.QueryRowContext(ctx, query,
+			tier.ID, tier.DungeonID, tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.CreatedAt, tier.UpdatedAt)
+	}
+
+	if err := row.Scan(&tier.ID); err != nil {
+		return fmt.Errorf("failed to create reward tier: %w", err)
+	}
+
+	return nil
+}
+
+const rewardTierColumns = `id, dungeon_id, name, description, discount_percent, min_purchases, created_at, updated_at`
+
+func scanRewardTier(row rowScanner) (*entity.RewardTier, error) {
+	var tier entity.RewardTier
+	err := row.Scan(&tier.ID, &tier.DungeonID, &tier.Name, &tier.Description, &tier.DiscountPercent, &tier.MinPurchases,
+		&tier.CreatedAt, &tier.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &tier, nil
+}
+
+func (r *RewardTierRepository) FindByID(ctx context.Context, id int64) (*entity.RewardTier, error) {
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `SELECT `+rewardTierColumns+` FROM reward_tiers WHERE id = $1`, id)
+	} else {
+		row = r.db.QueryRowContext(ctx, `SELECT `+rewardTierColumns+` FROM reward_tiers WHERE id = $1`, id)
+	}
+
+	tier, err := scanRewardTier(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("reward tier not found: %w", ports.ErrRewardTierNotFound)
+		}
+		return nil, fmt.Errorf("failed to query reward tier: %w", err)
+	}
+
+	return tier, nil
+}
+
+func (r *RewardTierRepository) FindAll(ctx context.Context) ([]*entity.RewardTier, error) {
+	return r.findWhere(ctx, `TRUE`)
+}
+
+func (r *RewardTierRepository) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.RewardTier, error) {
+	return r.findWhere(ctx, `dungeon_id = $1`, dungeonID)
+}
+
+func (r *RewardTierRepository) findWhere(ctx context.Context, condition string, args ...interface{}) ([]*entity.RewardTier, error) {
+	query := `SELECT ` + rewardTierColumns + ` FROM reward_tiers WHERE ` + condition + ` ORDER BY min_purchases ASC, id ASC`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reward tiers: %w", err)
+	}
+	defer rows.Close()
+
+	var tiers []*entity.RewardTier
+	for rows.Next() {
+		tier, err := scanRewardTier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reward tier: %w", err)
+		}
+		tiers = append(tiers, tier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reward tier rows: %w", err)
+	}
+
+	return tiers, nil
+}
+
+func (r *RewardTierRepository) Update(ctx context.Context, tier *entity.RewardTier) error {
+	query := `
+		UPDATE reward_tiers
+		SET name = $1, description = $2, discount_percent = $3, min_purchases = $4, updated_at = $5
+		WHERE id = $6`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
+			tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.UpdatedAt, tier.ID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query,
+			tier.Name, tier.Description, tier.DiscountPercent, tier.MinPurchases, tier.UpdatedAt, tier.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update reward tier: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrRewardTierNotFound
+	}
+
+	return nil
+}
+
+func (r *RewardTierRepository) Delete(ctx context.Context, id int64) error {
+	// Check if we're in a transaction
+	if tx, ok := GetTx(ctx); ok {
+		_, err := tx.ExecContext(ctx, `DELETE FROM reward_tiers WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete reward tier: %w", err)
+		}
+	} else {
+		_, err := r.db.ExecContext(ctx, `DELETE FROM reward_tiers WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete reward tier: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// tickBatchSize is how many due schedules one Tick transaction claims at a time
+const tickBatchSize = 100
+
+// Scheduler persists schedules and their occurrences. Every method runs in a
+// transaction and locks the schedule rows it advances, so any number of bot and
+// API replicas can share the same database: Tick claims due schedules with
+// FOR UPDATE SKIP LOCKED and occurrences are unique per (schedule, time).
+// SQLite lets one write transaction run at a time instead, so the schedules a
+// transaction advances cannot change underneath it either.
+type Scheduler struct {
+	db        *sql.DB
+	dialect   Dialect
+	txManager *TxManager
+}
+
+func NewScheduler(db *sql.DB, dialect Dialect) *Scheduler {
+	return &Scheduler{db: db, dialect: dialect, txManager: NewTxManager(db)}
+}
+
+// ScheduleRecurringTask creates or refreshes the quest's schedule. When the quest
+// was completed, the occurrence that was due at that time is marked completed.
+func (s *Scheduler) ScheduleRecurringTask(ctx context.Context, quest *entity.Quest) error {
+	now := time.Now()
+	schedule, err := entity.NewQuestSchedule(uuid.New().String(), quest, now)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		// Keep the original start date and ID when the schedule already exists
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO schedules (id, task_id, type, start_date, end_date, time_of_day, days_of_week,
+				days_of_month, skip_if_missed, timezone, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NULL, $5, $6, $7, $8, $9, $10, $10)
+			ON CONFLICT (task_id) DO UPDATE
+			SET type = EXCLUDED.type, end_date = NULL, time_of_day = EXCLUDED.time_of_day,
+				days_of_week = EXCLUDED.days_of_week, days_of_month = EXCLUDED.days_of_month,
+				timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at`,
+			schedule.ID, schedule.TaskID, schedule.Type, schedule.StartDate, schedule.TimeOfDay,
+			s.dialect.IntList(&schedule.DaysOfWeek), s.dialect.IntList(&schedule.DaysOfMonth),
+			schedule.SkipIfMissed, schedule.Timezone, now)
+		if err != nil {
+			return fmt.Errorf("failed to upsert schedule: %w", err)
+		}
+
+		schedule, err = s.lockSchedule(ctx, tx, quest.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.advance(ctx, tx, schedule, now); err != nil {
+			return err
+		}
+
+		if quest.LastCompletedAt != nil {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE schedule_occurrences SET completed_at = $2
+				WHERE id = (
+					SELECT id FROM schedule_occurrences
+					WHERE schedule_id = $1 AND scheduled_for <= $2
+					ORDER BY scheduled_for DESC
+					LIMIT 1
+				) AND completed_at IS NULL AND NOT skipped AND NOT missed`,
+				schedule.ID, *quest.LastCompletedAt)
+			if err != nil {
+				return fmt.Errorf("failed to complete schedule occurrence: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// CancelScheduledTask ends the task's schedule and drops its upcoming
+// occurrences. Past occurrences are kept as history.
+func (s *Scheduler) CancelScheduledTask(ctx context.Context, taskID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		schedule, err := s.lockSchedule(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM schedule_occurrences WHERE schedule_id = $1 AND scheduled_for > $2`,
+			schedule.ID, now)
+		if err != nil {
+			return fmt.Errorf("failed to delete upcoming occurrences: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE schedules SET end_date = $2, next_run_at = NULL, updated_at = $2 WHERE id = $1`,
+			schedule.ID, now)
+		if err != nil {
+			return fmt.Errorf("failed to cancel schedule: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Scheduler) GetNextOccurrence(ctx context.Context, taskID string) (time.Time, error) {
+	schedule, err := s.getSchedule(ctx, taskID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	var next sql.NullTime
+	query := `SELECT MIN(scheduled_for) FROM schedule_occurrences WHERE schedule_id = $1 AND scheduled_for > $2`
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.QueryRowContext(ctx, query, schedule.ID, now).Scan(&next)
+	} else {
+		err = s.db.QueryRowContext(ctx, query, schedule.ID, now).Scan(&next)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query next occurrence: %w", err)
+	}
+	if next.Valid {
+		return next.Time, nil
+	}
+
+	// Not persisted yet, compute it from the schedule
+	nextTime, ok, err := schedule.NextOccurrence(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return time.Time{}, entity.ErrScheduleEnded
+	}
+	return nextTime, nil
+}
+
+// Tick advances every schedule whose next run is due. Schedules locked by another
+// replica are skipped; that replica is already advancing them.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	claim := ``
+	if s.dialect.LocksRows {
+		claim = ` FOR UPDATE SKIP LOCKED`
+	}
+
+	for {
+		claimed := 0
+		err := s.withTx(ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, `
+				SELECT `+scheduleColumns+`
+				FROM schedules
+				WHERE next_run_at <= $1
+				ORDER BY next_run_at
+				LIMIT $2`+claim, now, tickBatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to claim due schedules: %w", err)
+			}
+
+			var schedules []*entity.Schedule
+			for rows.Next() {
+				schedule, err := s.scanSchedule(rows)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				schedules = append(schedules, schedule)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("error iterating over schedule rows: %w", err)
+			}
+
+			for _, schedule := range schedules {
+				if err := s.advance(ctx, tx, schedule, now); err != nil {
+					return err
+				}
+			}
+			claimed = len(schedules)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if claimed < tickBatchSize {
+			return nil
+		}
+	}
+}
+
+// advance persists the schedule's due and upcoming occurrences and closes out
+// open occurrences that were not completed in time. The schedule row must be
+// locked by tx.
+func (s *Scheduler) advance(ctx context.Context, tx *sql.Tx, schedule *entity.Schedule, now time.Time) error {
+	var latest sql.NullTime
+	err := tx.QueryRowContext(ctx, `
+		SELECT MAX(scheduled_for) FROM schedule_occurrences WHERE schedule_id = $1`, schedule.ID).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to query latest occurrence: %w", err)
+	}
+
+	var latestTime *time.Time
+	if latest.Valid {
+		latestTime = &latest.Time
+	}
+
+	adv, err := schedule.Advance(latestTime, now)
+	if err != nil {
+		return err
+	}
+
+	for _, scheduledFor := range adv.New {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO schedule_occurrences (id, schedule_id, scheduled_for)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (schedule_id, scheduled_for) DO NOTHING`,
+			uuid.New().String(), schedule.ID, scheduledFor)
+		if err != nil {
+			return fmt.Errorf("failed to insert schedule occurrence: %w", err)
+		}
+	}
+
+	if !adv.Current.IsZero() {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE schedule_occurrences SET skipped = $3, missed = NOT $3
+			WHERE schedule_id = $1 AND scheduled_for < $2
+				AND completed_at IS NULL AND NOT skipped AND NOT missed`,
+			schedule.ID, adv.Current, schedule.SkipIfMissed)
+		if err != nil {
+			return fmt.Errorf("failed to close overdue occurrences: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE schedules SET next_run_at = $2 WHERE id = $1`, schedule.ID, adv.NextRun)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule next run: %w", err)
+	}
+
+	return nil
+}
+
+const scheduleColumns = `id, task_id, type, start_date, end_date, time_of_day, days_of_week,
+	days_of_month, skip_if_missed, timezone, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *Scheduler) scanSchedule(row rowScanner) (*entity.Schedule, error) {
+	var schedule entity.Schedule
+	var endDate sql.NullTime
+	err := row.Scan(&schedule.ID, &schedule.TaskID, &schedule.Type, &schedule.StartDate, &endDate,
+		&schedule.TimeOfDay, s.dialect.IntList(&schedule.DaysOfWeek), s.dialect.IntList(&schedule.DaysOfMonth), &schedule.SkipIfMissed,
+		&schedule.Timezone, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to scan schedule: %w", err)
+	}
+
+	if endDate.Valid {
+		schedule.EndDate = &endDate.Time
+	}
+
+	return &schedule, nil
+}
+
+func (s *Scheduler) getSchedule(ctx context.Context, taskID string) (*entity.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE task_id = $1`
+	if tx, ok := GetTx(ctx); ok {
+		return s.scanSchedule(tx.QueryRowContext(ctx, query, taskID))
+	}
+	return s.scanSchedule(s.db.QueryRowContext(ctx, query, taskID))
+}
+
+func (s *Scheduler) lockSchedule(ctx context.Context, tx *sql.Tx, taskID string) (*entity.Schedule, error) {
+	return s.scanSchedule(tx.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+` FROM schedules WHERE task_id = $1`+s.dialect.forUpdate(), taskID))
+}
+
+// withTx runs fn in the transaction carried by ctx, or in a new one
+func (s *Scheduler) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		tx, _ := GetTx(ctx)
+		return fn(tx)
+	})
+}
+
+// Compile-time check that Scheduler implements ports.Scheduler
+var _ ports.Scheduler = (*Scheduler)(nil)
@@ -0,0 +1,261 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type ShopItemRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewShopItemRepository(db *sql.DB, dialect Dialect) *ShopItemRepository {
+	return &ShopItemRepository{db: db, dialect: dialect}
+}
+
+func (r *ShopItemRepository) Create(ctx context.Context, item *entity.ShopItem) error {
+	// A zero ID lets the database assign one
+	query := `
+		INSERT INTO shop_items (id, chat_id, dungeon_id, code, name, description, price, category, is_active, stock,
+			discount_tier_id, sale_price, sale_ends_at, created_at, updated_at)
+		VALUES (` + r.dialect.insertID("shop_items") + `,
+			$2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query,
+			item.ID, item.ChatID, item.DungeonID, item.Code, item.Name, item.Description, item.Price.String(),
+			item.Category, item.IsActive, item.Stock, item.DiscountTierID, salePrice(item), item.SaleEndsAt,
+			item.CreatedAt, item.UpdatedAt)
+	} else {
+		row = r.db.QueryRowContext(ctx, query,
+			item.ID, item.ChatID, item.DungeonID, item.Code, item.Name, item.Description, item.Price.String(),
+			item.Category, item.IsActive, item.Stock, item.DiscountTierID, salePrice(item), item.SaleEndsAt,
+			item.CreatedAt, item.UpdatedAt)
+	}
+
+	if err := row.Scan(&item.ID); err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ports.ErrShopItemCodeExists
+		}
+		return fmt.Errorf("failed to create shop item: %w", err)
+	}
+
+	return nil
+}
+
+const shopItemColumns = `id, chat_id, dungeon_id, code, name, description, price, category, is_active, stock,
+	discount_tier_id, sale_price, sale_ends_at, created_at, updated_at`
+
+// salePrice converts an item's sale price for the sale_price column
+func salePrice(item *entity.ShopItem) sql.NullString {
+	if item.SalePrice == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: item.SalePrice.String(), Valid: true}
+}
+
+func scanShopItem(row rowScanner) (*entity.ShopItem, error) {
+	var item entity.ShopItem
+	var priceStr string
+	var salePriceStr sql.NullString
+	var saleEndsAt sql.NullTime
+
+	err := row.Scan(&item.ID, &item.ChatID, &item.DungeonID, &item.Code, &item.Name, &item.Description, &priceStr,
+		&item.Category, &item.IsActive, &item.Stock, &item.DiscountTierID, &salePriceStr, &saleEndsAt,
+		&item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Price = valueobject.NewDecimal(priceStr)
+	if salePriceStr.Valid {
+		price := valueobject.NewDecimal(salePriceStr.String)
+		item.SalePrice = &price
+	}
+	if saleEndsAt.Valid {
+		item.SaleEndsAt = &saleEndsAt.Time
+	}
+
+	return &item, nil
+}
+
+// FindByID returns a shop item. Inside a transaction the row stays locked (or,
+// on SQLite, the whole database) until the transaction ends, so concurrent
+// edits and stock changes of one item are serialized.
+func (r *ShopItemRepository) FindByID(ctx context.Context, id int64) (*entity.ShopItem, error) {
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `SELECT `+shopItemColumns+` FROM shop_items WHERE id = $1`+r.dialect.forUpdate(), id)
+	} else {
+		row = r.db.QueryRowContext(ctx, `SELECT `+shopItemColumns+` FROM shop_items WHERE id = $1`, id)
+	}
+
+	item, err := scanShopItem(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shop item not found: %w", ports.ErrShopItemNotFound)
+		}
+		return nil, fmt.Errorf("failed to query shop item: %w", err)
+	}
+
+	return item, nil
+}
+
+func (r *ShopItemRepository) FindByCode(ctx context.Context, dungeonID string, code string) (*entity.ShopItem, error) {
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `SELECT `+shopItemColumns+` FROM shop_items WHERE dungeon_id = $1 AND code = $2`, dungeonID, code)
+	} else {
+		row = r.db.QueryRowContext(ctx, `SELECT `+shopItemColumns+` FROM shop_items WHERE dungeon_id = $1 AND code = $2`, dungeonID, code)
+	}
+
+	item, err := scanShopItem(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shop item not found: %w", ports.ErrShopItemNotFound)
+		}
+		return nil, fmt.Errorf("failed to query shop item: %w", err)
+	}
+
+	return item, nil
+}
+
+func (r *ShopItemRepository) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error) {
+	return r.findWhere(ctx, `dungeon_id = $1`, dungeonID)
+}
+
+func (r *ShopItemRepository) findWhere(ctx context.Context, condition string, args ...interface{}) ([]*entity.ShopItem, error) {
+	query := `SELECT ` + shopItemColumns + ` FROM shop_items WHERE ` + condition + ` ORDER BY id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shop items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*entity.ShopItem
+	for rows.Next() {
+		item, err := scanShopItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shop item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over shop item rows: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *ShopItemRepository) Update(ctx context.Context, item *entity.ShopItem) error {
+	query := `
+		UPDATE shop_items
+		SET code = $1, name = $2, description = $3, price = $4, category = $5, is_active = $6, stock = $7,
+			discount_tier_id = $8, sale_price = $9, sale_ends_at = $10, updated_at = $11
+		WHERE id = $12`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query,
+			item.Code, item.Name, item.Description, item.Price.String(), item.Category, item.IsActive,
+			item.Stock, item.DiscountTierID, salePrice(item), item.SaleEndsAt, item.UpdatedAt, item.ID)
+	} else {
+		result, err = r.db.ExecContext(ctx, query,
+			item.Code, item.Name, item.Description, item.Price.String(), item.Category, item.IsActive,
+			item.Stock, item.DiscountTierID, salePrice(item), item.SaleEndsAt, item.UpdatedAt, item.ID)
+	}
+	if err != nil {
+		if r.dialect.IsUniqueViolation(err) {
+			return ports.ErrShopItemCodeExists
+		}
+		return fmt.Errorf("failed to update shop item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrShopItemNotFound
+	}
+
+	return nil
+}
+
+// AdjustStock adds delta to a limited item's stock in a single statement, so
+// concurrent purchases and refunds cannot overwrite each other's changes.
+// Unlimited items are left alone.
+func (r *ShopItemRepository) AdjustStock(ctx context.Context, id int64, delta int) error {
+	query := `
+		UPDATE shop_items
+		SET stock = stock + $1, updated_at = $2
+		WHERE id = $3 AND (stock IS NULL OR stock + $1 >= 0)`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, delta, time.Now(), id)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, delta, time.Now(), id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to adjust shop item stock: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		// Either the item is gone or the change would oversell it
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return ports.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+func (r *ShopItemRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM shop_items WHERE id = $1`
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, id)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete shop item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrShopItemNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+const timerColumns = `id, task_id, user_id, type, start_time, duration_sec, current_value_sec, last_tick,
+	status, timezone, notification_id, notification_claimed_at, notification_attempts`
+
+type TimerRepository struct {
+	db *sql.DB
+}
+
+func NewTimerRepository(db *sql.DB) *TimerRepository {
+	return &TimerRepository{db: db}
+}
+
+func (r *TimerRepository) Create(ctx context.Context, timer *entity.Timer) error {
+	query := `INSERT INTO timers (` + timerColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	args := []interface{}{timer.ID, timer.TaskID, timer.UserID, timer.Type, timer.StartTime, timer.Duration,
+		timer.CurrentValue, timer.LastTick, timer.Status, timer.Timezone, timer.NotificationID,
+		timer.NotificationClaimedAt, timer.NotificationAttempts}
+
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create timer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TimerRepository) FindByID(ctx context.Context, id string) (*entity.Timer, error) {
+	query := `SELECT ` + timerColumns + ` FROM timers WHERE id = $1`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, id)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, id)
+	}
+
+	timer, err := scanTimer(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrTimerNotFound
+		}
+		return nil, fmt.Errorf("failed to query timer: %w", err)
+	}
+
+	return timer, nil
+}
+
+func (r *TimerRepository) FindByUser(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	return r.query(ctx, `SELECT `+timerColumns+` FROM timers WHERE user_id = $1 ORDER BY start_time DESC`, userID)
+}
+
+func (r *TimerRepository) FindByTask(ctx context.Context, taskID string) ([]*entity.Timer, error) {
+	return r.query(ctx, `SELECT `+timerColumns+` FROM timers WHERE task_id = $1 ORDER BY start_time DESC`, taskID)
+}
+
+func (r *TimerRepository) FindActiveByUser(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	return r.query(ctx, `
+		SELECT `+timerColumns+` FROM timers
+		WHERE user_id = $1 AND status IN ('running', 'paused')
+		ORDER BY start_time DESC`, userID)
+}
+
+// FindUsersWithActiveTimers returns the users that have running or paused timers
+func (r *TimerRepository) FindUsersWithActiveTimers(ctx context.Context) ([]int64, error) {
+	query := `SELECT DISTINCT user_id FROM timers WHERE status IN ('running', 'paused') ORDER BY user_id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users with active timers: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (r *TimerRepository) Update(ctx context.Context, timer *entity.Timer) error {
+	query := `
+		UPDATE timers
+		SET duration_sec = $1, current_value_sec = $2, last_tick = $3, status = $4, notification_id = $5,
+			notification_claimed_at = $6, notification_attempts = $7
+		WHERE id = $8`
+	args := []interface{}{timer.Duration, timer.CurrentValue, timer.LastTick, timer.Status, timer.NotificationID,
+		timer.NotificationClaimedAt, timer.NotificationAttempts, timer.ID}
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update timer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ports.ErrTimerNotFound
+	}
+
+	return nil
+}
+
+func (r *TimerRepository) ClaimExpiry(ctx context.Context, timerID, claim string, now, retryBefore time.Time) (int, error) {
+	query := `
+		UPDATE timers
+		SET notification_id = $1, notification_claimed_at = $2, notification_attempts = notification_attempts + 1
+		WHERE id = $3 AND status = 'running'
+			AND ((notification_id IS NULL AND notification_claimed_at IS NULL) OR notification_claimed_at <= $4)
+		RETURNING notification_attempts`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, claim, now, timerID, retryBefore)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, claim, now, timerID, retryBefore)
+	}
+
+	var attempts int
+	if err := row.Scan(&attempts); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ports.ErrTimerNotificationTaken
+		}
+		return 0, fmt.Errorf("failed to claim timer expiry: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (r *TimerRepository) SettleExpiry(ctx context.Context, timerID, claim string, notificationID *string) error {
+	// A settled expiry drops its claim time so it is never claimed again
+	query := `
+		UPDATE timers
+		SET notification_id = $1,
+			notification_claimed_at = CASE WHEN $2 THEN NULL ELSE notification_claimed_at END
+		WHERE id = $3 AND status = 'running' AND notification_id = $4`
+	settled := notificationID != nil
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, notificationID, settled, timerID, claim)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, notificationID, settled, timerID, claim)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to settle timer expiry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ports.ErrTimerNotificationTaken
+	}
+
+	return nil
+}
+
+func (r *TimerRepository) Delete(ctx context.Context, id string) error {
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, `DELETE FROM timers WHERE id = $1`, id)
+	} else {
+		_, err = r.db.ExecContext(ctx, `DELETE FROM timers WHERE id = $1`, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete timer: %w", err)
+	}
+
+	return nil
+}
+
+// BulkUpdate updates all timers in one transaction
+func (r *TimerRepository) BulkUpdate(ctx context.Context, timers []*entity.Timer) error {
+	return NewTxManager(r.db).WithTx(ctx, func(ctx context.Context) error {
+		for _, timer := range timers {
+			if err := r.Update(ctx, timer); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *TimerRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entity.Timer, error) {
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query timers: %w", err)
+	}
+	defer rows.Close()
+
+	var timers []*entity.Timer
+	for rows.Next() {
+		timer, err := scanTimer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan timer: %w", err)
+		}
+		timers = append(timers, timer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over timer rows: %w", err)
+	}
+
+	return timers, nil
+}
+
+func scanTimer(row rowScanner) (*entity.Timer, error) {
+	var timer entity.Timer
+	var lastTick sql.NullTime
+	var notificationID sql.NullString
+	var claimedAt sql.NullTime
+
+	err := row.Scan(&timer.ID, &timer.TaskID, &timer.UserID, &timer.Type, &timer.StartTime, &timer.Duration,
+		&timer.CurrentValue, &lastTick, &timer.Status, &timer.Timezone, &notificationID,
+		&claimedAt, &timer.NotificationAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastTick.Valid {
+		timer.LastTick = &lastTick.Time
+	}
+	if notificationID.Valid {
+		timer.NotificationID = &notificationID.String
+	}
+	if claimedAt.Valid {
+		timer.NotificationClaimedAt = &claimedAt.Time
+	}
+
+	return &timer, nil
+}
+
+type TimerEventRepository struct {
+	db *sql.DB
+}
+
+func NewTimerEventRepository(db *sql.DB) *TimerEventRepository {
+	return &TimerEventRepository{db: db}
+}
+
+func (r *TimerEventRepository) Create(ctx context.Context, event *entity.TimerEvent) error {
+	if event.Metadata == nil {
+		event.Metadata = map[string]interface{}{}
+	}
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timer event metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO timer_events (id, timer_id, event_type, occurred_at, metadata)
+		VALUES ($1, $2, $3, $4, $5)`
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, event.ID, event.TimerID, event.EventType, event.Timestamp, metadata)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, event.ID, event.TimerID, event.EventType, event.Timestamp, metadata)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create timer event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TimerEventRepository) FindByTimer(ctx context.Context, timerID string) ([]*entity.TimerEvent, error) {
+	query := `
+		SELECT id, timer_id, event_type, occurred_at, metadata
+		FROM timer_events WHERE timer_id = $1
+		ORDER BY occurred_at, id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, timerID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, timerID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query timer events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entity.TimerEvent
+	for rows.Next() {
+		var event entity.TimerEvent
+		var metadata []byte
+		if err := rows.Scan(&event.ID, &event.TimerID, &event.EventType, &event.Timestamp, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan timer event: %w", err)
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal timer event metadata: %w", err)
+			}
+		}
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over timer event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *TimerEventRepository) DeleteOldEvents(ctx context.Context, olderThan time.Time) error {
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, `DELETE FROM timer_events WHERE occurred_at < $1`, olderThan)
+	} else {
+		_, err = r.db.ExecContext(ctx, `DELETE FROM timer_events WHERE occurred_at < $1`, olderThan)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete old timer events: %w", err)
+	}
+
+	return nil
+}
+
+// Compile-time checks that the repositories implement their ports
+var (
+	_ ports.TimerRepository      = (*TimerRepository)(nil)
+	_ ports.TimerEventRepository = (*TimerEventRepository)(nil)
+)
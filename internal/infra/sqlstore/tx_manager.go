@@ -1,4 +1,4 @@
-package postgres
+package sqlstore
 
 import (
 	"context"
@@ -8,12 +8,12 @@ import (
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
-// TxManager implements transaction management for PostgreSQL
+// TxManager implements transaction management on a database/sql connection
 type TxManager struct {
 	db *sql.DB
 }
 
-// NewTxManager creates a new PostgreSQL transaction manager
+// NewTxManager creates a new transaction manager
 func NewTxManager(db *sql.DB) *TxManager {
 	return &TxManager{db: db}
 }
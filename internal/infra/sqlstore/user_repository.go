@@ -0,0 +1,262 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+type UserRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewUserRepository(db *sql.DB, dialect Dialect) *UserRepository {
+	return &UserRepository{db: db, dialect: dialect}
+}
+
+// Create stores a new user; display_name starts out as the username
+func (r *UserRepository) Create(ctx context.Context, user *entity.User) error {
+	// Check if we're in a transaction
+	if tx, ok := GetTx(ctx); ok {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO users (id, chat_id, timezone, display_name, username, balance, role, preferences_json)
+			VALUES ($1, $2, $3, $4, $4, $5, $6, $7)`,
+			user.ID, user.ChatID, user.TimeZone, user.Username, user.Balance.String(), userRole(user), "{}")
+		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+	} else {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO users (id, chat_id, timezone, display_name, username, balance, role, preferences_json)
+			VALUES ($1, $2, $3, $4, $4, $5, $6, $7)`,
+			user.ID, user.ChatID, user.TimeZone, user.Username, user.Balance.String(), userRole(user), "{}")
+		if err != nil {
+			if r.dialect.IsUniqueViolation(err) {
+				return ports.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// userRole returns the role to store for user, defaulting to member
+func userRole(user *entity.User) string {
+	if user.Role == "" {
+		return entity.UserRoleMember
+	}
+	return user.Role
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id int64) (*entity.User, error) {
+	var user entity.User
+	var balanceStr string
+	var role, timezone, username, preferencesJSON string
+	var createdAt, updatedAt interface{} // We'll ignore these for now
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, `
+			SELECT id, chat_id, role, timezone, username, preferences_json, balance, created_at, updated_at
+			FROM users WHERE id = $1`, id)
+	} else {
+		row = r.db.QueryRowContext(ctx, `
+			SELECT id, chat_id, role, timezone, username, preferences_json, balance, created_at, updated_at
+			FROM users WHERE id = $1`, id)
+	}
+
+	err := row.Scan(&user.ID, &user.ChatID, &role, &timezone, &username, &preferencesJSON, &balanceStr, &createdAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ports.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	// Map fields to user entity
+	user.Role = role
+	user.TimeZone = timezone
+	user.Username = username
+	user.Balance = valueobject.NewDecimal(balanceStr)
+
+	return &user, nil
+}
+
+// Lock locks the user's row until the transaction ends. SQLite transactions
+// already hold the database's write lock, so there it only checks the user exists.
+func (r *UserRepository) Lock(ctx context.Context, id int64) error {
+	query := `SELECT id FROM users WHERE id = $1` + r.dialect.forUpdate()
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, id)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, id)
+	}
+
+	var locked int64
+	if err := row.Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return ports.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// UpdateBalance adds delta to the balance. Without an exact numeric type the
+// balance is read and rewritten in one transaction instead.
+func (r *UserRepository) UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	if !r.dialect.ExactNumeric {
+		return r.rewriteBalance(ctx, userID, delta)
+	}
+
+	query := `
+		UPDATE users
+		SET balance = CAST(balance AS NUMERIC) + CAST($1 AS NUMERIC)
+		WHERE id = $2
+		RETURNING balance`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, delta.String(), userID)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, delta.String(), userID)
+	}
+
+	var balanceStr string
+	if err := row.Scan(&balanceStr); err != nil {
+		if err == sql.ErrNoRows {
+			return valueobject.Decimal{}, ports.ErrUserNotFound
+		}
+		return valueobject.Decimal{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	return valueobject.NewDecimal(balanceStr), nil
+}
+
+func (r *UserRepository) rewriteBalance(ctx context.Context, userID int64, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	var balance valueobject.Decimal
+	err := NewTxManager(r.db).WithTx(ctx, func(ctx context.Context) error {
+		tx, _ := GetTx(ctx)
+
+		var current valueobject.Decimal
+		err := tx.QueryRowContext(ctx, `SELECT balance FROM users WHERE id = $1`, userID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return ports.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read balance: %w", err)
+		}
+
+		balance = current.Add(delta)
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET balance = $1 WHERE id = $2`, balance.String(), userID); err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return valueobject.Decimal{}, err
+	}
+
+	return balance, nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+	query := "DELETE FROM users WHERE id = $1"
+
+	var result sql.Result
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, id)
+	} else {
+		result, err = r.db.ExecContext(ctx, query, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ports.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) FindByChatID(ctx context.Context, chatID int64) ([]*entity.User, error) {
+	query := `
+		SELECT id, chat_id, role, timezone, username, preferences_json, balance, created_at, updated_at
+		FROM users WHERE chat_id = $1 ORDER BY id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, chatID)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, chatID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users by chat_id: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
+	query := `
+		SELECT id, chat_id, role, timezone, username, preferences_json, balance, created_at, updated_at
+		FROM users ORDER BY id`
+
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+func scanUsers(rows *sql.Rows) ([]*entity.User, error) {
+	var users []*entity.User
+	for rows.Next() {
+		var user entity.User
+		var balanceStr string
+		var role, timezone, username, preferencesJSON string
+		var createdAt, updatedAt interface{} // We'll ignore these for now
+		err := rows.Scan(&user.ID, &user.ChatID, &role, &timezone, &username, &preferencesJSON, &balanceStr, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user.Role = role
+		user.TimeZone = timezone
+		user.Username = username
+		user.Balance = valueobject.NewDecimal(balanceStr)
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+
+	return users, nil
+}
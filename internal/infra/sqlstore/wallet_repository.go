@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+)
+
+type WalletRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewWalletRepository(db *sql.DB, dialect Dialect) *WalletRepository {
+	return &WalletRepository{db: db, dialect: dialect}
+}
+
+func (r *WalletRepository) Get(ctx context.Context, userID int64, dungeonID string) (*entity.Wallet, error) {
+	query := `SELECT balance FROM wallets WHERE user_id = $1 AND dungeon_id = $2`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, userID, dungeonID)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, userID, dungeonID)
+	}
+
+	wallet := &entity.Wallet{UserID: userID, DungeonID: dungeonID, Balance: valueobject.NewDecimal("0")}
+	var balanceStr string
+	err := row.Scan(&balanceStr)
+	if err == sql.ErrNoRows {
+		return wallet, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet: %w", err)
+	}
+
+	wallet.Balance = valueobject.NewDecimal(balanceStr)
+	return wallet, nil
+}
+
+func (r *WalletRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.Wallet, error) {
+	return r.list(ctx, `SELECT user_id, dungeon_id, balance FROM wallets WHERE user_id = $1 ORDER BY dungeon_id`, userID)
+}
+
+func (r *WalletRepository) FindAll(ctx context.Context) ([]*entity.Wallet, error) {
+	return r.list(ctx, `SELECT user_id, dungeon_id, balance FROM wallets ORDER BY user_id, dungeon_id`)
+}
+
+func (r *WalletRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.Wallet, error) {
+	var rows *sql.Rows
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*entity.Wallet
+	for rows.Next() {
+		var wallet entity.Wallet
+		var balanceStr string
+		if err := rows.Scan(&wallet.UserID, &wallet.DungeonID, &balanceStr); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallet.Balance = valueobject.NewDecimal(balanceStr)
+		wallets = append(wallets, &wallet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over wallet rows: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// UpdateBalance upserts the wallet; the row stays locked until the
+// transaction ends, like users in UserRepository.UpdateBalance. Without an
+// exact numeric type the balance is read and rewritten in one transaction.
+func (r *WalletRepository) UpdateBalance(ctx context.Context, userID int64, dungeonID string, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	if !r.dialect.ExactNumeric {
+		return r.rewriteBalance(ctx, userID, dungeonID, delta)
+	}
+
+	query := `
+		INSERT INTO wallets (user_id, dungeon_id, balance)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, dungeon_id) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance
+		RETURNING balance`
+
+	var row *sql.Row
+	if tx, ok := GetTx(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, userID, dungeonID, delta.String())
+	} else {
+		row = r.db.QueryRowContext(ctx, query, userID, dungeonID, delta.String())
+	}
+
+	var balanceStr string
+	if err := row.Scan(&balanceStr); err != nil {
+		return valueobject.Decimal{}, fmt.Errorf("failed to update wallet: %w", err)
+	}
+
+	return valueobject.NewDecimal(balanceStr), nil
+}
+
+func (r *WalletRepository) rewriteBalance(ctx context.Context, userID int64, dungeonID string, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	var balance valueobject.Decimal
+	err := NewTxManager(r.db).WithTx(ctx, func(ctx context.Context) error {
+		tx, _ := GetTx(ctx)
+
+		current := valueobject.NewDecimal("0")
+		err := tx.QueryRowContext(ctx, `SELECT balance FROM wallets WHERE user_id = $1 AND dungeon_id = $2`,
+			userID, dungeonID).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read wallet: %w", err)
+		}
+
+		balance = current.Add(delta)
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO wallets (user_id, dungeon_id, balance)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, dungeon_id) DO UPDATE SET balance = excluded.balance`,
+			userID, dungeonID, balance.String())
+		if err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return valueobject.Decimal{}, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,192 @@
+// Package storage opens the database named by DATABASE_URL and builds the
+// repositories for it, so the commands do not depend on a particular backend.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/jobs"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlite"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/sqlstore"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// sqliteScheme marks a DATABASE_URL that points at a SQLite file and
+// memoryURL one that keeps everything in memory. Everything else is handed to
+// the PostgreSQL driver.
+const (
+	sqliteScheme = "sqlite:"
+	memoryURL    = "memory:"
+)
+
+// Storage bundles the repositories of one backend
+type Storage struct {
+	DB *sql.DB // nil for the in-memory backend
+
+	Users          ports.UserRepository
+	Ledger         ports.LedgerRepository
+	Wallets        ports.WalletRepository
+	Quests         ports.QuestRepository
+	Completions    ports.QuestCompletionRepository
+	Streaks        ports.QuestStreakRepository
+	Dungeons       ports.DungeonRepository
+	DungeonMembers ports.DungeonMemberRepository
+	Invites        ports.DungeonInviteRepository
+	JoinRequests   ports.JoinRequestRepository
+	ChatConfigs    ports.ChatConfigRepository
+	ShopItems      ports.ShopItemRepository
+	Purchases      ports.PurchaseRepository
+	MemberPrices   ports.MemberPriceRepository
+	Timers         ports.TimerRepository
+	TimerEvents    ports.TimerEventRepository
+	RewardTiers    ports.RewardTierRepository
+	LoyaltyStatus  ports.LoyaltyStatusRepository
+	DiscountTiers  ports.DiscountTierRepository
+	Idempotency    ports.IdempotencyRepository
+
+	UUIDGen   ports.UUIDGenerator
+	Scheduler ports.Scheduler
+	TxManager ports.TxManager
+
+	// Elector picks the replica that runs background jobs. It is nil when the
+	// backend only supports one process, which then always runs them.
+	Elector ports.LeaderElector
+}
+
+// Open connects to the database at url. A url of the form sqlite:PATH (or
+// sqlite://PATH) opens that SQLite file and brings its schema up to date, and
+// memory: keeps everything in memory until the process exits; any other url
+// is a PostgreSQL connection string, migrated with cmd/migrate.
+func Open(ctx context.Context, url string) (*Storage, error) {
+	if url == memoryURL {
+		return NewInMemory(), nil
+	}
+
+	if path, ok := sqlitePath(url); ok {
+		db, err := sqlite.Open(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return newSQLite(db), nil
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return newPostgres(db), nil
+}
+
+// Close closes the database
+func (s *Storage) Close() error {
+	if s.DB == nil {
+		return nil
+	}
+	return s.DB.Close()
+}
+
+// sqlitePath returns the file path of a sqlite: url
+func sqlitePath(url string) (string, bool) {
+	if !strings.HasPrefix(url, sqliteScheme) {
+		return "", false
+	}
+	path := strings.TrimPrefix(url, sqliteScheme)
+	// sqlite:///var/lib/bot.db is absolute, sqlite://bot.db is relative
+	return strings.TrimPrefix(path, "//"), true
+}
+
+func newPostgres(db *sql.DB) *Storage {
+	s := newSQLStore(db, postgres.Dialect)
+	s.Elector = postgres.NewAdvisoryLockElector(db, jobs.LeaderLockID)
+	return s
+}
+
+func newSQLite(db *sql.DB) *Storage {
+	return newSQLStore(db, sqlite.Dialect)
+}
+
+// newSQLStore builds the database/sql repositories shared by PostgreSQL and SQLite
+func newSQLStore(db *sql.DB, dialect sqlstore.Dialect) *Storage {
+	return &Storage{
+		DB:             db,
+		Users:          sqlstore.NewUserRepository(db, dialect),
+		Ledger:         sqlstore.NewLedgerRepository(db, dialect),
+		Wallets:        sqlstore.NewWalletRepository(db, dialect),
+		Quests:         sqlstore.NewQuestRepository(db),
+		Completions:    sqlstore.NewQuestCompletionRepository(db, dialect),
+		Streaks:        sqlstore.NewQuestStreakRepository(db),
+		Dungeons:       sqlstore.NewDungeonRepository(db),
+		DungeonMembers: sqlstore.NewDungeonMemberRepository(db),
+		Invites:        sqlstore.NewDungeonInviteRepository(db),
+		JoinRequests:   sqlstore.NewJoinRequestRepository(db),
+		ChatConfigs:    sqlstore.NewChatConfigRepository(db),
+		ShopItems:      sqlstore.NewShopItemRepository(db, dialect),
+		Purchases:      sqlstore.NewPurchaseRepository(db, dialect),
+		MemberPrices:   sqlstore.NewMemberPriceRepository(db),
+		Timers:         sqlstore.NewTimerRepository(db),
+		TimerEvents:    sqlstore.NewTimerEventRepository(db),
+		RewardTiers:    sqlstore.NewRewardTierRepository(db, dialect),
+		LoyaltyStatus:  sqlstore.NewLoyaltyStatusRepository(db, dialect),
+		DiscountTiers:  sqlstore.NewDiscountTierRepository(db, dialect),
+		Idempotency:    sqlstore.NewIdempotencyRepository(db, dialect),
+		UUIDGen:        sqlstore.NewUUIDGenerator(),
+		Scheduler:      sqlstore.NewScheduler(db, dialect),
+		TxManager:      sqlstore.NewTxManager(db),
+	}
+}
+
+// NewInMemory returns empty in-memory repositories, for demos and tests that
+// should not need a database. Its TxManager rolls all of them back together.
+func NewInMemory() *Storage {
+	s := &Storage{
+		Users:          inmemory.NewUserRepository(),
+		Ledger:         inmemory.NewLedgerRepository(),
+		Wallets:        inmemory.NewWalletRepository(),
+		Quests:         inmemory.NewQuestRepository(),
+		Completions:    inmemory.NewQuestCompletionRepository(),
+		Streaks:        inmemory.NewQuestStreakRepository(),
+		Dungeons:       inmemory.NewDungeonRepository(),
+		DungeonMembers: inmemory.NewDungeonMemberRepository(),
+		Invites:        inmemory.NewDungeonInviteRepository(),
+		JoinRequests:   inmemory.NewJoinRequestRepository(),
+		ChatConfigs:    inmemory.NewChatConfigRepository(),
+		ShopItems:      inmemory.NewShopItemRepository(),
+		Purchases:      inmemory.NewPurchaseRepository(),
+		MemberPrices:   inmemory.NewMemberPriceRepository(),
+		Timers:         inmemory.NewTimerRepository(),
+		TimerEvents:    inmemory.NewTimerEventRepository(),
+		RewardTiers:    inmemory.NewRewardTierRepository(),
+		LoyaltyStatus:  inmemory.NewLoyaltyStatusRepository(),
+		DiscountTiers:  inmemory.NewDiscountTierRepository(),
+		Idempotency:    inmemory.NewInMemoryIdempotencyRepository(),
+		UUIDGen:        sqlstore.NewUUIDGenerator(),
+		Scheduler:      inmemory.NewInMemoryScheduler(),
+	}
+
+	var repos []inmemory.Snapshotter
+	for _, repo := range []interface{}{
+		s.Users, s.Ledger, s.Wallets, s.Quests, s.Completions, s.Streaks, s.Dungeons, s.DungeonMembers, s.Invites, s.JoinRequests,
+		s.ChatConfigs, s.ShopItems, s.Purchases, s.MemberPrices, s.Timers, s.TimerEvents,
+		s.RewardTiers, s.LoyaltyStatus, s.DiscountTiers, s.Idempotency, s.Scheduler,
+	} {
+		repos = append(repos, repo.(inmemory.Snapshotter))
+	}
+	s.TxManager = inmemory.NewTxManager(repos...)
+
+	return s
+}
@@ -0,0 +1,99 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/postgres/migrations"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/test/contract"
+)
+
+func TestSQLite(t *testing.T) {
+	contract.Run(t, func(t *testing.T) *storage.Storage {
+		store, err := storage.Open(context.Background(), "sqlite:"+filepath.Join(t.TempDir(), "bot.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestInMemory(t *testing.T) {
+	contract.Run(t, func(t *testing.T) *storage.Storage {
+		store, err := storage.Open(context.Background(), "memory:")
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	// TEST_DB_CONN names a server the suite may create databases on
+	connStr := os.Getenv("TEST_DB_CONN")
+	if connStr == "" {
+		connStr = "user=postgres dbname=postgres sslmode=disable"
+	}
+
+	admin, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	if err := admin.Ping(); err != nil {
+		t.Skip("Skipping test: database not available:", err)
+	}
+
+	// Run against a throwaway database so no real data is touched
+	name := fmt.Sprintf("adhd_bot_contract_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+	defer func() {
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)"); err != nil {
+			t.Errorf("failed to drop %s: %v", name, err)
+		}
+	}()
+
+	dbConnStr, err := withDatabase(connStr, name)
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", dbConnStr)
+	require.NoError(t, err)
+	defer db.Close()
+
+	all, err := migrations.Load(migrations.Files())
+	require.NoError(t, err)
+
+	contract.Run(t, func(t *testing.T) *storage.Storage {
+		// Start every test from an empty schema
+		_, err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public")
+		require.NoError(t, err)
+		_, err = migrations.NewRunner(db, all).Up(context.Background())
+		require.NoError(t, err)
+
+		store, err := storage.Open(context.Background(), dbConnStr)
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+// withDatabase points a key=value or URL connection string at another database
+func withDatabase(connStr, name string) (string, error) {
+	if !strings.HasPrefix(connStr, "postgres://") && !strings.HasPrefix(connStr, "postgresql://") {
+		// Later keys win, so appending overrides any dbname already given
+		return connStr + " dbname=" + name, nil
+	}
+
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", err
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
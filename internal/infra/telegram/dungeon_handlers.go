@@ -0,0 +1,296 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"gopkg.in/telebot.v3"
+)
+
+func (h *Handlers) start(c telebot.Context) error {
+	ctx := context.Background()
+	if _, err := h.ensureUser(ctx, c.Chat(), c.Sender()); err != nil {
+		log.Printf("Failed to create user: %v", err)
+		return c.Send("❌ Failed to register user")
+	}
+
+	// Invite links open the bot with /start join_<code>
+	if code, ok := strings.CutPrefix(c.Message().Payload, JoinDeepLinkPrefix); ok {
+		return c.Send(joinMessage(h.dungeonService.JoinWithInvite(ctx, c.Sender().ID, code)))
+	}
+
+	return c.Send("🎮 Welcome to ADHD Game Bot!\n" +
+		"Use /shop to see available items\n" +
+		"Use /buy <code> to purchase items\n" +
+		"Use /refund <purchase_id> to undo a purchase\n" +
+		"Use /tiers to see loyalty tiers and your progress\n" +
+		"Use /balance to check your balance\n" +
+		"Use /quests to see this chat's quests\n" +
+		"Use /done <quest> [minutes|percent] to complete one\n" +
+		"Use /newquest to add a quest (admins)\n" +
+		"Use /timezone to see or set the zone new quests start in\n" +
+		"Use /streak to see your quest streaks\n" +
+		"Use /history to see where your points went\n" +
+		"Use /linkdungeon in a group to give it a dungeon\n" +
+		"Use /invite to invite someone to this chat's dungeon\n" +
+		"Use /leave to leave it")
+}
+
+func (h *Handlers) userJoined(c telebot.Context) error {
+	if joined := c.Message().UserJoined; joined != nil && !joined.IsBot {
+		h.enrollChatMember(c.Chat(), joined)
+	}
+	return nil
+}
+
+// userLeft removes members who leave a linked group from its dungeon; owners
+// stay until they transfer it
+func (h *Handlers) userLeft(c telebot.Context) error {
+	left := c.Message().UserLeft
+	if left == nil || left.IsBot {
+		return nil
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeons.FindByTelegramChatID(ctx, c.Chat().ID)
+	if err != nil {
+		return nil
+	}
+	if err := h.dungeonService.LeaveDungeon(ctx, left.ID, dungeon.ID); err != nil && !errors.Is(err, usecase.ErrNotDungeonMember) {
+		log.Printf("Failed to remove user %d who left chat %d: %v", left.ID, c.Chat().ID, err)
+	}
+	return nil
+}
+
+func (h *Handlers) linkDungeon(c telebot.Context) error {
+	if c.Chat().Type == telebot.ChatPrivate {
+		return c.Send("🏰 Send /linkdungeon in the group you want to link.")
+	}
+	if len(c.Args()) == 0 {
+		return h.offerDungeonLink(c)
+	}
+	if len(c.Args()) > 1 {
+		return c.Send("Usage: /linkdungeon [dungeon_id]")
+	}
+
+	dungeon, err := h.dungeonService.LinkChat(context.Background(), c.Sender().ID, c.Args()[0], c.Chat().ID)
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not link the dungeon: %v", err))
+	}
+	return c.Send(fmt.Sprintf("🔗 This group now plays in \"%s\".", dungeon.Title))
+}
+
+// offerDungeonLink tells a group which dungeon it plays in, or offers the
+// sender's dungeons to attach and a button to create a new one
+func (h *Handlers) offerDungeonLink(c telebot.Context) error {
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err == nil {
+		return c.Send(fmt.Sprintf("🏰 This group plays in \"%s\".", dungeon.Title))
+	}
+	if !errors.Is(err, ports.ErrDungeonNotFound) {
+		log.Printf("Failed to find dungeon for chat %d: %v", c.Chat().ID, err)
+		return c.Send("❌ Could not check this group's dungeon")
+	}
+
+	owned, err := h.dungeonService.ListOwnedDungeons(ctx, c.Sender().ID)
+	if err != nil {
+		log.Printf("Failed to list dungeons of user %d: %v", c.Sender().ID, err)
+	}
+	return c.Send("🏰 This group has no dungeon yet. Attach one of yours or create a new one:",
+		LinkDungeonMarkup(owned))
+}
+
+func (h *Handlers) createDungeon(c telebot.Context) error {
+	chat := c.Chat()
+	dungeon, err := h.dungeonService.CreateDungeon(context.Background(), c.Sender().ID, chat.Title, &chat.ID)
+	if err != nil {
+		log.Printf("Failed to create dungeon for chat %d: %v", chat.ID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("❌ %v", err)})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit(fmt.Sprintf("🏰 Created \"%s\" for this group, owned by %s. Everyone who talks here joins it.",
+		dungeon.Title, c.Sender().FirstName))
+}
+
+func (h *Handlers) attachDungeon(c telebot.Context) error {
+	dungeon, err := h.dungeonService.LinkChat(context.Background(), c.Sender().ID, c.Data(), c.Chat().ID)
+	if err != nil {
+		log.Printf("Failed to link dungeon %s to chat %d: %v", c.Data(), c.Chat().ID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("❌ %v", err)})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit(fmt.Sprintf("🔗 This group now plays in \"%s\". Everyone who talks here joins it.", dungeon.Title))
+}
+
+func (h *Handlers) invite(c telebot.Context) error {
+	options := usecase.InviteOptions{ValidFor: 7 * 24 * time.Hour}
+	for _, arg := range c.Args() {
+		if arg == "approve" {
+			options.RequiresApproval = true
+			continue
+		}
+		uses, err := strconv.Atoi(arg)
+		if err != nil || uses <= 0 {
+			return c.Send("Usage: /invite [max_uses] [approve]")
+		}
+		options.MaxUses = &uses
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not create an invite"))
+	}
+
+	invite, err := h.dungeonService.CreateInvite(ctx, c.Sender().ID, dungeon.ID, options)
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not create an invite: %v", err))
+	}
+
+	message := fmt.Sprintf("🎟️ Join \"%s\": %s\nValid until %s", dungeon.Title,
+		InviteLink(c.Bot().Me.Username, invite.Code), invite.ExpiresAt.Format("Jan 2 15:04 MST"))
+	if invite.MaxUses != nil {
+		message += fmt.Sprintf(", %d uses", *invite.MaxUses)
+	}
+	if invite.RequiresApproval {
+		message += ", joins need approval"
+	}
+	return c.Send(message)
+}
+
+// joinMessage describes the outcome of redeeming an invite
+func joinMessage(result *usecase.JoinResult, err error) string {
+	switch {
+	case errors.Is(err, ports.ErrDungeonInviteNotFound):
+		return "❌ This invite link is not valid."
+	case errors.Is(err, entity.ErrInviteExpired):
+		return "⌛ This invite link has expired."
+	case errors.Is(err, entity.ErrInviteUsedUp):
+		return "⌛ This invite link has been used up."
+	case errors.Is(err, usecase.ErrAlreadyMember):
+		return "🏰 You are already a member of this dungeon."
+	case err != nil:
+		log.Printf("Failed to join with invite: %v", err)
+		return "❌ Could not join the dungeon"
+	case !result.Joined():
+		return fmt.Sprintf("⏳ Asked to join \"%s\". You'll hear back once someone approves.", result.Dungeon.Title)
+	default:
+		return fmt.Sprintf("🏰 Welcome to \"%s\"!", result.Dungeon.Title)
+	}
+}
+
+// decideJoinRequest applies decide to the join request of a button press and
+// replaces the request message with done
+func decideJoinRequest(c telebot.Context, decide func(ctx context.Context, actorID, requestID int64) (*entity.JoinRequest, error), done string) error {
+	requestID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Unknown join request"})
+	}
+
+	if _, err := decide(context.Background(), c.Sender().ID, requestID); err != nil {
+		log.Printf("Failed to decide join request %d: %v", requestID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("❌ %v", err)})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit(done)
+}
+
+func (h *Handlers) leave(c telebot.Context) error {
+	// Group members are enrolled again as soon as they talk
+	if c.Chat().Type != telebot.ChatPrivate {
+		return c.Send("👋 Leave this group to leave its dungeon.")
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not leave the dungeon"))
+	}
+
+	if err := h.dungeonService.LeaveDungeon(ctx, c.Sender().ID, dungeon.ID); err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not leave the dungeon: %v", err))
+	}
+	return c.Send(fmt.Sprintf("👋 You left \"%s\".", dungeon.Title))
+}
+
+func (h *Handlers) kick(c telebot.Context) error {
+	reply := c.Message().ReplyTo
+	if reply == nil || reply.Sender == nil {
+		return c.Send("Reply to a message of the member to remove with /kick")
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not remove the member"))
+	}
+
+	if err := h.dungeonService.RemoveMember(ctx, c.Sender().ID, dungeon.ID, reply.Sender.ID); err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not remove the member: %v", err))
+	}
+
+	// Removing them from the group too keeps them from being enrolled again;
+	// banning and unbanning lets them come back with a new invite
+	chat := c.Chat()
+	if err := c.Bot().Ban(chat, &telebot.ChatMember{User: reply.Sender}); err != nil {
+		log.Printf("Failed to remove user %d from chat %d: %v", reply.Sender.ID, chat.ID, err)
+	} else if err := c.Bot().Unban(chat, reply.Sender); err != nil {
+		log.Printf("Failed to unban user %d in chat %d: %v", reply.Sender.ID, chat.ID, err)
+	}
+	return c.Send(fmt.Sprintf("🚪 %s was removed from \"%s\".", reply.Sender.FirstName, dungeon.Title))
+}
+
+// timeZone shows the zone new quests of the chat's dungeon start in; with an
+// argument, owners and moderators change it
+func (h *Handlers) timeZone(c telebot.Context) error {
+	if len(c.Args()) > 1 {
+		return c.Send("Usage: /timezone [zone], e.g. /timezone Europe/Berlin")
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not find the time zone"))
+	}
+	if len(c.Args()) == 0 {
+		return c.Send(fmt.Sprintf("🕰️ New quests in \"%s\" start in %s.", dungeon.Title, dungeonTimeZone(dungeon)))
+	}
+
+	dungeon, err = h.dungeonService.SetTimeZone(ctx, c.Sender().ID, dungeon.ID, c.Args()[0])
+	switch {
+	case errors.Is(err, ports.ErrForbidden):
+		return c.Send("🔒 Only the dungeon's owner and moderators can change the time zone.")
+	case errors.Is(err, usecase.ErrInvalidTimeZone):
+		return c.Send(fmt.Sprintf("❌ %v", err))
+	case err != nil:
+		log.Printf("Failed to set time zone: %v", err)
+		return c.Send("❌ Could not change the time zone")
+	}
+	return c.Send(fmt.Sprintf("🕰️ New quests in \"%s\" now start in %s.", dungeon.Title, dungeon.TimeZone))
+}
+
+// dungeonTimeZone returns the zone new quests of the dungeon start in
+func dungeonTimeZone(dungeon *entity.Dungeon) string {
+	if dungeon.TimeZone == "" {
+		return "UTC"
+	}
+	return dungeon.TimeZone
+}
@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// Callback identifiers of the buttons attached to join request messages. Each
+// button carries the join request ID as its data.
+const (
+	JoinApproveUnique = "join_approve"
+	JoinDenyUnique    = "join_deny"
+)
+
+// JoinDeepLinkPrefix starts the /start payload of invite links
+const JoinDeepLinkPrefix = "join_"
+
+// InviteLink returns the deep link that redeems an invite with the bot
+func InviteLink(botUsername, code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", botUsername, JoinDeepLinkPrefix, code)
+}
+
+// Callback identifiers of the buttons offered when the bot joins a group.
+// Attach buttons carry the dungeon ID as data.
+const (
+	DungeonCreateUnique = "dungeon_create"
+	DungeonAttachUnique = "dungeon_attach"
+)
+
+// LinkDungeonMarkup offers to attach one of the dungeons to a group, or to
+// create a new one for it
+func LinkDungeonMarkup(dungeons []*entity.Dungeon) *telebot.ReplyMarkup {
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, dungeon := range dungeons {
+		rows = append(rows, markup.Row(markup.Data(fmt.Sprintf("🔗 Attach \"%s\"", dungeon.Title), DungeonAttachUnique, dungeon.ID)))
+	}
+	rows = append(rows, markup.Row(markup.Data("🏰 Create a new dungeon", DungeonCreateUnique)))
+	markup.Inline(rows...)
+	return markup
+}
+
+// DungeonNotifier sends join requests and their outcome as private Telegram messages
+type DungeonNotifier struct {
+	sender Sender
+}
+
+func NewDungeonNotifier(sender Sender) *DungeonNotifier {
+	return &DungeonNotifier{sender: sender}
+}
+
+// NotifyJoinRequest messages a reviewer with buttons to approve or deny the request
+func (n *DungeonNotifier) NotifyJoinRequest(ctx context.Context, reviewerID int64, dungeon *entity.Dungeon, request *entity.JoinRequest) error {
+	requestID := strconv.FormatInt(request.ID, 10)
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Approve", JoinApproveUnique, requestID),
+		markup.Data("🚫 Deny", JoinDenyUnique, requestID),
+	))
+
+	text := fmt.Sprintf("🚪 User %d wants to join \"%s\".", request.UserID, dungeon.Title)
+	if _, err := n.sender.Send(&telebot.User{ID: reviewerID}, text, markup); err != nil {
+		return fmt.Errorf("failed to send join request: %w", err)
+	}
+	return nil
+}
+
+// NotifyJoinDecided tells the requester whether they were let in
+func (n *DungeonNotifier) NotifyJoinDecided(ctx context.Context, dungeon *entity.Dungeon, request *entity.JoinRequest) error {
+	text := fmt.Sprintf("🏰 Welcome to \"%s\"! Your request to join was approved.", dungeon.Title)
+	if request.Status != entity.JoinRequestApproved {
+		text = fmt.Sprintf("🚫 Your request to join \"%s\" was declined.", dungeon.Title)
+	}
+
+	if _, err := n.sender.Send(&telebot.User{ID: request.UserID}, text); err != nil {
+		return fmt.Errorf("failed to send join decision: %w", err)
+	}
+	return nil
+}
+
+// Compile-time check that DungeonNotifier implements ports.DungeonNotifier
+var _ ports.DungeonNotifier = (*DungeonNotifier)(nil)
@@ -0,0 +1,69 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
+	"gopkg.in/telebot.v3"
+)
+
+func TestDungeonNotifier_NotifyJoinRequest(t *testing.T) {
+	ctx := context.Background()
+	dungeon := &entity.Dungeon{ID: "dungeon-1", Title: "Flat chores"}
+	request := &entity.JoinRequest{ID: 12, DungeonID: dungeon.ID, UserID: 9, Status: entity.JoinRequestPending}
+
+	sender := &fakeSender{}
+	require.NoError(t, telegram.NewDungeonNotifier(sender).NotifyJoinRequest(ctx, 7, dungeon, request))
+	assert.Equal(t, "7", sender.to.Recipient())
+	assert.Contains(t, sender.what, "Flat chores")
+
+	require.Len(t, sender.opts, 1)
+	markup := sender.opts[0].(*telebot.ReplyMarkup)
+	require.Len(t, markup.InlineKeyboard, 1)
+	var uniques []string
+	for _, button := range markup.InlineKeyboard[0] {
+		uniques = append(uniques, button.Unique)
+		assert.Equal(t, "12", button.Data)
+	}
+	assert.Equal(t, []string{telegram.JoinApproveUnique, telegram.JoinDenyUnique}, uniques)
+
+	err := telegram.NewDungeonNotifier(&fakeSender{err: errors.New("blocked")}).NotifyJoinRequest(ctx, 7, dungeon, request)
+	assert.Error(t, err)
+}
+
+func TestDungeonNotifier_NotifyJoinDecided(t *testing.T) {
+	ctx := context.Background()
+	dungeon := &entity.Dungeon{ID: "dungeon-1", Title: "Flat chores"}
+	request := &entity.JoinRequest{ID: 12, DungeonID: dungeon.ID, UserID: 9, Status: entity.JoinRequestDenied}
+
+	sender := &fakeSender{}
+	require.NoError(t, telegram.NewDungeonNotifier(sender).NotifyJoinDecided(ctx, dungeon, request))
+	assert.Equal(t, "9", sender.to.Recipient())
+	assert.Contains(t, sender.what, "declined")
+}
+
+func TestInviteLink(t *testing.T) {
+	assert.Equal(t, "https://t.me/adhd_bot?start=join_abc123", telegram.InviteLink("adhd_bot", "abc123"))
+}
+
+func TestLinkDungeonMarkup(t *testing.T) {
+	markup := telegram.LinkDungeonMarkup([]*entity.Dungeon{
+		{ID: "dungeon-1", Title: "Flat chores"},
+		{ID: "dungeon-2", Title: "Office"},
+	})
+
+	require.Len(t, markup.InlineKeyboard, 3)
+	assert.Equal(t, telegram.DungeonAttachUnique, markup.InlineKeyboard[0][0].Unique)
+	assert.Equal(t, "dungeon-1", markup.InlineKeyboard[0][0].Data)
+	assert.Contains(t, markup.InlineKeyboard[1][0].Text, "Office")
+	assert.Equal(t, telegram.DungeonCreateUnique, markup.InlineKeyboard[2][0].Unique)
+
+	markup = telegram.LinkDungeonMarkup(nil)
+	require.Len(t, markup.InlineKeyboard, 1)
+	assert.Equal(t, telegram.DungeonCreateUnique, markup.InlineKeyboard[0][0].Unique)
+}
@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"gopkg.in/telebot.v3"
+)
+
+// Handlers answers the bot's commands and buttons
+type Handlers struct {
+	users          ports.UserRepository
+	dungeons       ports.DungeonRepository
+	ledgerService  *usecase.LedgerService
+	loyaltyService *usecase.LoyaltyService
+	shopService    *usecase.ShopService
+	questService   *usecase.QuestService
+	dungeonService *usecase.DungeonService
+	timerService   *usecase.TimerService
+	questWizard    *QuestWizard
+}
+
+func NewHandlers(
+	users ports.UserRepository,
+	dungeons ports.DungeonRepository,
+	ledgerService *usecase.LedgerService,
+	loyaltyService *usecase.LoyaltyService,
+	shopService *usecase.ShopService,
+	questService *usecase.QuestService,
+	dungeonService *usecase.DungeonService,
+	timerService *usecase.TimerService,
+	questWizard *QuestWizard,
+) *Handlers {
+	return &Handlers{
+		users:          users,
+		dungeons:       dungeons,
+		ledgerService:  ledgerService,
+		loyaltyService: loyaltyService,
+		shopService:    shopService,
+		questService:   questService,
+		dungeonService: dungeonService,
+		timerService:   timerService,
+		questWizard:    questWizard,
+	}
+}
+
+// Register installs the handlers on bot
+func (h *Handlers) Register(bot *telebot.Bot) {
+	// Everyone who uses the bot in a group linked to a dungeon takes part in it
+	bot.Use(func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if sender := c.Sender(); sender != nil && !sender.IsBot && c.Chat() != nil {
+				h.enrollChatMember(c.Chat(), sender)
+			}
+			return next(c)
+		}
+	})
+
+	bot.Handle(telebot.OnAddedToGroup, h.offerDungeonLink)
+	bot.Handle(telebot.OnUserJoined, h.userJoined)
+	bot.Handle(telebot.OnUserLeft, h.userLeft)
+	bot.Handle("/start", h.start)
+	bot.Handle("/linkdungeon", h.linkDungeon)
+	bot.Handle("/invite", h.invite)
+	bot.Handle("/leave", h.leave)
+	bot.Handle("/kick", h.kick)
+	bot.Handle("/timezone", h.timeZone)
+
+	bot.Handle("/shop", h.shop)
+	bot.Handle("/buy", h.buy)
+	bot.Handle("/refund", h.refund)
+	bot.Handle("/tiers", h.tiers)
+	bot.Handle("/addtier", h.addTier)
+	bot.Handle("/deltier", h.deleteTier)
+	bot.Handle("/balance", h.balance)
+	bot.Handle("/history", h.history)
+
+	bot.Handle("/streak", h.streak)
+	bot.Handle("/quests", h.quests)
+	bot.Handle("/done", h.done)
+	bot.Handle("/newquest", h.newQuest)
+	bot.Handle("/cancel", h.cancel)
+	bot.Handle(telebot.OnText, h.text)
+
+	// Join request buttons; each carries the join request ID as data
+	bot.Handle(&telebot.Btn{Unique: JoinApproveUnique}, func(c telebot.Context) error {
+		return decideJoinRequest(c, h.dungeonService.ApproveJoinRequest, "✅ Approved, they're in.")
+	})
+	bot.Handle(&telebot.Btn{Unique: JoinDenyUnique}, func(c telebot.Context) error {
+		return decideJoinRequest(c, h.dungeonService.DenyJoinRequest, "🚫 Request denied.")
+	})
+
+	// Dungeon offer buttons sent when the bot joins a group
+	bot.Handle(&telebot.Btn{Unique: DungeonCreateUnique}, h.createDungeon)
+	bot.Handle(&telebot.Btn{Unique: DungeonAttachUnique}, h.attachDungeon)
+
+	bot.Handle(&telebot.Btn{Unique: QuestWizardUnique}, h.questWizardAnswer)
+	bot.Handle(&telebot.Btn{Unique: QuestDoneUnique}, h.questDone)
+
+	// Timer expiry notification buttons; each carries the timer ID as data
+	bot.Handle(&telebot.Btn{Unique: TimerCompleteUnique}, h.completeTimer)
+	bot.Handle(&telebot.Btn{Unique: TimerExtendUnique}, h.extendTimer)
+	bot.Handle(&telebot.Btn{Unique: TimerAbandonUnique}, h.abandonTimer)
+}
+
+// ensureUser returns the user of sender, registering them on their first
+// message in chat
+func (h *Handlers) ensureUser(ctx context.Context, chat *telebot.Chat, sender *telebot.User) (*entity.User, error) {
+	user, err := h.users.FindByID(ctx, sender.ID)
+	if !errors.Is(err, ports.ErrUserNotFound) {
+		return user, err
+	}
+
+	user = &entity.User{
+		ID:       sender.ID,
+		ChatID:   chat.ID,
+		Username: sender.FirstName,
+		Balance:  valueobject.NewDecimal("0.00"),
+		Role:     entity.UserRoleMember,
+		TimeZone: "UTC",
+	}
+	err = h.users.Create(ctx, user)
+	if errors.Is(err, ports.ErrUserAlreadyExists) {
+		// Registered concurrently, e.g. by the API's login
+		return h.users.FindByID(ctx, sender.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// enrollChatMember registers user if needed and, in a group linked to a
+// dungeon, makes them a member of it
+func (h *Handlers) enrollChatMember(chat *telebot.Chat, user *telebot.User) {
+	ctx := context.Background()
+	if _, err := h.ensureUser(ctx, chat, user); err != nil {
+		log.Printf("Failed to register user %d: %v", user.ID, err)
+		return
+	}
+
+	if chat.Type == telebot.ChatPrivate {
+		return
+	}
+	if _, err := h.dungeonService.EnrollChatMember(ctx, chat.ID, user.ID); err != nil && !errors.Is(err, ports.ErrDungeonNotFound) {
+		log.Printf("Failed to enroll user %d in the dungeon of chat %d: %v", user.ID, chat.ID, err)
+	}
+}
+
+// dungeonCurrency returns the currency name of the dungeon's group, or of
+// chatID for a dungeon without a group or no dungeon at all
+func (h *Handlers) dungeonCurrency(ctx context.Context, dungeon *entity.Dungeon, chatID int64) string {
+	if dungeon != nil && dungeon.TelegramChatID != nil {
+		chatID = *dungeon.TelegramChatID
+	}
+	currencyName, err := h.shopService.GetCurrencyName(ctx, chatID)
+	if err != nil {
+		return "Points"
+	}
+	return currencyName
+}
+
+// noDungeonMessage explains why a command has no dungeon to act on, falling
+// back to failure for unexpected errors
+func noDungeonMessage(err error, failure string) string {
+	switch {
+	case errors.Is(err, ports.ErrDungeonNotFound):
+		return "🏰 This chat is not linked to a dungeon. Its owner can link it with /linkdungeon."
+	case errors.Is(err, usecase.ErrSeveralDungeons):
+		return "🏰 You are in several dungeons. Send the command in the dungeon's group."
+	default:
+		log.Printf("Failed to find the chat's dungeon: %v", err)
+		return failure
+	}
+}
+
+// IdempotencyKey returns the idempotency key of an update handled by source,
+// the command or button it came from, told apart from other updates by parts
+func IdempotencyKey(source string, parts ...any) string {
+	key := "tg:" + source
+	for _, part := range parts {
+		key += fmt.Sprintf(":%v", part)
+	}
+	return key
+}
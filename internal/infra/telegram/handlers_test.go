@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"gopkg.in/telebot.v3"
+)
+
+func TestHandlers_EnsureUser(t *testing.T) {
+	ctx := context.Background()
+	users := inmemory.NewUserRepository()
+	h := &Handlers{users: users}
+	chat := &telebot.Chat{ID: -100}
+
+	user, err := h.ensureUser(ctx, chat, &telebot.User{ID: 7, FirstName: "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), user.ID)
+	assert.Equal(t, int64(-100), user.ChatID, "registered in the chat of their first message")
+	assert.Equal(t, "Ada", user.Username)
+	assert.True(t, user.Balance.IsZero())
+
+	stored, err := users.FindByID(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", stored.Username)
+
+	// Later messages find the registered user instead of registering them again
+	again, err := h.ensureUser(ctx, &telebot.Chat{ID: 7}, &telebot.User{ID: 7, FirstName: "Renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(-100), again.ChatID)
+	assert.Equal(t, "Ada", again.Username)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	// Keys stored before the handlers moved here must keep matching
+	assert.Equal(t, "tg:done:-100:42", IdempotencyKey("done", int64(-100), 42))
+	assert.Equal(t, "tg:quest_done:7:abc:20000", IdempotencyKey(QuestDoneUnique, int64(7), "abc", int64(20000)))
+
+	assert.NotEqual(t, IdempotencyKey(QuestDoneUnique, 7, "abc", 20000), IdempotencyKey(QuestDoneUnique, 8, "abc", 20000))
+	assert.NotEqual(t, IdempotencyKey(QuestDoneUnique, 7, "abc", 20000), IdempotencyKey(QuestDoneUnique, 7, "abc", 20001))
+}
+
+func TestCompletionMessage(t *testing.T) {
+	quest := &entity.Quest{Title: "Dishes", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}
+
+	full := &entity.QuestCompletion{AwardedPoints: valueobject.NewDecimal("10")}
+	assert.Equal(t, "✅ Dishes done! +10 Gems", completionMessage(quest, full, "Gems"))
+
+	capped := &entity.QuestCompletion{AwardedPoints: valueobject.NewDecimal("4")}
+	assert.Equal(t, "✅ Dishes done! +4 Gems, which reaches today's cap for it.", completionMessage(quest, capped, "Gems"))
+
+	none := &entity.QuestCompletion{AwardedPoints: valueobject.NewDecimal("0")}
+	assert.Equal(t, "✅ Dishes done! Today's cap for it is reached, so no Gems this time.", completionMessage(quest, none, "Gems"))
+}
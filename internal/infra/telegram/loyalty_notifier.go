@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// LoyaltyNotifier congratulates users on new loyalty tiers in a private message
+type LoyaltyNotifier struct {
+	sender Sender
+}
+
+func NewLoyaltyNotifier(sender Sender) *LoyaltyNotifier {
+	return &LoyaltyNotifier{sender: sender}
+}
+
+func (n *LoyaltyNotifier) NotifyTierReached(ctx context.Context, user *entity.User, tier *entity.RewardTier) error {
+	text := fmt.Sprintf("🏅 You reached the %s tier! You now get %g%% off in the shop.", tier.Name, tier.DiscountPercent)
+	if _, err := n.sender.Send(&telebot.User{ID: user.ID}, text); err != nil {
+		return fmt.Errorf("failed to send tier notification: %w", err)
+	}
+	return nil
+}
+
+// Compile-time check that LoyaltyNotifier implements ports.LoyaltyNotifier
+var _ ports.LoyaltyNotifier = (*LoyaltyNotifier)(nil)
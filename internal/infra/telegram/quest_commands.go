@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"gopkg.in/telebot.v3"
+)
+
+// QuestDoneUnique identifies the Done buttons of quest lists. Each button
+// carries "<quest id>|<key>". The random key goes into the idempotency key
+// with the presser and the quest's current period, so each user's presses of
+// one button complete the quest at most once per period, concurrent presses
+// included. Everyone in a group shares the button, and an old list completes
+// the quest again once a new period started.
+const QuestDoneUnique = "quest_done"
+
+// ErrInvalidCompletionAmount is returned for /done amounts that do not fit the quest's mode
+var ErrInvalidCompletionAmount = errors.New("give minutes for per-minute quests and a percentage for partial quests")
+
+// QuestListMarkup adds a Done button for each quest that can be completed with
+// a single tap. PARTIAL quests count as fully done; PER_MINUTE quests need the
+// minutes spent and are left to /done.
+func QuestListMarkup(quests []*entity.Quest) (*telebot.ReplyMarkup, error) {
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for i, quest := range quests {
+		if quest.Mode == entity.QuestModePerMinute {
+			continue
+		}
+
+		key, err := newButtonKey()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, markup.Row(markup.Data(fmt.Sprintf("✅ %d. %s", i+1, quest.Title), QuestDoneUnique, quest.ID, key)))
+	}
+	markup.Inline(rows...)
+	return markup, nil
+}
+
+// newButtonKey returns a random key short enough to fit next to a UUID in
+// Telegram's 64 bytes of callback data
+func newButtonKey() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate button key: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IsCompletionAmount reports whether a /done argument reads as minutes
+// ("25", "25m", "25min") or a percentage ("50%")
+func IsCompletionAmount(arg string) bool {
+	if number, ok := strings.CutSuffix(arg, "%"); ok {
+		_, err := strconv.ParseFloat(number, 64)
+		return err == nil
+	}
+	_, err := strconv.Atoi(trimMinutes(arg))
+	return err == nil
+}
+
+// ParseCompletionAmount turns the optional amount of /done into the
+// completion ratio of a PARTIAL quest or the minutes of a PER_MINUTE quest.
+// PARTIAL quests without an amount count as fully done; PER_MINUTE quests
+// without one are left for the quest service to reject.
+func ParseCompletionAmount(quest *entity.Quest, amount string) (ratio *float64, minutes *int, err error) {
+	switch quest.Mode {
+	case entity.QuestModePartial:
+		full := 1.0
+		if amount == "" {
+			return &full, nil, nil
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(amount, "%"), 64)
+		if err != nil {
+			return nil, nil, ErrInvalidCompletionAmount
+		}
+		ratio := percent / 100
+		return &ratio, nil, nil
+	case entity.QuestModePerMinute:
+		if amount == "" {
+			return nil, nil, nil
+		}
+		n, err := strconv.Atoi(trimMinutes(amount))
+		if err != nil {
+			return nil, nil, ErrInvalidCompletionAmount
+		}
+		return nil, &n, nil
+	default:
+		if amount != "" {
+			return nil, nil, ErrInvalidCompletionAmount
+		}
+		return nil, nil, nil
+	}
+}
+
+func trimMinutes(arg string) string {
+	if number, ok := strings.CutSuffix(arg, "min"); ok {
+		return number
+	}
+	return strings.TrimSuffix(arg, "m")
+}
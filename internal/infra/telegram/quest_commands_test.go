@@ -0,0 +1,74 @@
+package telegram_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
+)
+
+func TestQuestListMarkup(t *testing.T) {
+	questID := "6f1c2b3a-9d8e-4f70-a1b2-c3d4e5f60718"
+	markup, err := telegram.QuestListMarkup([]*entity.Quest{
+		{ID: questID, Title: "Dishes", Mode: entity.QuestModeBinary},
+		{ID: "reading", Title: "Reading", Mode: entity.QuestModePerMinute},
+		{ID: "tidy", Title: "Tidy up", Mode: entity.QuestModePartial},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, markup.InlineKeyboard, 2, "per-minute quests get no button")
+	first, second := markup.InlineKeyboard[0][0], markup.InlineKeyboard[1][0]
+	assert.Equal(t, "✅ 1. Dishes", first.Text)
+	assert.Equal(t, "✅ 3. Tidy up", second.Text, "buttons keep the list numbering")
+	assert.Equal(t, telegram.QuestDoneUnique, first.Unique)
+
+	data := strings.Split(first.Data, "|")
+	require.Len(t, data, 2)
+	assert.Equal(t, questID, data[0])
+	assert.NotEqual(t, data[1], strings.Split(second.Data, "|")[1], "each button has its own key")
+	assert.LessOrEqual(t, len("\f"+first.Unique+"|"+first.Data), 64, "callback data fits Telegram's limit")
+}
+
+func TestIsCompletionAmount(t *testing.T) {
+	for _, arg := range []string{"25", "25m", "25min", "50%", "12.5%"} {
+		assert.True(t, telegram.IsCompletionAmount(arg), arg)
+	}
+	for _, arg := range []string{"dishes", "m", "%", "2.5", "abc%"} {
+		assert.False(t, telegram.IsCompletionAmount(arg), arg)
+	}
+}
+
+func TestParseCompletionAmount(t *testing.T) {
+	binary := &entity.Quest{Mode: entity.QuestModeBinary}
+	partial := &entity.Quest{Mode: entity.QuestModePartial}
+	perMinute := &entity.Quest{Mode: entity.QuestModePerMinute}
+
+	ratio, minutes, err := telegram.ParseCompletionAmount(binary, "")
+	require.NoError(t, err)
+	assert.Nil(t, ratio)
+	assert.Nil(t, minutes)
+	_, _, err = telegram.ParseCompletionAmount(binary, "30")
+	assert.ErrorIs(t, err, telegram.ErrInvalidCompletionAmount)
+
+	ratio, _, err = telegram.ParseCompletionAmount(partial, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, *ratio, "no amount means fully done")
+	ratio, _, err = telegram.ParseCompletionAmount(partial, "40%")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.4, *ratio, 1e-9)
+	ratio, _, err = telegram.ParseCompletionAmount(partial, "75")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, *ratio, 1e-9)
+
+	_, minutes, err = telegram.ParseCompletionAmount(perMinute, "25min")
+	require.NoError(t, err)
+	assert.Equal(t, 25, *minutes)
+	_, minutes, err = telegram.ParseCompletionAmount(perMinute, "")
+	require.NoError(t, err)
+	assert.Nil(t, minutes, "missing minutes are left for the quest service to reject")
+	_, _, err = telegram.ParseCompletionAmount(perMinute, "50%")
+	assert.ErrorIs(t, err, telegram.ErrInvalidCompletionAmount)
+}
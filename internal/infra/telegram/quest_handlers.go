@@ -0,0 +1,355 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"gopkg.in/telebot.v3"
+)
+
+func (h *Handlers) streak(c telebot.Context) error {
+	ctx := context.Background()
+	streaks, err := h.questService.ListStreaks(ctx, c.Sender().ID)
+	if err != nil {
+		log.Printf("Failed to list streaks: %v", err)
+		return c.Send("❌ Error getting streaks")
+	}
+
+	if len(streaks) == 0 {
+		return c.Send("🔥 No streaks yet. Complete a quest to start one!")
+	}
+
+	message := "🔥 Your streaks:\n"
+	for _, streak := range streaks {
+		message += fmt.Sprintf("- %s: %d (best %d)\n", streak.QuestTitle, streak.Current, streak.Best)
+	}
+	return c.Send(message)
+}
+
+// bestActiveStreak returns the longest streak that is still running, if any
+func bestActiveStreak(streaks []*usecase.StreakSummary) *usecase.StreakSummary {
+	var best *usecase.StreakSummary
+	for _, streak := range streaks {
+		if streak.Current > 0 && (best == nil || streak.Current > best.Current) {
+			best = streak
+		}
+	}
+	return best
+}
+
+func (h *Handlers) quests(c telebot.Context) error {
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Error getting quests"))
+	}
+
+	quests, err := h.questService.ListActiveQuests(ctx, c.Sender().ID, dungeon.ID)
+	if err != nil {
+		log.Printf("Failed to list quests: %v", err)
+		return c.Send("❌ Error getting quests")
+	}
+
+	if len(quests) == 0 {
+		return c.Send(fmt.Sprintf("📜 \"%s\" has no active quests. Admins can add one with /newquest.", dungeon.Title))
+	}
+
+	currency := h.dungeonCurrency(ctx, dungeon, c.Chat().ID)
+	message := fmt.Sprintf("📜 Quests in \"%s\":\n", dungeon.Title)
+	for i, quest := range quests {
+		message += fmt.Sprintf("%d. %s: %s\n", i+1, quest.Title, questRewardLabel(quest, currency))
+	}
+	message += "\nTap a quest once it's done, or send /done <number> [minutes|percent]."
+
+	markup, err := QuestListMarkup(quests)
+	if err != nil {
+		log.Printf("Failed to build quest buttons: %v", err)
+		return c.Send(message)
+	}
+	return c.Send(message, markup)
+}
+
+// questRewardLabel describes what completing a quest pays
+func questRewardLabel(quest *entity.Quest, currency string) string {
+	switch quest.Mode {
+	case entity.QuestModePartial:
+		return fmt.Sprintf("up to %s %s, send /done with a percentage", quest.PointsAward, currency)
+	case entity.QuestModePerMinute:
+		rate := "some"
+		if quest.RatePointsPerMin != nil {
+			rate = quest.RatePointsPerMin.String()
+		}
+		return fmt.Sprintf("%s %s per minute, send /done with the minutes", rate, currency)
+	default:
+		return fmt.Sprintf("%s %s", quest.PointsAward, currency)
+	}
+}
+
+func (h *Handlers) done(c telebot.Context) error {
+	args := c.Args()
+	if len(args) == 0 {
+		return c.Send("Usage: /done <quest number or name> [minutes|percent]")
+	}
+
+	ctx := context.Background()
+	userID := c.Sender().ID
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, userID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not complete the quest"))
+	}
+
+	// The last argument is an amount unless it is part of the quest's name
+	amount := ""
+	quest, err := h.questService.FindActiveQuest(ctx, userID, dungeon.ID, strings.Join(args, " "))
+	if errors.Is(err, ports.ErrQuestNotFound) && len(args) > 1 && IsCompletionAmount(args[len(args)-1]) {
+		amount = args[len(args)-1]
+		quest, err = h.questService.FindActiveQuest(ctx, userID, dungeon.ID, strings.Join(args[:len(args)-1], " "))
+	}
+	if err != nil {
+		return c.Send(questErrorMessage(err, "❌ Could not complete the quest"))
+	}
+
+	ratio, minutes, err := ParseCompletionAmount(quest, amount)
+	if err != nil {
+		return c.Send(questErrorMessage(err, "❌ Could not complete the quest"))
+	}
+
+	// Keying on the message completes the quest once even if Telegram
+	// delivers the command again
+	completion, err := h.questService.CompleteQuest(ctx, userID, quest.ID, usecase.CompleteQuestInput{
+		IdempotencyKey:  IdempotencyKey("done", c.Chat().ID, c.Message().ID),
+		CompletionRatio: ratio,
+		Minutes:         minutes,
+	})
+	if err != nil {
+		return c.Send(questErrorMessage(err, "❌ Could not complete the quest"))
+	}
+	return c.Send(completionMessage(quest, completion, h.dungeonCurrency(ctx, dungeon, c.Chat().ID)))
+}
+
+// questDone answers the Done buttons of /quests lists; each carries the quest
+// ID and a key unique to the button
+func (h *Handlers) questDone(c telebot.Context) error {
+	args := c.Args()
+	if len(args) != 2 {
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Unknown quest"})
+	}
+
+	ctx := context.Background()
+	quest, err := h.questService.GetQuest(ctx, args[0])
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: questErrorMessage(err, "❌ Could not complete the quest")})
+	}
+
+	// A tap completes PARTIAL quests in full
+	ratio, minutes, err := ParseCompletionAmount(quest, "")
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: questErrorMessage(err, "❌ Could not complete the quest")})
+	}
+
+	// Taps repeat within the quest's period; a new period counts as a new completion
+	period, err := quest.StreakPeriod(time.Now())
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: questErrorMessage(err, "❌ Could not complete the quest")})
+	}
+
+	completion, err := h.questService.CompleteQuest(ctx, c.Sender().ID, quest.ID, usecase.CompleteQuestInput{
+		IdempotencyKey:  IdempotencyKey(QuestDoneUnique, c.Sender().ID, args[1], period),
+		CompletionRatio: ratio,
+		Minutes:         minutes,
+	})
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: questErrorMessage(err, "❌ Could not complete the quest")})
+	}
+
+	// The list stays for everyone else in the chat, so only the presser hears back
+	// Without its dungeon the reply falls back to the chat's currency
+	dungeon, _ := h.dungeons.GetByID(ctx, quest.DungeonID)
+	currency := h.dungeonCurrency(ctx, dungeon, c.Chat().ID)
+	return c.Respond(&telebot.CallbackResponse{Text: completionMessage(quest, completion, currency)})
+}
+
+// completionMessage reports what a quest completion paid, and whether the
+// quest's daily cap cut the award short. Partial and per-minute completions
+// can earn nothing without the cap being involved.
+func completionMessage(quest *entity.Quest, completion *entity.QuestCompletion, currency string) string {
+	capped := false
+	if full, err := quest.CalculateAward(completion.CompletionRatio, completion.Minutes); err == nil {
+		capped = completion.AwardedPoints.Cmp(full) < 0
+	}
+
+	switch {
+	case capped && completion.AwardedPoints.IsZero():
+		return fmt.Sprintf("✅ %s done! Today's cap for it is reached, so no %s this time.", quest.Title, currency)
+	case capped:
+		return fmt.Sprintf("✅ %s done! +%s %s, which reaches today's cap for it.", quest.Title, completion.AwardedPoints, currency)
+	default:
+		return fmt.Sprintf("✅ %s done! +%s %s", quest.Title, completion.AwardedPoints, currency)
+	}
+}
+
+// questErrorMessage explains why a quest could not be found or completed,
+// falling back to failure for unexpected errors
+func questErrorMessage(err error, failure string) string {
+	var cooldown *entity.QuestCooldownError
+	switch {
+	case errors.Is(err, ports.ErrQuestNotFound):
+		return "❓ No active quest matches that. See /quests for the list."
+	case errors.Is(err, usecase.ErrAmbiguousQuest):
+		return "❓ Several quests match that name. Use its number from /quests."
+	case errors.As(err, &cooldown):
+		return fmt.Sprintf("⏳ Not yet, you can do this quest again in %s.", cooldown.Remaining.Round(time.Second))
+	case errors.Is(err, entity.ErrQuestNotActive):
+		return "💤 This quest is not active right now."
+	case errors.Is(err, usecase.ErrCompletionInProgress):
+		return "⏳ Already on it, one moment."
+	case errors.Is(err, entity.ErrMinutesRequired):
+		return "⏱️ How many minutes did it take? Send /done <quest> <minutes>."
+	case errors.Is(err, ErrInvalidCompletionAmount),
+		errors.Is(err, entity.ErrInvalidCompletionRatio),
+		errors.Is(err, entity.ErrInvalidMinutes),
+		errors.Is(err, entity.ErrMinutesBelowMinimum):
+		return fmt.Sprintf("❌ %v", err)
+	default:
+		log.Printf("Failed to complete quest: %v", err)
+		return failure
+	}
+}
+
+// newQuest asks admins for the quest's details one message at a time
+func (h *Handlers) newQuest(c telebot.Context) error {
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not start a new quest"))
+	}
+
+	if err := h.questService.CanCreateQuests(ctx, c.Sender().ID, dungeon.ID); err != nil {
+		if errors.Is(err, ports.ErrForbidden) {
+			return c.Send("🔒 Only the dungeon's owner and moderators can add quests.")
+		}
+		log.Printf("Failed to check quest permissions: %v", err)
+		return c.Send("❌ Could not start a new quest")
+	}
+
+	reply := h.questWizard.Start(c.Chat().ID, c.Sender().ID, dungeon.ID)
+	return c.Send(reply.Text, reply.Markup)
+}
+
+func (h *Handlers) cancel(c telebot.Context) error {
+	if !h.questWizard.Cancel(c.Chat().ID, c.Sender().ID) {
+		return c.Send("Nothing to cancel.")
+	}
+	return c.Send("🛑 New quest cancelled.")
+}
+
+// text takes plain messages as answers to a /newquest conversation
+func (h *Handlers) text(c telebot.Context) error {
+	// Unknown commands are not answers
+	if strings.HasPrefix(c.Text(), "/") {
+		return nil
+	}
+	reply, ok := h.questWizard.Answer(c.Chat().ID, c.Sender().ID, c.Text())
+	if !ok {
+		return nil
+	}
+	return h.continueQuestWizard(c, reply)
+}
+
+func (h *Handlers) questWizardAnswer(c telebot.Context) error {
+	reply, ok := h.questWizard.Answer(c.Chat().ID, c.Sender().ID, c.Data())
+	if !ok {
+		return c.Respond(&telebot.CallbackResponse{Text: "This question was for someone else, or it has expired."})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return h.continueQuestWizard(c, reply)
+}
+
+// continueQuestWizard sends the next question of a /newquest conversation, or
+// creates the quest once the last one is answered
+func (h *Handlers) continueQuestWizard(c telebot.Context, reply WizardReply) error {
+	if reply.Draft == nil {
+		return c.Send(reply.Text, reply.Markup)
+	}
+
+	ctx := context.Background()
+	draft := reply.Draft
+	dungeon, err := h.dungeons.GetByID(ctx, draft.DungeonID)
+	if err != nil {
+		log.Printf("Failed to find the new quest's dungeon: %v", err)
+		return c.Send("❌ Could not create the quest")
+	}
+
+	input := usecase.CreateQuestInput{
+		Title:         draft.Title,
+		Category:      draft.Category,
+		Mode:          draft.Mode,
+		PointsAward:   draft.Points,
+		StreakEnabled: true,
+		Status:        "active",
+		TimeZone:      dungeonTimeZone(dungeon),
+	}
+	if draft.Mode == entity.QuestModePerMinute {
+		input.PointsAward = valueobject.NewDecimal("0")
+		input.RatePointsPerMin = &draft.Points
+	}
+
+	quest, err := h.questService.CreateQuest(ctx, c.Sender().ID, draft.DungeonID, input)
+	if err != nil {
+		log.Printf("Failed to create quest: %v", err)
+		return c.Send(fmt.Sprintf("❌ Could not create the quest: %v", err))
+	}
+	return c.Send(fmt.Sprintf("🆕 Added \"%s\". Everyone can find it in /quests.", quest.Title))
+}
+
+func (h *Handlers) completeTimer(c telebot.Context) error {
+	result, err := h.timerService.CompleteQuestFromTimer(context.Background(), c.Sender().ID, c.Data())
+	if err != nil {
+		log.Printf("Failed to complete quest from timer: %v", err)
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Could not complete the quest"})
+	}
+
+	message := "✅ Quest completed!"
+	if result.Completion != nil {
+		message = fmt.Sprintf("✅ Quest completed! +%s points", result.Completion.AwardedPoints)
+	}
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit(message)
+}
+
+func (h *Handlers) extendTimer(c telebot.Context) error {
+	if _, err := h.timerService.ExtendTimer(context.Background(), c.Sender().ID, c.Data(), 5*time.Minute); err != nil {
+		log.Printf("Failed to extend timer: %v", err)
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Could not extend the timer"})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit("⏳ Added 5 more minutes. I'll ping you when they're up.")
+}
+
+func (h *Handlers) abandonTimer(c telebot.Context) error {
+	if _, err := h.timerService.CancelTimer(context.Background(), c.Sender().ID, c.Data()); err != nil {
+		log.Printf("Failed to abandon timer: %v", err)
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Could not abandon the timer"})
+	}
+
+	if err := c.Respond(); err != nil {
+		return err
+	}
+	return c.Edit("🛑 Timer abandoned.")
+}
@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// QuestWizardUnique identifies the choice buttons of /newquest. Each button
+// carries the chosen answer as its data.
+const QuestWizardUnique = "newquest_answer"
+
+const maxQuestTitleLength = 100
+
+var pointsPattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// QuestDraft is a quest described in a /newquest conversation
+type QuestDraft struct {
+	DungeonID string
+	Title     string
+	Mode      string
+	Points    valueobject.Decimal // The award, or the rate per minute of PER_MINUTE quests
+	Category  string
+}
+
+// WizardReply is the bot's next message in a /newquest conversation. Draft is
+// set once the last question was answered.
+type WizardReply struct {
+	Text   string
+	Markup *telebot.ReplyMarkup
+	Draft  *QuestDraft
+}
+
+type questWizardStep int
+
+const (
+	stepTitle questWizardStep = iota
+	stepMode
+	stepPoints
+	stepCategory
+)
+
+type questWizardKey struct {
+	chatID int64
+	userID int64
+}
+
+type questConversation struct {
+	draft     QuestDraft
+	step      questWizardStep
+	expiresAt time.Time
+}
+
+// QuestWizard walks dungeon admins through describing a quest one question
+// at a time. Conversations are kept per user and chat, in memory, and are
+// dropped after ttl without an answer.
+type QuestWizard struct {
+	clock ports.Clock
+	ttl   time.Duration
+
+	mu            sync.Mutex
+	conversations map[questWizardKey]*questConversation
+}
+
+func NewQuestWizard(clock ports.Clock, ttl time.Duration) *QuestWizard {
+	return &QuestWizard{
+		clock:         clock,
+		ttl:           ttl,
+		conversations: make(map[questWizardKey]*questConversation),
+	}
+}
+
+// Start begins a conversation for a quest in dungeonID, replacing any the
+// user had going in the chat
+func (w *QuestWizard) Start(chatID, userID int64, dungeonID string) WizardReply {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	conversation := &questConversation{draft: QuestDraft{DungeonID: dungeonID}}
+	w.conversations[questWizardKey{chatID, userID}] = w.touch(conversation)
+	return conversation.prompt()
+}
+
+// Cancel ends the user's conversation in the chat and reports whether there was one
+func (w *QuestWizard) Cancel(chatID, userID int64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, ok := w.conversation(questWizardKey{chatID, userID})
+	delete(w.conversations, questWizardKey{chatID, userID})
+	return ok
+}
+
+// Answer records the user's answer to the current question and returns the
+// next one. It returns false if the user has no conversation in the chat.
+func (w *QuestWizard) Answer(chatID, userID int64, answer string) (WizardReply, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := questWizardKey{chatID, userID}
+	conversation, ok := w.conversation(key)
+	if !ok {
+		return WizardReply{}, false
+	}
+
+	if problem := conversation.answer(strings.TrimSpace(answer)); problem != "" {
+		reply := conversation.prompt()
+		reply.Text = problem + "\n" + reply.Text
+		w.touch(conversation)
+		return reply, true
+	}
+
+	if conversation.step > stepCategory {
+		delete(w.conversations, key)
+		draft := conversation.draft
+		return WizardReply{Draft: &draft}, true
+	}
+	w.touch(conversation)
+	return conversation.prompt(), true
+}
+
+// conversation returns the live conversation at key, forgetting expired ones
+func (w *QuestWizard) conversation(key questWizardKey) (*questConversation, bool) {
+	conversation, ok := w.conversations[key]
+	if !ok {
+		return nil, false
+	}
+	if !w.clock.Now().Before(conversation.expiresAt) {
+		delete(w.conversations, key)
+		return nil, false
+	}
+	return conversation, true
+}
+
+func (w *QuestWizard) touch(conversation *questConversation) *questConversation {
+	conversation.expiresAt = w.clock.Now().Add(w.ttl)
+	return conversation
+}
+
+// answer applies an answer to the current step and moves on, or explains
+// what was wrong with it
+func (c *questConversation) answer(answer string) string {
+	switch c.step {
+	case stepTitle:
+		if answer == "" || utf8.RuneCountInString(answer) > maxQuestTitleLength {
+			return fmt.Sprintf("❌ The name must be between 1 and %d characters.", maxQuestTitleLength)
+		}
+		c.draft.Title = answer
+	case stepMode:
+		mode, ok := parseQuestMode(answer)
+		if !ok {
+			return "❌ Pick one of the options."
+		}
+		c.draft.Mode = mode
+	case stepPoints:
+		if !pointsPattern.MatchString(answer) {
+			return "❌ Send a positive number, like 10 or 2.5."
+		}
+		points := valueobject.NewDecimal(answer)
+		if !points.IsPositive() {
+			return "❌ Send a positive number, like 10 or 2.5."
+		}
+		c.draft.Points = points
+	case stepCategory:
+		category := strings.ToLower(answer)
+		if category != "daily" && category != "weekly" && category != "adhoc" {
+			return "❌ Pick one of the options."
+		}
+		c.draft.Category = category
+	}
+	c.step++
+	return ""
+}
+
+// prompt asks the question of the current step
+func (c *questConversation) prompt() WizardReply {
+	// Forcing a reply lets the answers through in groups where the bot only
+	// sees commands and replies to itself
+	forceReply := &telebot.ReplyMarkup{ForceReply: true, Selective: true}
+
+	switch c.step {
+	case stepTitle:
+		return WizardReply{Text: "📝 What's the new quest called? Send /cancel to stop.", Markup: forceReply}
+	case stepMode:
+		markup := &telebot.ReplyMarkup{}
+		markup.Inline(
+			markup.Row(markup.Data("✅ Done or not", QuestWizardUnique, entity.QuestModeBinary)),
+			markup.Row(markup.Data("📊 Partly done counts", QuestWizardUnique, entity.QuestModePartial)),
+			markup.Row(markup.Data("⏱️ Points per minute", QuestWizardUnique, entity.QuestModePerMinute)),
+		)
+		return WizardReply{Text: fmt.Sprintf("🎯 How is \"%s\" scored?", c.draft.Title), Markup: markup}
+	case stepPoints:
+		text := "🏅 How many points is it worth?"
+		switch c.draft.Mode {
+		case entity.QuestModePartial:
+			text = "🏅 How many points for doing all of it?"
+		case entity.QuestModePerMinute:
+			text = "🏅 How many points per minute?"
+		}
+		return WizardReply{Text: text, Markup: forceReply}
+	default:
+		markup := &telebot.ReplyMarkup{}
+		markup.Inline(markup.Row(
+			markup.Data("📅 Daily", QuestWizardUnique, "daily"),
+			markup.Data("🗓️ Weekly", QuestWizardUnique, "weekly"),
+			markup.Data("🎲 Any time", QuestWizardUnique, "adhoc"),
+		))
+		return WizardReply{Text: "🔁 How often does it come up?", Markup: markup}
+	}
+}
+
+// parseQuestMode accepts a mode's name as sent by its button or typed out
+func parseQuestMode(answer string) (string, bool) {
+	switch strings.ToUpper(strings.ReplaceAll(answer, " ", "_")) {
+	case entity.QuestModeBinary:
+		return entity.QuestModeBinary, true
+	case entity.QuestModePartial:
+		return entity.QuestModePartial, true
+	case entity.QuestModePerMinute, "MINUTES":
+		return entity.QuestModePerMinute, true
+	default:
+		return "", false
+	}
+}
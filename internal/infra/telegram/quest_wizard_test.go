@@ -0,0 +1,86 @@
+package telegram_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time                         { return c.now }
+func (c *fakeClock) Sleep(d time.Duration)                  { c.now = c.now.Add(d) }
+func (c *fakeClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (c *fakeClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+func TestQuestWizard(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	wizard := telegram.NewQuestWizard(clk, 10*time.Minute)
+
+	_, ok := wizard.Answer(1, 7, "Dishes")
+	assert.False(t, ok, "no conversation before /newquest")
+
+	reply := wizard.Start(1, 7, "dungeon-1")
+	assert.Contains(t, reply.Text, "called")
+	assert.True(t, reply.Markup.ForceReply)
+
+	_, ok = wizard.Answer(1, 8, "Dishes")
+	assert.False(t, ok, "conversations belong to one user")
+
+	reply, ok = wizard.Answer(1, 7, "  Dishes ")
+	require.True(t, ok)
+	require.Len(t, reply.Markup.InlineKeyboard, 3)
+	assert.Equal(t, telegram.QuestWizardUnique, reply.Markup.InlineKeyboard[0][0].Unique)
+
+	reply, _ = wizard.Answer(1, 7, "sometimes")
+	assert.Contains(t, reply.Text, "Pick one", "invalid answers repeat the question")
+
+	reply, _ = wizard.Answer(1, 7, entity.QuestModePerMinute)
+	assert.Contains(t, reply.Text, "per minute")
+
+	reply, _ = wizard.Answer(1, 7, "-3")
+	assert.Contains(t, reply.Text, "positive number")
+	reply, _ = wizard.Answer(1, 7, "0")
+	assert.Contains(t, reply.Text, "positive number")
+
+	reply, _ = wizard.Answer(1, 7, "2.5")
+	assert.Nil(t, reply.Draft)
+	assert.Contains(t, reply.Text, "How often")
+
+	reply, ok = wizard.Answer(1, 7, "daily")
+	require.True(t, ok)
+	require.NotNil(t, reply.Draft)
+	assert.Equal(t, "dungeon-1", reply.Draft.DungeonID)
+	assert.Equal(t, "Dishes", reply.Draft.Title)
+	assert.Equal(t, entity.QuestModePerMinute, reply.Draft.Mode)
+	assert.Equal(t, "2.5", reply.Draft.Points.String())
+	assert.Equal(t, "daily", reply.Draft.Category)
+
+	_, ok = wizard.Answer(1, 7, "again")
+	assert.False(t, ok, "the conversation ends with the draft")
+}
+
+func TestQuestWizard_CancelAndExpiry(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	wizard := telegram.NewQuestWizard(clk, 10*time.Minute)
+
+	assert.False(t, wizard.Cancel(1, 7))
+	wizard.Start(1, 7, "dungeon-1")
+	assert.True(t, wizard.Cancel(1, 7))
+	_, ok := wizard.Answer(1, 7, "Dishes")
+	assert.False(t, ok)
+
+	wizard.Start(1, 7, "dungeon-1")
+	clk.Sleep(9 * time.Minute)
+	_, ok = wizard.Answer(1, 7, "Dishes")
+	assert.True(t, ok, "answering keeps the conversation alive")
+	clk.Sleep(10 * time.Minute)
+	_, ok = wizard.Answer(1, 7, "BINARY")
+	assert.False(t, ok, "idle conversations expire")
+}
@@ -0,0 +1,308 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"gopkg.in/telebot.v3"
+)
+
+func (h *Handlers) shop(c telebot.Context) error {
+	ctx := context.Background()
+	user, err := h.ensureUser(ctx, c.Chat(), c.Sender())
+	if err != nil {
+		log.Printf("Failed to create user: %v", err)
+		return c.Send("❌ Failed to register user")
+	}
+
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, user.ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Error getting shop items"))
+	}
+
+	items, err := h.shopService.GetShopItems(ctx, dungeon.ID)
+	if err != nil {
+		return c.Send("❌ Error getting shop items")
+	}
+
+	if len(items) == 0 {
+		return c.Send("🛒 No items available in the shop right now.")
+	}
+
+	now := time.Now()
+	message := "🛍️ Available Items:\n"
+	for _, item := range items {
+		stockInfo := ""
+		if item.Stock != nil {
+			stockInfo = fmt.Sprintf(" (Stock: %d)", *item.Stock)
+		}
+		priceInfo := item.Price.String()
+		if item.OnSale(now) {
+			priceInfo = fmt.Sprintf("%s (sale, was %s)", item.SalePrice, item.Price)
+		}
+		message += fmt.Sprintf("- %s (%s): %s%s\n",
+			item.Name, item.Code, priceInfo, stockInfo)
+	}
+	return c.Send(message)
+}
+
+func (h *Handlers) buy(c telebot.Context) error {
+	if len(c.Args()) == 0 {
+		return c.Send("Usage: /buy <item_code>")
+	}
+
+	ctx := context.Background()
+	user, err := h.ensureUser(ctx, c.Chat(), c.Sender())
+	if err != nil {
+		log.Printf("Failed to create user: %v", err)
+		return c.Send("❌ Failed to register user")
+	}
+
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, user.ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Purchase failed"))
+	}
+
+	itemCode := c.Args()[0]
+	purchase, err := h.shopService.PurchaseItem(ctx, user.ID, dungeon.ID, itemCode, 1, "")
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Purchase failed: %v", err))
+	}
+
+	message := fmt.Sprintf("✅ Purchased %s for %s! (purchase #%d)",
+		purchase.ItemName, purchase.TotalCost, purchase.ID)
+	for _, adjustment := range purchase.PriceBreakdown {
+		message += fmt.Sprintf("\n• %s: %s", adjustment.Description, adjustment.Amount)
+	}
+	return c.Send(message)
+}
+
+func (h *Handlers) refund(c telebot.Context) error {
+	args := c.Args()
+	if len(args) == 0 || len(args) > 2 {
+		return c.Send("Usage: /refund <purchase_id> [quantity]")
+	}
+
+	purchaseID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return c.Send("Usage: /refund <purchase_id> [quantity]")
+	}
+
+	quantity := 0 // everything not refunded yet
+	if len(args) == 2 {
+		quantity, err = strconv.Atoi(args[1])
+		if err != nil || quantity <= 0 {
+			return c.Send("❌ Quantity must be a positive number")
+		}
+	}
+
+	ctx := context.Background()
+	purchase, err := h.shopService.RefundPurchase(ctx, c.Sender().ID, purchaseID, usecase.RefundInput{Quantity: quantity})
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Refund failed: %v", err))
+	}
+
+	return c.Send(fmt.Sprintf("↩️ Refunded %d of %d %s, %s returned in total",
+		purchase.RefundedQuantity, purchase.Quantity, purchase.ItemName, purchase.RefundedAmount))
+}
+
+func (h *Handlers) tiers(c telebot.Context) error {
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Error getting loyalty tiers"))
+	}
+
+	tiers, err := h.loyaltyService.ListTiers(ctx, c.Sender().ID, dungeon.ID)
+	if err != nil {
+		log.Printf("Failed to list loyalty tiers: %v", err)
+		return c.Send("❌ Error getting loyalty tiers")
+	}
+
+	if len(tiers) == 0 {
+		return c.Send("🏅 This dungeon has no loyalty tiers yet.")
+	}
+
+	message := "🏅 Loyalty tiers:\n"
+	for _, tier := range tiers {
+		message += fmt.Sprintf("- #%d %s: %g%% off after %d purchases\n",
+			tier.ID, tier.Name, tier.DiscountPercent, tier.MinPurchases)
+	}
+
+	standing, err := h.loyaltyService.Standing(ctx, c.Sender().ID, dungeon.ID)
+	if err == nil {
+		if standing.Tier != nil {
+			message += fmt.Sprintf("\nYou are %s with %d purchases.", standing.Tier.Name, standing.PurchaseCount)
+		} else {
+			message += fmt.Sprintf("\nYou have %d purchases.", standing.PurchaseCount)
+		}
+		if standing.Next != nil {
+			message += fmt.Sprintf(" %d more to reach %s.", standing.Next.MinPurchases-standing.PurchaseCount, standing.Next.Name)
+		}
+	}
+	return c.Send(message)
+}
+
+func (h *Handlers) addTier(c telebot.Context) error {
+	args := c.Args()
+	if len(args) < 3 {
+		return c.Send("Usage: /addtier <min_purchases> <discount_percent> <name>")
+	}
+
+	minPurchases, err := strconv.Atoi(args[0])
+	if err != nil {
+		return c.Send("❌ min_purchases must be a whole number")
+	}
+	discount, err := strconv.ParseFloat(strings.TrimSuffix(args[1], "%"), 64)
+	if err != nil {
+		return c.Send("❌ discount_percent must be a number")
+	}
+
+	ctx := context.Background()
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, c.Sender().ID, c.Chat().ID)
+	if err != nil {
+		return c.Send(noDungeonMessage(err, "❌ Could not add tier"))
+	}
+
+	tier := &entity.RewardTier{
+		DungeonID:       dungeon.ID,
+		Name:            strings.Join(args[2:], " "),
+		DiscountPercent: discount,
+		MinPurchases:    minPurchases,
+	}
+	if err := h.loyaltyService.CreateTier(ctx, c.Sender().ID, tier); err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not add tier: %v", err))
+	}
+
+	return c.Send(fmt.Sprintf("🏅 Added tier #%d %s: %g%% off after %d purchases",
+		tier.ID, tier.Name, tier.DiscountPercent, tier.MinPurchases))
+}
+
+func (h *Handlers) deleteTier(c telebot.Context) error {
+	args := c.Args()
+	if len(args) != 1 {
+		return c.Send("Usage: /deltier <tier_id>")
+	}
+
+	tierID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return c.Send("Usage: /deltier <tier_id>")
+	}
+
+	if err := h.loyaltyService.DeleteTier(context.Background(), c.Sender().ID, tierID); err != nil {
+		return c.Send(fmt.Sprintf("❌ Could not remove tier: %v", err))
+	}
+	return c.Send("🗑️ Tier removed")
+}
+
+func (h *Handlers) balance(c telebot.Context) error {
+	chatID := c.Chat().ID
+
+	ctx := context.Background()
+	user, err := h.ensureUser(ctx, c.Chat(), c.Sender())
+	if err != nil {
+		log.Printf("Failed to create user: %v", err)
+		return c.Send("❌ Failed to register user")
+	}
+
+	var message string
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, user.ID, chatID)
+	switch {
+	case err == nil:
+		balance, err := h.ledgerService.Balance(ctx, user.ID, dungeon.ID)
+		if err != nil {
+			log.Printf("Failed to get balance: %v", err)
+			return c.Send("❌ Error getting your balance")
+		}
+		message = fmt.Sprintf("💰 Your balance in %s: %s %s", dungeon.Title, balance, h.dungeonCurrency(ctx, dungeon, chatID))
+	case errors.Is(err, usecase.ErrSeveralDungeons):
+		dungeons, err := h.dungeonService.ListUserDungeons(ctx, user.ID)
+		if err != nil {
+			log.Printf("Failed to list dungeons: %v", err)
+			return c.Send("❌ Error getting your balance")
+		}
+		message = "💰 Your balances:"
+		for _, d := range dungeons {
+			message += fmt.Sprintf("\n- %s: %s %s", d.Dungeon.Title, d.Balance, h.dungeonCurrency(ctx, d.Dungeon, chatID))
+		}
+	case errors.Is(err, ports.ErrDungeonNotFound):
+		// Points earned before the user joined any dungeon
+		message = fmt.Sprintf("💰 Your balance: %s %s", user.Balance, h.dungeonCurrency(ctx, nil, chatID))
+	default:
+		return c.Send(noDungeonMessage(err, "❌ Error getting your balance"))
+	}
+
+	streaks, err := h.questService.ListStreaks(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to list streaks: %v", err)
+	} else if best := bestActiveStreak(streaks); best != nil {
+		message += fmt.Sprintf("\n🔥 Top streak: %s (%d, best %d)", best.QuestTitle, best.Current, best.Best)
+	}
+
+	return c.Send(message)
+}
+
+func (h *Handlers) history(c telebot.Context) error {
+	ctx := context.Background()
+	userID := c.Sender().ID
+
+	// Show the chat dungeon's wallet; without one, activity everywhere
+	message := "📒 Recent activity:\n"
+	var entries []*entity.LedgerEntry
+	dungeon, err := h.dungeonService.ChatDungeon(ctx, userID, c.Chat().ID)
+	if err == nil {
+		message = fmt.Sprintf("📒 Recent activity in %s:\n", dungeon.Title)
+		entries, err = h.ledgerService.WalletHistory(ctx, userID, dungeon.ID, 10)
+	} else {
+		entries, err = h.ledgerService.History(ctx, userID, 10)
+	}
+	if err != nil {
+		log.Printf("Failed to get ledger history: %v", err)
+		return c.Send("❌ Error getting your history")
+	}
+
+	if len(entries) == 0 {
+		return c.Send("📒 No points activity yet.")
+	}
+
+	for _, entry := range entries {
+		sign := "+"
+		if entry.IsDebit() {
+			sign = ""
+		}
+		line := fmt.Sprintf("%s %s%s %s", entry.CreatedAt.Format("Jan 2"), sign, entry.Amount, ledgerReasonLabel(entry.Reason))
+		if entry.Note != "" {
+			line += ": " + entry.Note
+		}
+		message += fmt.Sprintf("- %s (balance %s)\n", line, entry.BalanceAfter)
+	}
+	return c.Send(message)
+}
+
+// ledgerReasonLabel returns a human readable name for a ledger entry reason
+func ledgerReasonLabel(reason string) string {
+	switch reason {
+	case entity.LedgerReasonQuestCompletion:
+		return "quest"
+	case entity.LedgerReasonPurchase:
+		return "purchase"
+	case entity.LedgerReasonRefund:
+		return "refund"
+	case entity.LedgerReasonAdminAdjustment:
+		return "adjustment"
+	case entity.LedgerReasonTransfer:
+		return "transfer"
+	case entity.LedgerReasonOpeningBalance:
+		return "opening balance"
+	default:
+		return reason
+	}
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// Callback identifiers of the buttons attached to expiry notifications. Each
+// button carries the timer ID as its data.
+const (
+	TimerCompleteUnique = "timer_complete"
+	TimerExtendUnique   = "timer_extend"
+	TimerAbandonUnique  = "timer_abandon"
+)
+
+// Sender sends Telegram messages; *telebot.Bot implements it
+type Sender interface {
+	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+}
+
+// TimerNotifier sends countdown expiry notifications as private Telegram messages
+type TimerNotifier struct {
+	sender Sender
+}
+
+func NewTimerNotifier(sender Sender) *TimerNotifier {
+	return &TimerNotifier{sender: sender}
+}
+
+// NotifyTimerExpired messages the timer's owner with buttons to complete the
+// quest, add five minutes or abandon the timer. The returned ID has the form
+// "<chat id>:<message id>".
+func (n *TimerNotifier) NotifyTimerExpired(ctx context.Context, timer *entity.Timer, quest *entity.Quest) (string, error) {
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Complete quest", TimerCompleteUnique, timer.ID),
+		markup.Data("⏳ +5 min", TimerExtendUnique, timer.ID),
+		markup.Data("🛑 Abandon", TimerAbandonUnique, timer.ID),
+	))
+
+	text := fmt.Sprintf("⏰ Time's up for \"%s\"!\nHow did it go?", quest.Title)
+	msg, err := n.sender.Send(&telebot.User{ID: timer.UserID}, text, markup)
+	if err != nil {
+		return "", fmt.Errorf("failed to send timer notification: %w", err)
+	}
+
+	return fmt.Sprintf("%d:%d", msg.Chat.ID, msg.ID), nil
+}
+
+// Compile-time check that TimerNotifier implements ports.TimerNotifier
+var _ ports.TimerNotifier = (*TimerNotifier)(nil)
@@ -0,0 +1,54 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/telegram"
+	"gopkg.in/telebot.v3"
+)
+
+type fakeSender struct {
+	to   telebot.Recipient
+	what interface{}
+	opts []interface{}
+	err  error
+}
+
+func (s *fakeSender) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.to, s.what, s.opts = to, what, opts
+	return &telebot.Message{ID: 42, Chat: &telebot.Chat{ID: 7}}, nil
+}
+
+func TestTimerNotifier(t *testing.T) {
+	ctx := context.Background()
+	timer := &entity.Timer{ID: "timer-1", UserID: 7}
+	quest := &entity.Quest{ID: "quest-1", Title: "Deep work"}
+
+	sender := &fakeSender{}
+	id, err := telegram.NewTimerNotifier(sender).NotifyTimerExpired(ctx, timer, quest)
+	require.NoError(t, err)
+	assert.Equal(t, "7:42", id)
+	assert.Equal(t, "7", sender.to.Recipient())
+	assert.Contains(t, sender.what, "Deep work")
+
+	require.Len(t, sender.opts, 1)
+	markup := sender.opts[0].(*telebot.ReplyMarkup)
+	require.Len(t, markup.InlineKeyboard, 1)
+	var uniques []string
+	for _, button := range markup.InlineKeyboard[0] {
+		uniques = append(uniques, button.Unique)
+		assert.Contains(t, button.Data, "timer-1")
+	}
+	assert.Equal(t, []string{telegram.TimerCompleteUnique, telegram.TimerExtendUnique, telegram.TimerAbandonUnique}, uniques)
+
+	_, err = telegram.NewTimerNotifier(&fakeSender{err: errors.New("blocked")}).NotifyTimerExpired(ctx, timer, quest)
+	assert.Error(t, err)
+}
@@ -4,18 +4,36 @@ import "errors"
 
 // Repository errors
 var (
-	ErrUserAlreadyExists      = errors.New("user already exists")
-	ErrUserNotFound           = errors.New("user not found")
-	ErrTaskNotFound           = errors.New("task not found")
-	ErrTimerNotFound          = errors.New("timer not found")
-	ErrChatConfigNotFound     = errors.New("chat config not found")
-	ErrShopItemNotFound       = errors.New("shop item not found")
-	ErrPurchaseNotFound       = errors.New("purchase not found")
-	ErrInsufficientStock      = errors.New("insufficient stock")
-	ErrInsufficientFunds      = errors.New("insufficient funds")
-	ErrIdempotencyKeyExists   = errors.New("idempotency key already exists")
-	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
-	ErrDuplicateRequest       = errors.New("duplicate request detected")
-	ErrDiscountTierExists     = errors.New("discount tier already exists")
-	ErrDiscountTierNotFound   = errors.New("discount tier not found")
+	ErrUserAlreadyExists       = errors.New("user already exists")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrTaskNotFound            = errors.New("task not found")
+	ErrTimerNotFound           = errors.New("timer not found")
+	ErrChatConfigNotFound      = errors.New("chat config not found")
+	ErrDungeonNotFound         = errors.New("dungeon not found")
+	ErrDungeonMemberNotFound   = errors.New("dungeon member not found")
+	ErrDungeonInviteNotFound   = errors.New("dungeon invite not found")
+	ErrJoinRequestNotFound     = errors.New("join request not found")
+	ErrQuestNotFound           = errors.New("quest not found")
+	ErrQuestCompletionNotFound = errors.New("quest completion not found")
+	ErrShopItemNotFound        = errors.New("shop item not found")
+	ErrShopItemCodeExists      = errors.New("a shop item with this code already exists")
+	ErrPurchaseNotFound        = errors.New("purchase not found")
+	ErrMemberPriceNotFound     = errors.New("member price not found")
+	ErrInsufficientStock       = errors.New("insufficient stock")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrIdempotencyKeyExists    = errors.New("idempotency key already exists")
+	ErrIdempotencyKeyNotFound  = errors.New("idempotency key not found")
+	ErrDuplicateRequest        = errors.New("duplicate request detected")
+	ErrDiscountTierExists      = errors.New("discount tier already exists")
+	ErrDiscountTierNotFound    = errors.New("discount tier not found")
+	ErrRewardTierNotFound      = errors.New("reward tier not found")
+	ErrLoyaltyStatusNotFound   = errors.New("loyalty status not found")
+	ErrQuestStreakNotFound     = errors.New("quest streak not found")
+	ErrTimerNotificationTaken  = errors.New("timer notification was changed by someone else")
+)
+
+// Authorization errors
+var (
+	// ErrForbidden is wrapped by every error for a user acting beyond their role
+	ErrForbidden = errors.New("forbidden")
 )
@@ -9,13 +9,45 @@ import (
 )
 
 type UserRepository interface {
+	// Create returns ErrUserAlreadyExists when the ID is taken
 	Create(ctx context.Context, user *entity.User) error
 	FindByID(ctx context.Context, id int64) (*entity.User, error)
+	// Lock keeps other transactions from locking the user until the current
+	// one ends, so checks against the user's history stay true until commit.
+	// It returns ErrUserNotFound for unknown users.
+	Lock(ctx context.Context, id int64) error
 	FindByChatID(ctx context.Context, chatID int64) ([]*entity.User, error)
-	UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) error
+	FindAll(ctx context.Context) ([]*entity.User, error)
+	// UpdateBalance adjusts the cached total balance and returns the new value.
+	// Use LedgerService instead of calling it directly so every change is recorded.
+	UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) (valueobject.Decimal, error)
 	Delete(ctx context.Context, id int64) error
 }
 
+type LedgerRepository interface {
+	Append(ctx context.Context, entry *entity.LedgerEntry) error
+	// ListByUser returns a user's entries, newest first
+	ListByUser(ctx context.Context, userID int64, limit int) ([]*entity.LedgerEntry, error)
+	// ListByWallet returns a user's entries in one dungeon, newest first
+	ListByWallet(ctx context.Context, userID int64, dungeonID string, limit int) ([]*entity.LedgerEntry, error)
+	// Balances returns the sum of all entries per user
+	Balances(ctx context.Context) (map[int64]valueobject.Decimal, error)
+	// WalletBalances returns the sum of the entries in each wallet that has any
+	WalletBalances(ctx context.Context) ([]*entity.Wallet, error)
+}
+
+type WalletRepository interface {
+	// Get returns a wallet, or an empty one if the user has no points there yet
+	Get(ctx context.Context, userID int64, dungeonID string) (*entity.Wallet, error)
+	// ListByUser returns the user's wallets ordered by dungeon ID
+	ListByUser(ctx context.Context, userID int64) ([]*entity.Wallet, error)
+	FindAll(ctx context.Context) ([]*entity.Wallet, error)
+	// UpdateBalance adjusts a wallet's cached balance, creating the wallet if
+	// needed, and returns the new value. Use LedgerService instead of calling
+	// it directly so every change is recorded.
+	UpdateBalance(ctx context.Context, userID int64, dungeonID string, delta valueobject.Decimal) (valueobject.Decimal, error)
+}
+
 type QuestRepository interface {
 	Create(ctx context.Context, quest *entity.Quest) error
 	GetByID(ctx context.Context, questID string) (*entity.Quest, error)
@@ -25,35 +57,95 @@ type QuestRepository interface {
 }
 
 type QuestCompletionRepository interface {
+	// Insert returns ErrIdempotencyKeyExists when another completion was
+	// recorded under the same non-empty idempotency key
 	Insert(ctx context.Context, completion *entity.QuestCompletion) error
+	// LastForUser returns the user's most recent completion of the quest, or
+	// ErrQuestCompletionNotFound when there is none
 	LastForUser(ctx context.Context, userID int64, questID string) (*entity.QuestCompletion, error)
 	SumAwardedForUserOnDay(ctx context.Context, userID int64, questID string, day time.Time, tz string) (valueobject.Decimal, error)
 }
 
+type QuestStreakRepository interface {
+	Get(ctx context.Context, userID int64, questID string) (*entity.QuestStreak, error)
+	Upsert(ctx context.Context, streak *entity.QuestStreak) error
+	ListByUser(ctx context.Context, userID int64) ([]*entity.QuestStreak, error)
+}
+
 type DungeonRepository interface {
 	Create(ctx context.Context, dungeon *entity.Dungeon) error
 	GetByID(ctx context.Context, dungeonID string) (*entity.Dungeon, error)
+	// FindByTelegramChatID returns the dungeon linked to a Telegram chat
+	FindByTelegramChatID(ctx context.Context, chatID int64) (*entity.Dungeon, error)
 	ListByAdmin(ctx context.Context, userID int64) ([]*entity.Dungeon, error)
+	// Update saves the title, admin, chat link and time zone, or returns ErrDungeonNotFound
+	Update(ctx context.Context, dungeon *entity.Dungeon) error
 }
 
 type DungeonMemberRepository interface {
-	Add(ctx context.Context, dungeonID string, userID int64) error
+	// Add makes the user a member with the given role. Existing members keep
+	// their role and join time.
+	Add(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error
+	// Get returns a membership, or ErrDungeonMemberNotFound
+	Get(ctx context.Context, dungeonID string, userID int64) (*entity.DungeonMember, error)
+	// SetRole changes a member's role, or returns ErrDungeonMemberNotFound
+	SetRole(ctx context.Context, dungeonID string, userID int64, role entity.DungeonRole) error
+	// Remove ends a membership, or returns ErrDungeonMemberNotFound
+	Remove(ctx context.Context, dungeonID string, userID int64) error
+	// List returns the dungeon's memberships ordered by user ID
+	List(ctx context.Context, dungeonID string) ([]*entity.DungeonMember, error)
+	// ListByUser returns the user's memberships, longest-standing first
+	ListByUser(ctx context.Context, userID int64) ([]*entity.DungeonMember, error)
 	ListUsers(ctx context.Context, dungeonID string) ([]int64, error)
 	IsMember(ctx context.Context, dungeonID string, userID int64) (bool, error)
 }
 
+type DungeonInviteRepository interface {
+	Create(ctx context.Context, invite *entity.DungeonInvite) error
+	// GetByCode returns an invite, or ErrDungeonInviteNotFound
+	GetByCode(ctx context.Context, code string) (*entity.DungeonInvite, error)
+	// ListByDungeon returns the dungeon's invites, newest first
+	ListByDungeon(ctx context.Context, dungeonID string) ([]*entity.DungeonInvite, error)
+	// Update saves the use count, or returns ErrDungeonInviteNotFound
+	Update(ctx context.Context, invite *entity.DungeonInvite) error
+	// Delete revokes an invite, or returns ErrDungeonInviteNotFound
+	Delete(ctx context.Context, code string) error
+}
+
+type JoinRequestRepository interface {
+	// Create stores the request and sets its ID
+	Create(ctx context.Context, request *entity.JoinRequest) error
+	// GetByID returns a request, or ErrJoinRequestNotFound
+	GetByID(ctx context.Context, id int64) (*entity.JoinRequest, error)
+	// FindPending returns the user's undecided request to join the dungeon,
+	// or ErrJoinRequestNotFound
+	FindPending(ctx context.Context, dungeonID string, userID int64) (*entity.JoinRequest, error)
+	// ListPending returns the dungeon's undecided requests, oldest first
+	ListPending(ctx context.Context, dungeonID string) ([]*entity.JoinRequest, error)
+	// Update saves the decision, or returns ErrJoinRequestNotFound
+	Update(ctx context.Context, request *entity.JoinRequest) error
+}
+
 type ChatConfigRepository interface {
 	Create(ctx context.Context, config *entity.ChatConfig) error
+	// FindByChatID returns ErrChatConfigNotFound for chats still on the defaults
 	FindByChatID(ctx context.Context, chatID int64) (*entity.ChatConfig, error)
 	Update(ctx context.Context, config *entity.ChatConfig) error
 }
 
+// ShopItemRepository stores the items of dungeon shops. Codes are unique per
+// dungeon; Create and Update return ErrShopItemCodeExists otherwise.
 type ShopItemRepository interface {
 	Create(ctx context.Context, item *entity.ShopItem) error
 	FindByID(ctx context.Context, id int64) (*entity.ShopItem, error)
-	FindByCode(ctx context.Context, chatID int64, code string) (*entity.ShopItem, error)
-	FindByChatID(ctx context.Context, chatID int64) ([]*entity.ShopItem, error)
+	FindByCode(ctx context.Context, dungeonID string, code string) (*entity.ShopItem, error)
+	FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error)
 	Update(ctx context.Context, item *entity.ShopItem) error
+	// AdjustStock adds delta to a limited item's stock in a single atomic
+	// change and leaves unlimited items alone. It returns ErrInsufficientStock
+	// when the stock would drop below zero and ErrShopItemNotFound for
+	// unknown items.
+	AdjustStock(ctx context.Context, id int64, delta int) error
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -62,6 +154,14 @@ type PurchaseRepository interface {
 	FindByID(ctx context.Context, id int64) (*entity.Purchase, error)
 	FindByUserID(ctx context.Context, userID int64) ([]*entity.Purchase, error)
 	FindByItemID(ctx context.Context, itemID int64) ([]*entity.Purchase, error)
+	Update(ctx context.Context, purchase *entity.Purchase) error
+}
+
+// MemberPriceRepository stores per-user price overrides for shop items
+type MemberPriceRepository interface {
+	Set(ctx context.Context, price *entity.MemberPrice) error
+	Find(ctx context.Context, itemID, userID int64) (*entity.MemberPrice, error)
+	Delete(ctx context.Context, itemID, userID int64) error
 }
 
 type UUIDGenerator interface {
@@ -72,6 +172,8 @@ type Scheduler interface {
 	ScheduleRecurringTask(ctx context.Context, task *entity.Quest) error
 	CancelScheduledTask(ctx context.Context, taskID string) error
 	GetNextOccurrence(ctx context.Context, taskID string) (time.Time, error)
+	// Tick persists occurrences that became due by now and closes out overdue ones
+	Tick(ctx context.Context, now time.Time) error
 }
 
 type TimerRepository interface {
@@ -80,7 +182,20 @@ type TimerRepository interface {
 	FindByUser(ctx context.Context, userID int64) ([]*entity.Timer, error)
 	FindByTask(ctx context.Context, taskID string) ([]*entity.Timer, error)
 	FindActiveByUser(ctx context.Context, userID int64) ([]*entity.Timer, error)
+	FindUsersWithActiveTimers(ctx context.Context) ([]int64, error)
 	Update(ctx context.Context, timer *entity.Timer) error
+	// ClaimExpiry claims the expiry notification of a running timer before it
+	// is sent: it sets the notification ID to claim, stamps the claim with now
+	// and counts the attempt. Expiries nobody claimed yet can be claimed, and
+	// so can expiries last claimed at retryBefore or earlier, whose send failed
+	// or whose process stopped. It returns the attempts made so far, or
+	// ErrTimerNotificationTaken.
+	ClaimExpiry(ctx context.Context, timerID, claim string, now, retryBefore time.Time) (int, error)
+	// SettleExpiry ends a claim the running timer still holds. A notification
+	// ID records the outcome for good; nil leaves the expiry to be claimed
+	// again after the retry delay. It returns ErrTimerNotificationTaken when
+	// the claim is gone.
+	SettleExpiry(ctx context.Context, timerID, claim string, notificationID *string) error
 	Delete(ctx context.Context, id string) error
 	BulkUpdate(ctx context.Context, timers []*entity.Timer) error
 }
@@ -104,10 +219,18 @@ type RewardTierRepository interface {
 	Create(ctx context.Context, tier *entity.RewardTier) error
 	FindByID(ctx context.Context, id int64) (*entity.RewardTier, error)
 	FindAll(ctx context.Context) ([]*entity.RewardTier, error)
+	FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.RewardTier, error)
 	Update(ctx context.Context, tier *entity.RewardTier) error
 	Delete(ctx context.Context, id int64) error
 }
 
+// LoyaltyStatusRepository stores the loyalty tier each user has reached per dungeon
+type LoyaltyStatusRepository interface {
+	Find(ctx context.Context, userID int64, dungeonID string) (*entity.LoyaltyStatus, error)
+	// Save creates or replaces the status
+	Save(ctx context.Context, status *entity.LoyaltyStatus) error
+}
+
 type DiscountTierRepository interface {
 	Create(ctx context.Context, tier *entity.DiscountTier) error
 	FindByID(ctx context.Context, id int64) (*entity.DiscountTier, error)
@@ -117,9 +240,16 @@ type DiscountTierRepository interface {
 }
 
 type IdempotencyRepository interface {
+	// Create returns ErrIdempotencyKeyExists when the key is taken
 	Create(ctx context.Context, key *entity.IdempotencyKey) error
+	// Claim atomically records key as pending when it is new or its earlier
+	// attempt failed or expired. It returns ErrIdempotencyKeyExists while
+	// another attempt holds the key or after it completed.
+	Claim(ctx context.Context, key *entity.IdempotencyKey) error
+	// FindByKey returns ErrIdempotencyKeyNotFound for unknown and expired keys
 	FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error)
 	Update(ctx context.Context, key *entity.IdempotencyKey) error
 	DeleteExpired(ctx context.Context) error
+	// Purge deletes keys created before olderThan, expired or not
 	Purge(ctx context.Context, olderThan time.Time) error
 }
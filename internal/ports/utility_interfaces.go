@@ -3,6 +3,8 @@ package ports
 import (
 	"context"
 	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 )
 
 // Clock provides time-related functionality that can be mocked for testing
@@ -18,3 +20,31 @@ type ContextProvider interface {
 	WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc)
 	WithCancel(parent context.Context) (context.Context, context.CancelFunc)
 }
+
+// LeaderElector decides which of several running replicas performs singleton work
+type LeaderElector interface {
+	// TryAcquire reports whether this process is the leader, taking leadership if it is free
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release gives up leadership if this process holds it
+	Release(ctx context.Context) error
+}
+
+// TimerNotifier tells users about their timers
+type TimerNotifier interface {
+	// NotifyTimerExpired tells the user their countdown reached zero and returns the ID of the sent message
+	NotifyTimerExpired(ctx context.Context, timer *entity.Timer, quest *entity.Quest) (string, error)
+}
+
+// LoyaltyNotifier tells users about their loyalty tiers
+type LoyaltyNotifier interface {
+	// NotifyTierReached tells the user they reached a new loyalty tier
+	NotifyTierReached(ctx context.Context, user *entity.User, tier *entity.RewardTier) error
+}
+
+// DungeonNotifier tells users about joining dungeons
+type DungeonNotifier interface {
+	// NotifyJoinRequest asks a reviewer to approve or deny a join request
+	NotifyJoinRequest(ctx context.Context, reviewerID int64, dungeon *entity.Dungeon, request *entity.JoinRequest) error
+	// NotifyJoinDecided tells the requester whether they were let in
+	NotifyJoinDecided(ctx context.Context, dungeon *entity.Dungeon, request *entity.JoinRequest) error
+}
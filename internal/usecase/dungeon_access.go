@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+var ErrNotDungeonMember = fmt.Errorf("%w: you are not a member of this dungeon", ports.ErrForbidden)
+
+// dungeonAccess looks up what a user may do in a dungeon. Services that act
+// on behalf of dungeon members embed it.
+type dungeonAccess struct {
+	dungeonRepo ports.DungeonRepository
+	memberRepo  ports.DungeonMemberRepository
+}
+
+// role returns the dungeon and actorID's role in it. The admin is always the
+// owner; users outside the dungeon get ErrNotDungeonMember.
+func (a dungeonAccess) role(ctx context.Context, actorID int64, dungeonID string) (*entity.Dungeon, entity.DungeonRole, error) {
+	dungeon, err := a.dungeonRepo.GetByID(ctx, dungeonID)
+	if err != nil {
+		return nil, "", err
+	}
+	if dungeon.AdminUserID == actorID {
+		return dungeon, entity.DungeonRoleOwner, nil
+	}
+
+	member, err := a.memberRepo.Get(ctx, dungeonID, actorID)
+	if errors.Is(err, ports.ErrDungeonMemberNotFound) {
+		return nil, "", ErrNotDungeonMember
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to check membership: %w", err)
+	}
+	if member.Role == entity.DungeonRoleOwner {
+		// Only the admin owns a dungeon; a stale owner row is a plain member
+		return dungeon, entity.DungeonRoleMember, nil
+	}
+	return dungeon, member.Role, nil
+}
+
+// authorize returns the dungeon if actorID's role grants the permission
+func (a dungeonAccess) authorize(ctx context.Context, actorID int64, dungeonID string, permission entity.DungeonPermission) (*entity.Dungeon, error) {
+	dungeon, role, err := a.role(ctx, actorID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.Can(permission) {
+		return nil, fmt.Errorf("%w: a %s cannot %s", ports.ErrForbidden, role, permission)
+	}
+	return dungeon, nil
+}
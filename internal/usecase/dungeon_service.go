@@ -2,33 +2,64 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
+var (
+	ErrInvalidDungeonRole  = errors.New("role must be moderator or member")
+	ErrOwnerRoleFixed      = errors.New("the owner's role only changes by transferring ownership")
+	ErrAlreadyOwner        = errors.New("user already owns this dungeon")
+	ErrAlreadyMember       = errors.New("you are already a member of this dungeon")
+	ErrOwnerCannotLeave    = errors.New("the owner must transfer ownership before leaving")
+	ErrOwnerNotRemovable   = fmt.Errorf("%w: the owner cannot be removed", ports.ErrForbidden)
+	ErrModeratorsOwnerOnly = fmt.Errorf("%w: only the owner can remove moderators", ports.ErrForbidden)
+	ErrChatAlreadyLinked   = errors.New("this chat is already linked to another dungeon")
+	ErrSeveralDungeons     = errors.New("you are in several dungeons, send the command in the dungeon's group")
+	ErrInvalidTimeZone     = errors.New("unknown time zone, use an IANA name such as Europe/Berlin")
+)
+
+// DungeonService manages dungeons and their members. What a member may do is
+// decided by their role, see entity.DungeonRole.
 type DungeonService struct {
-	dungeonRepo ports.DungeonRepository
-	memberRepo  ports.DungeonMemberRepository
-	userRepo    ports.UserRepository
-	uuidGen     ports.UUIDGenerator
-	txManager   ports.TxManager
+	dungeonAccess
+	inviteRepo      ports.DungeonInviteRepository
+	joinRequestRepo ports.JoinRequestRepository
+	userRepo        ports.UserRepository
+	ledger          *LedgerService
+	notifier        ports.DungeonNotifier
+	uuidGen         ports.UUIDGenerator
+	txManager       ports.TxManager
 }
 
+// NewDungeonService creates a DungeonService. A nil notifier leaves join
+// requests to be found with ListJoinRequests.
 func NewDungeonService(
 	dungeonRepo ports.DungeonRepository,
 	memberRepo ports.DungeonMemberRepository,
+	inviteRepo ports.DungeonInviteRepository,
+	joinRequestRepo ports.JoinRequestRepository,
 	userRepo ports.UserRepository,
+	ledger *LedgerService,
+	notifier ports.DungeonNotifier,
 	uuidGen ports.UUIDGenerator,
 	txManager ports.TxManager,
 ) *DungeonService {
 	return &DungeonService{
-		dungeonRepo: dungeonRepo,
-		memberRepo:  memberRepo,
-		userRepo:    userRepo,
-		uuidGen:     uuidGen,
-		txManager:   txManager,
+		dungeonAccess:   dungeonAccess{dungeonRepo: dungeonRepo, memberRepo: memberRepo},
+		inviteRepo:      inviteRepo,
+		joinRequestRepo: joinRequestRepo,
+		userRepo:        userRepo,
+		ledger:          ledger,
+		notifier:        notifier,
+		uuidGen:         uuidGen,
+		txManager:       txManager,
 	}
 }
 
@@ -45,17 +76,82 @@ func (s *DungeonService) CreateDungeon(ctx context.Context, adminUserID int64, t
 		Title:          title,
 		AdminUserID:    adminUserID,
 		TelegramChatID: telegramChatID,
+		TimeZone:       "UTC",
 		CreatedAt:      time.Now(),
 	}
 
-	// Create dungeon
-	err = s.dungeonRepo.Create(ctx, dungeon)
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if telegramChatID != nil {
+			if err := s.checkChatFree(ctx, *telegramChatID, dungeon.ID); err != nil {
+				return err
+			}
+		}
+		if err := s.dungeonRepo.Create(ctx, dungeon); err != nil {
+			return err
+		}
+		// The admin is the first member and owns the dungeon
+		return s.memberRepo.Add(ctx, dungeon.ID, adminUserID, entity.DungeonRoleOwner)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Add admin as first member
-	err = s.memberRepo.Add(ctx, dungeon.ID, adminUserID)
+	return dungeon, nil
+}
+
+// ListOwnedDungeons returns the dungeons the user owns, oldest first
+func (s *DungeonService) ListOwnedDungeons(ctx context.Context, userID int64) ([]*entity.Dungeon, error) {
+	return s.dungeonRepo.ListByAdmin(ctx, userID)
+}
+
+// UserDungeon is a dungeon the user belongs to, with their role and wallet
+// balance in it
+type UserDungeon struct {
+	Dungeon *entity.Dungeon
+	Role    entity.DungeonRole
+	Balance valueobject.Decimal
+}
+
+// ListUserDungeons returns the dungeons the user is a member of, in the order
+// they joined them
+func (s *DungeonService) ListUserDungeons(ctx context.Context, userID int64) ([]*UserDungeon, error) {
+	memberships, err := s.memberRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+
+	dungeons := make([]*UserDungeon, 0, len(memberships))
+	for _, membership := range memberships {
+		dungeon, err := s.dungeonRepo.GetByID(ctx, membership.DungeonID)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := s.ledger.Balance(ctx, userID, membership.DungeonID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance: %w", err)
+		}
+		dungeons = append(dungeons, &UserDungeon{Dungeon: dungeon, Role: membership.Role, Balance: balance})
+	}
+	return dungeons, nil
+}
+
+// LinkChat makes chatID the dungeon's Telegram group, replacing any group it
+// was linked to before
+func (s *DungeonService) LinkChat(ctx context.Context, actorID int64, dungeonID string, chatID int64) (*entity.Dungeon, error) {
+	var dungeon *entity.Dungeon
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		dungeon, err = s.authorize(ctx, actorID, dungeonID, entity.PermissionLinkChats)
+		if err != nil {
+			return err
+		}
+		if err := s.checkChatFree(ctx, chatID, dungeonID); err != nil {
+			return err
+		}
+
+		dungeon.TelegramChatID = &chatID
+		return s.dungeonRepo.Update(ctx, dungeon)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -63,38 +159,435 @@ func (s *DungeonService) CreateDungeon(ctx context.Context, adminUserID int64, t
 	return dungeon, nil
 }
 
-func (s *DungeonService) AddMember(ctx context.Context, adminUserID int64, dungeonID string, userID int64) error {
-	// Verify admin user exists and is the dungeon admin
-	dungeon, err := s.dungeonRepo.GetByID(ctx, dungeonID)
+// SetTimeZone changes the IANA zone the dungeon's new quests start in.
+// Existing quests keep their zone.
+func (s *DungeonService) SetTimeZone(ctx context.Context, actorID int64, dungeonID, timeZone string) (*entity.Dungeon, error) {
+	// LoadLocation also accepts "" and "Local", which are not zones
+	if timeZone == "" || timeZone == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	var dungeon *entity.Dungeon
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		dungeon, err = s.authorize(ctx, actorID, dungeonID, entity.PermissionCreateQuests)
+		if err != nil {
+			return err
+		}
+
+		dungeon.TimeZone = timeZone
+		return s.dungeonRepo.Update(ctx, dungeon)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if dungeon.AdminUserID != adminUserID {
-		return ports.ErrUserNotFound // Using this error for simplicity
+	return dungeon, nil
+}
+
+// checkChatFree returns ErrChatAlreadyLinked if a dungeon other than
+// dungeonID is linked to chatID
+func (s *DungeonService) checkChatFree(ctx context.Context, chatID int64, dungeonID string) error {
+	linked, err := s.dungeonRepo.FindByTelegramChatID(ctx, chatID)
+	if errors.Is(err, ports.ErrDungeonNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check chat link: %w", err)
+	}
+	if linked.ID != dungeonID {
+		return ErrChatAlreadyLinked
+	}
+	return nil
+}
+
+// EnrollChatMember makes userID a member of the dungeon linked to chatID, so
+// everyone in a linked group takes part. Existing members keep their role;
+// chats without a dungeon give ErrDungeonNotFound.
+func (s *DungeonService) EnrollChatMember(ctx context.Context, chatID, userID int64) (*entity.Dungeon, error) {
+	dungeon, err := s.dungeonRepo.FindByTelegramChatID(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.memberRepo.Add(ctx, dungeon.ID, userID, entity.DungeonRoleMember); err != nil {
+		return nil, err
+	}
+	return dungeon, nil
+}
+
+// ChatDungeon returns the dungeon a bot command from userID in chatID acts on.
+// In a group that is the group's dungeon, and the user is enrolled on the way;
+// in a private chat it is the user's only dungeon, or ErrSeveralDungeons.
+func (s *DungeonService) ChatDungeon(ctx context.Context, userID, chatID int64) (*entity.Dungeon, error) {
+	// Telegram gives a private chat the ID of the user
+	if chatID != userID {
+		return s.EnrollChatMember(ctx, chatID, userID)
+	}
+
+	memberships, err := s.memberRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	switch len(memberships) {
+	case 0:
+		return nil, ports.ErrDungeonNotFound
+	case 1:
+		return s.dungeonRepo.GetByID(ctx, memberships[0].DungeonID)
+	default:
+		return nil, ErrSeveralDungeons
+	}
+}
+
+// AddMember adds a user to the dungeon as a plain member
+func (s *DungeonService) AddMember(ctx context.Context, actorID int64, dungeonID string, userID int64) error {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionInviteMembers); err != nil {
+		return err
 	}
 
 	// Verify user exists
-	_, err = s.userRepo.FindByID(ctx, userID)
+	_, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	// Add member
-	return s.memberRepo.Add(ctx, dungeonID, userID)
+	return s.memberRepo.Add(ctx, dungeonID, userID, entity.DungeonRoleMember)
 }
 
-func (s *DungeonService) ListMembers(ctx context.Context, adminUserID int64, dungeonID string) ([]int64, error) {
-	// Verify admin user exists and is the dungeon admin
+// ListMembers returns the IDs of the dungeon's members to any of them
+func (s *DungeonService) ListMembers(ctx context.Context, actorID int64, dungeonID string) ([]int64, error) {
+	if _, _, err := s.role(ctx, actorID, dungeonID); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.ListUsers(ctx, dungeonID)
+}
+
+// SetMemberRole makes a member a moderator or a plain member again. Only the
+// owner manages roles, and their own role changes through TransferOwnership.
+func (s *DungeonService) SetMemberRole(ctx context.Context, actorID int64, dungeonID string, userID int64, role entity.DungeonRole) error {
+	if role != entity.DungeonRoleModerator && role != entity.DungeonRoleMember {
+		return ErrInvalidDungeonRole
+	}
+
+	dungeon, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionManageRoles)
+	if err != nil {
+		return err
+	}
+	if dungeon.AdminUserID == userID {
+		return ErrOwnerRoleFixed
+	}
+
+	return s.memberRepo.SetRole(ctx, dungeonID, userID, role)
+}
+
+// TransferOwnership hands the dungeon to another member. The previous owner
+// stays on as a moderator.
+func (s *DungeonService) TransferOwnership(ctx context.Context, actorID int64, dungeonID string, newOwnerID int64) (*entity.Dungeon, error) {
+	var dungeon *entity.Dungeon
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		dungeon, err = s.authorize(ctx, actorID, dungeonID, entity.PermissionManageRoles)
+		if err != nil {
+			return err
+		}
+		if dungeon.AdminUserID == newOwnerID {
+			return ErrAlreadyOwner
+		}
+
+		// The new owner must already be a member
+		if err := s.memberRepo.SetRole(ctx, dungeonID, newOwnerID, entity.DungeonRoleOwner); err != nil {
+			return err
+		}
+		if err := s.memberRepo.SetRole(ctx, dungeonID, dungeon.AdminUserID, entity.DungeonRoleModerator); err != nil {
+			return err
+		}
+
+		dungeon.AdminUserID = newOwnerID
+		return s.dungeonRepo.Update(ctx, dungeon)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dungeon, nil
+}
+
+// AdjustBalance credits or debits a member's wallet by hand, see LedgerService.Adjust
+func (s *DungeonService) AdjustBalance(ctx context.Context, actorID int64, dungeonID string, userID int64, amount valueobject.Decimal, note string) (*entity.LedgerEntry, error) {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionAdjustBalances); err != nil {
+		return nil, err
+	}
+
+	isMember, err := s.memberRepo.IsMember(ctx, dungeonID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+	if !isMember {
+		return nil, ports.ErrDungeonMemberNotFound
+	}
+
+	return s.ledger.Adjust(ctx, userID, dungeonID, amount, note)
+}
+
+// RemoveMember takes a user out of the dungeon. Moderators can remove plain
+// members; the owner can remove anyone but themselves.
+func (s *DungeonService) RemoveMember(ctx context.Context, actorID int64, dungeonID string, userID int64) error {
+	dungeon, role, err := s.role(ctx, actorID, dungeonID)
+	if err != nil {
+		return err
+	}
+	if !role.Can(entity.PermissionRemoveMembers) {
+		return fmt.Errorf("%w: a %s cannot %s", ports.ErrForbidden, role, entity.PermissionRemoveMembers)
+	}
+	if dungeon.AdminUserID == userID {
+		return ErrOwnerNotRemovable
+	}
+
+	member, err := s.memberRepo.Get(ctx, dungeonID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role != entity.DungeonRoleMember && role != entity.DungeonRoleOwner {
+		return ErrModeratorsOwnerOnly
+	}
+
+	return s.memberRepo.Remove(ctx, dungeonID, userID)
+}
+
+// LeaveDungeon ends the user's own membership. The owner has to hand the
+// dungeon over with TransferOwnership first.
+func (s *DungeonService) LeaveDungeon(ctx context.Context, userID int64, dungeonID string) error {
 	dungeon, err := s.dungeonRepo.GetByID(ctx, dungeonID)
 	if err != nil {
+		return err
+	}
+	if dungeon.AdminUserID == userID {
+		return ErrOwnerCannotLeave
+	}
+
+	err = s.memberRepo.Remove(ctx, dungeonID, userID)
+	if errors.Is(err, ports.ErrDungeonMemberNotFound) {
+		return ErrNotDungeonMember
+	}
+	return err
+}
+
+// InviteOptions limit how an invite can be used
+type InviteOptions struct {
+	MaxUses          *int          // nil for unlimited uses
+	ValidFor         time.Duration // zero for an invite that does not expire
+	RequiresApproval bool          // Joins wait for the owner or a moderator
+}
+
+// CreateInvite makes a new invite code for the dungeon
+func (s *DungeonService) CreateInvite(ctx context.Context, actorID int64, dungeonID string, options InviteOptions) (*entity.DungeonInvite, error) {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionInviteMembers); err != nil {
 		return nil, err
 	}
 
-	if dungeon.AdminUserID != adminUserID {
-		return nil, ports.ErrUserNotFound // Using this error for simplicity
+	now := time.Now()
+	invite := &entity.DungeonInvite{
+		// Telegram start parameters only allow letters, digits, _ and -
+		Code:             strings.ReplaceAll(s.uuidGen.New(), "-", ""),
+		DungeonID:        dungeonID,
+		CreatedBy:        actorID,
+		MaxUses:          options.MaxUses,
+		RequiresApproval: options.RequiresApproval,
+		CreatedAt:        now,
+	}
+	if options.ValidFor > 0 {
+		expiresAt := now.Add(options.ValidFor)
+		invite.ExpiresAt = &expiresAt
+	}
+	if err := invite.Validate(); err != nil {
+		return nil, err
 	}
 
-	// List members
-	return s.memberRepo.ListUsers(ctx, dungeonID)
+	if err := s.inviteRepo.Create(ctx, invite); err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// ListInvites returns the dungeon's invites, newest first
+func (s *DungeonService) ListInvites(ctx context.Context, actorID int64, dungeonID string) ([]*entity.DungeonInvite, error) {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionInviteMembers); err != nil {
+		return nil, err
+	}
+	return s.inviteRepo.ListByDungeon(ctx, dungeonID)
+}
+
+// RevokeInvite deletes an invite. Pending join requests made with it stay.
+func (s *DungeonService) RevokeInvite(ctx context.Context, actorID int64, dungeonID, code string) error {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionInviteMembers); err != nil {
+		return err
+	}
+
+	invite, err := s.inviteRepo.GetByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if invite.DungeonID != dungeonID {
+		return ports.ErrDungeonInviteNotFound
+	}
+	return s.inviteRepo.Delete(ctx, code)
+}
+
+// JoinResult is the outcome of redeeming an invite
+type JoinResult struct {
+	Dungeon *entity.Dungeon
+	Request *entity.JoinRequest // Set when the join waits for approval
+}
+
+// Joined reports whether the user is now a member
+func (r *JoinResult) Joined() bool {
+	return r.Request == nil
+}
+
+// JoinWithInvite redeems an invite code for the user. Invites that require
+// approval create a join request, and the owner and moderators are asked to
+// decide it. Redeeming again while a request is pending returns that request.
+func (s *DungeonService) JoinWithInvite(ctx context.Context, userID int64, code string) (*JoinResult, error) {
+	result := &JoinResult{}
+	notify := false
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		invite, err := s.inviteRepo.GetByCode(ctx, code)
+		if err != nil {
+			return err
+		}
+		if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		result.Dungeon, err = s.dungeonRepo.GetByID(ctx, invite.DungeonID)
+		if err != nil {
+			return err
+		}
+
+		isMember, err := s.memberRepo.IsMember(ctx, invite.DungeonID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check membership: %w", err)
+		}
+		if isMember || result.Dungeon.AdminUserID == userID {
+			return ErrAlreadyMember
+		}
+
+		if invite.RequiresApproval {
+			pending, err := s.joinRequestRepo.FindPending(ctx, invite.DungeonID, userID)
+			if err == nil {
+				result.Request = pending
+				return nil
+			}
+			if !errors.Is(err, ports.ErrJoinRequestNotFound) {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := invite.Redeem(now); err != nil {
+			return err
+		}
+		if err := s.inviteRepo.Update(ctx, invite); err != nil {
+			return err
+		}
+
+		if !invite.RequiresApproval {
+			return s.memberRepo.Add(ctx, invite.DungeonID, userID, entity.DungeonRoleMember)
+		}
+
+		result.Request = &entity.JoinRequest{
+			DungeonID:  invite.DungeonID,
+			UserID:     userID,
+			InviteCode: invite.Code,
+			Status:     entity.JoinRequestPending,
+			CreatedAt:  now,
+		}
+		notify = true
+		return s.joinRequestRepo.Create(ctx, result.Request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if notify {
+		s.notifyReviewers(ctx, result.Dungeon, result.Request)
+	}
+	return result, nil
+}
+
+// ListJoinRequests returns the dungeon's undecided join requests, oldest first
+func (s *DungeonService) ListJoinRequests(ctx context.Context, actorID int64, dungeonID string) ([]*entity.JoinRequest, error) {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionInviteMembers); err != nil {
+		return nil, err
+	}
+	return s.joinRequestRepo.ListPending(ctx, dungeonID)
+}
+
+// ApproveJoinRequest lets the requester into the dungeon
+func (s *DungeonService) ApproveJoinRequest(ctx context.Context, actorID, requestID int64) (*entity.JoinRequest, error) {
+	return s.decideJoinRequest(ctx, actorID, requestID, true)
+}
+
+// DenyJoinRequest turns the requester away
+func (s *DungeonService) DenyJoinRequest(ctx context.Context, actorID, requestID int64) (*entity.JoinRequest, error) {
+	return s.decideJoinRequest(ctx, actorID, requestID, false)
+}
+
+func (s *DungeonService) decideJoinRequest(ctx context.Context, actorID, requestID int64, approve bool) (*entity.JoinRequest, error) {
+	var dungeon *entity.Dungeon
+	var request *entity.JoinRequest
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.joinRequestRepo.GetByID(ctx, requestID)
+		if err != nil {
+			return err
+		}
+		dungeon, err = s.authorize(ctx, actorID, request.DungeonID, entity.PermissionInviteMembers)
+		if err != nil {
+			return err
+		}
+
+		if err := request.Decide(approve, actorID, time.Now()); err != nil {
+			return err
+		}
+		if err := s.joinRequestRepo.Update(ctx, request); err != nil {
+			return err
+		}
+
+		if !approve {
+			return nil
+		}
+		return s.memberRepo.Add(ctx, request.DungeonID, request.UserID, entity.DungeonRoleMember)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil {
+		_ = s.notifier.NotifyJoinDecided(ctx, dungeon, request)
+	}
+	return request, nil
+}
+
+// notifyReviewers asks the owner and moderators to decide a join request.
+// The request is already stored, so failed notifications are not reported.
+func (s *DungeonService) notifyReviewers(ctx context.Context, dungeon *entity.Dungeon, request *entity.JoinRequest) {
+	if s.notifier == nil {
+		return
+	}
+
+	members, err := s.memberRepo.List(ctx, dungeon.ID)
+	if err != nil {
+		return
+	}
+	for _, member := range members {
+		if member.Role.Can(entity.PermissionInviteMembers) {
+			_ = s.notifier.NotifyJoinRequest(ctx, member.UserID, dungeon, request)
+		}
+	}
 }
@@ -0,0 +1,353 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// newDungeonFixtureWithNotifier returns a service and a dungeon owned by
+// user 1, with user 2 a moderator and user 3 a member. Users 4 and 5 exist
+// outside the dungeon.
+func newDungeonFixtureWithNotifier(t *testing.T) (*usecase.DungeonService, *entity.Dungeon, *inmemory.DungeonNotifier) {
+	ctx := context.Background()
+	store := newTestStore()
+	createUsers(t, store, 1, 2, 3, 4, 5)
+	notifier := inmemory.NewDungeonNotifier()
+	service := usecase.NewDungeonService(store.Dungeons, store.DungeonMembers, store.Invites, store.JoinRequests,
+		store.Users, newTestLedger(store), notifier, store.UUIDGen, store.TxManager)
+
+	dungeon, err := service.CreateDungeon(ctx, 1, "Guild", nil)
+	require.NoError(t, err)
+	require.NoError(t, service.AddMember(ctx, 1, dungeon.ID, 2))
+	require.NoError(t, service.AddMember(ctx, 1, dungeon.ID, 3))
+	require.NoError(t, service.SetMemberRole(ctx, 1, dungeon.ID, 2, entity.DungeonRoleModerator))
+	return service, dungeon, notifier
+}
+
+func newDungeonFixture(t *testing.T) (*usecase.DungeonService, *entity.Dungeon) {
+	service, dungeon, _ := newDungeonFixtureWithNotifier(t)
+	return service, dungeon
+}
+
+func TestDungeonService_Permissions(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+
+	// Moderators invite, members do not
+	require.NoError(t, service.AddMember(ctx, 2, dungeon.ID, 4))
+	err := service.AddMember(ctx, 3, dungeon.ID, 4)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	// Only the owner adjusts balances and manages roles
+	entry, err := service.AdjustBalance(ctx, 1, dungeon.ID, 3, valueobject.NewDecimal("5"), "bonus")
+	require.NoError(t, err)
+	assert.Equal(t, "5", entry.BalanceAfter.String())
+	assert.Equal(t, dungeon.ID, entry.DungeonID)
+	_, err = service.AdjustBalance(ctx, 2, dungeon.ID, 3, valueobject.NewDecimal("5"), "bonus")
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+	err = service.SetMemberRole(ctx, 2, dungeon.ID, 3, entity.DungeonRoleModerator)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	// Members see the member list, outsiders do not
+	members, err := service.ListMembers(ctx, 3, dungeon.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4}, members)
+	_, err = service.ListMembers(ctx, 5, dungeon.ID)
+	assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+	_, err = service.ListMembers(ctx, 99, dungeon.ID)
+	assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+}
+
+func TestDungeonService_ListUserDungeons(t *testing.T) {
+	ctx := context.Background()
+	service, guild := newDungeonFixture(t)
+
+	party, err := service.CreateDungeon(ctx, 3, "Party", nil)
+	require.NoError(t, err)
+	_, err = service.AdjustBalance(ctx, 1, guild.ID, 3, valueobject.NewDecimal("5"), "bonus")
+	require.NoError(t, err)
+	_, err = service.AdjustBalance(ctx, 3, party.ID, 3, valueobject.NewDecimal("2"), "bonus")
+	require.NoError(t, err)
+
+	dungeons, err := service.ListUserDungeons(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, dungeons, 2)
+	assert.Equal(t, guild.ID, dungeons[0].Dungeon.ID)
+	assert.Equal(t, entity.DungeonRoleMember, dungeons[0].Role)
+	assert.Equal(t, "5", dungeons[0].Balance.String())
+	assert.Equal(t, party.ID, dungeons[1].Dungeon.ID)
+	assert.Equal(t, entity.DungeonRoleOwner, dungeons[1].Role)
+	assert.Equal(t, "2", dungeons[1].Balance.String())
+
+	dungeons, err = service.ListUserDungeons(ctx, 5)
+	require.NoError(t, err)
+	assert.Empty(t, dungeons)
+}
+
+func TestDungeonService_SetMemberRole(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+
+	err := service.SetMemberRole(ctx, 1, dungeon.ID, 3, entity.DungeonRoleOwner)
+	assert.ErrorIs(t, err, usecase.ErrInvalidDungeonRole)
+
+	err = service.SetMemberRole(ctx, 1, dungeon.ID, 1, entity.DungeonRoleMember)
+	assert.ErrorIs(t, err, usecase.ErrOwnerRoleFixed)
+
+	err = service.SetMemberRole(ctx, 1, dungeon.ID, 4, entity.DungeonRoleModerator)
+	assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+
+	// A demoted moderator loses their permissions
+	require.NoError(t, service.SetMemberRole(ctx, 1, dungeon.ID, 2, entity.DungeonRoleMember))
+	err = service.AddMember(ctx, 2, dungeon.ID, 4)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+}
+
+func TestDungeonService_TransferOwnership(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+
+	_, err := service.TransferOwnership(ctx, 2, dungeon.ID, 3)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	_, err = service.TransferOwnership(ctx, 1, dungeon.ID, 1)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyOwner)
+
+	_, err = service.TransferOwnership(ctx, 1, dungeon.ID, 4)
+	assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+
+	transferred, err := service.TransferOwnership(ctx, 1, dungeon.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), transferred.AdminUserID)
+
+	// The new owner manages roles; the old one stays on as a moderator
+	require.NoError(t, service.SetMemberRole(ctx, 3, dungeon.ID, 2, entity.DungeonRoleMember))
+	require.NoError(t, service.AddMember(ctx, 1, dungeon.ID, 4))
+	_, err = service.AdjustBalance(ctx, 1, dungeon.ID, 3, valueobject.NewDecimal("5"), "bonus")
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+}
+
+func TestDungeonService_JoinWithInvite(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+
+	_, err := service.CreateInvite(ctx, 3, dungeon.ID, usecase.InviteOptions{})
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	maxUses := 1
+	invite, err := service.CreateInvite(ctx, 2, dungeon.ID, usecase.InviteOptions{MaxUses: &maxUses, ValidFor: time.Hour})
+	require.NoError(t, err)
+	assert.NotContains(t, invite.Code, "-")
+	require.NotNil(t, invite.ExpiresAt)
+
+	_, err = service.JoinWithInvite(ctx, 3, invite.Code)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyMember)
+
+	result, err := service.JoinWithInvite(ctx, 4, invite.Code)
+	require.NoError(t, err)
+	assert.True(t, result.Joined())
+	assert.Equal(t, dungeon.ID, result.Dungeon.ID)
+	members, err := service.ListMembers(ctx, 4, dungeon.ID)
+	require.NoError(t, err)
+	assert.Contains(t, members, int64(4))
+
+	// The only use is gone
+	_, err = service.JoinWithInvite(ctx, 5, invite.Code)
+	assert.ErrorIs(t, err, entity.ErrInviteUsedUp)
+
+	_, err = service.JoinWithInvite(ctx, 5, "missing")
+	assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+
+	// Revoked invites stop working
+	open, err := service.CreateInvite(ctx, 1, dungeon.ID, usecase.InviteOptions{})
+	require.NoError(t, err)
+	invites, err := service.ListInvites(ctx, 1, dungeon.ID)
+	require.NoError(t, err)
+	assert.Len(t, invites, 2)
+	require.NoError(t, service.RevokeInvite(ctx, 1, dungeon.ID, open.Code))
+	_, err = service.JoinWithInvite(ctx, 5, open.Code)
+	assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+}
+
+func TestDungeonService_JoinRequests(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon, notifier := newDungeonFixtureWithNotifier(t)
+
+	invite, err := service.CreateInvite(ctx, 1, dungeon.ID, usecase.InviteOptions{RequiresApproval: true})
+	require.NoError(t, err)
+
+	result, err := service.JoinWithInvite(ctx, 4, invite.Code)
+	require.NoError(t, err)
+	require.False(t, result.Joined())
+	request := result.Request
+	assert.Equal(t, entity.JoinRequestPending, request.Status)
+
+	// The owner and the moderator are asked, the plain member is not
+	var reviewers []int64
+	for _, sent := range notifier.Sent() {
+		assert.Equal(t, request.ID, sent.RequestID)
+		reviewers = append(reviewers, sent.UserID)
+	}
+	assert.ElementsMatch(t, []int64{1, 2}, reviewers)
+
+	// Asking again returns the pending request without using the invite again
+	again, err := service.JoinWithInvite(ctx, 4, invite.Code)
+	require.NoError(t, err)
+	assert.Equal(t, request.ID, again.Request.ID)
+	invites, err := service.ListInvites(ctx, 1, dungeon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, invites[0].Uses)
+
+	pending, err := service.ListJoinRequests(ctx, 2, dungeon.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	_, err = service.ApproveJoinRequest(ctx, 3, request.ID)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	approved, err := service.ApproveJoinRequest(ctx, 2, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JoinRequestApproved, approved.Status)
+	members, err := service.ListMembers(ctx, 4, dungeon.ID)
+	require.NoError(t, err)
+	assert.Contains(t, members, int64(4))
+
+	sent := notifier.Sent()
+	assert.Equal(t, inmemory.JoinNotification{UserID: 4, RequestID: request.ID, Status: entity.JoinRequestApproved}, sent[len(sent)-1])
+
+	_, err = service.DenyJoinRequest(ctx, 1, request.ID)
+	assert.ErrorIs(t, err, entity.ErrJoinRequestDecided)
+
+	// Denied users stay out
+	result, err = service.JoinWithInvite(ctx, 5, invite.Code)
+	require.NoError(t, err)
+	denied, err := service.DenyJoinRequest(ctx, 1, result.Request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JoinRequestDenied, denied.Status)
+	_, err = service.ListMembers(ctx, 5, dungeon.ID)
+	assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+}
+
+func TestDungeonService_RemoveAndLeave(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+	require.NoError(t, service.AddMember(ctx, 1, dungeon.ID, 4))
+
+	// Moderators remove plain members, not other moderators or the owner
+	require.NoError(t, service.RemoveMember(ctx, 2, dungeon.ID, 4))
+	require.NoError(t, service.SetMemberRole(ctx, 1, dungeon.ID, 3, entity.DungeonRoleModerator))
+	err := service.RemoveMember(ctx, 2, dungeon.ID, 3)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+	err = service.RemoveMember(ctx, 2, dungeon.ID, 1)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+	err = service.RemoveMember(ctx, 1, dungeon.ID, 4)
+	assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+
+	require.NoError(t, service.RemoveMember(ctx, 1, dungeon.ID, 3))
+
+	err = service.LeaveDungeon(ctx, 1, dungeon.ID)
+	assert.ErrorIs(t, err, usecase.ErrOwnerCannotLeave)
+	require.NoError(t, service.LeaveDungeon(ctx, 2, dungeon.ID))
+	err = service.LeaveDungeon(ctx, 2, dungeon.ID)
+	assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+
+	members, err := service.ListMembers(ctx, 1, dungeon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, members)
+}
+
+func TestDungeonService_LinkChat(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+
+	_, err := service.LinkChat(ctx, 2, dungeon.ID, -100)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	linked, err := service.LinkChat(ctx, 1, dungeon.ID, -100)
+	require.NoError(t, err)
+	require.NotNil(t, linked.TelegramChatID)
+	assert.Equal(t, int64(-100), *linked.TelegramChatID)
+
+	// Relinking moves the dungeon to the new group
+	_, err = service.LinkChat(ctx, 1, dungeon.ID, -200)
+	require.NoError(t, err)
+	_, err = service.EnrollChatMember(ctx, -100, 4)
+	assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+
+	// A group belongs to one dungeon
+	other, err := service.CreateDungeon(ctx, 4, "Other", nil)
+	require.NoError(t, err)
+	_, err = service.LinkChat(ctx, 4, other.ID, -200)
+	assert.ErrorIs(t, err, usecase.ErrChatAlreadyLinked)
+	chatID := int64(-200)
+	_, err = service.CreateDungeon(ctx, 4, "Another", &chatID)
+	assert.ErrorIs(t, err, usecase.ErrChatAlreadyLinked)
+
+	owned, err := service.ListOwnedDungeons(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, other.ID, owned[0].ID)
+}
+
+func TestDungeonService_SetTimeZone(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+	assert.Equal(t, "UTC", dungeon.TimeZone)
+
+	_, err := service.SetTimeZone(ctx, 3, dungeon.ID, "Europe/Berlin")
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	_, err = service.SetTimeZone(ctx, 2, dungeon.ID, "Mars/Olympus")
+	assert.ErrorIs(t, err, usecase.ErrInvalidTimeZone)
+
+	updated, err := service.SetTimeZone(ctx, 2, dungeon.ID, "Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", updated.TimeZone)
+}
+
+func TestDungeonService_ChatDungeon(t *testing.T) {
+	ctx := context.Background()
+	service, dungeon := newDungeonFixture(t)
+	_, err := service.LinkChat(ctx, 1, dungeon.ID, -100)
+	require.NoError(t, err)
+
+	// Talking in the group enrolls the user
+	found, err := service.ChatDungeon(ctx, 4, -100)
+	require.NoError(t, err)
+	assert.Equal(t, dungeon.ID, found.ID)
+	members, err := service.ListMembers(ctx, 4, dungeon.ID)
+	require.NoError(t, err)
+	assert.Contains(t, members, int64(4))
+
+	// Enrolling keeps existing roles
+	_, err = service.ChatDungeon(ctx, 2, -100)
+	require.NoError(t, err)
+	require.NoError(t, service.AddMember(ctx, 2, dungeon.ID, 5))
+
+	_, err = service.ChatDungeon(ctx, 4, -300)
+	assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+
+	// Private chats use the user's only dungeon
+	found, err = service.ChatDungeon(ctx, 4, 4)
+	require.NoError(t, err)
+	assert.Equal(t, dungeon.ID, found.ID)
+
+	chatID := int64(-200)
+	_, err = service.CreateDungeon(ctx, 4, "Second group", &chatID)
+	require.NoError(t, err)
+	_, err = service.ChatDungeon(ctx, 4, 4)
+	assert.ErrorIs(t, err, usecase.ErrSeveralDungeons)
+	found, err = service.ChatDungeon(ctx, 4, -200)
+	require.NoError(t, err)
+	assert.Equal(t, "Second group", found.Title)
+}
@@ -0,0 +1,53 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+type sequenceUUIDGen struct {
+	n int
+}
+
+func (g *sequenceUUIDGen) New() string {
+	g.n++
+	return fmt.Sprintf("id-%d", g.n)
+}
+
+// newTestStore returns empty in-memory storage handing out sequential IDs.
+// Its TxManager rolls every repository back together, like a database would.
+func newTestStore() *storage.Storage {
+	store := storage.NewInMemory()
+	store.UUIDGen = &sequenceUUIDGen{}
+	return store
+}
+
+// newTestLedger returns the ledger service on store
+func newTestLedger(store *storage.Storage) *usecase.LedgerService {
+	return usecase.NewLedgerService(store.Ledger, store.Wallets, store.Users, store.UUIDGen, store.TxManager)
+}
+
+// createUsers creates users with an empty balance
+func createUsers(t *testing.T, store *storage.Storage, userIDs ...int64) {
+	t.Helper()
+	for _, userID := range userIDs {
+		require.NoError(t, store.Users.Create(context.Background(), &entity.User{ID: userID, ChatID: 100, Balance: valueobject.NewDecimal("0")}))
+	}
+}
+
+// createDungeon creates dungeon and adds its members with their roles
+func createDungeon(t *testing.T, store *storage.Storage, dungeon *entity.Dungeon, members map[int64]entity.DungeonRole) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, store.Dungeons.Create(ctx, dungeon))
+	for userID, role := range members {
+		require.NoError(t, store.DungeonMembers.Add(ctx, dungeon.ID, userID, role))
+	}
+}
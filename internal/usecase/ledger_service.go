@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+const (
+	defaultLedgerHistoryLimit = 20
+	maxLedgerHistoryLimit     = 100
+)
+
+var (
+	ErrInvalidTransferAmount = errors.New("transfer amount must be positive")
+	ErrSelfTransfer          = errors.New("cannot transfer points to yourself")
+)
+
+// LedgerService is the only writer of user balances. Every change is recorded
+// as a ledger entry and applied to the cached User.Balance, and to the dungeon
+// wallet it belongs to, in the same transaction.
+type LedgerService struct {
+	ledgerRepo ports.LedgerRepository
+	walletRepo ports.WalletRepository
+	userRepo   ports.UserRepository
+	uuidGen    ports.UUIDGenerator
+	txManager  ports.TxManager
+}
+
+func NewLedgerService(
+	ledgerRepo ports.LedgerRepository,
+	walletRepo ports.WalletRepository,
+	userRepo ports.UserRepository,
+	uuidGen ports.UUIDGenerator,
+	txManager ports.TxManager,
+) *LedgerService {
+	return &LedgerService{
+		ledgerRepo: ledgerRepo,
+		walletRepo: walletRepo,
+		userRepo:   userRepo,
+		uuidGen:    uuidGen,
+		txManager:  txManager,
+	}
+}
+
+// Posting describes one balance change
+type Posting struct {
+	UserID     int64
+	DungeonID  string              // Wallet to post to; empty for points outside any dungeon
+	Amount     valueobject.Decimal // Positive to credit, negative to debit
+	Reason     string              // One of the entity.LedgerReason* constants
+	SourceType string
+	SourceID   string
+	Note       string
+}
+
+// Post records a posting. Debits fail with ports.ErrInsufficientFunds when they
+// would take the balance below zero, except for admin adjustments. The balance
+// checked is the dungeon wallet's when the posting has a DungeonID.
+func (s *LedgerService) Post(ctx context.Context, posting Posting) (*entity.LedgerEntry, error) {
+	entry := &entity.LedgerEntry{
+		ID:         s.uuidGen.New(),
+		UserID:     posting.UserID,
+		DungeonID:  posting.DungeonID,
+		Amount:     posting.Amount,
+		Reason:     posting.Reason,
+		SourceType: posting.SourceType,
+		SourceID:   posting.SourceID,
+		Note:       posting.Note,
+		CreatedAt:  time.Now(),
+	}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		// The update locks the user's row, so checking the new balance
+		// afterwards is safe against concurrent debits
+		balance, err := s.userRepo.UpdateBalance(ctx, entry.UserID, entry.Amount)
+		if err != nil {
+			return err
+		}
+		if entry.DungeonID != "" {
+			balance, err = s.walletRepo.UpdateBalance(ctx, entry.UserID, entry.DungeonID, entry.Amount)
+			if err != nil {
+				return err
+			}
+		}
+		if entry.IsDebit() && balance.IsNegative() && entry.Reason != entity.LedgerReasonAdminAdjustment {
+			return ports.ErrInsufficientFunds
+		}
+
+		entry.BalanceAfter = balance
+		return s.ledgerRepo.Append(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Adjust credits or debits a user's wallet in a dungeon by hand, e.g. to
+// correct a mistake. The balance may go negative.
+func (s *LedgerService) Adjust(ctx context.Context, userID int64, dungeonID string, amount valueobject.Decimal, note string) (*entity.LedgerEntry, error) {
+	return s.Post(ctx, Posting{
+		UserID:    userID,
+		DungeonID: dungeonID,
+		Amount:    amount,
+		Reason:    entity.LedgerReasonAdminAdjustment,
+		Note:      note,
+	})
+}
+
+// Transfer moves points between two users' wallets in the same dungeon. Each
+// side's entry refers to the other user as its source.
+func (s *LedgerService) Transfer(ctx context.Context, fromUserID, toUserID int64, dungeonID string, amount valueobject.Decimal, note string) error {
+	if !amount.IsPositive() {
+		return ErrInvalidTransferAmount
+	}
+	if fromUserID == toUserID {
+		return ErrSelfTransfer
+	}
+
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		_, err := s.Post(ctx, Posting{
+			UserID:     fromUserID,
+			DungeonID:  dungeonID,
+			Amount:     amount.Mul(valueobject.NewDecimal("-1")),
+			Reason:     entity.LedgerReasonTransfer,
+			SourceType: entity.LedgerSourceUser,
+			SourceID:   strconv.FormatInt(toUserID, 10),
+			Note:       note,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Post(ctx, Posting{
+			UserID:     toUserID,
+			DungeonID:  dungeonID,
+			Amount:     amount,
+			Reason:     entity.LedgerReasonTransfer,
+			SourceType: entity.LedgerSourceUser,
+			SourceID:   strconv.FormatInt(fromUserID, 10),
+			Note:       note,
+		})
+		return err
+	})
+}
+
+// History returns a user's most recent ledger entries, newest first. A limit
+// outside 1..100 falls back to the default of 20.
+func (s *LedgerService) History(ctx context.Context, userID int64, limit int) ([]*entity.LedgerEntry, error) {
+	if limit <= 0 || limit > maxLedgerHistoryLimit {
+		limit = defaultLedgerHistoryLimit
+	}
+
+	_, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ledgerRepo.ListByUser(ctx, userID, limit)
+}
+
+// WalletHistory is History limited to the user's wallet in one dungeon
+func (s *LedgerService) WalletHistory(ctx context.Context, userID int64, dungeonID string, limit int) ([]*entity.LedgerEntry, error) {
+	if limit <= 0 || limit > maxLedgerHistoryLimit {
+		limit = defaultLedgerHistoryLimit
+	}
+
+	_, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ledgerRepo.ListByWallet(ctx, userID, dungeonID, limit)
+}
+
+// Balance returns the user's balance in a dungeon, zero if they never had
+// points there
+func (s *LedgerService) Balance(ctx context.Context, userID int64, dungeonID string) (valueobject.Decimal, error) {
+	wallet, err := s.walletRepo.Get(ctx, userID, dungeonID)
+	if err != nil {
+		return valueobject.Decimal{}, err
+	}
+	return wallet.Balance, nil
+}
+
+// Wallets returns the user's wallets, ordered by dungeon ID
+func (s *LedgerService) Wallets(ctx context.Context, userID int64) ([]*entity.Wallet, error) {
+	return s.walletRepo.ListByUser(ctx, userID)
+}
+
+// BalanceMismatch is a user, or one of their wallets, whose cached balance
+// disagrees with the ledger
+type BalanceMismatch struct {
+	UserID    int64
+	DungeonID string              // Empty for the user's total
+	Cached    valueobject.Decimal // users.balance or wallets.balance
+	Ledger    valueobject.Decimal // Sum of the matching ledger entries
+}
+
+// Reconcile compares every user's cached balance, and every wallet's, with the
+// sum of their ledger entries. With fix set, mismatched balances are reset to
+// the ledger sum; the ledger itself is never changed. Run it while no balances
+// are being written, otherwise an in-flight posting can show up as a spurious
+// mismatch.
+func (s *LedgerService) Reconcile(ctx context.Context, fix bool) ([]BalanceMismatch, error) {
+	var mismatches []BalanceMismatch
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		users, err := s.userRepo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		sums, err := s.ledgerRepo.Balances(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			sum, ok := sums[user.ID]
+			if !ok {
+				sum = valueobject.NewDecimal("0")
+			}
+			if user.Balance.Cmp(sum) == 0 {
+				continue
+			}
+
+			mismatches = append(mismatches, BalanceMismatch{UserID: user.ID, Cached: user.Balance, Ledger: sum})
+			if fix {
+				if _, err := s.userRepo.UpdateBalance(ctx, user.ID, sum.Sub(user.Balance)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.reconcileWallets(ctx, fix, &mismatches)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mismatches, nil
+}
+
+// reconcileWallets is the wallet half of Reconcile. A wallet with no ledger
+// entries should be zero; an entry with no wallet is reported with a zero
+// cached balance.
+func (s *LedgerService) reconcileWallets(ctx context.Context, fix bool, mismatches *[]BalanceMismatch) error {
+	wallets, err := s.walletRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	sums, err := s.ledgerRepo.WalletBalances(ctx)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		userID    int64
+		dungeonID string
+	}
+	cached := make(map[key]valueobject.Decimal, len(wallets))
+	for _, wallet := range wallets {
+		cached[key{wallet.UserID, wallet.DungeonID}] = wallet.Balance
+	}
+	ledger := make(map[key]valueobject.Decimal, len(sums))
+	for _, sum := range sums {
+		ledger[key{sum.UserID, sum.DungeonID}] = sum.Balance
+	}
+
+	// Check the union of both, in a stable order
+	var keys []key
+	for k := range cached {
+		keys = append(keys, k)
+	}
+	for k := range ledger {
+		if _, ok := cached[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userID != keys[j].userID {
+			return keys[i].userID < keys[j].userID
+		}
+		return keys[i].dungeonID < keys[j].dungeonID
+	})
+
+	for _, k := range keys {
+		balance, ok := cached[k]
+		if !ok {
+			balance = valueobject.NewDecimal("0")
+		}
+		sum, ok := ledger[k]
+		if !ok {
+			sum = valueobject.NewDecimal("0")
+		}
+		if balance.Cmp(sum) == 0 {
+			continue
+		}
+
+		*mismatches = append(*mismatches, BalanceMismatch{UserID: k.userID, DungeonID: k.dungeonID, Cached: balance, Ledger: sum})
+		if fix {
+			if _, err := s.walletRepo.UpdateBalance(ctx, k.userID, k.dungeonID, sum.Sub(balance)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
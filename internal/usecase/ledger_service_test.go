@@ -0,0 +1,241 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+// ledgerDungeon is the dungeon whose wallets newLedgerFixture funds
+const ledgerDungeon = "dungeon-1"
+
+// newLedgerFixture creates users 1..n, crediting each non-zero balance to
+// their wallet in ledgerDungeon
+func newLedgerFixture(t *testing.T, balances ...string) (*usecase.LedgerService, *storage.Storage) {
+	ctx := context.Background()
+	store := newTestStore()
+	ledger := newTestLedger(store)
+	for i, balance := range balances {
+		userID := int64(i + 1)
+		createUsers(t, store, userID)
+		if amount := valueobject.NewDecimal(balance); !amount.IsZero() {
+			_, err := ledger.Adjust(ctx, userID, ledgerDungeon, amount, "Opening balance")
+			require.NoError(t, err)
+		}
+	}
+	return ledger, store
+}
+
+func TestLedgerService_Post(t *testing.T) {
+	ctx := context.Background()
+	ledger, store := newLedgerFixture(t, "0")
+
+	entry, err := ledger.Post(ctx, usecase.Posting{
+		UserID:     1,
+		Amount:     valueobject.NewDecimal("10"),
+		Reason:     entity.LedgerReasonQuestCompletion,
+		SourceType: entity.LedgerSourceQuestCompletion,
+		SourceID:   "completion-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "10", entry.BalanceAfter.String())
+
+	entry, err = ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal("-4"), Reason: entity.LedgerReasonPurchase})
+	require.NoError(t, err)
+	assert.Equal(t, "6", entry.BalanceAfter.String())
+
+	user, err := store.Users.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "6", user.Balance.String())
+
+	_, err = ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal("-7"), Reason: entity.LedgerReasonPurchase})
+	assert.ErrorIs(t, err, ports.ErrInsufficientFunds)
+
+	_, err = ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal("0"), Reason: entity.LedgerReasonPurchase})
+	assert.ErrorIs(t, err, entity.ErrZeroLedgerAmount)
+
+	_, err = ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal("1"), Reason: "gift"})
+	assert.ErrorIs(t, err, entity.ErrInvalidLedgerReason)
+
+	_, err = ledger.Post(ctx, usecase.Posting{UserID: 99, Amount: valueobject.NewDecimal("1"), Reason: entity.LedgerReasonRefund})
+	assert.ErrorIs(t, err, ports.ErrUserNotFound)
+}
+
+func TestLedgerService_AdjustMayGoNegative(t *testing.T) {
+	ctx := context.Background()
+	ledger, _ := newLedgerFixture(t, "5")
+
+	entry, err := ledger.Adjust(ctx, 1, ledgerDungeon, valueobject.NewDecimal("-8"), "Reverting a duplicate award")
+	require.NoError(t, err)
+	assert.Equal(t, "-3", entry.BalanceAfter.String())
+	assert.Equal(t, entity.LedgerReasonAdminAdjustment, entry.Reason)
+}
+
+func TestLedgerService_Transfer(t *testing.T) {
+	ctx := context.Background()
+	ledger, store := newLedgerFixture(t, "20", "0")
+
+	require.NoError(t, ledger.Transfer(ctx, 1, 2, ledgerDungeon, valueobject.NewDecimal("15"), "thanks"))
+
+	sender, err := store.Users.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "5", sender.Balance.String())
+	receiver, err := store.Users.FindByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "15", receiver.Balance.String())
+	balance, err := ledger.Balance(ctx, 2, ledgerDungeon)
+	require.NoError(t, err)
+	assert.Equal(t, "15", balance.String())
+
+	history, err := ledger.History(ctx, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, entity.LedgerReasonTransfer, history[0].Reason)
+	assert.Equal(t, entity.LedgerSourceUser, history[0].SourceType)
+	assert.Equal(t, "1", history[0].SourceID)
+
+	assert.ErrorIs(t, ledger.Transfer(ctx, 1, 2, ledgerDungeon, valueobject.NewDecimal("0"), ""), usecase.ErrInvalidTransferAmount)
+	assert.ErrorIs(t, ledger.Transfer(ctx, 1, 1, ledgerDungeon, valueobject.NewDecimal("1"), ""), usecase.ErrSelfTransfer)
+	// The sender's points in ledgerDungeon do not cover a transfer elsewhere
+	assert.ErrorIs(t, ledger.Transfer(ctx, 1, 2, "dungeon-2", valueobject.NewDecimal("1"), ""), ports.ErrInsufficientFunds)
+}
+
+func TestLedgerService_Wallets(t *testing.T) {
+	ctx := context.Background()
+	ledger, store := newLedgerFixture(t, "10")
+
+	entry, err := ledger.Post(ctx, usecase.Posting{
+		UserID:    1,
+		DungeonID: "dungeon-2",
+		Amount:    valueobject.NewDecimal("4"),
+		Reason:    entity.LedgerReasonQuestCompletion,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "dungeon-2", entry.DungeonID)
+	assert.Equal(t, "4", entry.BalanceAfter.String(), "BalanceAfter is the wallet's balance")
+
+	// Debits are checked against the wallet, not the user's total of 14
+	_, err = ledger.Post(ctx, usecase.Posting{UserID: 1, DungeonID: "dungeon-2", Amount: valueobject.NewDecimal("-5"), Reason: entity.LedgerReasonPurchase})
+	assert.ErrorIs(t, err, ports.ErrInsufficientFunds)
+
+	entry, err = ledger.Post(ctx, usecase.Posting{UserID: 1, DungeonID: ledgerDungeon, Amount: valueobject.NewDecimal("-5"), Reason: entity.LedgerReasonPurchase})
+	require.NoError(t, err)
+	assert.Equal(t, "5", entry.BalanceAfter.String())
+
+	user, err := store.Users.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "9", user.Balance.String())
+
+	wallets, err := ledger.Wallets(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, wallets, 2)
+	assert.Equal(t, ledgerDungeon, wallets[0].DungeonID)
+	assert.Equal(t, "5", wallets[0].Balance.String())
+	assert.Equal(t, "dungeon-2", wallets[1].DungeonID)
+	assert.Equal(t, "4", wallets[1].Balance.String())
+
+	balance, err := ledger.Balance(ctx, 1, "dungeon-3")
+	require.NoError(t, err)
+	assert.True(t, balance.IsZero())
+
+	history, err := ledger.WalletHistory(ctx, 1, "dungeon-2", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "4", history[0].Amount.String())
+
+	_, err = ledger.WalletHistory(ctx, 99, "dungeon-2", 0)
+	assert.ErrorIs(t, err, ports.ErrUserNotFound)
+}
+
+func TestLedgerService_History(t *testing.T) {
+	ctx := context.Background()
+	ledger, _ := newLedgerFixture(t, "0")
+
+	for _, amount := range []string{"1", "2", "3"} {
+		_, err := ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal(amount), Reason: entity.LedgerReasonQuestCompletion})
+		require.NoError(t, err)
+	}
+
+	history, err := ledger.History(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "3", history[0].Amount.String())
+	assert.Equal(t, "6", history[0].BalanceAfter.String())
+	assert.Equal(t, "2", history[1].Amount.String())
+
+	_, err = ledger.History(ctx, 99, 0)
+	assert.ErrorIs(t, err, ports.ErrUserNotFound)
+}
+
+func TestLedgerService_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	ledger, store := newLedgerFixture(t, "0", "0")
+
+	_, err := ledger.Post(ctx, usecase.Posting{UserID: 1, Amount: valueobject.NewDecimal("10"), Reason: entity.LedgerReasonQuestCompletion})
+	require.NoError(t, err)
+
+	mismatches, err := ledger.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	// Balance changes that bypass the ledger show up as mismatches
+	_, err = store.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+	require.NoError(t, err)
+	_, err = store.Users.UpdateBalance(ctx, 2, valueobject.NewDecimal("3"))
+	require.NoError(t, err)
+
+	mismatches, err = ledger.Reconcile(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, int64(1), mismatches[0].UserID)
+	assert.Equal(t, "15", mismatches[0].Cached.String())
+	assert.Equal(t, "10", mismatches[0].Ledger.String())
+	assert.Equal(t, "0", mismatches[1].Ledger.String())
+
+	mismatches, err = ledger.Reconcile(ctx, true)
+	require.NoError(t, err)
+	assert.Len(t, mismatches, 2)
+
+	user, err := store.Users.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "10", user.Balance.String())
+
+	mismatches, err = ledger.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestLedgerService_ReconcileWallets(t *testing.T) {
+	ctx := context.Background()
+	ledger, store := newLedgerFixture(t, "10")
+
+	// Moving points between wallets by hand leaves the user's total intact
+	_, err := store.Wallets.UpdateBalance(ctx, 1, "dungeon-1", valueobject.NewDecimal("-4"))
+	require.NoError(t, err)
+	_, err = store.Wallets.UpdateBalance(ctx, 1, "dungeon-2", valueobject.NewDecimal("4"))
+	require.NoError(t, err)
+
+	mismatches, err := ledger.Reconcile(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, "dungeon-1", mismatches[0].DungeonID)
+	assert.Equal(t, "6", mismatches[0].Cached.String())
+	assert.Equal(t, "10", mismatches[0].Ledger.String())
+	assert.Equal(t, "dungeon-2", mismatches[1].DungeonID)
+	assert.Equal(t, "0", mismatches[1].Ledger.String())
+
+	balance, err := ledger.Balance(ctx, 1, "dungeon-1")
+	require.NoError(t, err)
+	assert.Equal(t, "10", balance.String())
+
+	mismatches, err = ledger.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// LoyaltyService assigns dungeon members to the dungeon's loyalty tiers based
+// on how many purchases they made in its shop, and lets the members who run
+// the shop manage those tiers
+type LoyaltyService struct {
+	dungeonAccess
+	tierRepo     ports.RewardTierRepository
+	statusRepo   ports.LoyaltyStatusRepository
+	purchaseRepo ports.PurchaseRepository
+	userRepo     ports.UserRepository
+	notifier     ports.LoyaltyNotifier
+	txManager    ports.TxManager
+}
+
+// NewLoyaltyService creates a LoyaltyService. A nil notifier disables tier notifications.
+func NewLoyaltyService(
+	tierRepo ports.RewardTierRepository,
+	statusRepo ports.LoyaltyStatusRepository,
+	purchaseRepo ports.PurchaseRepository,
+	userRepo ports.UserRepository,
+	dungeonRepo ports.DungeonRepository,
+	memberRepo ports.DungeonMemberRepository,
+	notifier ports.LoyaltyNotifier,
+	txManager ports.TxManager,
+) *LoyaltyService {
+	return &LoyaltyService{
+		dungeonAccess: dungeonAccess{dungeonRepo: dungeonRepo, memberRepo: memberRepo},
+		tierRepo:      tierRepo,
+		statusRepo:    statusRepo,
+		purchaseRepo:  purchaseRepo,
+		userRepo:      userRepo,
+		notifier:      notifier,
+		txManager:     txManager,
+	}
+}
+
+// LoyaltyStanding is where a member stands in their dungeon's loyalty tiers
+type LoyaltyStanding struct {
+	PurchaseCount int
+	Tier          *entity.RewardTier // Reached tier; nil below the lowest tier
+	Next          *entity.RewardTier // Next tier to reach; nil at the top
+}
+
+// Standing returns the member's tier in a dungeon and the next one to reach
+func (s *LoyaltyService) Standing(ctx context.Context, userID int64, dungeonID string) (*LoyaltyStanding, error) {
+	if _, _, err := s.role(ctx, userID, dungeonID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.countPurchases(ctx, userID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers, err := s.tierRepo.FindByDungeonID(ctx, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	standing := &LoyaltyStanding{PurchaseCount: count, Tier: entity.TierFor(tiers, count)}
+	for _, tier := range tiers {
+		if tier.MinPurchases > count && (standing.Next == nil || tier.MinPurchases < standing.Next.MinPurchases) {
+			standing.Next = tier
+		}
+	}
+
+	return standing, nil
+}
+
+// CurrentTier returns the tier the user was last assigned in a dungeon, or
+// nil if they have not reached one
+func (s *LoyaltyService) CurrentTier(ctx context.Context, user *entity.User, dungeonID string) (*entity.RewardTier, error) {
+	status, err := s.statusRepo.Find(ctx, user.ID, dungeonID)
+	if errors.Is(err, ports.ErrLoyaltyStatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status.TierID == nil {
+		return nil, nil
+	}
+
+	tier, err := s.tierRepo.FindByID(ctx, *status.TierID)
+	if errors.Is(err, ports.ErrRewardTierNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tier, nil
+}
+
+// Refresh recounts the user's purchases in a dungeon and reassigns their tier
+// there. Run it in the transaction that changed the purchases and call
+// NotifyPending once that transaction has committed.
+func (s *LoyaltyService) Refresh(ctx context.Context, userID int64, dungeonID string) (*entity.LoyaltyStatus, error) {
+	count, err := s.countPurchases(ctx, userID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers, err := s.tierRepo.FindByDungeonID(ctx, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.statusRepo.Find(ctx, userID, dungeonID)
+	if errors.Is(err, ports.ErrLoyaltyStatusNotFound) {
+		status = &entity.LoyaltyStatus{UserID: userID, DungeonID: dungeonID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	status.PurchaseCount = count
+	status.TierID = nil
+	if tier := entity.TierFor(tiers, count); tier != nil {
+		tierID := tier.ID
+		status.TierID = &tierID
+	}
+	status.UpdatedAt = time.Now()
+
+	if err := s.statusRepo.Save(ctx, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// NotifyPending tells the user about a tier they reached in a dungeon and have
+// not been told about yet. Dropping to a lower tier is recorded without a message.
+func (s *LoyaltyService) NotifyPending(ctx context.Context, userID int64, dungeonID string) error {
+	if s.notifier == nil {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	status, err := s.statusRepo.Find(ctx, user.ID, dungeonID)
+	if errors.Is(err, ports.ErrLoyaltyStatusNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !status.NotificationPending() {
+		return nil
+	}
+
+	// Dropping below the lowest tier; reaching it again is announced again
+	if status.TierID == nil {
+		status.NotifiedTierID = nil
+		return s.statusRepo.Save(ctx, status)
+	}
+
+	tier, err := s.tierRepo.FindByID(ctx, *status.TierID)
+	if err != nil {
+		return err
+	}
+
+	promoted := true
+	if status.NotifiedTierID != nil {
+		previous, err := s.tierRepo.FindByID(ctx, *status.NotifiedTierID)
+		if err == nil && previous.MinPurchases >= tier.MinPurchases {
+			promoted = false
+		}
+	}
+
+	if promoted {
+		if err := s.notifier.NotifyTierReached(ctx, user, tier); err != nil {
+			return err
+		}
+	}
+
+	tierID := tier.ID
+	status.NotifiedTierID = &tierID
+	return s.statusRepo.Save(ctx, status)
+}
+
+// countPurchases counts the purchases in a dungeon's shop that qualify towards
+// its tiers. Fully refunded purchases do not count.
+func (s *LoyaltyService) countPurchases(ctx context.Context, userID int64, dungeonID string) (int, error) {
+	purchases, err := s.purchaseRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, purchase := range purchases {
+		if purchase.DungeonID == dungeonID && purchase.Status == entity.PurchaseStatusCompleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListTiers returns a dungeon's tiers ordered by purchase threshold to one of its members
+func (s *LoyaltyService) ListTiers(ctx context.Context, actorID int64, dungeonID string) ([]*entity.RewardTier, error) {
+	if _, _, err := s.role(ctx, actorID, dungeonID); err != nil {
+		return nil, err
+	}
+	return s.tierRepo.FindByDungeonID(ctx, dungeonID)
+}
+
+// CreateTier adds a tier to tier.DungeonID on behalf of a member who may edit its shop
+func (s *LoyaltyService) CreateTier(ctx context.Context, actorID int64, tier *entity.RewardTier) error {
+	if err := tier.Validate(); err != nil {
+		return err
+	}
+
+	return s.changeTiers(ctx, actorID, tier.DungeonID, func(txCtx context.Context) error {
+		now := time.Now()
+		tier.CreatedAt = now
+		tier.UpdatedAt = now
+		return s.tierRepo.Create(txCtx, tier)
+	})
+}
+
+// UpdateTier changes the name, description, discount and threshold of a tier
+func (s *LoyaltyService) UpdateTier(ctx context.Context, actorID int64, tier *entity.RewardTier) error {
+	if err := tier.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.tierRepo.FindByID(ctx, tier.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.changeTiers(ctx, actorID, existing.DungeonID, func(txCtx context.Context) error {
+		tier.DungeonID = existing.DungeonID
+		tier.CreatedAt = existing.CreatedAt
+		tier.UpdatedAt = time.Now()
+		return s.tierRepo.Update(txCtx, tier)
+	})
+}
+
+// DeleteTier removes a tier; members who had reached it fall back to the next lower one
+func (s *LoyaltyService) DeleteTier(ctx context.Context, actorID, tierID int64) error {
+	existing, err := s.tierRepo.FindByID(ctx, tierID)
+	if err != nil {
+		return err
+	}
+
+	return s.changeTiers(ctx, actorID, existing.DungeonID, func(txCtx context.Context) error {
+		return s.tierRepo.Delete(txCtx, tierID)
+	})
+}
+
+// changeTiers checks actorID may edit the dungeon's shop, applies change and
+// reassigns every member of the dungeon in one transaction. Members who reach
+// a new tier are notified afterwards; a failed notification is retried after
+// that member's next purchase.
+func (s *LoyaltyService) changeTiers(ctx context.Context, actorID int64, dungeonID string, change func(context.Context) error) error {
+	if _, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionEditShop); err != nil {
+		return err
+	}
+
+	var members []int64
+	err := s.txManager.WithTx(ctx, func(txCtx context.Context) error {
+		if err := change(txCtx); err != nil {
+			return err
+		}
+
+		var err error
+		members, err = s.memberRepo.ListUsers(txCtx, dungeonID)
+		if err != nil {
+			return err
+		}
+		for _, memberID := range members {
+			if _, err := s.Refresh(txCtx, memberID, dungeonID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, memberID := range members {
+		_ = s.NotifyPending(ctx, memberID, dungeonID)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+type loyaltyFixture struct {
+	shop     *usecase.ShopService
+	loyalty  *usecase.LoyaltyService
+	notifier *inmemory.LoyaltyNotifier
+}
+
+// newLoyaltyFixture sets up dungeon d1 with a member (1), its owner (2), an
+// item "TEA" for 10 and a "Regular" tier at two purchases with 10% off. The
+// member also belongs to d2, owned by 3, which sells "COFFEE" for 10.
+func newLoyaltyFixture(t *testing.T) *loyaltyFixture {
+	ctx := context.Background()
+	store := newTestStore()
+
+	f := &loyaltyFixture{notifier: inmemory.NewLoyaltyNotifier()}
+	f.loyalty = usecase.NewLoyaltyService(store.RewardTiers, store.LoyaltyStatus, store.Purchases, store.Users,
+		store.Dungeons, store.DungeonMembers, f.notifier, store.TxManager)
+	f.shop = usecase.NewShopService(store.ShopItems, store.Purchases, store.Users, newTestLedger(store), store.ChatConfigs,
+		usecase.NewPricingPipeline(usecase.LoyaltyTierRule(f.loyalty)),
+		f.loyalty, store.UUIDGen, store.TxManager, nil, store.Dungeons, store.DungeonMembers)
+
+	createUsers(t, store, 1, 2, 3)
+	for dungeonID, ownerID := range map[string]int64{"d1": 2, "d2": 3} {
+		createDungeon(t, store, &entity.Dungeon{ID: dungeonID, Title: dungeonID, AdminUserID: ownerID}, map[int64]entity.DungeonRole{
+			ownerID: entity.DungeonRoleOwner,
+			1:       entity.DungeonRoleMember,
+		})
+		_, err := store.Wallets.UpdateBalance(ctx, 1, dungeonID, valueobject.NewDecimal("100"))
+		require.NoError(t, err)
+	}
+
+	for i, item := range []struct{ dungeonID, code, name string }{{"d1", "TEA", "Tea"}, {"d2", "COFFEE", "Coffee"}} {
+		dungeonID := item.dungeonID
+		require.NoError(t, store.ShopItems.Create(ctx, &entity.ShopItem{
+			ID: int64(i + 1), DungeonID: &dungeonID, Code: item.code, Name: item.name, Price: valueobject.NewDecimal("10"), IsActive: true,
+		}))
+	}
+	require.NoError(t, store.RewardTiers.Create(ctx, &entity.RewardTier{ID: 1, DungeonID: "d1", Name: "Regular", DiscountPercent: 10, MinPurchases: 2}))
+
+	return f
+}
+
+func TestLoyaltyService_PurchasesReachTiers(t *testing.T) {
+	ctx := context.Background()
+	f := newLoyaltyFixture(t)
+
+	first, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10", first.TotalCost.String())
+	assert.Empty(t, f.notifier.Sent())
+
+	standing, err := f.loyalty.Standing(ctx, 1, "d1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, standing.PurchaseCount)
+	assert.Nil(t, standing.Tier)
+	require.NotNil(t, standing.Next)
+	assert.Equal(t, "Regular", standing.Next.Name)
+
+	// The purchase that reaches the tier is still charged in full
+	second, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10", second.TotalCost.String())
+	assert.Equal(t, []inmemory.TierNotification{{UserID: 1, TierID: 1}}, f.notifier.Sent())
+
+	third, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "9", third.TotalCost.String())
+	require.Len(t, third.PriceBreakdown, 1)
+	assert.Equal(t, usecase.PricingRuleLoyaltyTier, third.PriceBreakdown[0].Rule)
+	assert.Equal(t, "Regular", third.PriceBreakdown[0].Description)
+	assert.Len(t, f.notifier.Sent(), 1, "staying in a tier is not announced again")
+
+	standing, err = f.loyalty.Standing(ctx, 1, "d1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, standing.PurchaseCount)
+	require.NotNil(t, standing.Tier)
+	assert.Equal(t, "Regular", standing.Tier.Name)
+	assert.Nil(t, standing.Next)
+}
+
+func TestLoyaltyService_TiersAreReachedPerDungeon(t *testing.T) {
+	ctx := context.Background()
+	f := newLoyaltyFixture(t)
+
+	for i := 0; i < 2; i++ {
+		_, err := f.shop.PurchaseItem(ctx, 1, "d2", "COFFEE", 1, "")
+		require.NoError(t, err)
+	}
+	assert.Empty(t, f.notifier.Sent(), "purchases in d2 do not count towards d1's tiers")
+
+	standing, err := f.loyalty.Standing(ctx, 1, "d1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, standing.PurchaseCount)
+
+	purchase, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10", purchase.TotalCost.String())
+}
+
+func TestLoyaltyService_RefundsDropTiersQuietly(t *testing.T) {
+	ctx := context.Background()
+	f := newLoyaltyFixture(t)
+
+	_, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	second, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	require.Len(t, f.notifier.Sent(), 1)
+
+	_, err = f.shop.RefundPurchase(ctx, 1, second.ID, usecase.RefundInput{})
+	require.NoError(t, err)
+
+	tier, err := f.loyalty.CurrentTier(ctx, &entity.User{ID: 1}, "d1")
+	require.NoError(t, err)
+	assert.Nil(t, tier)
+	assert.Len(t, f.notifier.Sent(), 1)
+
+	// Buying again reaches the tier again, which is announced again
+	_, err = f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+	assert.Len(t, f.notifier.Sent(), 2)
+}
+
+func TestLoyaltyService_FailedNotificationIsRetried(t *testing.T) {
+	ctx := context.Background()
+	f := newLoyaltyFixture(t)
+	f.notifier.Err = errors.New("telegram is down")
+
+	for i := 0; i < 2; i++ {
+		_, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+		require.NoError(t, err, "a failed notification does not fail the purchase")
+	}
+	assert.Empty(t, f.notifier.Sent())
+
+	f.notifier.Err = nil
+	require.NoError(t, f.loyalty.NotifyPending(ctx, 1, "d1"))
+	assert.Equal(t, []inmemory.TierNotification{{UserID: 1, TierID: 1}}, f.notifier.Sent())
+}
+
+func TestLoyaltyService_ManageTiers(t *testing.T) {
+	ctx := context.Background()
+	f := newLoyaltyFixture(t)
+
+	_, err := f.shop.PurchaseItem(ctx, 1, "d1", "TEA", 1, "")
+	require.NoError(t, err)
+
+	t.Run("members cannot manage tiers", func(t *testing.T) {
+		err := f.loyalty.CreateTier(ctx, 1, &entity.RewardTier{DungeonID: "d1", Name: "Mine", DiscountPercent: 50})
+		assert.ErrorIs(t, err, ports.ErrForbidden)
+		assert.ErrorIs(t, f.loyalty.DeleteTier(ctx, 1, 1), ports.ErrForbidden)
+	})
+
+	t.Run("owners only manage their own dungeon", func(t *testing.T) {
+		err := f.loyalty.CreateTier(ctx, 2, &entity.RewardTier{DungeonID: "d2", Name: "Elsewhere", DiscountPercent: 5})
+		assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+		assert.ErrorIs(t, f.loyalty.DeleteTier(ctx, 3, 1), usecase.ErrNotDungeonMember)
+	})
+
+	t.Run("only members see a dungeon's tiers", func(t *testing.T) {
+		_, err := f.loyalty.ListTiers(ctx, 3, "d1")
+		assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+		_, err = f.loyalty.Standing(ctx, 3, "d1")
+		assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+	})
+
+	t.Run("invalid tiers are rejected", func(t *testing.T) {
+		err := f.loyalty.CreateTier(ctx, 2, &entity.RewardTier{DungeonID: "d1", Name: "Broken", DiscountPercent: 0})
+		assert.ErrorIs(t, err, entity.ErrInvalidTierDiscount)
+	})
+
+	t.Run("a new tier is assigned to members who already qualify", func(t *testing.T) {
+		starter := &entity.RewardTier{DungeonID: "d1", Name: "Starter", DiscountPercent: 5, MinPurchases: 1}
+		require.NoError(t, f.loyalty.CreateTier(ctx, 2, starter))
+
+		tier, err := f.loyalty.CurrentTier(ctx, &entity.User{ID: 1}, "d1")
+		require.NoError(t, err)
+		require.NotNil(t, tier)
+		assert.Equal(t, starter.ID, tier.ID)
+		assert.Equal(t, []inmemory.TierNotification{{UserID: 1, TierID: starter.ID}}, f.notifier.Sent())
+
+		tiers, err := f.loyalty.ListTiers(ctx, 1, "d1")
+		require.NoError(t, err)
+		require.Len(t, tiers, 2)
+		assert.Equal(t, "Starter", tiers[0].Name)
+	})
+
+	t.Run("deleting a tier drops its members", func(t *testing.T) {
+		tiers, err := f.loyalty.ListTiers(ctx, 1, "d1")
+		require.NoError(t, err)
+		require.NoError(t, f.loyalty.DeleteTier(ctx, 2, tiers[0].ID))
+
+		tier, err := f.loyalty.CurrentTier(ctx, &entity.User{ID: 1}, "d1")
+		require.NoError(t, err)
+		assert.Nil(t, tier)
+	})
+}
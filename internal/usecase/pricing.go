@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// Pricing rule names recorded in purchase price breakdowns
+const (
+	PricingRuleMemberPrice  = "member_price"
+	PricingRuleSale         = "sale"
+	PricingRuleDiscountTier = "discount_tier"
+	PricingRuleLoyaltyTier  = "loyalty_tier"
+)
+
+// priceScale is the number of decimal places prices are rounded to
+const priceScale = 8
+
+// PriceQuote is the price of a purchase as it moves through the pricing rules
+type PriceQuote struct {
+	User     *entity.User
+	Item     *entity.ShopItem
+	Quantity int
+	At       time.Time
+
+	Subtotal  valueobject.Decimal // Item price * quantity
+	Total     valueobject.Decimal // Subtotal after the adjustments so far
+	Breakdown []entity.PriceAdjustment
+}
+
+// Adjust changes the total by amount and records why. The total never drops
+// below zero; a larger discount is recorded as what it actually took off.
+func (q *PriceQuote) Adjust(rule, description string, amount valueobject.Decimal) {
+	amount = amount.Round(priceScale)
+	if q.Total.Add(amount).IsNegative() {
+		amount = q.Total.Mul(valueobject.NewDecimal("-1"))
+	}
+	if amount.IsZero() {
+		return
+	}
+
+	q.Total = q.Total.Add(amount)
+	q.Breakdown = append(q.Breakdown, entity.PriceAdjustment{
+		Rule:        rule,
+		Description: description,
+		Amount:      amount,
+	})
+}
+
+// unitTotal is the total for the quote's quantity at the given unit price
+func (q *PriceQuote) unitTotal(unitPrice valueobject.Decimal) valueobject.Decimal {
+	return unitPrice.Mul(valueobject.NewDecimalFromInt(int64(q.Quantity)))
+}
+
+// PricingRule adjusts a quote. Rules run in order and each one sees the total
+// left by the rules before it.
+type PricingRule interface {
+	Apply(ctx context.Context, quote *PriceQuote) error
+}
+
+// PricingRuleFunc adapts a function to a PricingRule
+type PricingRuleFunc func(ctx context.Context, quote *PriceQuote) error
+
+func (f PricingRuleFunc) Apply(ctx context.Context, quote *PriceQuote) error {
+	return f(ctx, quote)
+}
+
+// PricingPipeline computes the final price of a purchase from a list of rules.
+// A nil pipeline charges the list price.
+type PricingPipeline struct {
+	rules []PricingRule
+}
+
+func NewPricingPipeline(rules ...PricingRule) *PricingPipeline {
+	return &PricingPipeline{rules: rules}
+}
+
+// Quote prices quantity units of item for user
+func (p *PricingPipeline) Quote(ctx context.Context, user *entity.User, item *entity.ShopItem, quantity int, at time.Time) (*PriceQuote, error) {
+	subtotal := item.Price.Mul(valueobject.NewDecimalFromInt(int64(quantity)))
+	quote := &PriceQuote{
+		User:     user,
+		Item:     item,
+		Quantity: quantity,
+		At:       at,
+		Subtotal: subtotal,
+		Total:    subtotal,
+	}
+	if p == nil {
+		return quote, nil
+	}
+
+	for _, rule := range p.rules {
+		if err := rule.Apply(ctx, quote); err != nil {
+			return nil, fmt.Errorf("failed to apply pricing rule: %w", err)
+		}
+	}
+
+	return quote, nil
+}
+
+// MemberPriceRule charges a user's own price for an item when one is set
+func MemberPriceRule(repo ports.MemberPriceRepository) PricingRule {
+	return PricingRuleFunc(func(ctx context.Context, quote *PriceQuote) error {
+		price, err := repo.Find(ctx, quote.Item.ID, quote.User.ID)
+		if errors.Is(err, ports.ErrMemberPriceNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		quote.Adjust(PricingRuleMemberPrice, "Member price", quote.unitTotal(price.Price).Sub(quote.Total))
+		return nil
+	})
+}
+
+// SaleRule charges the item's sale price while the sale runs, unless the
+// quote is already cheaper
+func SaleRule() PricingRule {
+	return PricingRuleFunc(func(ctx context.Context, quote *PriceQuote) error {
+		if !quote.Item.OnSale(quote.At) {
+			return nil
+		}
+
+		saleTotal := quote.unitTotal(*quote.Item.SalePrice)
+		if saleTotal.Cmp(quote.Total) < 0 {
+			quote.Adjust(PricingRuleSale, "Sale", saleTotal.Sub(quote.Total))
+		}
+		return nil
+	})
+}
+
+// DiscountTierRule takes the percentage of the item's discount tier off the total
+func DiscountTierRule(repo ports.DiscountTierRepository) PricingRule {
+	return PricingRuleFunc(func(ctx context.Context, quote *PriceQuote) error {
+		if quote.Item.DiscountTierID == nil {
+			return nil
+		}
+
+		tier, err := repo.FindByID(ctx, *quote.Item.DiscountTierID)
+		if errors.Is(err, ports.ErrDiscountTierNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		quote.Adjust(PricingRuleDiscountTier, tier.Name, percentOf(quote.Total, tier.DiscountPercent).Mul(valueobject.NewDecimal("-1")))
+		return nil
+	})
+}
+
+// LoyaltyTierRule takes the discount of the buyer's loyalty tier in the item's
+// dungeon off the total
+func LoyaltyTierRule(loyalty *LoyaltyService) PricingRule {
+	return PricingRuleFunc(func(ctx context.Context, quote *PriceQuote) error {
+		if quote.Item.DungeonID == nil {
+			return nil
+		}
+		tier, err := loyalty.CurrentTier(ctx, quote.User, *quote.Item.DungeonID)
+		if err != nil {
+			return err
+		}
+		if tier == nil {
+			return nil
+		}
+
+		quote.Adjust(PricingRuleLoyaltyTier, tier.Name, percentOf(quote.Total, tier.DiscountPercent).Mul(valueobject.NewDecimal("-1")))
+		return nil
+	})
+}
+
+// percentOf returns percent% of amount
+func percentOf(amount valueobject.Decimal, percent float64) valueobject.Decimal {
+	return amount.Mul(valueobject.NewDecimalFromFloat(percent)).Div(valueobject.NewDecimalFromInt(100))
+}
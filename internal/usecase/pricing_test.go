@@ -0,0 +1,209 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+func decimalPtr(s string) *valueobject.Decimal {
+	d := valueobject.NewDecimal(s)
+	return &d
+}
+
+func TestPricingPipeline_Quote(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	user := &entity.User{ID: 1, ChatID: 100}
+
+	memberPrices := inmemory.NewMemberPriceRepository()
+	discountTiers := inmemory.NewDiscountTierRepository()
+	require.NoError(t, discountTiers.Create(ctx, &entity.DiscountTier{ID: 1, Name: "Regulars", DiscountPercent: 10}))
+	require.NoError(t, discountTiers.Create(ctx, &entity.DiscountTier{ID: 2, Name: "Everything free", DiscountPercent: 150}))
+
+	pipeline := usecase.NewPricingPipeline(
+		usecase.MemberPriceRule(memberPrices),
+		usecase.SaleRule(),
+		usecase.DiscountTierRule(discountTiers),
+	)
+
+	tierID := int64(1)
+	freeTierID := int64(2)
+	missingTierID := int64(99)
+
+	tests := []struct {
+		name      string
+		item      *entity.ShopItem
+		quantity  int
+		total     string
+		breakdown []string // rule names in order
+	}{
+		{
+			name:     "list price",
+			item:     &entity.ShopItem{ID: 1, Price: valueobject.NewDecimal("10")},
+			quantity: 3,
+			total:    "30",
+		},
+		{
+			name:      "running sale",
+			item:      &entity.ShopItem{ID: 2, Price: valueobject.NewDecimal("10"), SalePrice: decimalPtr("7")},
+			quantity:  2,
+			total:     "14",
+			breakdown: []string{usecase.PricingRuleSale},
+		},
+		{
+			name: "ended sale",
+			item: &entity.ShopItem{
+				ID: 3, Price: valueobject.NewDecimal("10"), SalePrice: decimalPtr("7"), SaleEndsAt: &now,
+			},
+			quantity: 1,
+			total:    "10",
+		},
+		{
+			name:      "discount tier",
+			item:      &entity.ShopItem{ID: 4, Price: valueobject.NewDecimal("10"), DiscountTierID: &tierID},
+			quantity:  3,
+			total:     "27",
+			breakdown: []string{usecase.PricingRuleDiscountTier},
+		},
+		{
+			name: "sale then discount tier",
+			item: &entity.ShopItem{
+				ID: 5, Price: valueobject.NewDecimal("10"), SalePrice: decimalPtr("8"), DiscountTierID: &tierID,
+			},
+			quantity:  1,
+			total:     "7.2",
+			breakdown: []string{usecase.PricingRuleSale, usecase.PricingRuleDiscountTier},
+		},
+		{
+			name:     "missing discount tier",
+			item:     &entity.ShopItem{ID: 6, Price: valueobject.NewDecimal("10"), DiscountTierID: &missingTierID},
+			quantity: 1,
+			total:    "10",
+		},
+		{
+			name:      "discount never goes below zero",
+			item:      &entity.ShopItem{ID: 7, Price: valueobject.NewDecimal("10"), DiscountTierID: &freeTierID},
+			quantity:  1,
+			total:     "0",
+			breakdown: []string{usecase.PricingRuleDiscountTier},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := pipeline.Quote(ctx, user, tt.item, tt.quantity, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.item.Price.Mul(valueobject.NewDecimalFromInt(int64(tt.quantity))).String(), quote.Subtotal.String())
+			assert.Equal(t, tt.total, quote.Total.String())
+
+			var rules []string
+			sum := quote.Subtotal
+			for _, adjustment := range quote.Breakdown {
+				rules = append(rules, adjustment.Rule)
+				sum = sum.Add(adjustment.Amount)
+			}
+			assert.Equal(t, tt.breakdown, rules)
+			assert.Equal(t, quote.Total.String(), sum.String(), "breakdown must add up to the total")
+		})
+	}
+
+	t.Run("member price replaces the list price", func(t *testing.T) {
+		item := &entity.ShopItem{ID: 8, Price: valueobject.NewDecimal("10"), SalePrice: decimalPtr("9")}
+		require.NoError(t, memberPrices.Set(ctx, &entity.MemberPrice{ItemID: 8, UserID: 1, Price: valueobject.NewDecimal("5")}))
+
+		quote, err := pipeline.Quote(ctx, user, item, 2, now)
+		require.NoError(t, err)
+		assert.Equal(t, "10", quote.Total.String())
+		require.Len(t, quote.Breakdown, 1)
+		assert.Equal(t, usecase.PricingRuleMemberPrice, quote.Breakdown[0].Rule)
+		assert.Equal(t, "-10", quote.Breakdown[0].Amount.String())
+
+		// Other members pay the sale price
+		quote, err = pipeline.Quote(ctx, &entity.User{ID: 2, ChatID: 100}, item, 2, now)
+		require.NoError(t, err)
+		assert.Equal(t, "18", quote.Total.String())
+	})
+
+	t.Run("nil pipeline charges the list price", func(t *testing.T) {
+		var none *usecase.PricingPipeline
+		quote, err := none.Quote(ctx, user, &entity.ShopItem{Price: valueobject.NewDecimal("4")}, 2, now)
+		require.NoError(t, err)
+		assert.Equal(t, "8", quote.Total.String())
+		assert.Empty(t, quote.Breakdown)
+	})
+}
+
+func TestShopService_PurchaseUsesFinalPrice(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := inmemory.NewUserRepository()
+	shopItemRepo := inmemory.NewShopItemRepository()
+	purchaseRepo := inmemory.NewPurchaseRepository()
+	discountTiers := inmemory.NewDiscountTierRepository()
+	walletRepo := inmemory.NewWalletRepository()
+	txManager := inmemory.NewTxManager()
+	ledger := usecase.NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, &sequenceUUIDGen{}, txManager)
+	dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+	service := usecase.NewShopService(shopItemRepo, purchaseRepo, userRepo, ledger, inmemory.NewChatConfigRepository(),
+		usecase.NewPricingPipeline(usecase.SaleRule(), usecase.DiscountTierRule(discountTiers)),
+		nil, &sequenceUUIDGen{}, txManager, nil, dungeonRepo, memberRepo)
+
+	dungeonID := "d1"
+	require.NoError(t, discountTiers.Create(ctx, &entity.DiscountTier{ID: 1, Name: "Regulars", DiscountPercent: 20}))
+	tierID := int64(1)
+	require.NoError(t, shopItemRepo.Create(ctx, &entity.ShopItem{
+		ID: 1, DungeonID: &dungeonID, Code: "POTION", Name: "Potion", Price: valueobject.NewDecimal("100"), IsActive: true, DiscountTierID: &tierID,
+	}))
+	require.NoError(t, shopItemRepo.Create(ctx, &entity.ShopItem{
+		ID: 2, DungeonID: &dungeonID, Code: "FREEBIE", Name: "Freebie", Price: valueobject.NewDecimal("5"), IsActive: true, SalePrice: decimalPtr("0"),
+	}))
+	require.NoError(t, userRepo.Create(ctx, &entity.User{ID: 1, ChatID: 100, Balance: valueobject.NewDecimal("90")}))
+	_, err := walletRepo.UpdateBalance(ctx, 1, dungeonID, valueobject.NewDecimal("90"))
+	require.NoError(t, err)
+
+	t.Run("discounted price is affordable", func(t *testing.T) {
+		purchase, err := service.PurchaseItem(ctx, 1, "d1", "POTION", 1, "")
+		require.NoError(t, err)
+		assert.Equal(t, "100", purchase.Subtotal.String())
+		assert.Equal(t, "80", purchase.TotalCost.String())
+		require.Len(t, purchase.PriceBreakdown, 1)
+		assert.Equal(t, "Regulars", purchase.PriceBreakdown[0].Description)
+
+		stored, err := purchaseRepo.FindByID(ctx, purchase.ID)
+		require.NoError(t, err)
+		assert.Equal(t, purchase.PriceBreakdown, stored.PriceBreakdown)
+
+		user, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "10", user.Balance.String())
+	})
+
+	t.Run("final price above balance is rejected", func(t *testing.T) {
+		_, err := service.PurchaseItem(ctx, 1, "d1", "POTION", 1, "")
+		assert.ErrorIs(t, err, ports.ErrInsufficientFunds)
+	})
+
+	t.Run("free purchase leaves the ledger alone", func(t *testing.T) {
+		purchase, err := service.PurchaseItem(ctx, 1, "d1", "FREEBIE", 2, "")
+		require.NoError(t, err)
+		assert.True(t, purchase.TotalCost.IsZero())
+
+		history, err := ledger.History(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("invalid quantity", func(t *testing.T) {
+		_, err := service.PurchaseItem(ctx, 1, "d1", "POTION", 0, "")
+		assert.ErrorIs(t, err, usecase.ErrInvalidPurchaseQuantity)
+	})
+}
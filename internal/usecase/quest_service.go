@@ -2,7 +2,10 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
@@ -10,9 +13,20 @@ import (
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
+// ErrAmbiguousQuest is returned by FindActiveQuest when a name matches several quests
+var (
+	ErrAmbiguousQuest = errors.New("several quests match that name, use the quest's number instead")
+	// ErrCompletionInProgress is returned while another attempt holds the idempotency key
+	ErrCompletionInProgress = errors.New("this completion is already being recorded")
+)
+
 type QuestService struct {
+	dungeonAccess
 	questRepo       ports.QuestRepository
+	completionRepo  ports.QuestCompletionRepository
+	streakRepo      ports.QuestStreakRepository
 	userRepo        ports.UserRepository
+	ledger          *LedgerService
 	uuidGen         ports.UUIDGenerator
 	scheduler       ports.Scheduler
 	idempotencyRepo ports.IdempotencyRepository
@@ -21,15 +35,24 @@ type QuestService struct {
 
 func NewQuestService(
 	questRepo ports.QuestRepository,
+	completionRepo ports.QuestCompletionRepository,
+	streakRepo ports.QuestStreakRepository,
 	userRepo ports.UserRepository,
+	dungeonRepo ports.DungeonRepository,
+	memberRepo ports.DungeonMemberRepository,
+	ledger *LedgerService,
 	uuidGen ports.UUIDGenerator,
 	scheduler ports.Scheduler,
 	idempotencyRepo ports.IdempotencyRepository,
 	txManager ports.TxManager,
 ) *QuestService {
 	return &QuestService{
+		dungeonAccess:   dungeonAccess{dungeonRepo: dungeonRepo, memberRepo: memberRepo},
 		questRepo:       questRepo,
+		completionRepo:  completionRepo,
+		streakRepo:      streakRepo,
 		userRepo:        userRepo,
+		ledger:          ledger,
 		uuidGen:         uuidGen,
 		scheduler:       scheduler,
 		idempotencyRepo: idempotencyRepo,
@@ -38,53 +61,66 @@ func NewQuestService(
 }
 
 type CreateQuestInput struct {
-	Title            string
-	Description      string
-	Category         string
-	Difficulty       string
-	Mode             string
-	PointsAward      valueobject.Decimal
-	RatePointsPerMin *valueobject.Decimal
-	MinMinutes       *int
-	MaxMinutes       *int
-	DailyPointsCap   *valueobject.Decimal
-	CooldownSec      int
-	StreakEnabled    bool
-	Status           string
-	TimeZone         string
+	Title              string
+	Description        string
+	Category           string
+	Difficulty         string
+	Mode               string
+	PointsAward        valueobject.Decimal
+	RatePointsPerMin   *valueobject.Decimal
+	MinMinutes         *int
+	MaxMinutes         *int
+	DailyPointsCap     *valueobject.Decimal
+	CooldownSec        int
+	StreakEnabled      bool
+	StreakGracePeriods int
+	Status             string
+	TimeZone           string
+}
+
+// CanCreateQuests returns nil if userID may add quests to the dungeon, so
+// multi-step flows can refuse before asking for any details
+func (s *QuestService) CanCreateQuests(ctx context.Context, userID int64, dungeonID string) error {
+	_, err := s.authorize(ctx, userID, dungeonID, entity.PermissionCreateQuests)
+	return err
 }
 
+// CreateQuest adds a quest to a dungeon whose owner or moderators include userID
 func (s *QuestService) CreateQuest(ctx context.Context, userID int64, dungeonID string, input CreateQuestInput) (*entity.Quest, error) {
-	// Validate user exists
-	_, err := s.userRepo.FindByID(ctx, userID)
-	if err != nil {
+	if _, err := s.authorize(ctx, userID, dungeonID, entity.PermissionCreateQuests); err != nil {
 		return nil, err
 	}
 
+	status := input.Status
+	if status == "" {
+		status = "active"
+	}
+
 	// Create quest entity
 	quest := &entity.Quest{
-		ID:               s.uuidGen.New(),
-		DungeonID:        dungeonID,
-		Title:            input.Title,
-		Description:      input.Description,
-		Category:         input.Category,
-		Difficulty:       input.Difficulty,
-		Mode:             input.Mode,
-		PointsAward:      input.PointsAward,
-		RatePointsPerMin: input.RatePointsPerMin,
-		MinMinutes:       input.MinMinutes,
-		MaxMinutes:       input.MaxMinutes,
-		DailyPointsCap:   input.DailyPointsCap,
-		CooldownSec:      input.CooldownSec,
-		StreakEnabled:    input.StreakEnabled,
-		Status:           input.Status,
-		TimeZone:         input.TimeZone,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		ID:                 s.uuidGen.New(),
+		DungeonID:          dungeonID,
+		Title:              input.Title,
+		Description:        input.Description,
+		Category:           input.Category,
+		Difficulty:         input.Difficulty,
+		Mode:               input.Mode,
+		PointsAward:        input.PointsAward,
+		RatePointsPerMin:   input.RatePointsPerMin,
+		MinMinutes:         input.MinMinutes,
+		MaxMinutes:         input.MaxMinutes,
+		DailyPointsCap:     input.DailyPointsCap,
+		CooldownSec:        input.CooldownSec,
+		StreakEnabled:      input.StreakEnabled,
+		StreakGracePeriods: input.StreakGracePeriods,
+		Status:             status,
+		TimeZone:           input.TimeZone,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
 	}
 
 	// Create quest
-	err = s.questRepo.Create(ctx, quest)
+	err := s.questRepo.Create(ctx, quest)
 	if err != nil {
 		return nil, err
 	}
@@ -121,46 +157,67 @@ type CompleteQuestInput struct {
 	Minutes         *int     // For PER_MINUTE mode
 }
 
-func (s *QuestService) CompleteQuest(ctx context.Context, userID int64, questID string, input CompleteQuestInput) error {
-	// Create idempotency key
-	idempKey := &entity.IdempotencyKey{
-		Key:       input.IdempotencyKey,
-		Operation: "quest_complete",
-		UserID:    userID,
-		Status:    "pending",
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(24 * time.Hour), // Expire after 24 hours
-	}
-
-	// Check if operation already exists
-	existingKey, err := s.idempotencyRepo.FindByKey(ctx, idempKey.Key)
-	if err == nil && existingKey != nil {
-		if existingKey.IsCompleted() {
-			// Operation already completed, return success
-			return nil
+// CompleteQuest scores a completion according to the quest's Mode, records it and
+// credits the awarded points to the user. Completions inside the quest's cooldown
+// are rejected with *entity.QuestCooldownError, and awards are clipped so the user's
+// daily total for the quest never exceeds DailyPointsCap. When streaks are enabled
+// the user's streak on the quest advances at most once per period. Replaying the same
+// idempotency key returns the originally recorded completion without awarding
+// points again. The key is claimed inside the transaction, so a failed attempt
+// releases it for a retry and concurrent attempts cannot both award points.
+func (s *QuestService) CompleteQuest(ctx context.Context, userID int64, questID string, input CompleteQuestInput) (*entity.QuestCompletion, error) {
+	var idempKey *entity.IdempotencyKey
+	if input.IdempotencyKey != "" {
+		previous, err := s.recordedCompletion(ctx, input.IdempotencyKey)
+		if err != nil || previous != nil {
+			return previous, err
 		}
-		if !existingKey.IsExpired() {
-			// Operation is still pending
-			return errors.New("operation in progress")
+
+		idempKey = &entity.IdempotencyKey{
+			Key:       input.IdempotencyKey,
+			Operation: "quest_complete",
+			UserID:    userID,
+			Status:    "pending",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(24 * time.Hour), // Expire after 24 hours
 		}
 	}
 
-	// Create idempotency key
-	err = s.idempotencyRepo.Create(ctx, idempKey)
-	if err != nil && err != ports.ErrIdempotencyKeyExists {
-		return err
-	}
+	var completion *entity.QuestCompletion
 
 	// Execute the operation in a transaction
-	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		// Claim the key first; a concurrent attempt waits here until this
+		// transaction ends and then finds the key taken
+		if idempKey != nil {
+			if err := s.idempotencyRepo.Claim(ctx, idempKey); err != nil {
+				return err
+			}
+		}
+
 		// Get quest
 		quest, err := s.questRepo.GetByID(ctx, questID)
 		if err != nil {
 			return err
 		}
 
-		// Verify user exists
-		_, err = s.userRepo.FindByID(ctx, userID)
+		if quest.Status != "" && quest.Status != "active" {
+			return entity.ErrQuestNotActive
+		}
+
+		// Only members of the quest's dungeon earn points in it
+		if _, _, err := s.role(ctx, userID, quest.DungeonID); err != nil {
+			return err
+		}
+
+		// Lock the user so concurrent completions see each other when checking
+		// the cooldown and daily cap
+		if err := s.userRepo.Lock(ctx, userID); err != nil {
+			return err
+		}
+
+		// Score the completion
+		awarded, err := quest.CalculateAward(input.CompletionRatio, input.Minutes)
 		if err != nil {
 			return err
 		}
@@ -178,9 +235,71 @@ func (s *QuestService) CompleteQuest(ctx context.Context, userID int64, questID
 			now = time.Now().UTC()
 		}
 
+		// Enforce the cooldown between this user's completions
+		if quest.CooldownSec > 0 {
+			last, err := s.completionRepo.LastForUser(ctx, userID, quest.ID)
+			if err != nil && !errors.Is(err, ports.ErrQuestCompletionNotFound) {
+				return err
+			}
+			if last != nil {
+				if remaining := quest.CooldownRemaining(last.SubmittedAt, now); remaining > 0 {
+					return &entity.QuestCooldownError{Remaining: remaining}
+				}
+			}
+		}
+
+		// Clip the award to what is left of today's cap
+		if quest.DailyPointsCap != nil {
+			awardedToday, err := s.completionRepo.SumAwardedForUserOnDay(ctx, userID, quest.ID, now, quest.TimeZone)
+			if err != nil {
+				return err
+			}
+			awarded = quest.CapAward(awarded, awardedToday)
+		}
+
+		// Record the completion
+		completion = &entity.QuestCompletion{
+			ID:              s.uuidGen.New(),
+			QuestID:         quest.ID,
+			UserID:          userID,
+			DungeonID:       quest.DungeonID,
+			SubmittedAt:     now,
+			CompletionRatio: input.CompletionRatio,
+			Minutes:         input.Minutes,
+			AwardedPoints:   awarded,
+			IdempotencyKey:  input.IdempotencyKey,
+		}
+		err = s.completionRepo.Insert(ctx, completion)
+		if err != nil {
+			return err
+		}
+
+		// Credit the user's wallet in the quest's dungeon
+		if awarded.IsPositive() {
+			_, err = s.ledger.Post(ctx, Posting{
+				UserID:     userID,
+				DungeonID:  quest.DungeonID,
+				Amount:     awarded,
+				Reason:     entity.LedgerReasonQuestCompletion,
+				SourceType: entity.LedgerSourceQuestCompletion,
+				SourceID:   completion.ID,
+				Note:       quest.Title,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Advance the user's streak
+		if quest.StreakEnabled {
+			err = s.advanceStreak(ctx, userID, quest, now)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Update quest completion
 		quest.LastCompletedAt = &now
-		quest.StreakCount++
 
 		err = s.questRepo.Update(ctx, quest)
 		if err != nil {
@@ -195,35 +314,202 @@ func (s *QuestService) CompleteQuest(ctx context.Context, userID int64, questID
 			}
 		}
 
+		// Record the outcome under the key, committed with the completion
+		if idempKey != nil {
+			result, err := json.Marshal(completion)
+			if err != nil {
+				return err
+			}
+			completedAt := time.Now()
+			idempKey.Status = "completed"
+			idempKey.Result = string(result)
+			idempKey.CompletedAt = &completedAt
+			if err := s.idempotencyRepo.Update(ctx, idempKey); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
-	// Update idempotency key status
-	completedAt := time.Now()
-	idempKey.CompletedAt = &completedAt
+	if errors.Is(err, ports.ErrIdempotencyKeyExists) {
+		// Another attempt holds the key, or recorded its completion meanwhile
+		previous, findErr := s.recordedCompletion(ctx, input.IdempotencyKey)
+		if findErr != nil {
+			return nil, findErr
+		}
+		if previous == nil {
+			return nil, ErrCompletionInProgress
+		}
+		return previous, nil
+	}
 	if err != nil {
-		idempKey.Status = "failed"
-		idempKey.Result = err.Error()
-	} else {
-		idempKey.Status = "completed"
-		idempKey.Result = "success"
+		return nil, err
 	}
 
-	updateErr := s.idempotencyRepo.Update(ctx, idempKey)
-	if updateErr != nil {
-		// Log error but don't fail the operation
-		// In production, you'd want to log this
+	return completion, nil
+}
+
+// recordedCompletion returns the completion recorded under a completed
+// idempotency key, or nil when the key is unknown or not completed
+func (s *QuestService) recordedCompletion(ctx context.Context, key string) (*entity.QuestCompletion, error) {
+	existing, err := s.idempotencyRepo.FindByKey(ctx, key)
+	if err != nil || !existing.IsCompleted() {
+		return nil, nil
 	}
 
-	return err
+	var previous entity.QuestCompletion
+	if err := json.Unmarshal([]byte(existing.Result), &previous); err != nil {
+		return nil, err
+	}
+	return &previous, nil
 }
 
-func (s *QuestService) ListQuests(ctx context.Context, userID int64, dungeonID string) ([]*entity.Quest, error) {
-	// Verify user exists
-	_, err := s.userRepo.FindByID(ctx, userID)
+func (s *QuestService) advanceStreak(ctx context.Context, userID int64, quest *entity.Quest, now time.Time) error {
+	period, err := quest.StreakPeriod(now)
+	if err != nil {
+		return err
+	}
+
+	streak, err := s.streakRepo.Get(ctx, userID, quest.ID)
+	if err == ports.ErrQuestStreakNotFound {
+		streak = &entity.QuestStreak{UserID: userID, QuestID: quest.ID}
+	} else if err != nil {
+		return err
+	}
+
+	if !streak.Advance(period, quest.StreakGracePeriods) {
+		return nil
+	}
+	streak.UpdatedAt = now
+	return s.streakRepo.Upsert(ctx, streak)
+}
+
+// StreakSummary is a user's streak on a single quest as of now
+type StreakSummary struct {
+	QuestID    string
+	QuestTitle string
+	Current    int
+	Best       int
+}
+
+// GetStreak returns the user's current and best streak on a quest. A streak that
+// has missed more periods than the quest's grace allows is reported as zero.
+func (s *QuestService) GetStreak(ctx context.Context, userID int64, questID string) (*StreakSummary, error) {
+	quest, err := s.questRepo.GetByID(ctx, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	streak, err := s.streakRepo.Get(ctx, userID, questID)
+	if err == ports.ErrQuestStreakNotFound {
+		return &StreakSummary{QuestID: quest.ID, QuestTitle: quest.Title}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	return summarizeStreak(quest, streak, time.Now())
+}
+
+// ListStreaks returns the user's streaks on all quests they have completed
+func (s *QuestService) ListStreaks(ctx context.Context, userID int64) ([]*StreakSummary, error) {
+	streaks, err := s.streakRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summaries := make([]*StreakSummary, 0, len(streaks))
+	for _, streak := range streaks {
+		quest, err := s.questRepo.GetByID(ctx, streak.QuestID)
+		if err != nil {
+			return nil, err
+		}
+
+		summary, err := summarizeStreak(quest, streak, now)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func summarizeStreak(quest *entity.Quest, streak *entity.QuestStreak, now time.Time) (*StreakSummary, error) {
+	period, err := quest.StreakPeriod(now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreakSummary{
+		QuestID:    quest.ID,
+		QuestTitle: quest.Title,
+		Current:    streak.CurrentAt(period, quest.StreakGracePeriods),
+		Best:       streak.Best,
+	}, nil
+}
+
+// ListQuests returns the quests of a dungeon userID is a member of
+func (s *QuestService) ListQuests(ctx context.Context, userID int64, dungeonID string) ([]*entity.Quest, error) {
+	if _, _, err := s.role(ctx, userID, dungeonID); err != nil {
+		return nil, err
+	}
+
 	return s.questRepo.ListByDungeon(ctx, dungeonID)
 }
+
+// ListActiveQuests returns the quests of a dungeon userID is a member of that
+// can be completed right now, oldest first
+func (s *QuestService) ListActiveQuests(ctx context.Context, userID int64, dungeonID string) ([]*entity.Quest, error) {
+	quests, err := s.ListQuests(ctx, userID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	var active []*entity.Quest
+	for _, quest := range quests {
+		if quest.Status == "" || quest.Status == "active" {
+			active = append(active, quest)
+		}
+	}
+	return active, nil
+}
+
+// FindActiveQuest resolves ref to one of the dungeon's active quests. ref is
+// the quest's 1-based position in ListActiveQuests, its ID, its title or the
+// start of its title, ignoring case.
+func (s *QuestService) FindActiveQuest(ctx context.Context, userID int64, dungeonID, ref string) (*entity.Quest, error) {
+	quests, err := s.ListActiveQuests(ctx, userID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	ref = strings.TrimSpace(ref)
+	if n, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		if n < 1 || n > len(quests) {
+			return nil, ports.ErrQuestNotFound
+		}
+		return quests[n-1], nil
+	}
+
+	var prefixed []*entity.Quest
+	for _, quest := range quests {
+		if quest.ID == ref || strings.EqualFold(quest.Title, ref) {
+			return quest, nil
+		}
+		if ref != "" && strings.HasPrefix(strings.ToLower(quest.Title), strings.ToLower(ref)) {
+			prefixed = append(prefixed, quest)
+		}
+	}
+
+	switch len(prefixed) {
+	case 0:
+		return nil, ports.ErrQuestNotFound
+	case 1:
+		return prefixed[0], nil
+	default:
+		return nil, ErrAmbiguousQuest
+	}
+}
@@ -0,0 +1,199 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase/testhelpers"
+)
+
+// Zones with interesting DST rules: northern and southern hemisphere, half-hour
+// shifts, transitions at midnight, and zones without DST for contrast.
+var dstTestZones = []string{
+	"UTC",
+	"America/New_York",
+	"Europe/Berlin",
+	"Europe/London",
+	"Australia/Sydney",
+	"Australia/Lord_Howe",
+	"America/Santiago",
+	"America/Havana",
+	"Asia/Tehran",
+	"Asia/Tokyo",
+}
+
+// Random instants between 2000 and 2040
+func randomInstant(seconds uint32) time.Time {
+	const from = 946684800  // 2000-01-01
+	const span = 1262304000 // 40 years
+	return time.Unix(from+int64(seconds)%span, 0)
+}
+
+// nearTransition moves t to the zone transition closest to it, shifted by up to
+// ±3 hours, so that the properties below are exercised right at DST edges.
+func nearTransition(t time.Time, loc *time.Location, shift int16) time.Time {
+	_, end := t.In(loc).ZoneBounds()
+	if end.IsZero() {
+		return t
+	}
+	return end.Add(time.Duration(shift%180) * time.Minute)
+}
+
+func checkDayWindowProperties(t *testing.T, instant time.Time, tz string) bool {
+	loc, err := time.LoadLocation(tz)
+	require.NoError(t, err)
+
+	w, err := valueobject.NewDayWindow(instant, tz)
+	if err != nil {
+		return false
+	}
+
+	// The window contains the instant it was built from
+	if !w.Contains(instant) {
+		t.Logf("%s: window %v-%v does not contain %v", tz, w.Start, w.End, instant)
+		return false
+	}
+
+	// Start is the first instant of the instant's local date
+	y, m, d := instant.In(loc).Date()
+	sy, sm, sd := w.Start.In(loc).Date()
+	py, pm, pd := w.Start.Add(-time.Nanosecond).In(loc).Date()
+	if sy != y || sm != m || sd != d || (py == y && pm == m && pd == d) {
+		t.Logf("%s: %v is not the start of %04d-%02d-%02d", tz, w.Start, y, m, d)
+		return false
+	}
+
+	// The length of the day is the elapsed wall-clock time corrected by the change
+	// in UTC offset (days may start after midnight when midnight is skipped)
+	wallClock := func(t time.Time) time.Time {
+		lt := t.In(loc)
+		return time.Date(lt.Year(), lt.Month(), lt.Day(), lt.Hour(), lt.Minute(), lt.Second(), 0, time.UTC)
+	}
+	_, startOffset := w.Start.In(loc).Zone()
+	_, endOffset := w.End.In(loc).Zone()
+	want := wallClock(w.End).Sub(wallClock(w.Start)) + time.Duration(startOffset-endOffset)*time.Second
+	if w.Duration() != want {
+		t.Logf("%s: day starting %v lasts %v, want %v", tz, w.Start, w.Duration(), want)
+		return false
+	}
+
+	// Consecutive days tile the timeline without gaps or overlaps
+	next, err := valueobject.NewDayWindow(w.End, tz)
+	if err != nil || !next.Start.Equal(w.End) || !next.Start.Equal(w.Next().Start) || !next.End.Equal(w.Next().End) {
+		t.Logf("%s: day after %v does not start at its end %v", tz, w.Start, w.End)
+		return false
+	}
+
+	return true
+}
+
+// Property: every instant maps to exactly one local day and day windows tile time
+func TestDayWindow_RandomInstants(t *testing.T) {
+	for _, tz := range dstTestZones {
+		tz := tz
+		t.Run(tz, func(t *testing.T) {
+			f := func(seconds uint32) bool {
+				return checkDayWindowProperties(t, randomInstant(seconds), tz)
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Property: day windows stay correct within hours of a DST transition
+func TestDayWindow_AroundDSTTransitions(t *testing.T) {
+	for _, tz := range dstTestZones {
+		tz := tz
+		t.Run(tz, func(t *testing.T) {
+			loc, err := time.LoadLocation(tz)
+			require.NoError(t, err)
+
+			f := func(seconds uint32, shift int16) bool {
+				return checkDayWindowProperties(t, nearTransition(randomInstant(seconds), loc, shift), tz)
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Property: days containing a DST transition are never exactly 24 hours long
+func TestDayWindow_TransitionDaysAreShortOrLong(t *testing.T) {
+	for _, tz := range dstTestZones {
+		tz := tz
+		t.Run(tz, func(t *testing.T) {
+			loc, err := time.LoadLocation(tz)
+			require.NoError(t, err)
+
+			f := func(seconds uint32) bool {
+				instant := randomInstant(seconds)
+				transition := nearTransition(instant, loc, 0)
+				if transition.Equal(instant) {
+					return true // Zone without transitions
+				}
+				w, err := valueobject.NewDayWindow(transition, tz)
+				if err != nil {
+					return false
+				}
+				_, before := transition.Add(-time.Second).Zone()
+				_, after := transition.In(loc).Zone()
+				if before == after {
+					return true // Only the zone abbreviation changed
+				}
+				return w.Duration() != 24*time.Hour
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 200}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Property: the day used for the daily cap is the local day of the completion
+func TestQuestService_DailyCapUsesQuestLocalDay(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tz := range dstTestZones {
+		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
+		userRepo := new(testhelpers.MockUserRepository)
+		idempotencyRepo := new(testhelpers.MockIdempotencyRepository)
+
+		dailyCap := valueobject.NewDecimal("100")
+		quest := &entity.Quest{ID: "q", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1"), DailyPointsCap: &dailyCap, TimeZone: tz}
+
+		var capDay time.Time
+		questRepo.On("GetByID", ctx, "q").Return(quest, nil)
+		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil)
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil)
+		completionRepo.On("SumAwardedForUserOnDay", ctx, int64(1), "q", mock.AnythingOfType("time.Time"), tz).
+			Run(func(args mock.Arguments) { capDay = args.Get(3).(time.Time) }).
+			Return(valueobject.NewDecimal("0"), nil)
+		idempotencyRepo.On("FindByKey", ctx, tz).Return(nil, ports.ErrIdempotencyKeyNotFound)
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil)
+		idempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil)
+
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), &mockUUIDGen{}, new(testhelpers.MockScheduler), idempotencyRepo, &mockTxManager{})
+
+		completion, err := service.CompleteQuest(ctx, 1, "q", usecase.CompleteQuestInput{IdempotencyKey: tz})
+		require.NoError(t, err, tz)
+
+		w, err := valueobject.NewDayWindow(capDay, tz)
+		require.NoError(t, err)
+		require.True(t, w.Contains(completion.SubmittedAt), "%s: completion at %v outside cap day %v-%v", tz, completion.SubmittedAt, w.Start, w.End)
+	}
+}
@@ -2,11 +2,17 @@ package usecase_test
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase/testhelpers"
@@ -21,6 +27,7 @@ func (m *mockUUIDGen) New() string {
 func TestQuestService(t *testing.T) {
 	ctx := context.Background()
 	questRepo := new(testhelpers.MockQuestRepository)
+	completionRepo := new(testhelpers.MockQuestCompletionRepository)
 	userRepo := new(testhelpers.MockUserRepository)
 
 	uuidGen := &mockUUIDGen{}
@@ -28,7 +35,14 @@ func TestQuestService(t *testing.T) {
 	mockIdempotencyRepo := new(testhelpers.MockIdempotencyRepository)
 	mockTxManager := new(testhelpers.MockTxManager)
 
-	service := usecase.NewQuestService(questRepo, userRepo, uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
+	// User 1 owns dungeon-1 and user 2 is a plain member
+	dungeonRepo := inmemory.NewDungeonRepository()
+	memberRepo := inmemory.NewDungeonMemberRepository()
+	require.NoError(t, dungeonRepo.Create(ctx, &entity.Dungeon{ID: "dungeon-1", AdminUserID: 1}))
+	require.NoError(t, memberRepo.Add(ctx, "dungeon-1", 1, entity.DungeonRoleOwner))
+	require.NoError(t, memberRepo.Add(ctx, "dungeon-1", 2, entity.DungeonRoleMember))
+
+	service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
 
 	t.Run("CreateQuest", func(t *testing.T) {
 		input := usecase.CreateQuestInput{
@@ -39,7 +53,6 @@ func TestQuestService(t *testing.T) {
 
 		// Setup mock expectations
 		questRepo.On("Create", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil).Once()
-		userRepo.On("FindByID", ctx, int64(1)).Return(&entity.User{ID: 1}, nil).Once()
 
 		// Mock the scheduler call for daily quests
 		mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil).Once()
@@ -48,55 +61,497 @@ func TestQuestService(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "generated-uuid", created.ID)
 		require.Equal(t, "Test Quest", created.Title)
+		require.Equal(t, "active", created.Status, "quests start active unless told otherwise")
 
 		mockScheduler.AssertExpectations(t)
 		mockIdempotencyRepo.AssertExpectations(t)
 		mockTxManager.AssertExpectations(t)
 	})
 
+	t.Run("CreateQuestNeedsPermission", func(t *testing.T) {
+		_, err := service.CreateQuest(ctx, 2, "dungeon-1", usecase.CreateQuestInput{Title: "Mine", Category: "adhoc"})
+		require.ErrorIs(t, err, ports.ErrForbidden)
+
+		_, err = service.CreateQuest(ctx, 3, "dungeon-1", usecase.CreateQuestInput{Title: "Mine", Category: "adhoc"})
+		require.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+
+		require.NoError(t, service.CanCreateQuests(ctx, 1, "dungeon-1"))
+		require.ErrorIs(t, service.CanCreateQuests(ctx, 2, "dungeon-1"), ports.ErrForbidden)
+	})
+
 	t.Run("CompleteQuest", func(t *testing.T) {
 		quest := &entity.Quest{
 			ID:          "quest-1",
+			DungeonID:   "dungeon-1",
 			Title:       "Complete Me",
 			StreakCount: 0,
 			Category:    "daily",
+			Mode:        entity.QuestModeBinary,
+			PointsAward: valueobject.NewDecimal("10"),
 		}
 		questRepo.On("GetByID", ctx, "quest-1").Return(quest, nil)
 		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
-		userRepo.On("FindByID", ctx, int64(1)).Return(&entity.User{ID: 1}, nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), valueobject.NewDecimal("10")).Return(valueobject.NewDecimal("0"), nil)
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil)
 
 		// Mock idempotency check
 		mockIdempotencyRepo.On("FindByKey", ctx, mock.AnythingOfType("string")).Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
-		mockIdempotencyRepo.On("Create", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		mockIdempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 		mockIdempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 
-		// Mock transaction
-		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Run(func(args mock.Arguments) {
-			fn := args.Get(1).(func(context.Context) error)
-			// Mock the scheduler call for daily quests
-			mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
-			fn(ctx)
-		}).Return(nil).Once()
+		// Daily quests are rescheduled; the mock transaction runs fn once
+		mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
+		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Return(nil).Once()
 
 		input := usecase.CompleteQuestInput{
 			IdempotencyKey: "key-1",
 		}
-		err := service.CompleteQuest(ctx, 1, "quest-1", input)
+		completion, err := service.CompleteQuest(ctx, 1, "quest-1", input)
 		require.NoError(t, err)
+		require.Equal(t, "quest-1", completion.QuestID)
+		require.Equal(t, "dungeon-1", completion.DungeonID)
+		require.Equal(t, "10", completion.AwardedPoints.String())
 
 		mockScheduler.AssertExpectations(t)
+		userRepo.AssertCalled(t, "UpdateBalance", ctx, int64(1), valueobject.NewDecimal("10"))
+		completionRepo.AssertCalled(t, "Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion"))
 	})
 
 	t.Run("ListQuests", func(t *testing.T) {
-		userRepo.On("FindByID", ctx, int64(1)).Return(&entity.User{ID: 1}, nil).Once()
 		// Setup mock to return quests
 		questRepo.On("ListByDungeon", ctx, "dungeon-1").Return([]*entity.Quest{
 			{ID: "quest-1", Title: "Test Quest 1"},
 			{ID: "quest-2", Title: "Test Quest 2"},
 		}, nil).Once()
 
-		quests, err := service.ListQuests(ctx, 1, "dungeon-1")
+		quests, err := service.ListQuests(ctx, 2, "dungeon-1")
 		require.NoError(t, err)
 		require.Len(t, quests, 2)
+
+		_, err = service.ListQuests(ctx, 3, "dungeon-1")
+		require.ErrorIs(t, err, ports.ErrForbidden)
+	})
+}
+
+func TestQuestService_FindActiveQuest(t *testing.T) {
+	ctx := context.Background()
+	questRepo := inmemory.NewQuestRepository()
+	dungeonRepo := inmemory.NewDungeonRepository()
+	memberRepo := inmemory.NewDungeonMemberRepository()
+	require.NoError(t, dungeonRepo.Create(ctx, &entity.Dungeon{ID: "dungeon-1", AdminUserID: 1}))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, quest := range []*entity.Quest{
+		{ID: "q-walk", Title: "Walk the dog", Status: "active"},
+		{ID: "q-paused", Title: "Wash the car", Status: "paused"},
+		{ID: "q-wash", Title: "Wash dishes", Status: "active"},
+		{ID: "q-water", Title: "Water plants"},
+	} {
+		quest.DungeonID = "dungeon-1"
+		quest.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, questRepo.Create(ctx, quest))
+	}
+
+	userRepo := new(testhelpers.MockUserRepository)
+	service := usecase.NewQuestService(questRepo, new(testhelpers.MockQuestCompletionRepository), new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), &mockUUIDGen{}, new(testhelpers.MockScheduler), new(testhelpers.MockIdempotencyRepository), &mockTxManager{})
+
+	active, err := service.ListActiveQuests(ctx, 1, "dungeon-1")
+	require.NoError(t, err)
+	var ids []string
+	for _, quest := range active {
+		ids = append(ids, quest.ID)
+	}
+	require.Equal(t, []string{"q-walk", "q-wash", "q-water"}, ids, "paused quests are left out")
+
+	for ref, want := range map[string]string{
+		"2":           "q-wash",
+		"#3":          "q-water",
+		"q-walk":      "q-walk",
+		"WASH DISHES": "q-wash",
+		"wash":        "q-wash",
+		"wa":          "",
+		"Wash the":    "",
+		"4":           "",
+		"0":           "",
+	} {
+		quest, err := service.FindActiveQuest(ctx, 1, "dungeon-1", ref)
+		switch {
+		case want != "":
+			require.NoError(t, err, ref)
+			require.Equal(t, want, quest.ID, ref)
+		case ref == "wa":
+			require.ErrorIs(t, err, usecase.ErrAmbiguousQuest, ref)
+		default:
+			require.ErrorIs(t, err, ports.ErrQuestNotFound, ref)
+		}
+	}
+
+	_, err = service.FindActiveQuest(ctx, 2, "dungeon-1", "1")
+	require.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+}
+
+func TestQuestService_CompleteQuestScoring(t *testing.T) {
+	ctx := context.Background()
+	ratio := 0.5
+
+	newService := func(quest *entity.Quest) (*usecase.QuestService, *testhelpers.MockUserRepository, *testhelpers.MockQuestCompletionRepository, *testhelpers.MockIdempotencyRepository) {
+		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
+		userRepo := new(testhelpers.MockUserRepository)
+		idempotencyRepo := new(testhelpers.MockIdempotencyRepository)
+
+		questRepo.On("GetByID", ctx, quest.ID).Return(quest, nil)
+		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), &mockUUIDGen{}, new(testhelpers.MockScheduler), idempotencyRepo, &mockTxManager{})
+		return service, userRepo, completionRepo, idempotencyRepo
+	}
+
+	t.Run("PARTIAL quest awards a share of the points", func(t *testing.T) {
+		quest := &entity.Quest{ID: "partial", Category: "adhoc", Mode: entity.QuestModePartial, PointsAward: valueobject.NewDecimal("30")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		idempotencyRepo.On("FindByKey", ctx, "partial-key").Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		idempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil).Once()
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+
+		completion, err := service.CompleteQuest(ctx, 1, "partial", usecase.CompleteQuestInput{
+			IdempotencyKey:  "partial-key",
+			CompletionRatio: &ratio,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, completion.AwardedPoints.Cmp(valueobject.NewDecimal("15")))
+		require.Equal(t, &ratio, completion.CompletionRatio)
+		idempotencyRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid input is rejected without crediting", func(t *testing.T) {
+		quest := &entity.Quest{ID: "partial", Category: "adhoc", Mode: entity.QuestModePartial, PointsAward: valueobject.NewDecimal("30")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		idempotencyRepo.On("FindByKey", ctx, "bad-key").Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		idempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+
+		_, err := service.CompleteQuest(ctx, 1, "partial", usecase.CompleteQuestInput{IdempotencyKey: "bad-key"})
+		require.ErrorIs(t, err, entity.ErrCompletionRatioRequired)
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("users outside the quest's dungeon cannot complete it", func(t *testing.T) {
+		quest := &entity.Quest{ID: "binary", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		idempotencyRepo.On("FindByKey", ctx, "outsider-key").Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		idempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+
+		_, err := service.CompleteQuest(ctx, 2, "binary", usecase.CompleteQuestInput{IdempotencyKey: "outsider-key"})
+		require.ErrorIs(t, err, ports.ErrForbidden)
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("completed idempotency key returns the recorded completion", func(t *testing.T) {
+		quest := &entity.Quest{ID: "binary", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		idempotencyRepo.On("FindByKey", ctx, "done-key").Return(&entity.IdempotencyKey{
+			Key:    "done-key",
+			Status: "completed",
+			Result: `{"ID":"c-1","QuestID":"binary","UserID":1,"AwardedPoints":"10"}`,
+		}, nil).Once()
+
+		completion, err := service.CompleteQuest(ctx, 1, "binary", usecase.CompleteQuestInput{IdempotencyKey: "done-key"})
+		require.NoError(t, err)
+		require.Equal(t, "c-1", completion.ID)
+		require.Equal(t, "10", completion.AwardedPoints.String())
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("key claimed by a concurrent attempt returns its completion", func(t *testing.T) {
+		quest := &entity.Quest{ID: "binary", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		idempotencyRepo.On("FindByKey", ctx, "race-key").Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(ports.ErrIdempotencyKeyExists).Once()
+		idempotencyRepo.On("FindByKey", ctx, "race-key").Return(&entity.IdempotencyKey{
+			Key:    "race-key",
+			Status: "completed",
+			Result: `{"ID":"c-1","QuestID":"binary","UserID":1,"AwardedPoints":"10"}`,
+		}, nil).Once()
+
+		completion, err := service.CompleteQuest(ctx, 1, "binary", usecase.CompleteQuestInput{IdempotencyKey: "race-key"})
+		require.NoError(t, err)
+		require.Equal(t, "c-1", completion.ID)
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("key held by a pending attempt is in progress", func(t *testing.T) {
+		quest := &entity.Quest{ID: "binary", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10")}
+		service, userRepo, completionRepo, idempotencyRepo := newService(quest)
+
+		pending := &entity.IdempotencyKey{Key: "busy-key", Status: "pending", ExpiresAt: time.Now().Add(time.Hour)}
+		idempotencyRepo.On("FindByKey", ctx, "busy-key").Return(pending, nil).Twice()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(ports.ErrIdempotencyKeyExists).Once()
+
+		_, err := service.CompleteQuest(ctx, 1, "binary", usecase.CompleteQuestInput{IdempotencyKey: "busy-key"})
+		require.ErrorIs(t, err, usecase.ErrCompletionInProgress)
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestQuestService_ConcurrentPressesAwardOnce(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewInMemory()
+	require.NoError(t, s.Users.Create(ctx, &entity.User{ID: 1, Balance: valueobject.NewDecimal("0")}))
+	require.NoError(t, s.Dungeons.Create(ctx, &entity.Dungeon{ID: "d1", Title: "Test", AdminUserID: 1}))
+	require.NoError(t, s.Quests.Create(ctx, &entity.Quest{ID: "q1", DungeonID: "d1", Title: "Dishes", Category: "adhoc",
+		Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10"), Status: "active"}))
+
+	ledger := usecase.NewLedgerService(s.Ledger, s.Wallets, s.Users, s.UUIDGen, s.TxManager)
+	service := usecase.NewQuestService(s.Quests, s.Completions, s.Streaks, s.Users, s.Dungeons, s.DungeonMembers, ledger,
+		s.UUIDGen, s.Scheduler, s.Idempotency, s.TxManager)
+
+	// Every press of the same button reports the one recorded completion
+	ids := make([]string, 8)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			completion, err := service.CompleteQuest(ctx, 1, "q1", usecase.CompleteQuestInput{IdempotencyKey: "press"})
+			if err == nil {
+				ids[i] = completion.ID
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.NotEmpty(t, ids[0])
+	for _, id := range ids {
+		require.Equal(t, ids[0], id)
+	}
+	balance, err := ledger.Balance(ctx, 1, "d1")
+	require.NoError(t, err)
+	require.Equal(t, "10", balance.String())
+}
+
+func TestQuestService_CompleteQuestAntiAbuse(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(quest *entity.Quest, key string) (*usecase.QuestService, *testhelpers.MockUserRepository, *testhelpers.MockQuestCompletionRepository) {
+		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
+		userRepo := new(testhelpers.MockUserRepository)
+		idempotencyRepo := new(testhelpers.MockIdempotencyRepository)
+
+		questRepo.On("GetByID", ctx, quest.ID).Return(quest, nil)
+		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		idempotencyRepo.On("FindByKey", ctx, key).Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
+		idempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		idempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), &mockUUIDGen{}, new(testhelpers.MockScheduler), idempotencyRepo, &mockTxManager{})
+		return service, userRepo, completionRepo
+	}
+
+	t.Run("completion during cooldown is rejected with time left", func(t *testing.T) {
+		quest := &entity.Quest{ID: "cool", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10"), CooldownSec: 3600}
+		service, userRepo, completionRepo := setup(quest, "cool-key")
+
+		completionRepo.On("LastForUser", ctx, int64(1), "cool").Return(&entity.QuestCompletion{
+			SubmittedAt: time.Now().Add(-10 * time.Minute),
+		}, nil).Once()
+
+		_, err := service.CompleteQuest(ctx, 1, "cool", usecase.CompleteQuestInput{IdempotencyKey: "cool-key"})
+		require.ErrorIs(t, err, entity.ErrQuestOnCooldown)
+
+		var cooldownErr *entity.QuestCooldownError
+		require.True(t, errors.As(err, &cooldownErr))
+		require.InDelta(t, (50 * time.Minute).Seconds(), cooldownErr.Remaining.Seconds(), 5)
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+		completionRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("completion after cooldown is accepted", func(t *testing.T) {
+		quest := &entity.Quest{ID: "cool", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10"), CooldownSec: 60}
+		service, userRepo, completionRepo := setup(quest, "cool-key-2")
+
+		completionRepo.On("LastForUser", ctx, int64(1), "cool").Return(&entity.QuestCompletion{
+			SubmittedAt: time.Now().Add(-2 * time.Minute),
+		}, nil).Once()
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil).Once()
+		userRepo.On("UpdateBalance", ctx, int64(1), valueobject.NewDecimal("10")).Return(valueobject.NewDecimal("0"), nil).Once()
+
+		_, err := service.CompleteQuest(ctx, 1, "cool", usecase.CompleteQuestInput{IdempotencyKey: "cool-key-2"})
+		require.NoError(t, err)
+	})
+
+	t.Run("award is clipped to the remaining daily cap", func(t *testing.T) {
+		dailyCap := valueobject.NewDecimal("25")
+		quest := &entity.Quest{ID: "capped", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10"), DailyPointsCap: &dailyCap, TimeZone: "Europe/Berlin"}
+		service, userRepo, completionRepo := setup(quest, "cap-key")
+
+		completionRepo.On("SumAwardedForUserOnDay", ctx, int64(1), "capped", mock.AnythingOfType("time.Time"), "Europe/Berlin").
+			Return(valueobject.NewDecimal("20"), nil).Once()
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil).Once()
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+
+		completion, err := service.CompleteQuest(ctx, 1, "capped", usecase.CompleteQuestInput{IdempotencyKey: "cap-key"})
+		require.NoError(t, err)
+		require.Equal(t, "5", completion.AwardedPoints.String())
+	})
+
+	t.Run("nothing is credited once the cap is reached", func(t *testing.T) {
+		dailyCap := valueobject.NewDecimal("20")
+		quest := &entity.Quest{ID: "capped", Category: "adhoc", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("10"), DailyPointsCap: &dailyCap}
+		service, userRepo, completionRepo := setup(quest, "cap-key-2")
+
+		completionRepo.On("SumAwardedForUserOnDay", ctx, int64(1), "capped", mock.AnythingOfType("time.Time"), "").
+			Return(valueobject.NewDecimal("20"), nil).Once()
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil).Once()
+
+		completion, err := service.CompleteQuest(ctx, 1, "capped", usecase.CompleteQuestInput{IdempotencyKey: "cap-key-2"})
+		require.NoError(t, err)
+		require.True(t, completion.AwardedPoints.IsZero())
+		userRepo.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// newLedger returns a ledger over userRepo with its own transaction manager, so
+// that tests asserting on their service's WithTx calls are not affected
+func newLedger(userRepo ports.UserRepository) *usecase.LedgerService {
+	return usecase.NewLedgerService(inmemory.NewLedgerRepository(), inmemory.NewWalletRepository(), userRepo, &mockUUIDGen{}, &mockTxManager{})
+}
+
+// newDungeon returns dungeon repositories holding dungeonID, owned by ownerID
+// and joined by members
+func newDungeon(t *testing.T, dungeonID string, ownerID int64, members ...int64) (*inmemory.DungeonRepository, *inmemory.DungeonMemberRepository) {
+	t.Helper()
+	ctx := context.Background()
+	dungeonRepo := inmemory.NewDungeonRepository()
+	memberRepo := inmemory.NewDungeonMemberRepository()
+	require.NoError(t, dungeonRepo.Create(ctx, &entity.Dungeon{ID: dungeonID, Title: "Test", AdminUserID: ownerID}))
+	for _, userID := range members {
+		require.NoError(t, memberRepo.Add(ctx, dungeonID, userID, entity.DungeonRoleMember))
+	}
+	return dungeonRepo, memberRepo
+}
+
+func TestQuestService_Streaks(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(quest *entity.Quest, streakRepo *testhelpers.MockQuestStreakRepository) *usecase.QuestService {
+		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
+		userRepo := new(testhelpers.MockUserRepository)
+		idempotencyRepo := new(testhelpers.MockIdempotencyRepository)
+
+		questRepo.On("GetByID", ctx, quest.ID).Return(quest, nil)
+		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.Anything).Return(valueobject.NewDecimal("0"), nil)
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil)
+		idempotencyRepo.On("FindByKey", ctx, mock.Anything).Return(nil, ports.ErrIdempotencyKeyNotFound)
+		idempotencyRepo.On("Claim", ctx, mock.Anything).Return(nil)
+		idempotencyRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		return usecase.NewQuestService(questRepo, completionRepo, streakRepo, userRepo, dungeonRepo, memberRepo, newLedger(userRepo), &mockUUIDGen{}, new(testhelpers.MockScheduler), idempotencyRepo, &mockTxManager{})
+	}
+
+	today, err := (&entity.Quest{}).StreakPeriod(time.Now())
+	require.NoError(t, err)
+
+	t.Run("first completion starts a streak", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1"), StreakEnabled: true}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+		streakRepo.On("Get", ctx, int64(1), "quest-1").Return(nil, ports.ErrQuestStreakNotFound)
+		streakRepo.On("Upsert", ctx, mock.MatchedBy(func(s *entity.QuestStreak) bool {
+			return s.UserID == 1 && s.QuestID == "quest-1" && s.Current == 1 && s.Best == 1 && s.LastPeriod == today
+		})).Return(nil).Once()
+
+		_, err := newService(quest, streakRepo).CompleteQuest(ctx, 1, "quest-1", usecase.CompleteQuestInput{IdempotencyKey: "k"})
+		require.NoError(t, err)
+		streakRepo.AssertExpectations(t)
+		require.Zero(t, quest.StreakCount)
+	})
+
+	t.Run("second completion in the same period keeps the streak", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1"), StreakEnabled: true}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+		streakRepo.On("Get", ctx, int64(1), "quest-1").Return(&entity.QuestStreak{UserID: 1, QuestID: "quest-1", Current: 3, Best: 3, LastPeriod: today}, nil)
+
+		_, err := newService(quest, streakRepo).CompleteQuest(ctx, 1, "quest-1", usecase.CompleteQuestInput{IdempotencyKey: "k"})
+		require.NoError(t, err)
+		streakRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("completion after a missed day restarts the streak", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1"), StreakEnabled: true}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+		streakRepo.On("Get", ctx, int64(1), "quest-1").Return(&entity.QuestStreak{UserID: 1, QuestID: "quest-1", Current: 3, Best: 3, LastPeriod: today - 2}, nil)
+		streakRepo.On("Upsert", ctx, mock.MatchedBy(func(s *entity.QuestStreak) bool {
+			return s.Current == 1 && s.Best == 3
+		})).Return(nil).Once()
+
+		_, err := newService(quest, streakRepo).CompleteQuest(ctx, 1, "quest-1", usecase.CompleteQuestInput{IdempotencyKey: "k"})
+		require.NoError(t, err)
+		streakRepo.AssertExpectations(t)
+	})
+
+	t.Run("grace period bridges a missed day", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1"), StreakEnabled: true, StreakGracePeriods: 1}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+		streakRepo.On("Get", ctx, int64(1), "quest-1").Return(&entity.QuestStreak{UserID: 1, QuestID: "quest-1", Current: 3, Best: 3, LastPeriod: today - 2}, nil)
+		streakRepo.On("Upsert", ctx, mock.MatchedBy(func(s *entity.QuestStreak) bool {
+			return s.Current == 4 && s.Best == 4
+		})).Return(nil).Once()
+
+		_, err := newService(quest, streakRepo).CompleteQuest(ctx, 1, "quest-1", usecase.CompleteQuestInput{IdempotencyKey: "k"})
+		require.NoError(t, err)
+		streakRepo.AssertExpectations(t)
+	})
+
+	t.Run("streaks disabled", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("1")}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+
+		_, err := newService(quest, streakRepo).CompleteQuest(ctx, 1, "quest-1", usecase.CompleteQuestInput{IdempotencyKey: "k"})
+		require.NoError(t, err)
+		streakRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("broken streaks are reported as zero", func(t *testing.T) {
+		quest := &entity.Quest{ID: "quest-1", Title: "Stretch"}
+		streakRepo := new(testhelpers.MockQuestStreakRepository)
+		streakRepo.On("ListByUser", ctx, int64(1)).Return([]*entity.QuestStreak{
+			{UserID: 1, QuestID: "quest-1", Current: 5, Best: 8, LastPeriod: today - 3},
+		}, nil)
+
+		summaries, err := newService(quest, streakRepo).ListStreaks(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		require.Equal(t, "Stretch", summaries[0].QuestTitle)
+		require.Equal(t, 0, summaries[0].Current)
+		require.Equal(t, 8, summaries[0].Best)
 	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase/testhelpers"
@@ -18,6 +19,7 @@ func TestTimezoneAwareScheduling(t *testing.T) {
 
 	t.Run("Quest with timezone uses local time", func(t *testing.T) {
 		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
 		userRepo := new(testhelpers.MockUserRepository)
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		mockScheduler := new(testhelpers.MockScheduler)
@@ -30,41 +32,45 @@ func TestTimezoneAwareScheduling(t *testing.T) {
 			Category:    "daily",
 			TimeZone:    "America/New_York",
 			StreakCount: 0,
+			Mode:        entity.QuestModeBinary,
+			PointsAward: valueobject.NewDecimal("5"),
 		}
 
 		questRepo.On("GetByID", ctx, "tz-quest").Return(quest, nil)
 		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
-		userRepo.On("FindByID", ctx, int64(1)).Return(&entity.User{ID: 1}, nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil)
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil)
+		uuidGen.On("New").Return("completion-1")
 
-		service := usecase.NewQuestService(questRepo, userRepo, uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
 
 		// Mock idempotency
 		mockIdempotencyRepo.On("FindByKey", ctx, mock.AnythingOfType("string")).Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
-		mockIdempotencyRepo.On("Create", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		mockIdempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 		mockIdempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 
-		// Mock transaction
-		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Run(func(args mock.Arguments) {
-			fn := args.Get(1).(func(context.Context) error)
-			// Expect scheduler to be called with timezone-adjusted quest
-			mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).
-				Return(nil).
-				Run(func(args mock.Arguments) {
-					scheduledQuest := args.Get(1).(*entity.Quest)
-					require.Equal(t, "America/New_York", scheduledQuest.TimeZone)
-					require.NotNil(t, scheduledQuest.LastCompletedAt)
-					_, offset := scheduledQuest.LastCompletedAt.Zone()
-					nyLoc, _ := time.LoadLocation("America/New_York")
-					_, expectedOffset := time.Now().In(nyLoc).Zone()
-					require.Equal(t, expectedOffset, offset)
-				})
-			fn(ctx)
-		}).Return(nil).Once()
+		// Expect scheduler to be called with timezone-adjusted quest
+		mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				scheduledQuest := args.Get(1).(*entity.Quest)
+				require.Equal(t, "America/New_York", scheduledQuest.TimeZone)
+				require.NotNil(t, scheduledQuest.LastCompletedAt)
+				_, offset := scheduledQuest.LastCompletedAt.Zone()
+				nyLoc, _ := time.LoadLocation("America/New_York")
+				_, expectedOffset := time.Now().In(nyLoc).Zone()
+				require.Equal(t, expectedOffset, offset)
+			})
+
+		// The mock transaction runs fn once
+		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Return(nil).Once()
 
 		input := usecase.CompleteQuestInput{
 			IdempotencyKey: "key-1",
 		}
-		err := service.CompleteQuest(ctx, 1, "tz-quest", input)
+		_, err := service.CompleteQuest(ctx, 1, "tz-quest", input)
 		require.NoError(t, err)
 		mockScheduler.AssertExpectations(t)
 		mockIdempotencyRepo.AssertExpectations(t)
@@ -73,6 +79,7 @@ func TestTimezoneAwareScheduling(t *testing.T) {
 
 	t.Run("Quest without timezone uses UTC", func(t *testing.T) {
 		questRepo := new(testhelpers.MockQuestRepository)
+		completionRepo := new(testhelpers.MockQuestCompletionRepository)
 		userRepo := new(testhelpers.MockUserRepository)
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		mockScheduler := new(testhelpers.MockScheduler)
@@ -84,39 +91,43 @@ func TestTimezoneAwareScheduling(t *testing.T) {
 			Title:       "No Timezone",
 			Category:    "daily",
 			StreakCount: 0,
+			Mode:        entity.QuestModeBinary,
+			PointsAward: valueobject.NewDecimal("5"),
 		}
 
 		questRepo.On("GetByID", ctx, "no-tz-quest").Return(quest, nil)
 		questRepo.On("Update", ctx, mock.AnythingOfType("*entity.Quest")).Return(nil)
-		userRepo.On("FindByID", ctx, int64(1)).Return(&entity.User{ID: 1}, nil)
+		userRepo.On("Lock", ctx, int64(1)).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil)
+		completionRepo.On("Insert", ctx, mock.AnythingOfType("*entity.QuestCompletion")).Return(nil)
+		uuidGen.On("New").Return("completion-1")
 
-		service := usecase.NewQuestService(questRepo, userRepo, uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
+		dungeonRepo, memberRepo := newDungeon(t, quest.DungeonID, 1)
+		service := usecase.NewQuestService(questRepo, completionRepo, new(testhelpers.MockQuestStreakRepository), userRepo, dungeonRepo, memberRepo, newLedger(userRepo), uuidGen, mockScheduler, mockIdempotencyRepo, mockTxManager)
 
 		// Mock idempotency
 		mockIdempotencyRepo.On("FindByKey", ctx, mock.AnythingOfType("string")).Return(nil, ports.ErrIdempotencyKeyNotFound).Once()
-		mockIdempotencyRepo.On("Create", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
+		mockIdempotencyRepo.On("Claim", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 		mockIdempotencyRepo.On("Update", ctx, mock.AnythingOfType("*entity.IdempotencyKey")).Return(nil).Once()
 
-		// Mock transaction
-		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Run(func(args mock.Arguments) {
-			fn := args.Get(1).(func(context.Context) error)
-			// Expect scheduler to be called with UTC time
-			mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).
-				Return(nil).
-				Run(func(args mock.Arguments) {
-					scheduledQuest := args.Get(1).(*entity.Quest)
-					require.Empty(t, scheduledQuest.TimeZone)
-					require.NotNil(t, scheduledQuest.LastCompletedAt)
-					_, offset := scheduledQuest.LastCompletedAt.Zone()
-					require.Equal(t, 0, offset) // UTC
-				})
-			fn(ctx)
-		}).Return(nil).Once()
+		// Expect scheduler to be called with UTC time
+		mockScheduler.On("ScheduleRecurringTask", ctx, mock.AnythingOfType("*entity.Quest")).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				scheduledQuest := args.Get(1).(*entity.Quest)
+				require.Empty(t, scheduledQuest.TimeZone)
+				require.NotNil(t, scheduledQuest.LastCompletedAt)
+				_, offset := scheduledQuest.LastCompletedAt.Zone()
+				require.Equal(t, 0, offset) // UTC
+			})
+
+		// The mock transaction runs fn once
+		mockTxManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).Return(nil).Once()
 
 		input := usecase.CompleteQuestInput{
 			IdempotencyKey: "key-2",
 		}
-		err := service.CompleteQuest(ctx, 1, "no-tz-quest", input)
+		_, err := service.CompleteQuest(ctx, 1, "no-tz-quest", input)
 		require.NoError(t, err)
 		mockScheduler.AssertExpectations(t)
 		mockIdempotencyRepo.AssertExpectations(t)
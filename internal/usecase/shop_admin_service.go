@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+var (
+	ErrShopAdminOnly     = fmt.Errorf("%w: only the dungeon owner and moderators can manage its shop", ports.ErrForbidden)
+	ErrPurchasesSelfOnly = fmt.Errorf("%w: members can only list their own purchases", ports.ErrForbidden)
+)
+
+// ShopAdminService manages the shop of a dungeon. Members whose role may edit
+// the shop manage items and see every purchase; other members can browse the
+// shop and list their own purchases.
+type ShopAdminService struct {
+	dungeonAccess
+	shopItemRepo     ports.ShopItemRepository
+	purchaseRepo     ports.PurchaseRepository
+	discountTierRepo ports.DiscountTierRepository
+	txManager        ports.TxManager
+}
+
+func NewShopAdminService(
+	dungeonRepo ports.DungeonRepository,
+	memberRepo ports.DungeonMemberRepository,
+	shopItemRepo ports.ShopItemRepository,
+	purchaseRepo ports.PurchaseRepository,
+	discountTierRepo ports.DiscountTierRepository,
+	txManager ports.TxManager,
+) *ShopAdminService {
+	return &ShopAdminService{
+		dungeonAccess:    dungeonAccess{dungeonRepo: dungeonRepo, memberRepo: memberRepo},
+		shopItemRepo:     shopItemRepo,
+		purchaseRepo:     purchaseRepo,
+		discountTierRepo: discountTierRepo,
+		txManager:        txManager,
+	}
+}
+
+// ListItems returns the items of a dungeon's shop. Those who manage the shop
+// also see inactive items.
+func (s *ShopAdminService) ListItems(ctx context.Context, actorID int64, dungeonID string) ([]*entity.ShopItem, error) {
+	_, role, err := s.role(ctx, actorID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.shopItemRepo.FindByDungeonID(ctx, dungeonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	if role.Can(entity.PermissionEditShop) {
+		return items, nil
+	}
+
+	var active []*entity.ShopItem
+	for _, item := range items {
+		if item.IsActive {
+			active = append(active, item)
+		}
+	}
+	return active, nil
+}
+
+// GetItem returns one item of a dungeon's shop
+func (s *ShopAdminService) GetItem(ctx context.Context, actorID int64, dungeonID string, itemID int64) (*entity.ShopItem, error) {
+	_, role, err := s.role(ctx, actorID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.findItem(ctx, dungeonID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !item.IsActive && !role.Can(entity.PermissionEditShop) {
+		return nil, fmt.Errorf("item not found: %w", ports.ErrShopItemNotFound)
+	}
+	return item, nil
+}
+
+// CreateItem adds an item to a dungeon's shop. Codes are unique within a
+// shop; a taken code fails with ports.ErrShopItemCodeExists.
+func (s *ShopAdminService) CreateItem(ctx context.Context, actorID int64, dungeonID string, item *entity.ShopItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	dungeon, err := s.authorizeAdmin(ctx, actorID, dungeonID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDiscountTier(ctx, item.DiscountTierID); err != nil {
+		return err
+	}
+
+	item.DungeonID = &dungeon.ID
+	now := time.Now()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+
+	return s.shopItemRepo.Create(ctx, item)
+}
+
+// UpdateItem changes the code, name, description, category, price, sale and
+// stock of an item. Activation and the discount tier have their own methods.
+func (s *ShopAdminService) UpdateItem(ctx context.Context, actorID int64, dungeonID string, item *entity.ShopItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	var updated *entity.ShopItem
+	err := s.changeItem(ctx, actorID, dungeonID, item.ID, func(existing *entity.ShopItem) error {
+		existing.Code = item.Code
+		existing.Name = item.Name
+		existing.Description = item.Description
+		existing.Category = item.Category
+		existing.Price = item.Price
+		existing.SalePrice = item.SalePrice
+		existing.SaleEndsAt = item.SaleEndsAt
+		existing.Stock = item.Stock
+		updated = existing
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*item = *updated
+	return nil
+}
+
+// SetItemActive lists or unlists an item
+func (s *ShopAdminService) SetItemActive(ctx context.Context, actorID int64, dungeonID string, itemID int64, active bool) (*entity.ShopItem, error) {
+	var item *entity.ShopItem
+	err := s.changeItem(ctx, actorID, dungeonID, itemID, func(existing *entity.ShopItem) error {
+		existing.IsActive = active
+		item = existing
+		return nil
+	})
+	return item, err
+}
+
+// RestockItem adds quantity units to an item with limited stock
+func (s *ShopAdminService) RestockItem(ctx context.Context, actorID int64, dungeonID string, itemID int64, quantity int) (*entity.ShopItem, error) {
+	var item *entity.ShopItem
+	err := s.changeItem(ctx, actorID, dungeonID, itemID, func(existing *entity.ShopItem) error {
+		item = existing
+		return existing.Restock(quantity)
+	})
+	return item, err
+}
+
+// AssignDiscountTier applies a discount tier to an item; a nil tierID removes it
+func (s *ShopAdminService) AssignDiscountTier(ctx context.Context, actorID int64, dungeonID string, itemID int64, tierID *int64) (*entity.ShopItem, error) {
+	var item *entity.ShopItem
+	err := s.changeItem(ctx, actorID, dungeonID, itemID, func(existing *entity.ShopItem) error {
+		if err := s.checkDiscountTier(ctx, tierID); err != nil {
+			return err
+		}
+		existing.DiscountTierID = tierID
+		item = existing
+		return nil
+	})
+	return item, err
+}
+
+// DeleteItem removes an item from a dungeon's shop. Past purchases keep the
+// item's name and price.
+func (s *ShopAdminService) DeleteItem(ctx context.Context, actorID int64, dungeonID string, itemID int64) error {
+	if _, err := s.authorizeAdmin(ctx, actorID, dungeonID); err != nil {
+		return err
+	}
+	if _, err := s.findItem(ctx, dungeonID, itemID); err != nil {
+		return err
+	}
+	return s.shopItemRepo.Delete(ctx, itemID)
+}
+
+// ItemPurchases returns every purchase of an item, newest first
+func (s *ShopAdminService) ItemPurchases(ctx context.Context, actorID int64, dungeonID string, itemID int64) ([]*entity.Purchase, error) {
+	if _, err := s.authorizeAdmin(ctx, actorID, dungeonID); err != nil {
+		return nil, err
+	}
+	if _, err := s.findItem(ctx, dungeonID, itemID); err != nil {
+		return nil, err
+	}
+	return s.purchaseRepo.FindByItemID(ctx, itemID)
+}
+
+// MemberPurchases returns a member's purchases in a dungeon's shop. Members
+// can list their own purchases; those who manage the shop can list anyone's.
+func (s *ShopAdminService) MemberPurchases(ctx context.Context, actorID int64, dungeonID string, userID int64) ([]*entity.Purchase, error) {
+	_, role, err := s.role(ctx, actorID, dungeonID)
+	if err != nil {
+		return nil, err
+	}
+	if actorID != userID && !role.Can(entity.PermissionEditShop) {
+		return nil, ErrPurchasesSelfOnly
+	}
+
+	items, err := s.shopItemRepo.FindByDungeonID(ctx, dungeonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	inDungeon := make(map[int64]bool, len(items))
+	for _, item := range items {
+		inDungeon[item.ID] = true
+	}
+
+	purchases, err := s.purchaseRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchases: %w", err)
+	}
+
+	var result []*entity.Purchase
+	for _, purchase := range purchases {
+		if purchase.DungeonID == dungeonID || inDungeon[purchase.ItemID] {
+			result = append(result, purchase)
+		}
+	}
+	return result, nil
+}
+
+// DiscountTiers returns the discount tiers items can be assigned to
+func (s *ShopAdminService) DiscountTiers(ctx context.Context) ([]*entity.DiscountTier, error) {
+	return s.discountTierRepo.FindAll(ctx)
+}
+
+// changeItem checks actorID may edit dungeonID's shop and applies change to one
+// of its items in a transaction
+func (s *ShopAdminService) changeItem(ctx context.Context, actorID int64, dungeonID string, itemID int64, change func(*entity.ShopItem) error) error {
+	if _, err := s.authorizeAdmin(ctx, actorID, dungeonID); err != nil {
+		return err
+	}
+
+	return s.txManager.WithTx(ctx, func(txCtx context.Context) error {
+		// Inside the transaction the item stays locked until it is saved, so
+		// purchases and other edits cannot be overwritten by the full-row update
+		item, err := s.findItem(txCtx, dungeonID, itemID)
+		if err != nil {
+			return err
+		}
+		if err := change(item); err != nil {
+			return err
+		}
+
+		item.UpdatedAt = time.Now()
+		if err := s.shopItemRepo.Update(txCtx, item); err != nil {
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+		return nil
+	})
+}
+
+// findItem returns an item of the dungeon; items of other shops are not found
+func (s *ShopAdminService) findItem(ctx context.Context, dungeonID string, itemID int64) (*entity.ShopItem, error) {
+	item, err := s.shopItemRepo.FindByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.DungeonID == nil || *item.DungeonID != dungeonID {
+		return nil, fmt.Errorf("item not found: %w", ports.ErrShopItemNotFound)
+	}
+	return item, nil
+}
+
+// checkDiscountTier makes sure a discount tier exists before items use it
+func (s *ShopAdminService) checkDiscountTier(ctx context.Context, tierID *int64) error {
+	if tierID == nil {
+		return nil
+	}
+	_, err := s.discountTierRepo.FindByID(ctx, *tierID)
+	return err
+}
+
+// authorizeAdmin returns the dungeon if actorID's role may edit its shop
+func (s *ShopAdminService) authorizeAdmin(ctx context.Context, actorID int64, dungeonID string) (*entity.Dungeon, error) {
+	dungeon, err := s.authorize(ctx, actorID, dungeonID, entity.PermissionEditShop)
+	if errors.Is(err, ports.ErrForbidden) && !errors.Is(err, ErrNotDungeonMember) {
+		return nil, ErrShopAdminOnly
+	}
+	return dungeon, err
+}
@@ -0,0 +1,200 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+type shopAdminFixture struct {
+	service *usecase.ShopAdminService
+	store   *storage.Storage
+}
+
+// newShopAdminFixture sets up dungeon "d1" run by admin 1 with member 2 and
+// moderator 5, and dungeon "d2" run by admin 3. User 4 is in neither.
+func newShopAdminFixture(t *testing.T) *shopAdminFixture {
+	ctx := context.Background()
+	store := newTestStore()
+	chatID := int64(100)
+	createDungeon(t, store, &entity.Dungeon{ID: "d1", AdminUserID: 1, TelegramChatID: &chatID}, map[int64]entity.DungeonRole{
+		1: entity.DungeonRoleOwner,
+		2: entity.DungeonRoleMember,
+		5: entity.DungeonRoleModerator,
+	})
+	createDungeon(t, store, &entity.Dungeon{ID: "d2", AdminUserID: 3}, map[int64]entity.DungeonRole{
+		3: entity.DungeonRoleOwner,
+	})
+
+	require.NoError(t, store.DiscountTiers.Create(ctx, &entity.DiscountTier{ID: 1, Name: "Regulars", DiscountPercent: 10}))
+	return &shopAdminFixture{
+		service: usecase.NewShopAdminService(store.Dungeons, store.DungeonMembers, store.ShopItems, store.Purchases, store.DiscountTiers, store.TxManager),
+		store:   store,
+	}
+}
+
+func (f *shopAdminFixture) createItem(t *testing.T, code string, stock *int) *entity.ShopItem {
+	item := &entity.ShopItem{Code: code, Name: code, Price: valueobject.NewDecimal("10"), IsActive: true, Stock: stock}
+	require.NoError(t, f.service.CreateItem(context.Background(), 1, "d1", item))
+	return item
+}
+
+func TestShopAdminService_ManageItems(t *testing.T) {
+	ctx := context.Background()
+	f := newShopAdminFixture(t)
+
+	stock := 2
+	item := f.createItem(t, "POTION", &stock)
+	assert.NotZero(t, item.ID)
+	require.NotNil(t, item.DungeonID)
+	assert.Equal(t, "d1", *item.DungeonID)
+
+	t.Run("update keeps activation and tier", func(t *testing.T) {
+		tierID := int64(1)
+		_, err := f.service.AssignDiscountTier(ctx, 1, "d1", item.ID, &tierID)
+		require.NoError(t, err)
+
+		update := &entity.ShopItem{ID: item.ID, Code: "POTION", Name: "Big potion", Price: valueobject.NewDecimal("15"), Stock: &stock}
+		require.NoError(t, f.service.UpdateItem(ctx, 1, "d1", update))
+		assert.Equal(t, "Big potion", update.Name)
+		assert.True(t, update.IsActive)
+		assert.Equal(t, &tierID, update.DiscountTierID)
+
+		stored, err := f.store.ShopItems.FindByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "15", stored.Price.String())
+	})
+
+	t.Run("restock", func(t *testing.T) {
+		restocked, err := f.service.RestockItem(ctx, 1, "d1", item.ID, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 5, *restocked.Stock)
+
+		_, err = f.service.RestockItem(ctx, 1, "d1", item.ID, 0)
+		assert.ErrorIs(t, err, entity.ErrInvalidRestock)
+
+		unlimited := f.createItem(t, "SCROLL", nil)
+		_, err = f.service.RestockItem(ctx, 1, "d1", unlimited.ID, 1)
+		assert.ErrorIs(t, err, entity.ErrItemStockUnlimited)
+	})
+
+	t.Run("deactivated items are hidden from members", func(t *testing.T) {
+		_, err := f.service.SetItemActive(ctx, 1, "d1", item.ID, false)
+		require.NoError(t, err)
+
+		adminItems, err := f.service.ListItems(ctx, 1, "d1")
+		require.NoError(t, err)
+		memberItems, err := f.service.ListItems(ctx, 2, "d1")
+		require.NoError(t, err)
+		assert.Len(t, memberItems, len(adminItems)-1)
+
+		_, err = f.service.GetItem(ctx, 2, "d1", item.ID)
+		assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+	})
+
+	t.Run("unknown discount tier", func(t *testing.T) {
+		missing := int64(99)
+		_, err := f.service.AssignDiscountTier(ctx, 1, "d1", item.ID, &missing)
+		assert.ErrorIs(t, err, ports.ErrDiscountTierNotFound)
+	})
+
+	t.Run("codes are unique per dungeon", func(t *testing.T) {
+		err := f.service.CreateItem(ctx, 1, "d1", &entity.ShopItem{Code: "POTION", Name: "Copy", Price: valueobject.NewDecimal("1")})
+		assert.ErrorIs(t, err, ports.ErrShopItemCodeExists)
+
+		other := &entity.ShopItem{Code: "POTION", Name: "Other shop", Price: valueobject.NewDecimal("1")}
+		assert.NoError(t, f.service.CreateItem(ctx, 3, "d2", other))
+
+		elixir := f.createItem(t, "ELIXIR", nil)
+		elixir.Code = "POTION"
+		assert.ErrorIs(t, f.service.UpdateItem(ctx, 1, "d1", elixir), ports.ErrShopItemCodeExists)
+	})
+
+	t.Run("invalid item", func(t *testing.T) {
+		err := f.service.CreateItem(ctx, 1, "d1", &entity.ShopItem{Code: "BAD", Name: "Bad", Price: valueobject.NewDecimal("-1")})
+		assert.ErrorIs(t, err, entity.ErrInvalidItemPrice)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, f.service.DeleteItem(ctx, 1, "d1", item.ID))
+		_, err := f.service.GetItem(ctx, 1, "d1", item.ID)
+		assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+	})
+}
+
+func TestShopAdminService_Permissions(t *testing.T) {
+	ctx := context.Background()
+	f := newShopAdminFixture(t)
+	item := f.createItem(t, "POTION", nil)
+
+	// Members browse but do not manage
+	_, err := f.service.ListItems(ctx, 2, "d1")
+	assert.NoError(t, err)
+	err = f.service.CreateItem(ctx, 2, "d1", &entity.ShopItem{Code: "MINE", Name: "Mine", Price: valueobject.NewDecimal("1")})
+	assert.ErrorIs(t, err, usecase.ErrShopAdminOnly)
+	_, err = f.service.SetItemActive(ctx, 2, "d1", item.ID, false)
+	assert.ErrorIs(t, err, usecase.ErrShopAdminOnly)
+	_, err = f.service.ItemPurchases(ctx, 2, "d1", item.ID)
+	assert.ErrorIs(t, err, usecase.ErrShopAdminOnly)
+	assert.ErrorIs(t, err, ports.ErrForbidden)
+
+	// Moderators manage the shop like the owner
+	require.NoError(t, f.service.CreateItem(ctx, 5, "d1", &entity.ShopItem{Code: "MOD", Name: "Mod", Price: valueobject.NewDecimal("1")}))
+	_, err = f.service.SetItemActive(ctx, 5, "d1", item.ID, false)
+	require.NoError(t, err)
+	_, err = f.service.GetItem(ctx, 5, "d1", item.ID)
+	assert.NoError(t, err, "moderators see inactive items")
+	_, err = f.service.GetItem(ctx, 2, "d1", item.ID)
+	assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+
+	// Outsiders see nothing
+	_, err = f.service.ListItems(ctx, 4, "d1")
+	assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+
+	// Admins of other dungeons cannot reach this dungeon's items
+	_, err = f.service.GetItem(ctx, 3, "d2", item.ID)
+	assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+	assert.ErrorIs(t, f.service.DeleteItem(ctx, 3, "d2", item.ID), ports.ErrShopItemNotFound)
+
+	_, err = f.service.ListItems(ctx, 1, "missing")
+	assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+}
+
+func TestShopAdminService_Purchases(t *testing.T) {
+	ctx := context.Background()
+	f := newShopAdminFixture(t)
+	item := f.createItem(t, "POTION", nil)
+	other := "d2"
+	require.NoError(t, f.store.ShopItems.Create(ctx, &entity.ShopItem{ID: 50, DungeonID: &other, Code: "OTHER", Name: "Other"}))
+
+	for _, purchase := range []*entity.Purchase{
+		{UserID: 2, ItemID: item.ID, Quantity: 1, Status: entity.PurchaseStatusCompleted},
+		{UserID: 2, ItemID: 50, Quantity: 1, Status: entity.PurchaseStatusCompleted},
+		{UserID: 1, ItemID: item.ID, Quantity: 2, Status: entity.PurchaseStatusCompleted},
+	} {
+		require.NoError(t, f.store.Purchases.Create(ctx, purchase))
+	}
+
+	byItem, err := f.service.ItemPurchases(ctx, 1, "d1", item.ID)
+	require.NoError(t, err)
+	assert.Len(t, byItem, 2)
+
+	own, err := f.service.MemberPurchases(ctx, 2, "d1", 2)
+	require.NoError(t, err)
+	require.Len(t, own, 1, "purchases from other shops are left out")
+	assert.Equal(t, item.ID, own[0].ItemID)
+
+	byAdmin, err := f.service.MemberPurchases(ctx, 1, "d1", 2)
+	require.NoError(t, err)
+	assert.Len(t, byAdmin, 1)
+
+	_, err = f.service.MemberPurchases(ctx, 2, "d1", 1)
+	assert.ErrorIs(t, err, usecase.ErrPurchasesSelfOnly)
+}
@@ -0,0 +1,164 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+type refundFixture struct {
+	service *usecase.ShopService
+	store   *storage.Storage
+	ledger  *usecase.LedgerService
+}
+
+// newRefundFixture sets up dungeon d1, linked to chat 100, with a buyer (1),
+// another member (2), the owner (3), a global admin outside the dungeon (4), a
+// moderator (5) and a limited item "SWORD"
+func newRefundFixture(t *testing.T) *refundFixture {
+	ctx := context.Background()
+	store := newTestStore()
+	f := &refundFixture{store: store, ledger: newTestLedger(store)}
+	f.service = usecase.NewShopService(store.ShopItems, store.Purchases, store.Users, f.ledger, store.ChatConfigs,
+		nil, nil, store.UUIDGen, store.TxManager, store.Idempotency, store.Dungeons, store.DungeonMembers)
+
+	chatID := int64(100)
+	createDungeon(t, store, &entity.Dungeon{ID: "d1", Title: "Test", AdminUserID: 3, TelegramChatID: &chatID}, map[int64]entity.DungeonRole{
+		1: entity.DungeonRoleMember,
+		2: entity.DungeonRoleMember,
+		5: entity.DungeonRoleModerator,
+	})
+	createUsers(t, store, 2, 3, 5)
+	require.NoError(t, store.Users.Create(ctx, &entity.User{ID: 1, ChatID: 100, Balance: valueobject.NewDecimal("100")}))
+	require.NoError(t, store.Users.Create(ctx, &entity.User{ID: 4, ChatID: 100, Balance: valueobject.NewDecimal("0"), Role: entity.UserRoleAdmin}))
+	_, err := store.Wallets.UpdateBalance(ctx, 1, "d1", valueobject.NewDecimal("100"))
+	require.NoError(t, err)
+
+	dungeonID := "d1"
+	stock := 5
+	require.NoError(t, store.ShopItems.Create(ctx, &entity.ShopItem{
+		ID: 1, DungeonID: &dungeonID, Code: "SWORD", Name: "Sword", Price: valueobject.NewDecimal("10"), IsActive: true, Stock: &stock,
+	}))
+
+	return f
+}
+
+func (f *refundFixture) balance(t *testing.T, userID int64) string {
+	user, err := f.store.Users.FindByID(context.Background(), userID)
+	require.NoError(t, err)
+	return user.Balance.String()
+}
+
+func (f *refundFixture) stock(t *testing.T) int {
+	item, err := f.store.ShopItems.FindByID(context.Background(), 1)
+	require.NoError(t, err)
+	return *item.Stock
+}
+
+func TestShopService_RefundPurchase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("buyer refunds part then the rest", func(t *testing.T) {
+		f := newRefundFixture(t)
+		purchase, err := f.service.PurchaseItem(ctx, 1, "d1", "SWORD", 3, "")
+		require.NoError(t, err)
+		assert.Equal(t, "70", f.balance(t, 1))
+		assert.Equal(t, 2, f.stock(t))
+
+		refunded, err := f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{Quantity: 1})
+		require.NoError(t, err)
+		assert.Equal(t, entity.PurchaseStatusCompleted, refunded.Status)
+		assert.Equal(t, 1, refunded.RefundedQuantity)
+		assert.Equal(t, "80", f.balance(t, 1))
+		assert.Equal(t, 3, f.stock(t))
+
+		refunded, err = f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{})
+		require.NoError(t, err)
+		assert.Equal(t, entity.PurchaseStatusRefunded, refunded.Status)
+		assert.Equal(t, "100", f.balance(t, 1))
+		assert.Equal(t, 5, f.stock(t))
+
+		stored, err := f.store.Purchases.FindByID(ctx, purchase.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.PurchaseStatusRefunded, stored.Status)
+
+		_, err = f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{})
+		assert.ErrorIs(t, err, entity.ErrPurchaseNotRefundable)
+
+		history, err := f.ledger.History(ctx, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, entity.LedgerReasonRefund, history[0].Reason)
+		assert.Equal(t, entity.LedgerSourcePurchase, history[0].SourceType)
+		assert.Equal(t, "20", history[0].Amount.String())
+		assert.Equal(t, "d1", history[0].DungeonID)
+		assert.Equal(t, "100", history[0].BalanceAfter.String(), "the refund goes back to the d1 wallet")
+	})
+
+	t.Run("other members cannot refund", func(t *testing.T) {
+		f := newRefundFixture(t)
+		purchase, err := f.service.PurchaseItem(ctx, 1, "d1", "SWORD", 1, "")
+		require.NoError(t, err)
+
+		_, err = f.service.RefundPurchase(ctx, 2, purchase.ID, usecase.RefundInput{})
+		assert.ErrorIs(t, err, usecase.ErrRefundNotAllowed)
+
+		// Being an admin elsewhere does not reach into the dungeon
+		_, err = f.service.RefundPurchase(ctx, 4, purchase.ID, usecase.RefundInput{})
+		assert.ErrorIs(t, err, usecase.ErrNotDungeonMember)
+
+		_, err = f.service.RefundPurchase(ctx, 1, 999, usecase.RefundInput{})
+		assert.ErrorIs(t, err, ports.ErrPurchaseNotFound)
+	})
+
+	t.Run("refund window and admin-only chats", func(t *testing.T) {
+		f := newRefundFixture(t)
+		require.NoError(t, f.store.ChatConfigs.Create(ctx, &entity.ChatConfig{ChatID: 100, CurrencyName: "Gold", RefundWindowSec: 3600}))
+
+		purchase, err := f.service.PurchaseItem(ctx, 1, "d1", "SWORD", 1, "")
+		require.NoError(t, err)
+
+		// Age the purchase past the window
+		stored, err := f.store.Purchases.FindByID(ctx, purchase.ID)
+		require.NoError(t, err)
+		stored.PurchasedAt = time.Now().Add(-2 * time.Hour)
+		require.NoError(t, f.store.Purchases.Update(ctx, stored))
+
+		_, err = f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{})
+		assert.ErrorIs(t, err, usecase.ErrRefundWindowExpired)
+
+		// Shop managers are not bound by the window
+		_, err = f.service.RefundPurchase(ctx, 5, purchase.ID, usecase.RefundInput{Reason: "Goodwill"})
+		require.NoError(t, err)
+		assert.Equal(t, "100", f.balance(t, 1))
+
+		require.NoError(t, f.store.ChatConfigs.Update(ctx, &entity.ChatConfig{ChatID: 100, CurrencyName: "Gold", RefundWindowSec: 3600, RefundsAdminOnly: true}))
+		purchase, err = f.service.PurchaseItem(ctx, 1, "d1", "SWORD", 1, "")
+		require.NoError(t, err)
+
+		_, err = f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{})
+		assert.ErrorIs(t, err, usecase.ErrRefundNotAllowed)
+
+		_, err = f.service.RefundPurchase(ctx, 3, purchase.ID, usecase.RefundInput{})
+		require.NoError(t, err, "the owner can always refund")
+	})
+
+	t.Run("invalid quantity leaves everything untouched", func(t *testing.T) {
+		f := newRefundFixture(t)
+		purchase, err := f.service.PurchaseItem(ctx, 1, "d1", "SWORD", 2, "")
+		require.NoError(t, err)
+
+		_, err = f.service.RefundPurchase(ctx, 1, purchase.ID, usecase.RefundInput{Quantity: 3})
+		assert.ErrorIs(t, err, entity.ErrInvalidRefundQuantity)
+		assert.Equal(t, "80", f.balance(t, 1))
+		assert.Equal(t, 3, f.stock(t))
+	})
+}
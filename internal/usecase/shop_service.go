@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
@@ -10,68 +12,61 @@ import (
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
+var (
+	ErrInvalidPurchaseQuantity = errors.New("purchase quantity must be positive")
+	ErrItemNotAvailable        = errors.New("item is not available")
+	ErrRefundNotAllowed        = errors.New("you are not allowed to refund this purchase")
+	ErrRefundWindowExpired     = errors.New("the refund window for this purchase has passed")
+)
+
 type ShopService struct {
+	dungeonAccess
 	shopItemRepo    ports.ShopItemRepository
 	purchaseRepo    ports.PurchaseRepository
 	userRepo        ports.UserRepository
+	ledger          *LedgerService
 	chatConfigRepo  ports.ChatConfigRepository
-	rewardTierRepo  ports.RewardTierRepository
+	pricing         *PricingPipeline
+	loyalty         *LoyaltyService
 	uuidGen         ports.UUIDGenerator
 	txManager       ports.TxManager
 	idempotencyRepo ports.IdempotencyRepository
-	// Deprecated fields for backward compatibility
-	legacyMode bool
 }
 
-// NewShopService creates a new ShopService instance
-// For backward compatibility, idempotencyRepo is optional (will use no-op implementation if nil)
-// NewShopService creates a new ShopService instance with backward compatibility
+// NewShopService creates a new ShopService instance. A nil pricing pipeline
+// charges list prices, a nil loyalty service leaves loyalty tiers alone and a
+// nil idempotencyRepo disables idempotency keys.
 func NewShopService(
 	shopItemRepo ports.ShopItemRepository,
 	purchaseRepo ports.PurchaseRepository,
 	userRepo ports.UserRepository,
+	ledger *LedgerService,
 	chatConfigRepo ports.ChatConfigRepository,
-	args ...interface{},
+	pricing *PricingPipeline,
+	loyalty *LoyaltyService,
+	uuidGen ports.UUIDGenerator,
+	txManager ports.TxManager,
+	idempotencyRepo ports.IdempotencyRepository,
+	dungeonRepo ports.DungeonRepository,
+	memberRepo ports.DungeonMemberRepository,
 ) *ShopService {
-	var (
-		rewardTierRepo  ports.RewardTierRepository
-		uuidGen         ports.UUIDGenerator
-		txManager       ports.TxManager
-		idempotencyRepo ports.IdempotencyRepository
-	)
-
-	// Handle backward compatibility
-	switch len(args) {
-	case 3: // Old format (uuidGen, txManager)
-		uuidGen = args[0].(ports.UUIDGenerator)
-		txManager = args[1].(ports.TxManager)
+	if idempotencyRepo == nil {
 		idempotencyRepo = &noopIdempotencyRepo{}
-	case 4: // New format (rewardTierRepo, uuidGen, txManager, idempotencyRepo)
-		rewardTierRepo = args[0].(ports.RewardTierRepository)
-		uuidGen = args[1].(ports.UUIDGenerator)
-		txManager = args[2].(ports.TxManager)
-		idempotencyRepo = args[3].(ports.IdempotencyRepository)
-	default:
-		panic("invalid number of arguments")
 	}
-	svc := &ShopService{
+
+	return &ShopService{
+		dungeonAccess:   dungeonAccess{dungeonRepo: dungeonRepo, memberRepo: memberRepo},
 		shopItemRepo:    shopItemRepo,
 		purchaseRepo:    purchaseRepo,
 		userRepo:        userRepo,
+		ledger:          ledger,
 		chatConfigRepo:  chatConfigRepo,
-		rewardTierRepo:  rewardTierRepo,
+		pricing:         pricing,
+		loyalty:         loyalty,
 		uuidGen:         uuidGen,
 		txManager:       txManager,
 		idempotencyRepo: idempotencyRepo,
 	}
-
-	if idempotencyRepo == nil {
-		svc.legacyMode = true
-		// Initialize with no-op implementation
-		svc.idempotencyRepo = &noopIdempotencyRepo{}
-	}
-
-	return svc
 }
 
 // CreateShopItem creates a new item in the shop
@@ -79,60 +74,40 @@ func (s *ShopService) CreateShopItem(ctx context.Context, item *entity.ShopItem)
 	return s.shopItemRepo.Create(ctx, item)
 }
 
-// GetShopItems returns all available items for a chat (including global items)
-func (s *ShopService) GetShopItems(ctx context.Context, chatID int64) ([]*entity.ShopItem, error) {
-	// Get chat-specific items
-	chatItems, err := s.shopItemRepo.FindByChatID(ctx, chatID)
+// GetShopItems returns the active items of a dungeon's shop
+func (s *ShopService) GetShopItems(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error) {
+	items, err := s.shopItemRepo.FindByDungeonID(ctx, dungeonID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chat items: %w", err)
+		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
-	// Get global items (chatID = 0)
-	globalItems, err := s.shopItemRepo.FindByChatID(ctx, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get global items: %w", err)
-	}
-
-	// Combine and filter active items
-	var allItems []*entity.ShopItem
-	for _, item := range append(chatItems, globalItems...) {
+	var active []*entity.ShopItem
+	for _, item := range items {
 		if item.IsActive {
-			allItems = append(allItems, item)
+			active = append(active, item)
 		}
-
 	}
 
-	return allItems, nil
+	return active, nil
 }
 
-// noopIdempotencyRepo provides a no-op implementation for backward compatibility
-type noopIdempotencyRepo struct{}
-
-func (r *noopIdempotencyRepo) Create(ctx context.Context, key *entity.IdempotencyKey) error {
-	return nil
-}
-
-func (r *noopIdempotencyRepo) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
-	return nil, nil
-}
-
-func (r *noopIdempotencyRepo) Update(ctx context.Context, key *entity.IdempotencyKey) error {
-	return nil
-}
-
-func (r *noopIdempotencyRepo) DeleteExpired(ctx context.Context) error {
-	return nil
-}
-
-func (r *noopIdempotencyRepo) Purge(ctx context.Context, olderThan time.Time) error {
-	return nil
-}
-
-// PurchaseItem handles a user purchasing an item from the shop with idempotency
-func (s *ShopService) PurchaseItem(ctx context.Context, userID int64, itemCode string, quantity int, idempotencyKey string) (*entity.Purchase, error) {
-	var purchase *entity.Purchase
+// PurchaseItem buys quantity units of an item from a dungeon's shop for a
+// user. The price comes from
+// the pricing pipeline and the user must be able to afford the final price.
+// A non-empty idempotencyKey makes retries of the same purchase fail with
+// ports.ErrDuplicateRequest instead of charging twice.
+func (s *ShopService) PurchaseItem(
+	ctx context.Context,
+	userID int64,
+	dungeonID string,
+	itemCode string,
+	quantity int,
+	idempotencyKey string,
+) (*entity.Purchase, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidPurchaseQuantity
+	}
 
-	// Check idempotency first
 	if idempotencyKey != "" {
 		existing, err := s.idempotencyRepo.FindByKey(ctx, idempotencyKey)
 		if err == nil && existing != nil {
@@ -140,82 +115,98 @@ func (s *ShopService) PurchaseItem(ctx context.Context, userID int64, itemCode s
 		}
 	}
 
+	var purchase *entity.Purchase
 	err := s.txManager.WithTx(ctx, func(txCtx context.Context) error {
-		// Get user
 		user, err := s.userRepo.FindByID(txCtx, userID)
 		if err != nil {
 			return fmt.Errorf("user not found: %w", err)
 		}
 
-		// Get item
-		item, err := s.shopItemRepo.FindByCode(txCtx, user.ChatID, itemCode)
+		// Only members can spend in a dungeon's shop
+		if _, _, err := s.role(txCtx, userID, dungeonID); err != nil {
+			return err
+		}
+
+		item, err := s.shopItemRepo.FindByCode(txCtx, dungeonID, itemCode)
 		if err != nil {
-			// Try global items
-			item, err = s.shopItemRepo.FindByCode(txCtx, 0, itemCode)
-			if err != nil {
-				return fmt.Errorf("item not found: %w", err)
-			}
+			return fmt.Errorf("item not found: %w", err)
 		}
 
-		// Check if item is active
+		// Check item availability
 		if !item.IsActive {
-			return fmt.Errorf("item is not available")
+			return ErrItemNotAvailable
 		}
-
-		// Check stock
 		if item.Stock != nil && *item.Stock < quantity {
-			return fmt.Errorf("insufficient stock")
+			return ports.ErrInsufficientStock
 		}
 
-		// Calculate total cost
-		totalCost := item.Price.Mul(valueobject.NewDecimal(fmt.Sprintf("%d", quantity)))
-
-		// Check user balance
-		if user.Balance.Cmp(totalCost) < 0 {
-			return fmt.Errorf("insufficient balance")
+		now := time.Now()
+		quote, err := s.pricing.Quote(txCtx, user, item, quantity, now)
+		if err != nil {
+			return err
+		}
+		balance, err := s.ledger.Balance(txCtx, userID, dungeonID)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(quote.Total) < 0 {
+			return fmt.Errorf("insufficient balance: %w", ports.ErrInsufficientFunds)
 		}
 
-		// Create purchase record
 		purchase = &entity.Purchase{
-			UserID:    userID,
-			ItemID:    item.ID,
-			ItemName:  item.Name,
-			ItemPrice: item.Price,
-			Quantity:  quantity,
-			TotalCost: totalCost,
-			Status:    "completed",
-		}
-
-		// 1. Deduct user balance
-		negativeAmount := totalCost.Mul(valueobject.NewDecimal("-1"))
-		err = s.userRepo.UpdateBalance(txCtx, userID, negativeAmount)
-		if err != nil {
-			return fmt.Errorf("failed to update balance: %w", err)
+			UserID:         userID,
+			ItemID:         item.ID,
+			DungeonID:      dungeonID,
+			ItemName:       item.Name,
+			ItemPrice:      item.Price,
+			Quantity:       quantity,
+			Subtotal:       quote.Subtotal,
+			TotalCost:      quote.Total,
+			PriceBreakdown: quote.Breakdown,
+			Status:         entity.PurchaseStatusCompleted,
+			DiscountTierID: item.DiscountTierID,
+			PurchasedAt:    now,
 		}
 
-		// 2. Update stock if limited
 		if item.Stock != nil {
-			newStock := *item.Stock - quantity
-			item.Stock = &newStock
-			err = s.shopItemRepo.Update(txCtx, item)
-			if err != nil {
+			if err := s.shopItemRepo.AdjustStock(txCtx, item.ID, -quantity); err != nil {
 				return fmt.Errorf("failed to update stock: %w", err)
 			}
 		}
 
-		// 3. Create purchase record
-		err = s.purchaseRepo.Create(txCtx, purchase)
-		if err != nil {
+		if err := s.purchaseRepo.Create(txCtx, purchase); err != nil {
 			return fmt.Errorf("failed to create purchase: %w", err)
 		}
 
+		// Free purchases leave the balance alone
+		if purchase.TotalCost.IsPositive() {
+			if _, err := s.ledger.Post(txCtx, purchasePosting(purchase)); err != nil {
+				return fmt.Errorf("failed to update balance: %w", err)
+			}
+		}
+
+		if err := s.refreshLoyalty(txCtx, userID, dungeonID); err != nil {
+			return err
+		}
+
+		// Record idempotency key if provided
+		if idempotencyKey != "" {
+			key := &entity.IdempotencyKey{
+				Key:       idempotencyKey,
+				ExpiresAt: now.Add(24 * time.Hour),
+			}
+			if err := s.idempotencyRepo.Create(txCtx, key); err != nil {
+				return fmt.Errorf("failed to record idempotency key: %w", err)
+			}
+		}
+
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
+	s.notifyLoyalty(ctx, userID, dungeonID)
 	return purchase, nil
 }
 
@@ -224,25 +215,155 @@ func (s *ShopService) GetUserPurchases(ctx context.Context, userID int64) ([]*en
 	return s.purchaseRepo.FindByUserID(ctx, userID)
 }
 
-// GetCurrencyName returns the currency name for a chat
-func (s *ShopService) GetCurrencyName(ctx context.Context, chatID int64) (string, error) {
-	config, err := s.chatConfigRepo.FindByChatID(ctx, chatID)
+// RefundInput describes which part of a purchase to refund
+type RefundInput struct {
+	Quantity int    // Units to refund; 0 refunds every unit not refunded yet
+	Reason   string // Recorded on the ledger entry
+}
+
+// RefundPurchase refunds some or all units of a purchase on behalf of actorID.
+// The buyer is credited, limited stock is put back and the purchase is updated
+// in one transaction. Dungeon roles that may adjust balances or edit the shop
+// can refund any purchase made in the dungeon at any time. Other members can
+// only refund their own purchases, within the refund window of the dungeon's
+// chat and only if that chat does not restrict refunds to admins.
+func (s *ShopService) RefundPurchase(ctx context.Context, actorID, purchaseID int64, input RefundInput) (*entity.Purchase, error) {
+	var purchase *entity.Purchase
+
+	err := s.txManager.WithTx(ctx, func(txCtx context.Context) error {
+		var err error
+		purchase, err = s.purchaseRepo.FindByID(txCtx, purchaseID)
+		if err != nil {
+			return err
+		}
+
+		dungeon, role, err := s.role(txCtx, actorID, purchase.DungeonID)
+		if err != nil {
+			return err
+		}
+		manager := role.Can(entity.PermissionAdjustBalances) || role.Can(entity.PermissionEditShop)
+		if purchase.UserID != actorID && !manager {
+			return ErrRefundNotAllowed
+		}
+
+		now := time.Now()
+		if !manager {
+			config := s.dungeonChatConfig(txCtx, dungeon)
+			if config.RefundsAdminOnly {
+				return ErrRefundNotAllowed
+			}
+			if now.Sub(purchase.PurchasedAt) > config.RefundWindow() {
+				return ErrRefundWindowExpired
+			}
+		}
+
+		quantity := input.Quantity
+		if quantity == 0 {
+			quantity = purchase.RefundableQuantity()
+		}
+		amount, err := purchase.Refund(quantity, now)
+		if err != nil {
+			return err
+		}
+
+		// Put limited stock back, unless the item is gone
+		err = s.shopItemRepo.AdjustStock(txCtx, purchase.ItemID, quantity)
+		if err != nil && !errors.Is(err, ports.ErrShopItemNotFound) {
+			return fmt.Errorf("failed to update stock: %w", err)
+		}
+
+		if err := s.purchaseRepo.Update(txCtx, purchase); err != nil {
+			return fmt.Errorf("failed to update purchase: %w", err)
+		}
+
+		if err := s.refreshLoyalty(txCtx, purchase.UserID, purchase.DungeonID); err != nil {
+			return err
+		}
+
+		// Units bought for free have nothing to credit back
+		if amount.IsZero() {
+			return nil
+		}
+
+		note := input.Reason
+		if note == "" {
+			note = fmt.Sprintf("%dx %s", quantity, purchase.ItemName)
+		}
+		_, err = s.ledger.Post(txCtx, Posting{
+			UserID:     purchase.UserID,
+			DungeonID:  purchase.DungeonID,
+			Amount:     amount,
+			Reason:     entity.LedgerReasonRefund,
+			SourceType: entity.LedgerSourcePurchase,
+			SourceID:   strconv.FormatInt(purchase.ID, 10),
+			Note:       note,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		// Return default if not configured
-		return "Points", nil
+		return nil, err
+	}
+
+	s.notifyLoyalty(ctx, purchase.UserID, purchase.DungeonID)
+	return purchase, nil
+}
+
+// refreshLoyalty reassigns the user's loyalty tier in a dungeon after their
+// purchases there changed
+func (s *ShopService) refreshLoyalty(ctx context.Context, userID int64, dungeonID string) error {
+	if s.loyalty == nil {
+		return nil
+	}
+	if _, err := s.loyalty.Refresh(ctx, userID, dungeonID); err != nil {
+		return fmt.Errorf("failed to update loyalty tier: %w", err)
+	}
+	return nil
+}
+
+// notifyLoyalty tells the user about a new loyalty tier. The purchase has
+// already committed, so a failed notification is left pending and retried
+// after the user's next purchase.
+func (s *ShopService) notifyLoyalty(ctx context.Context, userID int64, dungeonID string) {
+	if s.loyalty == nil {
+		return
+	}
+	_ = s.loyalty.NotifyPending(ctx, userID, dungeonID)
+}
+
+// chatConfig returns the stored config for a chat, or the defaults
+func (s *ShopService) chatConfig(ctx context.Context, chatID int64) *entity.ChatConfig {
+	config, err := s.chatConfigRepo.FindByChatID(ctx, chatID)
+	if err != nil || config == nil {
+		return entity.DefaultChatConfig(chatID)
 	}
-	return config.CurrencyName, nil
+	return config
+}
+
+// dungeonChatConfig returns the config of the chat linked to a dungeon, or the
+// defaults for dungeons without one
+func (s *ShopService) dungeonChatConfig(ctx context.Context, dungeon *entity.Dungeon) *entity.ChatConfig {
+	if dungeon.TelegramChatID == nil {
+		return entity.DefaultChatConfig(0)
+	}
+	return s.chatConfig(ctx, *dungeon.TelegramChatID)
+}
+
+// GetCurrencyName returns the currency name for a chat
+func (s *ShopService) GetCurrencyName(ctx context.Context, chatID int64) (string, error) {
+	return s.chatConfig(ctx, chatID).CurrencyName, nil
 }
 
 // SetCurrencyName sets the currency name for a chat
 func (s *ShopService) SetCurrencyName(ctx context.Context, chatID int64, currencyName string) error {
 	config, err := s.chatConfigRepo.FindByChatID(ctx, chatID)
-	if err != nil {
+	if err != nil || config == nil {
 		// Create new config
-		config = &entity.ChatConfig{
-			ChatID:       chatID,
-			CurrencyName: currencyName,
-		}
+		config = entity.DefaultChatConfig(chatID)
+		config.CurrencyName = currencyName
 		return s.chatConfigRepo.Create(ctx, config)
 	}
 
@@ -250,3 +371,44 @@ func (s *ShopService) SetCurrencyName(ctx context.Context, chatID int64, currenc
 	config.CurrencyName = currencyName
 	return s.chatConfigRepo.Update(ctx, config)
 }
+
+// purchasePosting is the ledger debit for a purchase, from the wallet of the
+// dungeon it was bought in
+func purchasePosting(purchase *entity.Purchase) Posting {
+	return Posting{
+		UserID:     purchase.UserID,
+		DungeonID:  purchase.DungeonID,
+		Amount:     purchase.TotalCost.Mul(valueobject.NewDecimal("-1")),
+		Reason:     entity.LedgerReasonPurchase,
+		SourceType: entity.LedgerSourcePurchase,
+		SourceID:   strconv.FormatInt(purchase.ID, 10),
+		Note:       fmt.Sprintf("%dx %s", purchase.Quantity, purchase.ItemName),
+	}
+}
+
+// noopIdempotencyRepo stands in when no idempotency repository is configured
+type noopIdempotencyRepo struct{}
+
+func (r *noopIdempotencyRepo) Create(ctx context.Context, key *entity.IdempotencyKey) error {
+	return nil
+}
+
+func (r *noopIdempotencyRepo) Claim(ctx context.Context, key *entity.IdempotencyKey) error {
+	return nil
+}
+
+func (r *noopIdempotencyRepo) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
+	return nil, ports.ErrIdempotencyKeyNotFound
+}
+
+func (r *noopIdempotencyRepo) Update(ctx context.Context, key *entity.IdempotencyKey) error {
+	return nil
+}
+
+func (r *noopIdempotencyRepo) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func (r *noopIdempotencyRepo) Purge(ctx context.Context, olderThan time.Time) error {
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
 	"github.com/supercakecrumb/adhd-game-bot/internal/usecase/testhelpers"
@@ -32,9 +33,11 @@ func TestShopService_BalanceNeverNegative(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Create test data
@@ -43,11 +46,11 @@ func TestShopService_BalanceNeverNegative(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal(fmt.Sprintf("%d", balance)),
 		}
+		wallets.balance = user.Balance
 
 		stockInt := int(stock)
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal(fmt.Sprintf("%d", price)),
@@ -61,11 +64,11 @@ func TestShopService_BalanceNeverNegative(t *testing.T) {
 				fn := args.Get(1).(func(context.Context) error)
 
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Once()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Once()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Once()
 
 				if balance >= price && stock > 0 {
-					userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Once()
-					shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Once()
+					userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+					shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Once()
 					purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).Return(nil).Once()
 				}
 
@@ -73,7 +76,7 @@ func TestShopService_BalanceNeverNegative(t *testing.T) {
 			}).Return(nil).Once()
 
 		// Execute purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 1, "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", 1, "")
 
 		// Property check: if purchase succeeded, balance should not be negative
 		if err == nil {
@@ -108,9 +111,11 @@ func TestShopService_StockNeverNegative(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Create test data
@@ -119,11 +124,11 @@ func TestShopService_StockNeverNegative(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal("1000000"), // Plenty of money
 		}
+		wallets.balance = user.Balance
 
 		stockInt := int(stock)
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal("1"),
@@ -133,12 +138,11 @@ func TestShopService_StockNeverNegative(t *testing.T) {
 
 		// Setup mock expectations
 		userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Maybe()
-		shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Maybe()
-		shopItemRepo.On("FindByCode", ctx, int64(0), "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
+		shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Maybe()
 
 		if stock >= uint16(quantity) {
-			userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Maybe()
-			shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Maybe()
+			userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Maybe()
+			shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Maybe()
 			purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).Return(nil).Maybe()
 		}
 
@@ -149,7 +153,7 @@ func TestShopService_StockNeverNegative(t *testing.T) {
 			}).Return(nil).Once()
 
 		// Execute purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", int(quantity), "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", int(quantity), "")
 
 		// Property check: if purchase succeeded, stock should not be negative
 		if err == nil {
@@ -184,9 +188,11 @@ func TestShopService_TotalCostCalculation(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Calculate expected total
@@ -198,10 +204,10 @@ func TestShopService_TotalCostCalculation(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal(fmt.Sprintf("%d", expectedTotal+1000)), // Enough balance
 		}
+		wallets.balance = user.Balance
 
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal(fmt.Sprintf("%d", price)),
@@ -217,9 +223,9 @@ func TestShopService_TotalCostCalculation(t *testing.T) {
 				fn := args.Get(1).(func(context.Context) error)
 
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Once()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Once()
-				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Once()
-				shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Once()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Once()
+				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+				shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Once()
 				purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).
 					Run(func(args mock.Arguments) {
 						capturedPurchase = args.Get(1).(*entity.Purchase)
@@ -229,7 +235,7 @@ func TestShopService_TotalCostCalculation(t *testing.T) {
 			}).Return(nil).Once()
 
 		// Execute purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", int(quantity), "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", int(quantity), "")
 		require.NoError(t, err)
 
 		// Property check: total cost should equal price * quantity
@@ -261,9 +267,11 @@ func TestShopService_LargeQuantityPurchases(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Calculate expected total
@@ -275,11 +283,11 @@ func TestShopService_LargeQuantityPurchases(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal(fmt.Sprintf("%d", expectedTotal+1000)), // Enough balance
 		}
+		wallets.balance = user.Balance
 
 		stock := int(quantity * 2) // Plenty of stock
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal(fmt.Sprintf("%d", price)),
@@ -295,9 +303,9 @@ func TestShopService_LargeQuantityPurchases(t *testing.T) {
 				fn := args.Get(1).(func(context.Context) error)
 
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Once()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Once()
-				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Once()
-				shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Once()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Once()
+				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+				shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Once()
 				purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).
 					Run(func(args mock.Arguments) {
 						capturedPurchase = args.Get(1).(*entity.Purchase)
@@ -307,7 +315,7 @@ func TestShopService_LargeQuantityPurchases(t *testing.T) {
 			}).Return(nil).Once()
 
 		// Execute purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", int(quantity), "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", int(quantity), "")
 		if err != nil {
 			return false
 		}
@@ -341,9 +349,11 @@ func TestShopService_DecimalPrecision(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Calculate expected total with precise decimal math
@@ -355,11 +365,11 @@ func TestShopService_DecimalPrecision(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal(fmt.Sprintf("%.4f", expectedTotal+1000)), // Enough balance
 		}
+		wallets.balance = user.Balance
 
 		stock := 1000 // Plenty of stock
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal(fmt.Sprintf("%.4f", price)),
@@ -375,9 +385,9 @@ func TestShopService_DecimalPrecision(t *testing.T) {
 				fn := args.Get(1).(func(context.Context) error)
 
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Once()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Once()
-				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Once()
-				shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Once()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Once()
+				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+				shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Once()
 				purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).
 					Run(func(args mock.Arguments) {
 						capturedPurchase = args.Get(1).(*entity.Purchase)
@@ -387,7 +397,7 @@ func TestShopService_DecimalPrecision(t *testing.T) {
 			}).Return(nil).Once()
 
 		// Execute purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", int(quantity), "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", int(quantity), "")
 		if err != nil {
 			return false
 		}
@@ -422,9 +432,11 @@ func TestShopService_IdempotentPurchase(t *testing.T) {
 		uuidGen := new(testhelpers.MockUUIDGenerator)
 		txManager := new(testhelpers.MockTxManager)
 
-		service := usecase.NewShopServiceV2(
-			shopItemRepo, purchaseRepo, userRepo,
-			chatConfigRepo, nil, uuidGen, txManager, nil,
+		wallets := new(fixedWallets)
+		dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+		service := usecase.NewShopService(
+			shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+			chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 		)
 
 		// Create test data
@@ -433,10 +445,10 @@ func TestShopService_IdempotentPurchase(t *testing.T) {
 			ChatID:  100,
 			Balance: valueobject.NewDecimal(fmt.Sprintf("%d", price*2)), // Enough for 2 purchases
 		}
+		wallets.balance = user.Balance
 
 		item := &entity.ShopItem{
 			ID:       1,
-			ChatID:   100,
 			Code:     "TEST",
 			Name:     "Test Item",
 			Price:    valueobject.NewDecimal(fmt.Sprintf("%d", price)),
@@ -450,22 +462,22 @@ func TestShopService_IdempotentPurchase(t *testing.T) {
 				fn := args.Get(1).(func(context.Context) error)
 
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Once()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Once()
-				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Once()
-				shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Once()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Once()
+				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Once()
+				shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Once()
 				purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).Return(nil).Once()
 
 				fn(ctx)
 			}).Return(nil).Twice() // Expect two calls but same mocks
 
 		// Execute first purchase
-		_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 1, "")
+		_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", 1, "")
 		if err != nil {
 			return false
 		}
 
 		// Execute second identical purchase
-		_, err = service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 1, "")
+		_, err = service.PurchaseItem(ctx, 1, "d1", "TEST", 1, "")
 		if err != nil {
 			return false
 		}
@@ -498,9 +510,11 @@ func TestShopService_EdgeCases(t *testing.T) {
 				uuidGen := new(testhelpers.MockUUIDGenerator)
 				txManager := new(testhelpers.MockTxManager)
 
-				service := usecase.NewShopServiceV2(
-					shopItemRepo, purchaseRepo, userRepo,
-					chatConfigRepo, nil, uuidGen, txManager, nil,
+				wallets := new(fixedWallets)
+				dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+				service := usecase.NewShopService(
+					shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+					chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 				)
 
 				user := &entity.User{
@@ -508,10 +522,10 @@ func TestShopService_EdgeCases(t *testing.T) {
 					ChatID:  100,
 					Balance: valueobject.NewDecimal("100"),
 				}
+				wallets.balance = user.Balance
 
 				item := &entity.ShopItem{
 					ID:       1,
-					ChatID:   100,
 					Code:     "TEST",
 					Name:     "Test Item",
 					Price:    valueobject.NewDecimal("100"),
@@ -521,10 +535,9 @@ func TestShopService_EdgeCases(t *testing.T) {
 
 				// Setup mock expectations
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(0), "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
-				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(nil).Maybe()
-				shopItemRepo.On("Update", ctx, mock.AnythingOfType("*entity.ShopItem")).Return(nil).Maybe()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Maybe()
+				userRepo.On("UpdateBalance", ctx, int64(1), mock.AnythingOfType("valueobject.Decimal")).Return(valueobject.NewDecimal("0"), nil).Maybe()
+				shopItemRepo.On("AdjustStock", ctx, mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil).Maybe()
 				purchaseRepo.On("Create", ctx, mock.AnythingOfType("*entity.Purchase")).Return(nil).Maybe()
 
 				txManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).
@@ -533,7 +546,7 @@ func TestShopService_EdgeCases(t *testing.T) {
 						fn(ctx)
 					}).Return(nil).Once()
 
-				_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 1, "")
+				_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", 1, "")
 				require.NoError(t, err)
 			},
 		},
@@ -549,9 +562,11 @@ func TestShopService_EdgeCases(t *testing.T) {
 				uuidGen := new(testhelpers.MockUUIDGenerator)
 				txManager := new(testhelpers.MockTxManager)
 
-				service := usecase.NewShopServiceV2(
-					shopItemRepo, purchaseRepo, userRepo,
-					chatConfigRepo, nil, uuidGen, txManager, nil,
+				wallets := new(fixedWallets)
+				dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+				service := usecase.NewShopService(
+					shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+					chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 				)
 
 				// Create test data for mocks
@@ -560,11 +575,11 @@ func TestShopService_EdgeCases(t *testing.T) {
 					ChatID:  100,
 					Balance: valueobject.NewDecimal("1000"),
 				}
+				wallets.balance = user.Balance
 
 				// Setup mock expectations
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(0), "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
 
 				// Setup transaction manager mock to handle the call
 				txManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).
@@ -574,7 +589,7 @@ func TestShopService_EdgeCases(t *testing.T) {
 					}).Return(nil).Maybe()
 
 				// Should fail validation before any repository calls
-				_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 0, "")
+				_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", 0, "")
 				require.Error(t, err)
 			},
 		},
@@ -590,9 +605,11 @@ func TestShopService_EdgeCases(t *testing.T) {
 				uuidGen := new(testhelpers.MockUUIDGenerator)
 				txManager := new(testhelpers.MockTxManager)
 
-				service := usecase.NewShopServiceV2(
-					shopItemRepo, purchaseRepo, userRepo,
-					chatConfigRepo, nil, uuidGen, txManager, nil,
+				wallets := new(fixedWallets)
+				dungeonRepo, memberRepo := newDungeon(t, "d1", 1)
+				service := usecase.NewShopService(
+					shopItemRepo, purchaseRepo, userRepo, newShopLedger(userRepo, wallets),
+					chatConfigRepo, nil, nil, uuidGen, txManager, nil, dungeonRepo, memberRepo,
 				)
 
 				user := &entity.User{
@@ -600,10 +617,10 @@ func TestShopService_EdgeCases(t *testing.T) {
 					ChatID:  100,
 					Balance: valueobject.NewDecimal("1000"),
 				}
+				wallets.balance = user.Balance
 
 				item := &entity.ShopItem{
 					ID:       1,
-					ChatID:   100,
 					Code:     "TEST",
 					Name:     "Test Item",
 					Price:    valueobject.NewDecimal("100"),
@@ -613,8 +630,7 @@ func TestShopService_EdgeCases(t *testing.T) {
 
 				// Setup mock expectations
 				userRepo.On("FindByID", ctx, int64(1)).Return(user, nil).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(100), "TEST").Return(item, nil).Maybe()
-				shopItemRepo.On("FindByCode", ctx, int64(0), "TEST").Return(nil, fmt.Errorf("not found")).Maybe()
+				shopItemRepo.On("FindByCode", ctx, "d1", "TEST").Return(item, nil).Maybe()
 
 				txManager.On("WithTx", ctx, mock.AnythingOfType("func(context.Context) error")).
 					Run(func(args mock.Arguments) {
@@ -622,7 +638,7 @@ func TestShopService_EdgeCases(t *testing.T) {
 						fn(ctx)
 					}).Return(nil).Once()
 
-				_, err := service.PurchaseItemWithIdempotency(ctx, 1, "TEST", 1, "")
+				_, err := service.PurchaseItem(ctx, 1, "d1", "TEST", 1, "")
 				require.Error(t, err)
 			},
 		},
@@ -632,3 +648,30 @@ func TestShopService_EdgeCases(t *testing.T) {
 		t.Run(tt.name, tt.test)
 	}
 }
+
+// fixedWallets stands in for the wallets of the mocked users above, whose
+// balance never changes: every wallet holds balance, before and after a posting
+type fixedWallets struct {
+	balance valueobject.Decimal
+}
+
+func (w *fixedWallets) Get(ctx context.Context, userID int64, dungeonID string) (*entity.Wallet, error) {
+	return &entity.Wallet{UserID: userID, DungeonID: dungeonID, Balance: w.balance}, nil
+}
+
+func (w *fixedWallets) ListByUser(ctx context.Context, userID int64) ([]*entity.Wallet, error) {
+	return nil, nil
+}
+
+func (w *fixedWallets) FindAll(ctx context.Context) ([]*entity.Wallet, error) {
+	return nil, nil
+}
+
+func (w *fixedWallets) UpdateBalance(ctx context.Context, userID int64, dungeonID string, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	return w.balance.Add(delta), nil
+}
+
+// newShopLedger is newLedger with the given wallets
+func newShopLedger(userRepo ports.UserRepository, wallets ports.WalletRepository) *usecase.LedgerService {
+	return usecase.NewLedgerService(inmemory.NewLedgerRepository(), wallets, userRepo, &mockUUIDGen{}, &mockTxManager{})
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
 	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
 	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
 )
 
@@ -24,13 +26,13 @@ func (m *mockShopItemRepo) FindByID(ctx context.Context, id int64) (*entity.Shop
 	return args.Get(0).(*entity.ShopItem), args.Error(1)
 }
 
-func (m *mockShopItemRepo) FindByCode(ctx context.Context, chatID int64, code string) (*entity.ShopItem, error) {
-	args := m.Called(ctx, chatID, code)
+func (m *mockShopItemRepo) FindByCode(ctx context.Context, dungeonID string, code string) (*entity.ShopItem, error) {
+	args := m.Called(ctx, dungeonID, code)
 	return args.Get(0).(*entity.ShopItem), args.Error(1)
 }
 
-func (m *mockShopItemRepo) FindByChatID(ctx context.Context, chatID int64) ([]*entity.ShopItem, error) {
-	args := m.Called(ctx, chatID)
+func (m *mockShopItemRepo) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error) {
+	args := m.Called(ctx, dungeonID)
 	return args.Get(0).([]*entity.ShopItem), args.Error(1)
 }
 
@@ -38,6 +40,10 @@ func (m *mockShopItemRepo) Update(ctx context.Context, item *entity.ShopItem) er
 	return m.Called(ctx, item).Error(0)
 }
 
+func (m *mockShopItemRepo) AdjustStock(ctx context.Context, id int64, delta int) error {
+	return m.Called(ctx, id, delta).Error(0)
+}
+
 func (m *mockShopItemRepo) Delete(ctx context.Context, id int64) error {
 	return m.Called(ctx, id).Error(0)
 }
@@ -63,6 +69,10 @@ func (m *mockPurchaseRepo) FindByItemID(ctx context.Context, itemID int64) ([]*e
 	return args.Get(0).([]*entity.Purchase), args.Error(1)
 }
 
+func (m *mockPurchaseRepo) Update(ctx context.Context, purchase *entity.Purchase) error {
+	return m.Called(ctx, purchase).Error(0)
+}
+
 type mockUserRepo struct{ mock.Mock }
 
 func (m *mockUserRepo) Create(ctx context.Context, user *entity.User) error {
@@ -74,13 +84,23 @@ func (m *mockUserRepo) FindByID(ctx context.Context, id int64) (*entity.User, er
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *mockUserRepo) Lock(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
 func (m *mockUserRepo) FindByChatID(ctx context.Context, chatID int64) ([]*entity.User, error) {
 	args := m.Called(ctx, chatID)
 	return args.Get(0).([]*entity.User), args.Error(1)
 }
 
-func (m *mockUserRepo) UpdateBalance(ctx context.Context, id int64, amount valueobject.Decimal) error {
-	return m.Called(ctx, id, amount).Error(0)
+func (m *mockUserRepo) FindAll(ctx context.Context) ([]*entity.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) UpdateBalance(ctx context.Context, id int64, amount valueobject.Decimal) (valueobject.Decimal, error) {
+	args := m.Called(ctx, id, amount)
+	return args.Get(0).(valueobject.Decimal), args.Error(1)
 }
 
 func (m *mockUserRepo) Delete(ctx context.Context, id int64) error {
@@ -93,6 +113,10 @@ func (m *mockIdempotencyRepo) Create(ctx context.Context, key *entity.Idempotenc
 	return m.Called(ctx, key).Error(0)
 }
 
+func (m *mockIdempotencyRepo) Claim(ctx context.Context, key *entity.IdempotencyKey) error {
+	return m.Called(ctx, key).Error(0)
+}
+
 func (m *mockIdempotencyRepo) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
 	args := m.Called(ctx, key)
 	return args.Get(0).(*entity.IdempotencyKey), args.Error(1)
@@ -110,7 +134,7 @@ func (m *mockIdempotencyRepo) Purge(ctx context.Context, olderThan time.Time) er
 	return m.Called(ctx, olderThan).Error(0)
 }
 
-func TestShopServiceV2(t *testing.T) {
+func TestShopService(t *testing.T) {
 	t.Parallel()
 
 	ctx := context.Background()
@@ -118,35 +142,50 @@ func TestShopServiceV2(t *testing.T) {
 	purchaseRepo := &mockPurchaseRepo{}
 	userRepo := &mockUserRepo{}
 	idempotencyRepo := &mockIdempotencyRepo{}
+	walletRepo := inmemory.NewWalletRepository()
+	dungeonRepo := inmemory.NewDungeonRepository()
+	require.NoError(t, dungeonRepo.Create(ctx, &entity.Dungeon{ID: "d1", Title: "Test", AdminUserID: 1}))
 
-	service := NewShopServiceV2(
+	service := NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, &mockUUIDGen{}, &mockTxManager{}),
 		nil,              // chatConfigRepo
-		nil,              // discountTierRepo
+		nil,              // pricing
+		nil,              // loyalty
 		nil,              // uuidGen
 		&mockTxManager{}, // txManager
 		idempotencyRepo,
+		dungeonRepo,
+		inmemory.NewDungeonMemberRepository(),
 	)
 
-	t.Run("PurchaseItemWithIdempotency succeeds", func(t *testing.T) {
+	t.Run("PurchaseItem succeeds", func(t *testing.T) {
 		user := &entity.User{ID: 1, Balance: valueobject.NewDecimal("100.00")}
 		item := &entity.ShopItem{ID: 1, Price: valueobject.NewDecimal("10.00"), IsActive: true}
+		_, err := walletRepo.UpdateBalance(ctx, 1, "d1", valueobject.NewDecimal("100.00"))
+		assert.NoError(t, err)
 
 		userRepo.On("FindByID", ctx, int64(1)).Return(user, nil)
-		shopItemRepo.On("FindByCode", ctx, int64(0), "ITEM").Return(item, nil)
+		shopItemRepo.On("FindByCode", ctx, "d1", "ITEM").Return(item, nil)
 		purchaseRepo.On("Create", ctx, mock.Anything).Return(nil)
-		userRepo.On("UpdateBalance", ctx, int64(1), mock.Anything).Return(nil)
+		userRepo.On("UpdateBalance", ctx, int64(1), mock.Anything).Return(valueobject.NewDecimal("0"), nil)
 		idempotencyRepo.On("FindByKey", ctx, "key123").Return((*entity.IdempotencyKey)(nil), ports.ErrIdempotencyKeyNotFound)
 		idempotencyRepo.On("Create", ctx, mock.Anything).Return(nil)
 
-		purchase, err := service.PurchaseItemWithIdempotency(ctx, 1, "ITEM", 1, "key123")
+		purchase, err := service.PurchaseItem(ctx, 1, "d1", "ITEM", 1, "key123")
 		assert.NoError(t, err)
 		assert.NotNil(t, purchase)
 	})
 }
 
+type mockUUIDGen struct{}
+
+func (m *mockUUIDGen) New() string {
+	return "generated-uuid"
+}
+
 // Mock transaction manager
 type mockTxManager struct{ mock.Mock }
 
@@ -26,11 +26,24 @@ func (m *MockUserRepository) FindByID(ctx context.Context, id int64) (*entity.Us
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) error {
-	args := m.Called(ctx, userID, delta)
+func (m *MockUserRepository) Lock(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateBalance(ctx context.Context, userID int64, delta valueobject.Decimal) (valueobject.Decimal, error) {
+	args := m.Called(ctx, userID, delta)
+	return args.Get(0).(valueobject.Decimal), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -83,16 +96,16 @@ func (m *MockShopItemRepository) FindByID(ctx context.Context, id int64) (*entit
 	return args.Get(0).(*entity.ShopItem), args.Error(1)
 }
 
-func (m *MockShopItemRepository) FindByCode(ctx context.Context, chatID int64, code string) (*entity.ShopItem, error) {
-	args := m.Called(ctx, chatID, code)
+func (m *MockShopItemRepository) FindByCode(ctx context.Context, dungeonID string, code string) (*entity.ShopItem, error) {
+	args := m.Called(ctx, dungeonID, code)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*entity.ShopItem), args.Error(1)
 }
 
-func (m *MockShopItemRepository) FindByChatID(ctx context.Context, chatID int64) ([]*entity.ShopItem, error) {
-	args := m.Called(ctx, chatID)
+func (m *MockShopItemRepository) FindByDungeonID(ctx context.Context, dungeonID string) ([]*entity.ShopItem, error) {
+	args := m.Called(ctx, dungeonID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -104,6 +117,11 @@ func (m *MockShopItemRepository) Update(ctx context.Context, item *entity.ShopIt
 	return args.Error(0)
 }
 
+func (m *MockShopItemRepository) AdjustStock(ctx context.Context, id int64, delta int) error {
+	args := m.Called(ctx, id, delta)
+	return args.Error(0)
+}
+
 func (m *MockShopItemRepository) Delete(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -142,6 +160,11 @@ func (m *MockPurchaseRepository) FindByItemID(ctx context.Context, itemID int64)
 	return args.Get(0).([]*entity.Purchase), args.Error(1)
 }
 
+func (m *MockPurchaseRepository) Update(ctx context.Context, purchase *entity.Purchase) error {
+	args := m.Called(ctx, purchase)
+	return args.Error(0)
+}
+
 type MockUUIDGenerator struct {
 	mock.Mock
 }
@@ -195,6 +218,11 @@ func (m *MockIdempotencyRepository) Create(ctx context.Context, key *entity.Idem
 	return args.Error(0)
 }
 
+func (m *MockIdempotencyRepository) Claim(ctx context.Context, key *entity.IdempotencyKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
 func (m *MockIdempotencyRepository) FindByKey(ctx context.Context, key string) (*entity.IdempotencyKey, error) {
 	args := m.Called(ctx, key)
 	if args.Get(0) == nil {
@@ -227,6 +255,11 @@ func (m *MockScheduler) GetNextOccurrence(ctx context.Context, taskID string) (t
 	return args.Get(0).(time.Time), args.Error(1)
 }
 
+func (m *MockScheduler) Tick(ctx context.Context, now time.Time) error {
+	args := m.Called(ctx, now)
+	return args.Error(0)
+}
+
 func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -248,3 +281,51 @@ func (m *MockTxManager) WithTx(ctx context.Context, fn func(ctx context.Context)
 	}
 	return args.Error(0)
 }
+
+type MockQuestCompletionRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuestCompletionRepository) Insert(ctx context.Context, completion *entity.QuestCompletion) error {
+	args := m.Called(ctx, completion)
+	return args.Error(0)
+}
+
+func (m *MockQuestCompletionRepository) LastForUser(ctx context.Context, userID int64, questID string) (*entity.QuestCompletion, error) {
+	args := m.Called(ctx, userID, questID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.QuestCompletion), args.Error(1)
+}
+
+func (m *MockQuestCompletionRepository) SumAwardedForUserOnDay(ctx context.Context, userID int64, questID string, day time.Time, tz string) (valueobject.Decimal, error) {
+	args := m.Called(ctx, userID, questID, day, tz)
+	return args.Get(0).(valueobject.Decimal), args.Error(1)
+}
+
+// MockQuestStreakRepository is a mock implementation of QuestStreakRepository
+type MockQuestStreakRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuestStreakRepository) Get(ctx context.Context, userID int64, questID string) (*entity.QuestStreak, error) {
+	args := m.Called(ctx, userID, questID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.QuestStreak), args.Error(1)
+}
+
+func (m *MockQuestStreakRepository) Upsert(ctx context.Context, streak *entity.QuestStreak) error {
+	args := m.Called(ctx, streak)
+	return args.Error(0)
+}
+
+func (m *MockQuestStreakRepository) ListByUser(ctx context.Context, userID int64) ([]*entity.QuestStreak, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.QuestStreak), args.Error(1)
+}
@@ -0,0 +1,380 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// TimerService runs focus sessions: countdown and stopwatch timers attached to quests
+type TimerService struct {
+	timerRepo    ports.TimerRepository
+	eventRepo    ports.TimerEventRepository
+	questRepo    ports.QuestRepository
+	questService *QuestService
+	notifier     ports.TimerNotifier
+	uuidGen      ports.UUIDGenerator
+	clock        ports.Clock
+	txManager    ports.TxManager
+}
+
+// NewTimerService creates the service. The notifier may be nil in processes that
+// never dispatch expiry notifications.
+func NewTimerService(
+	timerRepo ports.TimerRepository,
+	eventRepo ports.TimerEventRepository,
+	questRepo ports.QuestRepository,
+	questService *QuestService,
+	notifier ports.TimerNotifier,
+	uuidGen ports.UUIDGenerator,
+	clock ports.Clock,
+	txManager ports.TxManager,
+) *TimerService {
+	return &TimerService{
+		timerRepo:    timerRepo,
+		eventRepo:    eventRepo,
+		questRepo:    questRepo,
+		questService: questService,
+		notifier:     notifier,
+		uuidGen:      uuidGen,
+		clock:        clock,
+		txManager:    txManager,
+	}
+}
+
+type StartTimerInput struct {
+	QuestID     string
+	Type        string // "countdown" | "stopwatch"
+	DurationSec int    // Required for countdowns
+}
+
+// CompleteTimerResult is the outcome of completing a timer
+type CompleteTimerResult struct {
+	Timer      *entity.Timer
+	Completion *entity.QuestCompletion // Set when the timer submitted a quest completion
+}
+
+// StartTimer starts a timer on a quest. A user can have one active timer per quest.
+func (s *TimerService) StartTimer(ctx context.Context, userID int64, input StartTimerInput) (*entity.Timer, error) {
+	var timer *entity.Timer
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		quest, err := s.questRepo.GetByID(ctx, input.QuestID)
+		if err != nil {
+			return err
+		}
+
+		// Timers submit completions, so they are for the quest's dungeon members only
+		if _, _, err := s.questService.role(ctx, userID, quest.DungeonID); err != nil {
+			return err
+		}
+
+		active, err := s.timerRepo.FindActiveByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, existing := range active {
+			if existing.TaskID == quest.ID {
+				return entity.ErrTimerAlreadyActive
+			}
+		}
+
+		now := s.clock.Now()
+		timer, err = entity.NewTimer(s.uuidGen.New(), userID, quest.ID, input.Type, input.DurationSec, quest.TimeZone, now)
+		if err != nil {
+			return err
+		}
+
+		if err := s.timerRepo.Create(ctx, timer); err != nil {
+			return err
+		}
+
+		return s.recordEvent(ctx, timer, entity.TimerEventStart, now, map[string]interface{}{
+			"type":         timer.Type,
+			"duration_sec": timer.Duration,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+// PauseTimer stops the clock of a running timer
+func (s *TimerService) PauseTimer(ctx context.Context, userID int64, timerID string) (*entity.Timer, error) {
+	return s.transition(ctx, userID, timerID, entity.TimerEventPause, (*entity.Timer).Pause)
+}
+
+// ResumeTimer restarts a paused timer
+func (s *TimerService) ResumeTimer(ctx context.Context, userID int64, timerID string) (*entity.Timer, error) {
+	return s.transition(ctx, userID, timerID, entity.TimerEventResume, (*entity.Timer).Resume)
+}
+
+// CancelTimer abandons a timer without submitting anything
+func (s *TimerService) CancelTimer(ctx context.Context, userID int64, timerID string) (*entity.Timer, error) {
+	return s.transition(ctx, userID, timerID, entity.TimerEventCancel, (*entity.Timer).Cancel)
+}
+
+// ExtendTimer gives a countdown extra time, counted from now if it already expired
+func (s *TimerService) ExtendTimer(ctx context.Context, userID int64, timerID string, extra time.Duration) (*entity.Timer, error) {
+	return s.transition(ctx, userID, timerID, entity.TimerEventExtend, func(timer *entity.Timer, now time.Time) error {
+		return timer.Extend(now, extra)
+	})
+}
+
+// CompleteTimer finishes a timer. Completing a stopwatch on a PER_MINUTE quest
+// submits a quest completion for the whole minutes measured, in the same
+// transaction; the timer ID doubles as the idempotency key.
+func (s *TimerService) CompleteTimer(ctx context.Context, userID int64, timerID string) (*CompleteTimerResult, error) {
+	return s.complete(ctx, userID, timerID, false)
+}
+
+// CompleteQuestFromTimer finishes a timer and always submits a completion of its
+// quest: the measured minutes for PER_MINUTE quests and a full completion otherwise.
+func (s *TimerService) CompleteQuestFromTimer(ctx context.Context, userID int64, timerID string) (*CompleteTimerResult, error) {
+	return s.complete(ctx, userID, timerID, true)
+}
+
+const (
+	// expiryClaimPrefix starts the notification ID of a timer whose expiry
+	// notification is being sent
+	expiryClaimPrefix = "sending:"
+	// expiryFailed is the notification ID of a timer whose expiry
+	// notification was given up on
+	expiryFailed = "failed"
+	// expiryRetryDelay is how long a claimed expiry waits before it is claimed
+	// again, after its send failed or its process stopped
+	expiryRetryDelay = time.Minute
+	// maxExpiryAttempts is how often an expiry notification is tried before
+	// it is given up, so permanent failures like a blocked bot end
+	maxExpiryAttempts = 5
+)
+
+// DispatchExpired notifies the owners of running countdowns that reached zero
+// and have not been notified yet. Timers are read from the repository on every
+// call, so nothing is lost when the process restarts, and each expiry is
+// claimed before it is sent, so processes dispatching at the same time notify
+// the user once. Failed sends and claims of stopped processes are retried
+// after expiryRetryDelay, up to maxExpiryAttempts times. It returns how many
+// notifications were sent.
+func (s *TimerService) DispatchExpired(ctx context.Context, now time.Time) (int, error) {
+	if s.notifier == nil {
+		return 0, errors.New("timer notifier is not configured")
+	}
+
+	userIDs, err := s.timerRepo.FindUsersWithActiveTimers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, userID := range userIDs {
+		timers, err := s.timerRepo.FindActiveByUser(ctx, userID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, timer := range timers {
+			if !timer.IsExpired(now) || !expiryDue(timer, now) {
+				continue
+			}
+			notified, err := s.notifyExpired(ctx, timer, now)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if notified {
+				sent++
+			}
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// expiryDue reports whether the timer's expiry notification may be claimed,
+// matching the repository's ClaimExpiry
+func expiryDue(timer *entity.Timer, now time.Time) bool {
+	if timer.NotificationClaimedAt == nil {
+		return timer.NotificationID == nil
+	}
+	return !timer.NotificationClaimedAt.After(now.Add(-expiryRetryDelay))
+}
+
+// notifyExpired claims the timer's expiry, sends the notification and then
+// records it. It reports false if another process claimed the expiry first.
+func (s *TimerService) notifyExpired(ctx context.Context, timer *entity.Timer, now time.Time) (bool, error) {
+	quest, err := s.questRepo.GetByID(ctx, timer.TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	claim := expiryClaimPrefix + s.uuidGen.New()
+	attempts, err := s.timerRepo.ClaimExpiry(ctx, timer.ID, claim, now, now.Add(-expiryRetryDelay))
+	if errors.Is(err, ports.ErrTimerNotificationTaken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	notificationID, err := s.notifier.NotifyTimerExpired(ctx, timer, quest)
+	if err != nil {
+		if attempts < maxExpiryAttempts {
+			// Release the claim so a later dispatch tries again
+			if releaseErr := s.timerRepo.SettleExpiry(ctx, timer.ID, claim, nil); releaseErr != nil && !errors.Is(releaseErr, ports.ErrTimerNotificationTaken) {
+				err = errors.Join(err, releaseErr)
+			}
+			return false, err
+		}
+
+		failed := expiryFailed
+		return false, errors.Join(err, s.settleExpiry(ctx, timer, claim, &failed, now, map[string]interface{}{
+			"error":    err.Error(),
+			"attempts": attempts,
+		}))
+	}
+
+	return true, s.settleExpiry(ctx, timer, claim, &notificationID, now, map[string]interface{}{
+		"notification_id": notificationID,
+	})
+}
+
+// settleExpiry records the outcome of a claimed expiry and its expire event
+func (s *TimerService) settleExpiry(ctx context.Context, timer *entity.Timer, claim string, notificationID *string, now time.Time, metadata map[string]interface{}) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		err := s.timerRepo.SettleExpiry(ctx, timer.ID, claim, notificationID)
+		if errors.Is(err, ports.ErrTimerNotificationTaken) {
+			// The user completed, cancelled or extended the timer meanwhile
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.recordEvent(ctx, timer, entity.TimerEventExpire, now, metadata)
+	})
+}
+
+func (s *TimerService) complete(ctx context.Context, userID int64, timerID string, alwaysSubmit bool) (*CompleteTimerResult, error) {
+	result := &CompleteTimerResult{}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		timer, err := s.applyTransition(ctx, userID, timerID, entity.TimerEventComplete, (*entity.Timer).Complete)
+		if err != nil {
+			return err
+		}
+		result.Timer = timer
+
+		if !alwaysSubmit && timer.Type != entity.TimerTypeStopwatch {
+			return nil
+		}
+
+		quest, err := s.questRepo.GetByID(ctx, timer.TaskID)
+		if err != nil {
+			return err
+		}
+
+		input := CompleteQuestInput{IdempotencyKey: "timer:" + timer.ID}
+		switch {
+		case quest.Mode == entity.QuestModePerMinute:
+			minutes := timer.CurrentValue / 60
+			input.Minutes = &minutes
+		case !alwaysSubmit:
+			return nil
+		case quest.Mode == entity.QuestModePartial:
+			full := 1.0
+			input.CompletionRatio = &full
+		}
+
+		result.Completion, err = s.questService.CompleteQuest(ctx, userID, quest.ID, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTimer returns one of the user's timers
+func (s *TimerService) GetTimer(ctx context.Context, userID int64, timerID string) (*entity.Timer, error) {
+	timer, err := s.timerRepo.FindByID(ctx, timerID)
+	if err != nil {
+		return nil, err
+	}
+	if timer.UserID != userID {
+		return nil, ports.ErrTimerNotFound
+	}
+	return timer, nil
+}
+
+// ListActiveTimers returns the user's running and paused timers
+func (s *TimerService) ListActiveTimers(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	return s.timerRepo.FindActiveByUser(ctx, userID)
+}
+
+// ListTimerEvents returns the recorded transitions of one of the user's timers
+func (s *TimerService) ListTimerEvents(ctx context.Context, userID int64, timerID string) ([]*entity.TimerEvent, error) {
+	if _, err := s.GetTimer(ctx, userID, timerID); err != nil {
+		return nil, err
+	}
+	return s.eventRepo.FindByTimer(ctx, timerID)
+}
+
+func (s *TimerService) transition(ctx context.Context, userID int64, timerID, eventType string, apply func(*entity.Timer, time.Time) error) (*entity.Timer, error) {
+	var timer *entity.Timer
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		timer, err = s.applyTransition(ctx, userID, timerID, eventType, apply)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+// applyTransition loads the user's timer, applies the state change, persists it
+// and records the event. It must run inside a transaction.
+func (s *TimerService) applyTransition(ctx context.Context, userID int64, timerID, eventType string, apply func(*entity.Timer, time.Time) error) (*entity.Timer, error) {
+	timer, err := s.GetTimer(ctx, userID, timerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	if err := apply(timer, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.timerRepo.Update(ctx, timer); err != nil {
+		return nil, err
+	}
+
+	err = s.recordEvent(ctx, timer, eventType, now, map[string]interface{}{
+		"elapsed_sec": timer.CurrentValue,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+func (s *TimerService) recordEvent(ctx context.Context, timer *entity.Timer, eventType string, now time.Time, metadata map[string]interface{}) error {
+	return s.eventRepo.Create(ctx, &entity.TimerEvent{
+		ID:        s.uuidGen.New(),
+		TimerID:   timer.ID,
+		EventType: eventType,
+		Timestamp: now,
+		Metadata:  metadata,
+	})
+}
@@ -0,0 +1,355 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/inmemory"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+	"github.com/supercakecrumb/adhd-game-bot/internal/usecase"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time                         { return c.now }
+func (c *fakeClock) Sleep(d time.Duration)                  { c.now = c.now.Add(d) }
+func (c *fakeClock) After(d time.Duration) <-chan time.Time { c.Sleep(d); return time.After(0) }
+func (c *fakeClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+type timerFixture struct {
+	service  *usecase.TimerService
+	clock    *fakeClock
+	store    *storage.Storage
+	notifier *inmemory.TimerNotifier
+}
+
+// newTimerFixture stores quest in a dungeon owned by user 1, with user 2 a member
+func newTimerFixture(t *testing.T, quest *entity.Quest) *timerFixture {
+	store := newTestStore()
+	require.NoError(t, store.Quests.Create(context.Background(), quest))
+	createUsers(t, store, 1, 2)
+	createDungeon(t, store, &entity.Dungeon{ID: quest.DungeonID, Title: "Test", AdminUserID: 1}, map[int64]entity.DungeonRole{
+		1: entity.DungeonRoleOwner,
+		2: entity.DungeonRoleMember,
+	})
+
+	questService := usecase.NewQuestService(store.Quests, store.Completions, store.Streaks, store.Users, store.Dungeons,
+		store.DungeonMembers, newTestLedger(store), store.UUIDGen, store.Scheduler, store.Idempotency, store.TxManager)
+
+	f := &timerFixture{
+		clock:    &fakeClock{now: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)},
+		store:    store,
+		notifier: inmemory.NewTimerNotifier(),
+	}
+	f.service = usecase.NewTimerService(store.Timers, store.TimerEvents, store.Quests, questService, f.notifier, store.UUIDGen, f.clock, store.TxManager)
+	return f
+}
+
+func TestTimerService_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 1500})
+	require.NoError(t, err)
+	assert.Equal(t, entity.TimerStatusRunning, timer.Status)
+
+	_, err = f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeStopwatch})
+	assert.ErrorIs(t, err, entity.ErrTimerAlreadyActive)
+
+	f.clock.Sleep(10 * time.Minute)
+	timer, err = f.service.PauseTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 600, timer.CurrentValue)
+
+	f.clock.Sleep(time.Hour)
+	_, err = f.service.PauseTimer(ctx, 1, timer.ID)
+	assert.ErrorIs(t, err, entity.ErrInvalidTimerTransition)
+
+	_, err = f.service.ResumeTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+
+	f.clock.Sleep(5 * time.Minute)
+	result, err := f.service.CompleteTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.TimerStatusCompleted, result.Timer.Status)
+	assert.Equal(t, 900, result.Timer.CurrentValue)
+	assert.Nil(t, result.Completion, "countdowns do not submit completions")
+
+	events, err := f.service.ListTimerEvents(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{"start", "pause", "resume", "complete"}, types)
+
+	active, err := f.service.ListActiveTimers(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+}
+
+func TestTimerService_Cancel(t *testing.T) {
+	ctx := context.Background()
+	f := newTimerFixture(t, &entity.Quest{ID: "quest-1", Mode: entity.QuestModePerMinute})
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeStopwatch})
+	require.NoError(t, err)
+
+	f.clock.Sleep(30 * time.Minute)
+	timer, err = f.service.CancelTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.TimerStatusCancelled, timer.Status)
+	_, err = f.store.Completions.LastForUser(ctx, 1, "quest-1")
+	assert.ErrorIs(t, err, ports.ErrQuestCompletionNotFound)
+
+	_, err = f.service.CompleteTimer(ctx, 1, timer.ID)
+	assert.ErrorIs(t, err, entity.ErrInvalidTimerTransition)
+}
+
+func TestTimerService_StopwatchSubmitsPerMinuteCompletion(t *testing.T) {
+	ctx := context.Background()
+	rate := valueobject.NewDecimal("2")
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModePerMinute, RatePointsPerMin: &rate}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeStopwatch})
+	require.NoError(t, err)
+
+	f.clock.Sleep(25*time.Minute + 40*time.Second)
+	result, err := f.service.CompleteTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, result.Completion)
+	require.NotNil(t, result.Completion.Minutes)
+	assert.Equal(t, 25, *result.Completion.Minutes)
+	assert.Equal(t, "50", result.Completion.AwardedPoints.String())
+	assert.Equal(t, "timer:"+timer.ID, result.Completion.IdempotencyKey)
+
+	user, err := f.store.Users.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "50", user.Balance.String())
+}
+
+func TestTimerService_OtherUsersTimers(t *testing.T) {
+	ctx := context.Background()
+	f := newTimerFixture(t, &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary})
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeStopwatch})
+	require.NoError(t, err)
+
+	_, err = f.service.PauseTimer(ctx, 2, timer.ID)
+	assert.ErrorIs(t, err, ports.ErrTimerNotFound)
+
+	_, err = f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "missing", Type: entity.TimerTypeStopwatch})
+	assert.Error(t, err)
+
+	_, err = f.service.StartTimer(ctx, 2, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown})
+	assert.ErrorIs(t, err, entity.ErrInvalidTimerDuration)
+
+	_, err = f.service.StartTimer(ctx, 3, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeStopwatch})
+	assert.ErrorIs(t, err, ports.ErrForbidden, "user 3 is not in the quest's dungeon")
+}
+
+func TestTimerService_DispatchExpired(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 300})
+	require.NoError(t, err)
+
+	sent, err := f.service.DispatchExpired(ctx, f.clock.Now().Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	f.clock.Sleep(5 * time.Minute)
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	stored, err := f.service.GetTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.NotificationID)
+	assert.Equal(t, f.notifier.Sent()[0].ID, *stored.NotificationID)
+
+	// Already notified timers are skipped
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// Extending re-arms the notification
+	_, err = f.service.ExtendTimer(ctx, 1, timer.ID, 5*time.Minute)
+	require.NoError(t, err)
+	f.clock.Sleep(5 * time.Minute)
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, f.notifier.Sent(), 2)
+
+	events, err := f.service.ListTimerEvents(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{entity.TimerEventStart, entity.TimerEventExpire, entity.TimerEventExtend, entity.TimerEventExpire}, types)
+}
+
+func TestTimerService_DispatchExpiredAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	_, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+
+	// A fresh service over the same storage picks up the timer
+	notifier := inmemory.NewTimerNotifier()
+	restarted := usecase.NewTimerService(f.store.Timers, f.store.TimerEvents, f.store.Quests, nil, notifier, &sequenceUUIDGen{n: 100}, f.clock, f.store.TxManager)
+
+	f.clock.Sleep(2 * time.Minute)
+	sent, err := restarted.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, notifier.Sent(), 1)
+	assert.Equal(t, int64(1), notifier.Sent()[0].UserID)
+}
+
+// racingTimerRepo lets another process claim every expiry right after the
+// dispatcher read the timers
+type racingTimerRepo struct {
+	ports.TimerRepository
+	clock *fakeClock
+}
+
+func (r racingTimerRepo) FindActiveByUser(ctx context.Context, userID int64) ([]*entity.Timer, error) {
+	timers, err := r.TimerRepository.FindActiveByUser(ctx, userID)
+	for _, timer := range timers {
+		_, _ = r.ClaimExpiry(ctx, timer.ID, "sending:elsewhere", r.clock.Now(), r.clock.Now().Add(-time.Minute))
+	}
+	return timers, err
+}
+
+func TestTimerService_DispatchExpiredClaimedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	_, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+
+	racing := usecase.NewTimerService(racingTimerRepo{f.store.Timers, f.clock}, f.store.TimerEvents, f.store.Quests, nil, f.notifier, &sequenceUUIDGen{n: 100}, f.clock, f.store.TxManager)
+
+	f.clock.Sleep(2 * time.Minute)
+	sent, err := racing.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, f.notifier.Sent(), "the process that claimed the expiry sends it")
+}
+
+func TestTimerService_DispatchExpiredNotifierFailure(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+
+	f.notifier.Err = fmt.Errorf("telegram unavailable")
+	f.clock.Sleep(2 * time.Minute)
+	sent, err := f.service.DispatchExpired(ctx, f.clock.Now())
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+
+	// The timer stays pending and is retried once the retry delay passed
+	f.notifier.Err = nil
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	f.clock.Sleep(time.Minute)
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	stored, err := f.service.GetTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.NotificationID)
+}
+
+func TestTimerService_DispatchExpiredReclaimsStoppedClaims(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+
+	// A process claimed the expiry and stopped before sending it
+	f.clock.Sleep(2 * time.Minute)
+	_, err = f.store.Timers.ClaimExpiry(ctx, timer.ID, "sending:stopped", f.clock.Now(), f.clock.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	sent, err := f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent, "the claim is still fresh")
+
+	f.clock.Sleep(time.Minute)
+	sent, err = f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, f.notifier.Sent(), 1)
+}
+
+func TestTimerService_DispatchExpiredGivesUp(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+
+	// The user blocked the bot, so every send fails
+	f.notifier.Err = fmt.Errorf("bot was blocked by the user")
+	f.clock.Sleep(2 * time.Minute)
+	for i := 0; i < 5; i++ {
+		_, err := f.service.DispatchExpired(ctx, f.clock.Now())
+		assert.Error(t, err)
+		f.clock.Sleep(time.Minute)
+	}
+
+	sent, err := f.service.DispatchExpired(ctx, f.clock.Now())
+	require.NoError(t, err, "the expiry was given up")
+	assert.Equal(t, 0, sent)
+
+	events, err := f.service.ListTimerEvents(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, entity.TimerEventExpire, events[1].EventType)
+	assert.Equal(t, "bot was blocked by the user", events[1].Metadata["error"])
+}
+
+func TestTimerService_CompleteQuestFromCountdown(t *testing.T) {
+	ctx := context.Background()
+	quest := &entity.Quest{ID: "quest-1", Mode: entity.QuestModeBinary, PointsAward: valueobject.NewDecimal("5")}
+	f := newTimerFixture(t, quest)
+
+	timer, err := f.service.StartTimer(ctx, 1, usecase.StartTimerInput{QuestID: "quest-1", Type: entity.TimerTypeCountdown, DurationSec: 60})
+	require.NoError(t, err)
+	f.clock.Sleep(time.Minute)
+
+	result, err := f.service.CompleteQuestFromTimer(ctx, 1, timer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.TimerStatusCompleted, result.Timer.Status)
+	require.NotNil(t, result.Completion)
+	assert.Equal(t, "5", result.Completion.AwardedPoints.String())
+}
@@ -25,19 +25,27 @@ func TestCompleteShopWorkflow(t *testing.T) {
 	chatConfigRepo := inmemory.NewChatConfigRepository()
 	discountTierRepo := inmemory.NewDiscountTierRepository()
 	idempotencyRepo := inmemory.NewInMemoryIdempotencyRepository()
+	walletRepo := inmemory.NewWalletRepository()
 	txManager := inmemory.NewTxManager()
 	uuidGen := &mockUUIDGenerator{counter: 0}
+	dungeonID := "dungeon-100"
 
 	// Create services
-	shopService := usecase.NewShopServiceV2(
+	dungeonRepo, memberRepo := newDungeonRepos(t, map[string][]int64{dungeonID: {1, 2}})
+
+	shopService := usecase.NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		usecase.NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, uuidGen, txManager),
 		chatConfigRepo,
-		discountTierRepo,
+		usecase.NewPricingPipeline(usecase.DiscountTierRule(discountTierRepo)),
+		nil, // loyalty
 		uuidGen,
 		txManager,
 		idempotencyRepo,
+		dungeonRepo,
+		memberRepo,
 	)
 
 	// Step 1: Setup chat configuration
@@ -67,6 +75,8 @@ func TestCompleteShopWorkflow(t *testing.T) {
 		}
 		err := userRepo.Create(ctx, user1)
 		require.NoError(t, err)
+		_, err = walletRepo.UpdateBalance(ctx, user1.ID, dungeonID, user1.Balance)
+		require.NoError(t, err)
 
 		user2 = &entity.User{
 			ID:      2,
@@ -75,6 +85,8 @@ func TestCompleteShopWorkflow(t *testing.T) {
 		}
 		err = userRepo.Create(ctx, user2)
 		require.NoError(t, err)
+		_, err = walletRepo.UpdateBalance(ctx, user2.ID, dungeonID, user2.Balance)
+		require.NoError(t, err)
 	})
 
 	// Step 3: Create shop items
@@ -82,39 +94,39 @@ func TestCompleteShopWorkflow(t *testing.T) {
 		// Create a limited stock item
 		stock := 10
 		item1 := &entity.ShopItem{
-			ID:       1,
-			ChatID:   100,
-			Code:     "SWORD",
-			Name:     "Iron Sword",
-			Price:    valueobject.NewDecimal("150"),
-			IsActive: true,
-			Stock:    &stock,
+			ID:        1,
+			DungeonID: &dungeonID,
+			Code:      "SWORD",
+			Name:      "Iron Sword",
+			Price:     valueobject.NewDecimal("150"),
+			IsActive:  true,
+			Stock:     &stock,
 		}
 		err := shopItemRepo.Create(ctx, item1)
 		require.NoError(t, err)
 
 		// Create an unlimited stock item
 		item2 := &entity.ShopItem{
-			ID:       2,
-			ChatID:   100,
-			Code:     "POTION",
-			Name:     "Health Potion",
-			Price:    valueobject.NewDecimal("50"),
-			IsActive: true,
-			Stock:    nil,
+			ID:        2,
+			DungeonID: &dungeonID,
+			Code:      "POTION",
+			Name:      "Health Potion",
+			Price:     valueobject.NewDecimal("50"),
+			IsActive:  true,
+			Stock:     nil,
 		}
 		err = shopItemRepo.Create(ctx, item2)
 		require.NoError(t, err)
 
-		// Create a global item (available to all chats)
+		// Create another unlimited stock item
 		item3 := &entity.ShopItem{
-			ID:       3,
-			ChatID:   0, // Global item
-			Code:     "BOOST",
-			Name:     "XP Boost",
-			Price:    valueobject.NewDecimal("200"),
-			IsActive: true,
-			Stock:    nil,
+			ID:        3,
+			DungeonID: &dungeonID,
+			Code:      "BOOST",
+			Name:      "XP Boost",
+			Price:     valueobject.NewDecimal("200"),
+			IsActive:  true,
+			Stock:     nil,
 		}
 		err = shopItemRepo.Create(ctx, item3)
 		require.NoError(t, err)
@@ -122,21 +134,21 @@ func TestCompleteShopWorkflow(t *testing.T) {
 
 	// Step 4: List available items
 	t.Run("List available items", func(t *testing.T) {
-		items, err := shopService.GetShopItems(ctx, 100)
+		items, err := shopService.GetShopItems(ctx, dungeonID)
 		require.NoError(t, err)
-		assert.Len(t, items, 3) // 2 chat-specific + 1 global
+		assert.Len(t, items, 3)
 	})
 
 	// Step 5: User 1 makes purchases
 	t.Run("User 1 purchases items", func(t *testing.T) {
 		// Purchase 2 swords
-		purchase1, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "SWORD", 2, "")
+		purchase1, err := shopService.PurchaseItem(ctx, 1, dungeonID, "SWORD", 2, "")
 		require.NoError(t, err)
 		assert.Equal(t, 2, purchase1.Quantity)
 		assert.Equal(t, "300", purchase1.TotalCost.String())
 
 		// Purchase 5 potions
-		purchase2, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "POTION", 5, "")
+		purchase2, err := shopService.PurchaseItem(ctx, 1, dungeonID, "POTION", 5, "")
 		require.NoError(t, err)
 		assert.Equal(t, 5, purchase2.Quantity)
 		assert.Equal(t, "250", purchase2.TotalCost.String())
@@ -147,7 +159,7 @@ func TestCompleteShopWorkflow(t *testing.T) {
 		assert.Equal(t, "450", updatedUser.Balance.String()) // 1000 - 300 - 250
 
 		// Check updated stock
-		item, err := shopItemRepo.FindByCode(ctx, 100, "SWORD")
+		item, err := shopItemRepo.FindByCode(ctx, dungeonID, "SWORD")
 		require.NoError(t, err)
 		assert.Equal(t, 8, *item.Stock) // 10 - 2
 	})
@@ -155,7 +167,7 @@ func TestCompleteShopWorkflow(t *testing.T) {
 	// Step 6: User 2 tries to purchase with insufficient funds
 	t.Run("User 2 insufficient funds", func(t *testing.T) {
 		// Try to purchase 4 swords (600 cost, but only has 500)
-		_, err := shopService.PurchaseItemWithIdempotency(ctx, 2, "SWORD", 4, "")
+		_, err := shopService.PurchaseItem(ctx, 2, dungeonID, "SWORD", 4, "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "insufficient balance")
 
@@ -165,9 +177,9 @@ func TestCompleteShopWorkflow(t *testing.T) {
 		assert.Equal(t, "500", user.Balance.String())
 	})
 
-	// Step 7: Purchase global item
-	t.Run("Purchase global item", func(t *testing.T) {
-		purchase, err := shopService.PurchaseItemWithIdempotency(ctx, 2, "BOOST", 1, "")
+	// Step 7: User 2 buys something affordable
+	t.Run("User 2 purchases item", func(t *testing.T) {
+		purchase, err := shopService.PurchaseItem(ctx, 2, dungeonID, "BOOST", 1, "")
 		require.NoError(t, err)
 		assert.Equal(t, "XP Boost", purchase.ItemName)
 		assert.Equal(t, "200", purchase.TotalCost.String())
@@ -194,7 +206,7 @@ func TestCompleteShopWorkflow(t *testing.T) {
 	// Step 9: Out of stock scenario
 	t.Run("Out of stock scenario", func(t *testing.T) {
 		// Try to purchase more swords than available (8 remaining)
-		_, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "SWORD", 10, "")
+		_, err := shopService.PurchaseItem(ctx, 1, dungeonID, "SWORD", 10, "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "insufficient stock")
 	})
@@ -202,19 +214,19 @@ func TestCompleteShopWorkflow(t *testing.T) {
 	// Step 10: Deactivate item
 	t.Run("Deactivate item", func(t *testing.T) {
 		// Deactivate the sword
-		item, err := shopItemRepo.FindByCode(ctx, 100, "SWORD")
+		item, err := shopItemRepo.FindByCode(ctx, dungeonID, "SWORD")
 		require.NoError(t, err)
 		item.IsActive = false
 		err = shopItemRepo.Update(ctx, item)
 		require.NoError(t, err)
 
 		// Try to purchase deactivated item
-		_, err = shopService.PurchaseItemWithIdempotency(ctx, 1, "SWORD", 1, "")
+		_, err = shopService.PurchaseItem(ctx, 1, dungeonID, "SWORD", 1, "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "not available")
 
 		// Verify it doesn't appear in the shop list
-		items, err := shopItemRepo.FindByChatID(ctx, 100)
+		items, err := shopService.GetShopItems(ctx, dungeonID)
 		require.NoError(t, err)
 		assert.Len(t, items, 2) // Only potion and boost remain active
 	})
@@ -232,18 +244,26 @@ func TestConcurrentPurchases(t *testing.T) {
 	purchaseRepo := inmemory.NewPurchaseRepository()
 	discountTierRepo := inmemory.NewDiscountTierRepository()
 	idempotencyRepo := inmemory.NewInMemoryIdempotencyRepository()
+	walletRepo := inmemory.NewWalletRepository()
 	txManager := inmemory.NewTxManager()
 	uuidGen := &mockUUIDGenerator{counter: 0}
+	dungeonID := "dungeon-100"
+
+	dungeonRepo, memberRepo := newDungeonRepos(t, map[string][]int64{dungeonID: {1, 2, 3, 4, 5}})
 
-	shopService := usecase.NewShopServiceV2(
+	shopService := usecase.NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		usecase.NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, uuidGen, txManager),
 		chatConfigRepo,
-		discountTierRepo,
+		usecase.NewPricingPipeline(usecase.DiscountTierRule(discountTierRepo)),
+		nil, // loyalty
 		uuidGen,
 		txManager,
 		idempotencyRepo,
+		dungeonRepo,
+		memberRepo,
 	)
 
 	// Create users
@@ -255,18 +275,20 @@ func TestConcurrentPurchases(t *testing.T) {
 		}
 		err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
+		_, err = walletRepo.UpdateBalance(ctx, user.ID, dungeonID, user.Balance)
+		require.NoError(t, err)
 	}
 
 	// Create limited stock item
 	stock := 10
 	item := &entity.ShopItem{
-		ID:       1,
-		ChatID:   100,
-		Code:     "LIMITED",
-		Name:     "Limited Edition Item",
-		Price:    valueobject.NewDecimal("100"),
-		IsActive: true,
-		Stock:    &stock,
+		ID:        1,
+		DungeonID: &dungeonID,
+		Code:      "LIMITED",
+		Name:      "Limited Edition Item",
+		Price:     valueobject.NewDecimal("100"),
+		IsActive:  true,
+		Stock:     &stock,
 	}
 	err := shopItemRepo.Create(ctx, item)
 	require.NoError(t, err)
@@ -282,7 +304,7 @@ func TestConcurrentPurchases(t *testing.T) {
 	// Launch 5 concurrent purchases
 	for i := 1; i <= 5; i++ {
 		go func(userID int64) {
-			_, err := shopService.PurchaseItemWithIdempotency(ctx, userID, "LIMITED", 3, "")
+			_, err := shopService.PurchaseItem(ctx, userID, dungeonID, "LIMITED", 3, "")
 			results <- result{userID: userID, err: err}
 		}(int64(i))
 	}
@@ -307,15 +329,15 @@ func TestConcurrentPurchases(t *testing.T) {
 	assert.LessOrEqual(t, totalPurchased, 10, "Total purchased should not exceed initial stock")
 
 	// Verify final stock is non-negative
-	finalItem, err := shopItemRepo.FindByCode(ctx, 100, "LIMITED")
+	finalItem, err := shopItemRepo.FindByCode(ctx, dungeonID, "LIMITED")
 	require.NoError(t, err)
 	if finalItem.Stock != nil {
 		assert.GreaterOrEqual(t, *finalItem.Stock, 0, "Stock should not be negative")
 	}
 }
 
-// TestMultiChatWorkflow tests workflows across multiple chats
-func TestMultiChatWorkflow(t *testing.T) {
+// TestMultiDungeonWorkflow tests workflows across multiple dungeons
+func TestMultiDungeonWorkflow(t *testing.T) {
 	ctx := context.Background()
 
 	// Setup infrastructure
@@ -325,18 +347,29 @@ func TestMultiChatWorkflow(t *testing.T) {
 	purchaseRepo := inmemory.NewPurchaseRepository()
 	discountTierRepo := inmemory.NewDiscountTierRepository()
 	idempotencyRepo := inmemory.NewInMemoryIdempotencyRepository()
+	walletRepo := inmemory.NewWalletRepository()
 	txManager := inmemory.NewTxManager()
 	uuidGen := &mockUUIDGenerator{counter: 0}
 
-	shopService := usecase.NewShopServiceV2(
+	dungeonRepo, memberRepo := newDungeonRepos(t, map[string][]int64{
+		"dungeon-100": {1, 2},
+		"dungeon-200": {3},
+		"dungeon-300": {4},
+	})
+
+	shopService := usecase.NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		usecase.NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, uuidGen, txManager),
 		chatConfigRepo,
-		discountTierRepo,
+		usecase.NewPricingPipeline(usecase.DiscountTierRule(discountTierRepo)),
+		nil, // loyalty
 		uuidGen,
 		txManager,
 		idempotencyRepo,
+		dungeonRepo,
+		memberRepo,
 	)
 
 	// Setup different currencies for different chats
@@ -383,83 +416,89 @@ func TestMultiChatWorkflow(t *testing.T) {
 			}
 			err := userRepo.Create(ctx, user)
 			require.NoError(t, err)
+			_, err = walletRepo.UpdateBalance(ctx, user.ID, fmt.Sprintf("dungeon-%d", u.chatID), user.Balance)
+			require.NoError(t, err)
 		}
 	})
 
-	// Create chat-specific and global items
-	t.Run("Create items for different chats", func(t *testing.T) {
-		// Chat 100 item
-		item1 := &entity.ShopItem{
-			ID:       1,
-			ChatID:   100,
-			Code:     "GOLD_SWORD",
-			Name:     "Golden Sword",
-			Price:    valueobject.NewDecimal("500"),
-			IsActive: true,
+	// Each dungeon runs its own shop; codes only need to be unique per dungeon
+	dungeonOf := map[int64]string{1: "dungeon-100", 2: "dungeon-100", 3: "dungeon-200", 4: "dungeon-300"}
+	t.Run("Create items for different dungeons", func(t *testing.T) {
+		items := []struct {
+			id        int64
+			dungeonID string
+			code      string
+			name      string
+			price     string
+		}{
+			{1, "dungeon-100", "GOLD_SWORD", "Golden Sword", "500"},
+			{2, "dungeon-200", "CREDIT_BOOST", "Credit Booster", "300"},
+			{3, "dungeon-100", "UNIVERSAL", "Universal Token", "100"},
+			{4, "dungeon-200", "UNIVERSAL", "Universal Token", "100"},
+			{5, "dungeon-300", "UNIVERSAL", "Universal Token", "100"},
 		}
-		err := shopItemRepo.Create(ctx, item1)
-		require.NoError(t, err)
 
-		// Chat 200 item
-		item2 := &entity.ShopItem{
-			ID:       2,
-			ChatID:   200,
-			Code:     "CREDIT_BOOST",
-			Name:     "Credit Booster",
-			Price:    valueobject.NewDecimal("300"),
-			IsActive: true,
+		for _, item := range items {
+			dungeonID := item.dungeonID
+			err := shopItemRepo.Create(ctx, &entity.ShopItem{
+				ID:        item.id,
+				DungeonID: &dungeonID,
+				Code:      item.code,
+				Name:      item.name,
+				Price:     valueobject.NewDecimal(item.price),
+				IsActive:  true,
+			})
+			require.NoError(t, err)
 		}
-		err = shopItemRepo.Create(ctx, item2)
-		require.NoError(t, err)
 
-		// Global item
-		item3 := &entity.ShopItem{
-			ID:       3,
-			ChatID:   0,
-			Code:     "UNIVERSAL",
-			Name:     "Universal Token",
-			Price:    valueobject.NewDecimal("100"),
-			IsActive: true,
-		}
-		err = shopItemRepo.Create(ctx, item3)
-		require.NoError(t, err)
+		dungeonID := "dungeon-100"
+		err := shopItemRepo.Create(ctx, &entity.ShopItem{
+			DungeonID: &dungeonID,
+			Code:      "UNIVERSAL",
+			Name:      "Duplicate Token",
+			Price:     valueobject.NewDecimal("1"),
+			IsActive:  true,
+		})
+		assert.ErrorIs(t, err, ports.ErrShopItemCodeExists)
 	})
 
-	// Test chat isolation
-	t.Run("Test chat isolation", func(t *testing.T) {
-		// User from chat 100 can see their item + global
-		items100, err := shopService.GetShopItems(ctx, 100)
+	// Test dungeon isolation
+	t.Run("Test dungeon isolation", func(t *testing.T) {
+		items100, err := shopService.GetShopItems(ctx, "dungeon-100")
 		require.NoError(t, err)
 		assert.Len(t, items100, 2)
 
-		// User from chat 200 can see their item + global
-		items200, err := shopService.GetShopItems(ctx, 200)
+		items200, err := shopService.GetShopItems(ctx, "dungeon-200")
 		require.NoError(t, err)
 		assert.Len(t, items200, 2)
 
-		// User from chat 300 can only see global item
-		items300, err := shopService.GetShopItems(ctx, 300)
+		items300, err := shopService.GetShopItems(ctx, "dungeon-300")
 		require.NoError(t, err)
 		assert.Len(t, items300, 1)
 	})
 
-	// Test cross-chat purchase attempts
-	t.Run("Test cross-chat purchase restrictions", func(t *testing.T) {
-		// User 1 (chat 100) tries to buy chat 200's item - should fail
-		_, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "CREDIT_BOOST", 1, "")
+	// Test cross-dungeon purchase attempts
+	t.Run("Test cross-dungeon purchase restrictions", func(t *testing.T) {
+		// Dungeon 100's shop does not sell dungeon 200's item
+		_, err := shopService.PurchaseItem(ctx, 1, "dungeon-100", "CREDIT_BOOST", 1, "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "not found")
 
-		// User 3 (chat 200) tries to buy chat 100's item - should fail
-		_, err = shopService.PurchaseItemWithIdempotency(ctx, 3, "GOLD_SWORD", 1, "")
+		// And the other way around
+		_, err = shopService.PurchaseItem(ctx, 3, "dungeon-200", "GOLD_SWORD", 1, "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "not found")
 
-		// All users can buy global item
+		// Only members can shop in a dungeon
+		_, err = shopService.PurchaseItem(ctx, 1, "dungeon-200", "CREDIT_BOOST", 1, "")
+		assert.ErrorIs(t, err, ports.ErrForbidden)
+
+		// Every dungeon sells its own token
 		for userID := int64(1); userID <= 4; userID++ {
-			purchase, err := shopService.PurchaseItemWithIdempotency(ctx, userID, "UNIVERSAL", 1, "")
+			purchase, err := shopService.PurchaseItem(ctx, userID, dungeonOf[userID], "UNIVERSAL", 1, "")
 			require.NoError(t, err)
 			assert.Equal(t, "Universal Token", purchase.ItemName)
+			assert.Equal(t, dungeonOf[userID], purchase.DungeonID)
 		}
 	})
 }
@@ -475,18 +514,26 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 	purchaseRepo := inmemory.NewPurchaseRepository()
 	discountTierRepo := inmemory.NewDiscountTierRepository()
 	idempotencyRepo := inmemory.NewInMemoryIdempotencyRepository()
+	walletRepo := inmemory.NewWalletRepository()
 	txManager := inmemory.NewTxManager()
 	uuidGen := &mockUUIDGenerator{counter: 0}
+	dungeonID := "dungeon-100"
 
-	shopService := usecase.NewShopServiceV2(
+	dungeonRepo, memberRepo := newDungeonRepos(t, map[string][]int64{dungeonID: {1}})
+
+	shopService := usecase.NewShopService(
 		shopItemRepo,
 		purchaseRepo,
 		userRepo,
+		usecase.NewLedgerService(inmemory.NewLedgerRepository(), walletRepo, userRepo, uuidGen, txManager),
 		chatConfigRepo,
-		discountTierRepo,
+		usecase.NewPricingPipeline(usecase.DiscountTierRule(discountTierRepo)),
+		nil, // loyalty
 		uuidGen,
 		txManager,
 		idempotencyRepo,
+		dungeonRepo,
+		memberRepo,
 	)
 
 	// Create user with precise balance
@@ -497,6 +544,8 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 	}
 	err := userRepo.Create(ctx, user)
 	require.NoError(t, err)
+	_, err = walletRepo.UpdateBalance(ctx, user.ID, dungeonID, user.Balance)
+	require.NoError(t, err)
 
 	// Create items with precise prices
 	items := []struct {
@@ -511,12 +560,12 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 
 	for i, item := range items {
 		shopItem := &entity.ShopItem{
-			ID:       int64(i + 1),
-			ChatID:   100,
-			Code:     item.code,
-			Name:     item.name,
-			Price:    valueobject.NewDecimal(item.price),
-			IsActive: true,
+			ID:        int64(i + 1),
+			DungeonID: &dungeonID,
+			Code:      item.code,
+			Name:      item.name,
+			Price:     valueobject.NewDecimal(item.price),
+			IsActive:  true,
 		}
 		err := shopItemRepo.Create(ctx, shopItem)
 		require.NoError(t, err)
@@ -525,17 +574,17 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 	// Make purchases and verify precision
 	t.Run("Multiple precise purchases", func(t *testing.T) {
 		// Purchase 1: Buy 3 of item 1
-		purchase1, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "ITEM1", 3, "")
+		purchase1, err := shopService.PurchaseItem(ctx, 1, dungeonID, "ITEM1", 3, "")
 		require.NoError(t, err)
 		assert.Equal(t, "370.3701", purchase1.TotalCost.String()) // 123.4567 * 3
 
 		// Purchase 2: Buy 1000 of item 2
-		purchase2, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "ITEM2", 1000, "")
+		purchase2, err := shopService.PurchaseItem(ctx, 1, dungeonID, "ITEM2", 1000, "")
 		require.NoError(t, err)
 		assert.Equal(t, "0.1", purchase2.TotalCost.String()) // 0.0001 * 1000
 
 		// Purchase 3: Buy 2 of item 3
-		purchase3, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "ITEM3", 2, "")
+		purchase3, err := shopService.PurchaseItem(ctx, 1, dungeonID, "ITEM3", 2, "")
 		require.NoError(t, err)
 		assert.Equal(t, "199.9998", purchase3.TotalCost.String()) // 99.9999 * 2
 
@@ -550,18 +599,18 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 	t.Run("Exact balance purchase", func(t *testing.T) {
 		// Create item with exact remaining balance price
 		exactItem := &entity.ShopItem{
-			ID:       10,
-			ChatID:   100,
-			Code:     "EXACT",
-			Name:     "Exact Balance Item",
-			Price:    valueobject.NewDecimal("430.0979"),
-			IsActive: true,
+			ID:        10,
+			DungeonID: &dungeonID,
+			Code:      "EXACT",
+			Name:      "Exact Balance Item",
+			Price:     valueobject.NewDecimal("430.0979"),
+			IsActive:  true,
 		}
 		err := shopItemRepo.Create(ctx, exactItem)
 		require.NoError(t, err)
 
 		// Purchase with exact balance
-		purchase, err := shopService.PurchaseItemWithIdempotency(ctx, 1, "EXACT", 1, "")
+		purchase, err := shopService.PurchaseItem(ctx, 1, dungeonID, "EXACT", 1, "")
 		require.NoError(t, err)
 		assert.Equal(t, "430.0979", purchase.TotalCost.String())
 
@@ -572,6 +621,21 @@ func TestDecimalPrecisionWorkflow(t *testing.T) {
 	})
 }
 
+// newDungeonRepos returns dungeon repositories holding the given dungeons. The
+// first user listed for a dungeon owns it and the rest are members.
+func newDungeonRepos(t *testing.T, dungeons map[string][]int64) (*inmemory.DungeonRepository, *inmemory.DungeonMemberRepository) {
+	ctx := context.Background()
+	dungeonRepo := inmemory.NewDungeonRepository()
+	memberRepo := inmemory.NewDungeonMemberRepository()
+	for dungeonID, users := range dungeons {
+		require.NoError(t, dungeonRepo.Create(ctx, &entity.Dungeon{ID: dungeonID, Title: dungeonID, AdminUserID: users[0]}))
+		for _, userID := range users[1:] {
+			require.NoError(t, memberRepo.Add(ctx, dungeonID, userID, entity.DungeonRoleMember))
+		}
+	}
+	return dungeonRepo, memberRepo
+}
+
 // mockUUIDGenerator is a simple UUID generator for testing
 type mockUUIDGenerator struct {
 	counter int
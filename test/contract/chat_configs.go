@@ -0,0 +1,50 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunChatConfigRepository tests a ports.ChatConfigRepository
+func RunChatConfigRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.ChatConfigs.FindByChatID(ctx, 100)
+			assert.ErrorIs(t, err, ports.ErrChatConfigNotFound)
+
+			assert.ErrorIs(t, s.ChatConfigs.Update(ctx, entity.DefaultChatConfig(100)), ports.ErrChatConfigNotFound)
+		}},
+		{"CreateAndUpdate", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			config := newChatConfig(100)
+			require.NoError(t, s.ChatConfigs.Create(ctx, config))
+			assert.Error(t, s.ChatConfigs.Create(ctx, newChatConfig(100)))
+
+			config.CurrencyName = "Stars"
+			config.RefundsAdminOnly = true
+			require.NoError(t, s.ChatConfigs.Update(ctx, config))
+
+			found, err := s.ChatConfigs.FindByChatID(ctx, 100)
+			require.NoError(t, err)
+			assert.Equal(t, "Stars", found.CurrencyName)
+			assert.True(t, found.RefundsAdminOnly)
+			assert.Equal(t, entity.DefaultRefundWindowSec, found.RefundWindowSec)
+		}},
+	})
+}
+
+func newChatConfig(chatID int64) *entity.ChatConfig {
+	config := entity.DefaultChatConfig(chatID)
+	config.CreatedAt = time.Now()
+	config.UpdatedAt = time.Now()
+	return config
+}
@@ -0,0 +1,147 @@
+// Package contract holds the repository tests every storage backend must
+// pass. There is one Run function per ports interface; a backend's test calls
+// them, or Run for all of them, with a function that opens an empty database.
+package contract
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+)
+
+// Opener returns the repositories of a backend on an empty database. It is
+// called once per test and should register cleanup with t.
+type Opener func(t *testing.T) *storage.Storage
+
+// Run runs the whole suite against the backend
+func Run(t *testing.T, open Opener) {
+	suites := []struct {
+		name string
+		run  func(t *testing.T, open Opener)
+	}{
+		{"UserRepository", RunUserRepository},
+		{"LedgerRepository", RunLedgerRepository},
+		{"WalletRepository", RunWalletRepository},
+		{"DungeonRepository", RunDungeonRepository},
+		{"DungeonMemberRepository", RunDungeonMemberRepository},
+		{"DungeonInviteRepository", RunDungeonInviteRepository},
+		{"JoinRequestRepository", RunJoinRequestRepository},
+		{"QuestRepository", RunQuestRepository},
+		{"QuestCompletionRepository", RunQuestCompletionRepository},
+		{"QuestStreakRepository", RunQuestStreakRepository},
+		{"ChatConfigRepository", RunChatConfigRepository},
+		{"ShopItemRepository", RunShopItemRepository},
+		{"PurchaseRepository", RunPurchaseRepository},
+		{"MemberPriceRepository", RunMemberPriceRepository},
+		{"DiscountTierRepository", RunDiscountTierRepository},
+		{"RewardTierRepository", RunRewardTierRepository},
+		{"LoyaltyStatusRepository", RunLoyaltyStatusRepository},
+		{"TimerRepository", RunTimerRepository},
+		{"TimerEventRepository", RunTimerEventRepository},
+		{"IdempotencyRepository", RunIdempotencyRepository},
+		{"TxManager", RunTxManager},
+		{"Scheduler", RunScheduler},
+	}
+
+	for _, suite := range suites {
+		t.Run(suite.name, func(t *testing.T) {
+			suite.run(t, open)
+		})
+	}
+}
+
+// testCase is one test of a suite, run on a fresh database
+type testCase struct {
+	name string
+	fn   func(t *testing.T, s *storage.Storage)
+}
+
+func runCases(t *testing.T, open Opener, cases []testCase) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, open(t))
+		})
+	}
+}
+
+func newUser(id int64) *entity.User {
+	return &entity.User{
+		ID:        id,
+		ChatID:    100,
+		Username:  fmt.Sprintf("User %d", id),
+		Balance:   valueobject.NewDecimal("10.5"),
+		Role:      entity.UserRoleMember,
+		TimeZone:  "UTC",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func createUser(t *testing.T, s *storage.Storage, id int64) {
+	t.Helper()
+	require.NoError(t, s.Users.Create(context.Background(), newUser(id)))
+}
+
+func createDungeon(t *testing.T, s *storage.Storage, adminID, chatID int64) *entity.Dungeon {
+	t.Helper()
+	dungeon := &entity.Dungeon{
+		ID:             uuid.New().String(),
+		Title:          "Flat chores",
+		AdminUserID:    adminID,
+		TelegramChatID: &chatID,
+		TimeZone:       "UTC",
+		CreatedAt:      time.Now(),
+	}
+	require.NoError(t, s.Dungeons.Create(context.Background(), dungeon))
+	return dungeon
+}
+
+func newQuest(dungeonID, title string) *entity.Quest {
+	return &entity.Quest{
+		ID:          uuid.New().String(),
+		DungeonID:   dungeonID,
+		Title:       title,
+		Category:    "daily",
+		Difficulty:  "easy",
+		Mode:        "BINARY",
+		PointsAward: valueobject.NewDecimal("5"),
+		Status:      "active",
+		TimeZone:    "Europe/Berlin",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func createQuest(t *testing.T, s *storage.Storage, dungeonID string) *entity.Quest {
+	t.Helper()
+	quest := newQuest(dungeonID, "Wash the dishes")
+	require.NoError(t, s.Quests.Create(context.Background(), quest))
+	return quest
+}
+
+func newShopItem(dungeonID, code string) *entity.ShopItem {
+	return &entity.ShopItem{
+		DungeonID: &dungeonID,
+		Code:      code,
+		Name:      "Item " + code,
+		Price:     valueobject.NewDecimal("12.5"),
+		Category:  "rewards",
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func createShopItem(t *testing.T, s *storage.Storage, dungeonID, code string) *entity.ShopItem {
+	t.Helper()
+	item := newShopItem(dungeonID, code)
+	require.NoError(t, s.ShopItems.Create(context.Background(), item))
+	return item
+}
@@ -0,0 +1,258 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunDungeonRepository tests a ports.DungeonRepository
+func RunDungeonRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			found, err := s.Dungeons.GetByID(ctx, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, dungeon.Title, found.Title)
+			assert.Equal(t, int64(1), found.AdminUserID)
+			require.NotNil(t, found.TelegramChatID)
+			assert.Equal(t, int64(-100), *found.TelegramChatID)
+			assert.Equal(t, "UTC", found.TimeZone)
+
+			found, err = s.Dungeons.FindByTelegramChatID(ctx, -100)
+			require.NoError(t, err)
+			assert.Equal(t, dungeon.ID, found.ID)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Dungeons.GetByID(ctx, uuid.New().String())
+			assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+
+			_, err = s.Dungeons.FindByTelegramChatID(ctx, -100)
+			assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+
+			err = s.Dungeons.Update(ctx, &entity.Dungeon{ID: uuid.New().String(), Title: "Missing", AdminUserID: 1})
+			assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+		}},
+		{"Update", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			chatID := int64(-200)
+			dungeon.Title = "Renamed"
+			dungeon.AdminUserID = 2
+			dungeon.TelegramChatID = &chatID
+			dungeon.TimeZone = "Europe/Berlin"
+			require.NoError(t, s.Dungeons.Update(ctx, dungeon))
+
+			found, err := s.Dungeons.FindByTelegramChatID(ctx, -200)
+			require.NoError(t, err)
+			assert.Equal(t, "Renamed", found.Title)
+			assert.Equal(t, int64(2), found.AdminUserID)
+			assert.Equal(t, "Europe/Berlin", found.TimeZone)
+
+			_, err = s.Dungeons.FindByTelegramChatID(ctx, -100)
+			assert.ErrorIs(t, err, ports.ErrDungeonNotFound)
+		}},
+		{"ChatLinkedOnce", func(t *testing.T, s *storage.Storage) {
+			createUser(t, s, 1)
+			createDungeon(t, s, 1, -100)
+
+			chatID := int64(-100)
+			err := s.Dungeons.Create(context.Background(), &entity.Dungeon{
+				ID:             uuid.New().String(),
+				Title:          "Second",
+				AdminUserID:    1,
+				TelegramChatID: &chatID,
+				CreatedAt:      time.Now(),
+			})
+			assert.Error(t, err)
+		}},
+		{"ListByAdminIsOldestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+
+			base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			var want []string
+			for i, title := range []string{"Flat", "Office", "Garden"} {
+				dungeon := &entity.Dungeon{
+					ID:          uuid.New().String(),
+					Title:       title,
+					AdminUserID: 1,
+					CreatedAt:   base.Add(time.Duration(i) * time.Hour),
+				}
+				require.NoError(t, s.Dungeons.Create(ctx, dungeon))
+				want = append(want, title)
+			}
+			createDungeon(t, s, 2, -200)
+
+			dungeons, err := s.Dungeons.ListByAdmin(ctx, 1)
+			require.NoError(t, err)
+			var titles []string
+			for _, dungeon := range dungeons {
+				titles = append(titles, dungeon.Title)
+				assert.Nil(t, dungeon.TelegramChatID)
+			}
+			assert.Equal(t, want, titles)
+
+			dungeons, err = s.Dungeons.ListByAdmin(ctx, 3)
+			require.NoError(t, err)
+			assert.Empty(t, dungeons)
+		}},
+	})
+}
+
+// RunDungeonMemberRepository tests a ports.DungeonMemberRepository
+func RunDungeonMemberRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"Membership", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleModerator))
+			// Adding an existing member is a no-op
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleMember))
+
+			member, err := s.DungeonMembers.Get(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.Equal(t, entity.DungeonRoleModerator, member.Role)
+			assert.False(t, member.JoinedAt.IsZero())
+
+			isMember, err := s.DungeonMembers.IsMember(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.True(t, isMember)
+
+			isMember, err = s.DungeonMembers.IsMember(ctx, dungeon.ID, 1)
+			require.NoError(t, err)
+			assert.False(t, isMember)
+
+			members, err := s.DungeonMembers.ListUsers(ctx, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{2}, members)
+		}},
+		{"SetRole", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleMember))
+
+			require.NoError(t, s.DungeonMembers.SetRole(ctx, dungeon.ID, 2, entity.DungeonRoleModerator))
+			member, err := s.DungeonMembers.Get(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.Equal(t, entity.DungeonRoleModerator, member.Role)
+		}},
+		{"MemberNotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			_, err := s.DungeonMembers.Get(ctx, dungeon.ID, 1)
+			assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+
+			err = s.DungeonMembers.SetRole(ctx, dungeon.ID, 1, entity.DungeonRoleModerator)
+			assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+
+			err = s.DungeonMembers.Remove(ctx, dungeon.ID, 1)
+			assert.ErrorIs(t, err, ports.ErrDungeonMemberNotFound)
+		}},
+		{"Remove", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleModerator))
+
+			require.NoError(t, s.DungeonMembers.Remove(ctx, dungeon.ID, 2))
+			isMember, err := s.DungeonMembers.IsMember(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.False(t, isMember)
+
+			// Rejoining starts over as whatever role it is added with
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleMember))
+			member, err := s.DungeonMembers.Get(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.Equal(t, entity.DungeonRoleMember, member.Role)
+		}},
+		{"ListUsersIsOrderedByID", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			for _, id := range []int64{1, 5, 3, 4} {
+				createUser(t, s, id)
+			}
+			dungeon := createDungeon(t, s, 1, -100)
+			for _, id := range []int64{5, 3, 4} {
+				require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, id, entity.DungeonRoleMember))
+			}
+
+			members, err := s.DungeonMembers.ListUsers(ctx, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{3, 4, 5}, members)
+
+			members, err = s.DungeonMembers.ListUsers(ctx, uuid.New().String())
+			require.NoError(t, err)
+			assert.Empty(t, members)
+		}},
+		{"ListIsOrderedByID", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			for _, id := range []int64{1, 5, 3} {
+				createUser(t, s, id)
+			}
+			dungeon := createDungeon(t, s, 1, -100)
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 5, entity.DungeonRoleMember))
+			require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 3, entity.DungeonRoleModerator))
+
+			members, err := s.DungeonMembers.List(ctx, dungeon.ID)
+			require.NoError(t, err)
+			require.Len(t, members, 2)
+			assert.Equal(t, int64(3), members[0].UserID)
+			assert.Equal(t, entity.DungeonRoleModerator, members[0].Role)
+			assert.Equal(t, dungeon.ID, members[0].DungeonID)
+			assert.Equal(t, int64(5), members[1].UserID)
+			assert.Equal(t, entity.DungeonRoleMember, members[1].Role)
+		}},
+		{"ListByUser", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			first := createDungeon(t, s, 1, -100)
+			second := createDungeon(t, s, 2, -200)
+			createDungeon(t, s, 2, -300)
+			require.NoError(t, s.DungeonMembers.Add(ctx, first.ID, 2, entity.DungeonRoleModerator))
+
+			members, err := s.DungeonMembers.ListByUser(ctx, 2)
+			require.NoError(t, err)
+			roles := make(map[string]entity.DungeonRole)
+			for _, member := range members {
+				assert.Equal(t, int64(2), member.UserID)
+				roles[member.DungeonID] = member.Role
+			}
+			// createDungeon leaves the owner's membership to the service
+			assert.Equal(t, map[string]entity.DungeonRole{first.ID: entity.DungeonRoleModerator}, roles)
+
+			require.NoError(t, s.DungeonMembers.Add(ctx, second.ID, 2, entity.DungeonRoleOwner))
+			members, err = s.DungeonMembers.ListByUser(ctx, 2)
+			require.NoError(t, err)
+			assert.Len(t, members, 2)
+
+			members, err = s.DungeonMembers.ListByUser(ctx, 3)
+			require.NoError(t, err)
+			assert.Empty(t, members)
+		}},
+	})
+}
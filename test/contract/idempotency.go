@@ -0,0 +1,106 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunIdempotencyRepository tests a ports.IdempotencyRepository
+func RunIdempotencyRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateFindUpdate", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			key := newIdempotencyKey("k1", time.Hour)
+			require.NoError(t, s.Idempotency.Create(ctx, key))
+
+			found, err := s.Idempotency.FindByKey(ctx, "k1")
+			require.NoError(t, err)
+			assert.Equal(t, "pending", found.Status)
+			assert.Equal(t, int64(1), found.UserID)
+
+			completedAt := time.Now()
+			key.Status = "completed"
+			key.Result = `{"ok":true}`
+			key.CompletedAt = &completedAt
+			require.NoError(t, s.Idempotency.Update(ctx, key))
+
+			found, err = s.Idempotency.FindByKey(ctx, "k1")
+			require.NoError(t, err)
+			assert.True(t, found.IsCompleted())
+			assert.Equal(t, `{"ok":true}`, found.Result)
+		}},
+		{"DuplicateKey", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			require.NoError(t, s.Idempotency.Create(ctx, newIdempotencyKey("k1", time.Hour)))
+			err := s.Idempotency.Create(ctx, newIdempotencyKey("k1", time.Hour))
+			assert.ErrorIs(t, err, ports.ErrIdempotencyKeyExists)
+		}},
+		{"Claim", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			require.NoError(t, s.Idempotency.Claim(ctx, newIdempotencyKey("new", time.Hour)))
+
+			// Pending and completed keys stay with their attempt
+			assert.ErrorIs(t, s.Idempotency.Claim(ctx, newIdempotencyKey("new", time.Hour)), ports.ErrIdempotencyKeyExists)
+			completed := newIdempotencyKey("done", time.Hour)
+			completed.Status = "completed"
+			require.NoError(t, s.Idempotency.Create(ctx, completed))
+			assert.ErrorIs(t, s.Idempotency.Claim(ctx, newIdempotencyKey("done", time.Hour)), ports.ErrIdempotencyKeyExists)
+
+			// Failed and expired keys can be claimed again
+			failed := newIdempotencyKey("failed", time.Hour)
+			failed.Status = "failed"
+			require.NoError(t, s.Idempotency.Create(ctx, failed))
+			require.NoError(t, s.Idempotency.Claim(ctx, newIdempotencyKey("failed", time.Hour)))
+			require.NoError(t, s.Idempotency.Create(ctx, newIdempotencyKey("expired", -time.Minute)))
+			require.NoError(t, s.Idempotency.Claim(ctx, newIdempotencyKey("expired", time.Hour)))
+
+			found, err := s.Idempotency.FindByKey(ctx, "failed")
+			require.NoError(t, err)
+			assert.Equal(t, "pending", found.Status)
+			_, err = s.Idempotency.FindByKey(ctx, "expired")
+			assert.NoError(t, err)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Idempotency.FindByKey(ctx, "missing")
+			assert.ErrorIs(t, err, ports.ErrIdempotencyKeyNotFound)
+
+			assert.ErrorIs(t, s.Idempotency.Update(ctx, newIdempotencyKey("missing", time.Hour)), ports.ErrIdempotencyKeyNotFound)
+		}},
+		{"ExpiredKeysAreHidden", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			require.NoError(t, s.Idempotency.Create(ctx, newIdempotencyKey("old", -time.Minute)))
+			require.NoError(t, s.Idempotency.Create(ctx, newIdempotencyKey("new", time.Hour)))
+
+			_, err := s.Idempotency.FindByKey(ctx, "old")
+			assert.ErrorIs(t, err, ports.ErrIdempotencyKeyNotFound)
+
+			require.NoError(t, s.Idempotency.DeleteExpired(ctx))
+			// The purged key can be used again
+			require.NoError(t, s.Idempotency.Create(ctx, newIdempotencyKey("old", time.Hour)))
+
+			require.NoError(t, s.Idempotency.Purge(ctx, time.Now().Add(2*time.Hour)))
+			_, err = s.Idempotency.FindByKey(ctx, "new")
+			assert.ErrorIs(t, err, ports.ErrIdempotencyKeyNotFound)
+		}},
+	})
+}
+
+func newIdempotencyKey(key string, ttl time.Duration) *entity.IdempotencyKey {
+	return &entity.IdempotencyKey{
+		Key:       key,
+		Operation: "quest_complete",
+		UserID:    1,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
@@ -0,0 +1,184 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+func newInvite(dungeonID, code string, createdAt time.Time) *entity.DungeonInvite {
+	return &entity.DungeonInvite{
+		Code:      code,
+		DungeonID: dungeonID,
+		CreatedBy: 1,
+		CreatedAt: createdAt,
+	}
+}
+
+// RunDungeonInviteRepository tests a ports.DungeonInviteRepository
+func RunDungeonInviteRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndGet", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			maxUses := 3
+			expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+			invite := newInvite(dungeon.ID, "abc123", time.Now())
+			invite.MaxUses = &maxUses
+			invite.ExpiresAt = &expiresAt
+			invite.RequiresApproval = true
+			require.NoError(t, s.Invites.Create(ctx, invite))
+
+			found, err := s.Invites.GetByCode(ctx, "abc123")
+			require.NoError(t, err)
+			assert.Equal(t, dungeon.ID, found.DungeonID)
+			assert.Equal(t, int64(1), found.CreatedBy)
+			require.NotNil(t, found.MaxUses)
+			assert.Equal(t, 3, *found.MaxUses)
+			require.NotNil(t, found.ExpiresAt)
+			assert.True(t, expiresAt.Equal(*found.ExpiresAt))
+			assert.True(t, found.RequiresApproval)
+			assert.Zero(t, found.Uses)
+
+			// Unlimited invites that never expire keep their nil limits
+			require.NoError(t, s.Invites.Create(ctx, newInvite(dungeon.ID, "open", time.Now())))
+			found, err = s.Invites.GetByCode(ctx, "open")
+			require.NoError(t, err)
+			assert.Nil(t, found.MaxUses)
+			assert.Nil(t, found.ExpiresAt)
+			assert.False(t, found.RequiresApproval)
+		}},
+		{"UpdateAndDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			invite := newInvite(dungeon.ID, "abc123", time.Now())
+			require.NoError(t, s.Invites.Create(ctx, invite))
+
+			invite.Uses = 2
+			require.NoError(t, s.Invites.Update(ctx, invite))
+			found, err := s.Invites.GetByCode(ctx, "abc123")
+			require.NoError(t, err)
+			assert.Equal(t, 2, found.Uses)
+
+			require.NoError(t, s.Invites.Delete(ctx, "abc123"))
+			_, err = s.Invites.GetByCode(ctx, "abc123")
+			assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Invites.GetByCode(ctx, "missing")
+			assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+
+			err = s.Invites.Update(ctx, &entity.DungeonInvite{Code: "missing", Uses: 1})
+			assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+
+			err = s.Invites.Delete(ctx, "missing")
+			assert.ErrorIs(t, err, ports.ErrDungeonInviteNotFound)
+		}},
+		{"ListByDungeonIsNewestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+
+			base := time.Now().Add(-time.Hour)
+			require.NoError(t, s.Invites.Create(ctx, newInvite(dungeon.ID, "first", base)))
+			require.NoError(t, s.Invites.Create(ctx, newInvite(dungeon.ID, "second", base.Add(time.Minute))))
+			require.NoError(t, s.Invites.Create(ctx, newInvite(other.ID, "elsewhere", base)))
+
+			invites, err := s.Invites.ListByDungeon(ctx, dungeon.ID)
+			require.NoError(t, err)
+			require.Len(t, invites, 2)
+			assert.Equal(t, "second", invites[0].Code)
+			assert.Equal(t, "first", invites[1].Code)
+		}},
+	})
+}
+
+// RunJoinRequestRepository tests a ports.JoinRequestRepository
+func RunJoinRequestRepository(t *testing.T, open Opener) {
+	newRequest := func(dungeonID string, userID int64, createdAt time.Time) *entity.JoinRequest {
+		return &entity.JoinRequest{
+			DungeonID:  dungeonID,
+			UserID:     userID,
+			InviteCode: "abc123",
+			Status:     entity.JoinRequestPending,
+			CreatedAt:  createdAt,
+		}
+	}
+
+	runCases(t, open, []testCase{
+		{"CreateAndDecide", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			request := newRequest(dungeon.ID, 2, time.Now())
+			require.NoError(t, s.JoinRequests.Create(ctx, request))
+			assert.NotZero(t, request.ID)
+
+			found, err := s.JoinRequests.FindPending(ctx, dungeon.ID, 2)
+			require.NoError(t, err)
+			assert.Equal(t, request.ID, found.ID)
+			assert.Equal(t, "abc123", found.InviteCode)
+			assert.Nil(t, found.DecidedBy)
+			assert.Nil(t, found.DecidedAt)
+
+			require.NoError(t, found.Decide(true, 1, time.Now()))
+			require.NoError(t, s.JoinRequests.Update(ctx, found))
+
+			found, err = s.JoinRequests.GetByID(ctx, request.ID)
+			require.NoError(t, err)
+			assert.Equal(t, entity.JoinRequestApproved, found.Status)
+			require.NotNil(t, found.DecidedBy)
+			assert.Equal(t, int64(1), *found.DecidedBy)
+			assert.NotNil(t, found.DecidedAt)
+
+			// Decided requests are no longer pending
+			_, err = s.JoinRequests.FindPending(ctx, dungeon.ID, 2)
+			assert.ErrorIs(t, err, ports.ErrJoinRequestNotFound)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.JoinRequests.GetByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrJoinRequestNotFound)
+
+			err = s.JoinRequests.Update(ctx, &entity.JoinRequest{ID: 999, Status: entity.JoinRequestDenied})
+			assert.ErrorIs(t, err, ports.ErrJoinRequestNotFound)
+		}},
+		{"ListPendingIsOldestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			for _, id := range []int64{1, 2, 3, 4} {
+				createUser(t, s, id)
+			}
+			dungeon := createDungeon(t, s, 1, -100)
+
+			base := time.Now().Add(-time.Hour)
+			later := newRequest(dungeon.ID, 2, base.Add(time.Minute))
+			require.NoError(t, s.JoinRequests.Create(ctx, later))
+			earlier := newRequest(dungeon.ID, 3, base)
+			require.NoError(t, s.JoinRequests.Create(ctx, earlier))
+			denied := newRequest(dungeon.ID, 4, base)
+			require.NoError(t, denied.Decide(false, 1, time.Now()))
+			require.NoError(t, s.JoinRequests.Create(ctx, denied))
+
+			requests, err := s.JoinRequests.ListPending(ctx, dungeon.ID)
+			require.NoError(t, err)
+			require.Len(t, requests, 2)
+			assert.Equal(t, earlier.ID, requests[0].ID)
+			assert.Equal(t, later.ID, requests[1].ID)
+		}},
+	})
+}
@@ -0,0 +1,112 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+)
+
+// RunLedgerRepository tests a ports.LedgerRepository
+func RunLedgerRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"ListByUserIsNewestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			appendEntries(t, ctx, s, []ledgerPosting{{1, "10.5", ""}, {1, "-2.25", ""}, {2, "7", ""}, {1, "1", ""}})
+
+			entries, err := s.Ledger.ListByUser(ctx, 1, 10)
+			require.NoError(t, err)
+			require.Len(t, entries, 3)
+			assert.Equal(t, "1", entries[0].Amount.String())
+			assert.Equal(t, "-2.25", entries[1].Amount.String())
+			assert.Equal(t, "10.5", entries[2].Amount.String())
+
+			entries, err = s.Ledger.ListByUser(ctx, 1, 2)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2)
+
+			entries, err = s.Ledger.ListByUser(ctx, 3, 10)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		}},
+		{"Balances", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			appendEntries(t, ctx, s, []ledgerPosting{{1, "10.5", ""}, {1, "-2.25", ""}, {2, "7", ""}})
+
+			balances, err := s.Ledger.Balances(ctx)
+			require.NoError(t, err)
+			assert.Len(t, balances, 2)
+			assert.Equal(t, "8.25", balances[1].String())
+			assert.Equal(t, "7", balances[2].String())
+		}},
+		{"ListByWallet", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			flat := createDungeon(t, s, 1, 100)
+			office := createDungeon(t, s, 1, 200)
+			appendEntries(t, ctx, s, []ledgerPosting{{1, "10", flat.ID}, {1, "4", office.ID}, {1, "-3", flat.ID}, {1, "2", ""}})
+
+			entries, err := s.Ledger.ListByWallet(ctx, 1, flat.ID, 10)
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			assert.Equal(t, "-3", entries[0].Amount.String())
+			assert.Equal(t, flat.ID, entries[0].DungeonID)
+			assert.Equal(t, "10", entries[1].Amount.String())
+
+			entries, err = s.Ledger.ListByUser(ctx, 1, 10)
+			require.NoError(t, err)
+			require.Len(t, entries, 4)
+			assert.Empty(t, entries[0].DungeonID)
+			assert.Equal(t, office.ID, entries[2].DungeonID)
+		}},
+		{"WalletBalances", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			flat := createDungeon(t, s, 1, 100)
+			appendEntries(t, ctx, s, []ledgerPosting{{1, "10.5", flat.ID}, {1, "-2.25", flat.ID}, {2, "7", flat.ID}, {2, "1", ""}})
+
+			wallets, err := s.Ledger.WalletBalances(ctx)
+			require.NoError(t, err)
+			require.Len(t, wallets, 2)
+			assert.Equal(t, int64(1), wallets[0].UserID)
+			assert.Equal(t, flat.ID, wallets[0].DungeonID)
+			assert.Equal(t, "8.25", wallets[0].Balance.String())
+			assert.Equal(t, int64(2), wallets[1].UserID)
+			assert.Equal(t, "7", wallets[1].Balance.String())
+		}},
+	})
+}
+
+type ledgerPosting struct {
+	userID    int64
+	amount    string
+	dungeonID string // Empty for points outside any dungeon
+}
+
+func appendEntries(t *testing.T, ctx context.Context, s *storage.Storage, postings []ledgerPosting) {
+	t.Helper()
+	for _, posting := range postings {
+		err := s.Ledger.Append(ctx, &entity.LedgerEntry{
+			ID:           uuid.New().String(),
+			UserID:       posting.userID,
+			DungeonID:    posting.dungeonID,
+			Amount:       valueobject.NewDecimal(posting.amount),
+			BalanceAfter: valueobject.NewDecimal("0"),
+			Reason:       entity.LedgerReasonAdminAdjustment,
+			SourceType:   "user",
+			CreatedAt:    time.Now(),
+		})
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,245 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunQuestRepository tests a ports.QuestRepository
+func RunQuestRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := newQuest(dungeon.ID, "Wash the dishes")
+			quest.DailyPointsCap = decimalPtr("12.5")
+			quest.CooldownSec = 3600
+			require.NoError(t, s.Quests.Create(ctx, quest))
+
+			found, err := s.Quests.GetByID(ctx, quest.ID)
+			require.NoError(t, err)
+			assert.Equal(t, quest.Title, found.Title)
+			assert.Equal(t, "5", found.PointsAward.String())
+			require.NotNil(t, found.DailyPointsCap)
+			assert.Equal(t, "12.5", found.DailyPointsCap.String())
+			assert.Equal(t, 3600, found.CooldownSec)
+			assert.Equal(t, "Europe/Berlin", found.TimeZone)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			_, err := s.Quests.GetByID(ctx, uuid.New().String())
+			assert.ErrorIs(t, err, ports.ErrQuestNotFound)
+
+			err = s.Quests.Update(ctx, newQuest(dungeon.ID, "Missing"))
+			assert.ErrorIs(t, err, ports.ErrQuestNotFound)
+		}},
+		{"UpdateAndDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			quest.Title = "Water the plants"
+			quest.Status = "paused"
+			require.NoError(t, s.Quests.Update(ctx, quest))
+
+			found, err := s.Quests.GetByID(ctx, quest.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Water the plants", found.Title)
+			assert.Equal(t, "paused", found.Status)
+
+			require.NoError(t, s.Quests.Delete(ctx, quest.ID))
+			_, err = s.Quests.GetByID(ctx, quest.ID)
+			assert.ErrorIs(t, err, ports.ErrQuestNotFound)
+		}},
+		{"ListByDungeonIsOldestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+			createQuest(t, s, other.ID)
+
+			base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			titles := []string{"Laundry", "Dishes", "Vacuum"}
+			for i, title := range titles {
+				quest := newQuest(dungeon.ID, title)
+				quest.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+				require.NoError(t, s.Quests.Create(ctx, quest))
+			}
+
+			quests, err := s.Quests.ListByDungeon(ctx, dungeon.ID)
+			require.NoError(t, err)
+			var got []string
+			for _, quest := range quests {
+				got = append(got, quest.Title)
+			}
+			assert.Equal(t, titles, got)
+
+			quests, err = s.Quests.ListByDungeon(ctx, uuid.New().String())
+			require.NoError(t, err)
+			assert.Empty(t, quests)
+		}},
+	})
+}
+
+// RunQuestCompletionRepository tests a ports.QuestCompletionRepository
+func RunQuestCompletionRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"LastForUser", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			_, err := s.Completions.LastForUser(ctx, 1, quest.ID)
+			assert.ErrorIs(t, err, ports.ErrQuestCompletionNotFound)
+
+			day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			insertCompletion(t, s, quest, 1, day.Add(time.Hour), "2.25")
+			insertCompletion(t, s, quest, 1, day, "1.5")
+			insertCompletion(t, s, quest, 2, day.Add(2*time.Hour), "3")
+
+			last, err := s.Completions.LastForUser(ctx, 1, quest.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "2.25", last.AwardedPoints.String())
+			assert.True(t, last.SubmittedAt.Equal(day.Add(time.Hour)))
+
+			_, err = s.Completions.LastForUser(ctx, 1, uuid.New().String())
+			assert.ErrorIs(t, err, ports.ErrQuestCompletionNotFound)
+		}},
+		{"DuplicateIdempotencyKey", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			newCompletion := func(key string) *entity.QuestCompletion {
+				return &entity.QuestCompletion{
+					ID:             uuid.New().String(),
+					QuestID:        quest.ID,
+					UserID:         1,
+					DungeonID:      quest.DungeonID,
+					SubmittedAt:    time.Now(),
+					AwardedPoints:  valueobject.NewDecimal("1"),
+					IdempotencyKey: key,
+				}
+			}
+			require.NoError(t, s.Completions.Insert(ctx, newCompletion("k1")))
+			assert.ErrorIs(t, s.Completions.Insert(ctx, newCompletion("k1")), ports.ErrIdempotencyKeyExists)
+
+			// Completions without a key are not deduplicated
+			require.NoError(t, s.Completions.Insert(ctx, newCompletion("")))
+			require.NoError(t, s.Completions.Insert(ctx, newCompletion("")))
+		}},
+		{"SumAwardedForUserOnDay", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			insertCompletion(t, s, quest, 1, day, "1.5")
+			insertCompletion(t, s, quest, 1, day.Add(time.Hour), "2.25")
+			insertCompletion(t, s, quest, 1, day.Add(11*time.Hour+30*time.Minute), "4")
+
+			sum, err := s.Completions.SumAwardedForUserOnDay(ctx, 1, quest.ID, day, "UTC")
+			require.NoError(t, err)
+			assert.Equal(t, "7.75", sum.String())
+
+			// 23:30 UTC is already the next day in Berlin
+			sum, err = s.Completions.SumAwardedForUserOnDay(ctx, 1, quest.ID, day, "Europe/Berlin")
+			require.NoError(t, err)
+			assert.Equal(t, "3.75", sum.String())
+
+			sum, err = s.Completions.SumAwardedForUserOnDay(ctx, 1, quest.ID, day.AddDate(0, 0, 1), "Europe/Berlin")
+			require.NoError(t, err)
+			assert.Equal(t, "4", sum.String())
+
+			sum, err = s.Completions.SumAwardedForUserOnDay(ctx, 1, quest.ID, day.AddDate(0, 0, 2), "UTC")
+			require.NoError(t, err)
+			assert.True(t, sum.IsZero())
+		}},
+	})
+}
+
+// RunQuestStreakRepository tests a ports.QuestStreakRepository
+func RunQuestStreakRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"UpsertAndGet", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			_, err := s.Streaks.Get(ctx, 1, quest.ID)
+			assert.ErrorIs(t, err, ports.ErrQuestStreakNotFound)
+
+			streak := &entity.QuestStreak{UserID: 1, QuestID: quest.ID, Current: 1, Best: 1, LastPeriod: 100, UpdatedAt: time.Now()}
+			require.NoError(t, s.Streaks.Upsert(ctx, streak))
+			streak.Current, streak.Best, streak.LastPeriod = 2, 2, 101
+			require.NoError(t, s.Streaks.Upsert(ctx, streak))
+
+			found, err := s.Streaks.Get(ctx, 1, quest.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, found.Current)
+			assert.Equal(t, 2, found.Best)
+			assert.Equal(t, int64(101), found.LastPeriod)
+		}},
+		{"ListByUserIsBestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			for _, best := range []int{2, 5, 3} {
+				quest := createQuest(t, s, dungeon.ID)
+				require.NoError(t, s.Streaks.Upsert(ctx, &entity.QuestStreak{
+					UserID: 1, QuestID: quest.ID, Current: 1, Best: best, LastPeriod: 100, UpdatedAt: time.Now(),
+				}))
+			}
+
+			streaks, err := s.Streaks.ListByUser(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, streaks, 3)
+			assert.Equal(t, 5, streaks[0].Best)
+			assert.Equal(t, 3, streaks[1].Best)
+			assert.Equal(t, 2, streaks[2].Best)
+
+			streaks, err = s.Streaks.ListByUser(ctx, 2)
+			require.NoError(t, err)
+			assert.Empty(t, streaks)
+		}},
+	})
+}
+
+func insertCompletion(t *testing.T, s *storage.Storage, quest *entity.Quest, userID int64, at time.Time, points string) {
+	t.Helper()
+	err := s.Completions.Insert(context.Background(), &entity.QuestCompletion{
+		ID:             uuid.New().String(),
+		QuestID:        quest.ID,
+		UserID:         userID,
+		DungeonID:      quest.DungeonID,
+		SubmittedAt:    at,
+		AwardedPoints:  valueobject.NewDecimal(points),
+		IdempotencyKey: uuid.New().String(),
+	})
+	require.NoError(t, err)
+}
+
+func decimalPtr(value string) *valueobject.Decimal {
+	d := valueobject.NewDecimal(value)
+	return &d
+}
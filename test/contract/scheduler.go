@@ -0,0 +1,44 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunScheduler tests a ports.Scheduler
+func RunScheduler(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			_, err := s.Scheduler.GetNextOccurrence(context.Background(), uuid.New().String())
+			assert.ErrorIs(t, err, ports.ErrTaskNotFound)
+		}},
+		{"ScheduleTickCancel", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			quest := createQuest(t, s, dungeon.ID)
+
+			require.NoError(t, s.Scheduler.ScheduleRecurringTask(ctx, quest))
+			// Scheduling again refreshes the schedule instead of failing
+			require.NoError(t, s.Scheduler.ScheduleRecurringTask(ctx, quest))
+
+			next, err := s.Scheduler.GetNextOccurrence(ctx, quest.ID)
+			require.NoError(t, err)
+			assert.True(t, next.After(time.Now()))
+
+			require.NoError(t, s.Scheduler.Tick(ctx, time.Now().Add(48*time.Hour)))
+
+			require.NoError(t, s.Scheduler.CancelScheduledTask(ctx, quest.ID))
+			_, err = s.Scheduler.GetNextOccurrence(ctx, quest.ID)
+			assert.ErrorIs(t, err, entity.ErrScheduleEnded)
+		}},
+	})
+}
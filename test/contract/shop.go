@@ -0,0 +1,444 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunShopItemRepository tests a ports.ShopItemRepository
+func RunShopItemRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			item := createShopItem(t, s, dungeon.ID, "tea")
+			assert.NotZero(t, item.ID)
+
+			found, err := s.ShopItems.FindByCode(ctx, dungeon.ID, "tea")
+			require.NoError(t, err)
+			assert.Equal(t, item.ID, found.ID)
+			assert.Equal(t, "12.5", found.Price.String())
+
+			found, err = s.ShopItems.FindByID(ctx, item.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "tea", found.Code)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			_, err := s.ShopItems.FindByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+
+			_, err = s.ShopItems.FindByCode(ctx, dungeon.ID, "tea")
+			assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+
+			missing := newShopItem(dungeon.ID, "tea")
+			missing.ID = 999
+			assert.ErrorIs(t, s.ShopItems.Update(ctx, missing), ports.ErrShopItemNotFound)
+			assert.ErrorIs(t, s.ShopItems.Delete(ctx, 999), ports.ErrShopItemNotFound)
+		}},
+		{"CodesAreUniquePerDungeon", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+			createShopItem(t, s, dungeon.ID, "tea")
+
+			err := s.ShopItems.Create(ctx, newShopItem(dungeon.ID, "tea"))
+			assert.ErrorIs(t, err, ports.ErrShopItemCodeExists)
+			createShopItem(t, s, other.ID, "tea")
+
+			coffee := createShopItem(t, s, dungeon.ID, "coffee")
+			coffee.Code = "tea"
+			assert.ErrorIs(t, s.ShopItems.Update(ctx, coffee), ports.ErrShopItemCodeExists)
+		}},
+		{"FindByDungeonIDIsOrderedByID", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+			for _, code := range []string{"tea", "coffee", "cake"} {
+				createShopItem(t, s, dungeon.ID, code)
+			}
+			createShopItem(t, s, other.ID, "juice")
+
+			items, err := s.ShopItems.FindByDungeonID(ctx, dungeon.ID)
+			require.NoError(t, err)
+			var codes []string
+			for _, item := range items {
+				codes = append(codes, item.Code)
+			}
+			assert.Equal(t, []string{"tea", "coffee", "cake"}, codes)
+		}},
+		{"UpdateAndDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			item := createShopItem(t, s, dungeon.ID, "tea")
+
+			item.Name = "Green tea"
+			item.Price = valueobject.NewDecimal("8")
+			require.NoError(t, s.ShopItems.Update(ctx, item))
+
+			found, err := s.ShopItems.FindByID(ctx, item.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Green tea", found.Name)
+			assert.Equal(t, "8", found.Price.String())
+
+			require.NoError(t, s.ShopItems.Delete(ctx, item.ID))
+			_, err = s.ShopItems.FindByID(ctx, item.ID)
+			assert.ErrorIs(t, err, ports.ErrShopItemNotFound)
+		}},
+		{"AdjustStock", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			unlimited := createShopItem(t, s, dungeon.ID, "tea")
+			limited := newShopItem(dungeon.ID, "cake")
+			stock := 3
+			limited.Stock = &stock
+			require.NoError(t, s.ShopItems.Create(ctx, limited))
+
+			require.NoError(t, s.ShopItems.AdjustStock(ctx, limited.ID, -2))
+			require.NoError(t, s.ShopItems.AdjustStock(ctx, limited.ID, 1))
+			assert.ErrorIs(t, s.ShopItems.AdjustStock(ctx, limited.ID, -3), ports.ErrInsufficientStock)
+			found, err := s.ShopItems.FindByID(ctx, limited.ID)
+			require.NoError(t, err)
+			require.NotNil(t, found.Stock)
+			assert.Equal(t, 2, *found.Stock)
+
+			require.NoError(t, s.ShopItems.AdjustStock(ctx, unlimited.ID, -5))
+			found, err = s.ShopItems.FindByID(ctx, unlimited.ID)
+			require.NoError(t, err)
+			assert.Nil(t, found.Stock)
+
+			assert.ErrorIs(t, s.ShopItems.AdjustStock(ctx, 999, 1), ports.ErrShopItemNotFound)
+		}},
+	})
+}
+
+// RunPurchaseRepository tests a ports.PurchaseRepository
+func RunPurchaseRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			item := createShopItem(t, s, dungeon.ID, "tea")
+
+			purchase := newPurchase(1, item, time.Now())
+			purchase.PriceBreakdown = []entity.PriceAdjustment{
+				{Rule: "sale", Amount: valueobject.NewDecimal("-2.5")},
+			}
+			require.NoError(t, s.Purchases.Create(ctx, purchase))
+			assert.NotZero(t, purchase.ID)
+
+			found, err := s.Purchases.FindByID(ctx, purchase.ID)
+			require.NoError(t, err)
+			assert.Equal(t, item.ID, found.ItemID)
+			assert.Equal(t, dungeon.ID, found.DungeonID)
+			assert.Equal(t, "25", found.TotalCost.String())
+			require.Len(t, found.PriceBreakdown, 1)
+			assert.Equal(t, "-2.5", found.PriceBreakdown[0].Amount.String())
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Purchases.FindByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrPurchaseNotFound)
+
+			assert.ErrorIs(t, s.Purchases.Update(ctx, &entity.Purchase{ID: 999, Status: "refunded"}), ports.ErrPurchaseNotFound)
+		}},
+		{"ListsAreNewestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			dungeon := createDungeon(t, s, 1, -100)
+			tea := createShopItem(t, s, dungeon.ID, "tea")
+			cake := createShopItem(t, s, dungeon.ID, "cake")
+
+			base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			var ids []int64
+			for i, item := range []*entity.ShopItem{tea, cake, tea} {
+				purchase := newPurchase(1, item, base.Add(time.Duration(i)*time.Hour))
+				require.NoError(t, s.Purchases.Create(ctx, purchase))
+				ids = append(ids, purchase.ID)
+			}
+			require.NoError(t, s.Purchases.Create(ctx, newPurchase(2, tea, base)))
+
+			purchases, err := s.Purchases.FindByUserID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{ids[2], ids[1], ids[0]}, purchaseIDs(purchases))
+
+			purchases, err = s.Purchases.FindByItemID(ctx, cake.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{ids[1]}, purchaseIDs(purchases))
+		}},
+		{"Refund", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			purchase := newPurchase(1, createShopItem(t, s, dungeon.ID, "tea"), time.Now())
+			require.NoError(t, s.Purchases.Create(ctx, purchase))
+
+			refundedAt := time.Now()
+			purchase.Status = "refunded"
+			purchase.RefundedQuantity = 2
+			purchase.RefundedAmount = valueobject.NewDecimal("25")
+			purchase.RefundedAt = &refundedAt
+			require.NoError(t, s.Purchases.Update(ctx, purchase))
+
+			found, err := s.Purchases.FindByID(ctx, purchase.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "refunded", found.Status)
+			assert.Equal(t, 2, found.RefundedQuantity)
+			assert.Equal(t, "25", found.RefundedAmount.String())
+			assert.NotNil(t, found.RefundedAt)
+		}},
+	})
+}
+
+// RunMemberPriceRepository tests a ports.MemberPriceRepository
+func RunMemberPriceRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"SetFindDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			item := createShopItem(t, s, dungeon.ID, "tea")
+
+			_, err := s.MemberPrices.Find(ctx, item.ID, 1)
+			assert.ErrorIs(t, err, ports.ErrMemberPriceNotFound)
+
+			price := &entity.MemberPrice{ItemID: item.ID, UserID: 1, Price: valueobject.NewDecimal("3"), UpdatedAt: time.Now()}
+			require.NoError(t, s.MemberPrices.Set(ctx, price))
+			// Setting again replaces the price
+			price.Price = valueobject.NewDecimal("4.5")
+			require.NoError(t, s.MemberPrices.Set(ctx, price))
+
+			found, err := s.MemberPrices.Find(ctx, item.ID, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "4.5", found.Price.String())
+
+			require.NoError(t, s.MemberPrices.Delete(ctx, item.ID, 1))
+			_, err = s.MemberPrices.Find(ctx, item.ID, 1)
+			assert.ErrorIs(t, err, ports.ErrMemberPriceNotFound)
+		}},
+	})
+}
+
+// RunDiscountTierRepository tests a ports.DiscountTierRepository
+func RunDiscountTierRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAssignsIDs", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			first := newDiscountTier("Silver", 5)
+			second := newDiscountTier("Gold", 10)
+			require.NoError(t, s.DiscountTiers.Create(ctx, first))
+			require.NoError(t, s.DiscountTiers.Create(ctx, second))
+			assert.NotZero(t, first.ID)
+			assert.NotEqual(t, first.ID, second.ID)
+
+			found, err := s.DiscountTiers.FindByID(ctx, second.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Gold", found.Name)
+			assert.Equal(t, 10.0, found.DiscountPercent)
+		}},
+		{"DuplicateID", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			tier := newDiscountTier("Silver", 5)
+			tier.ID = 7
+			require.NoError(t, s.DiscountTiers.Create(ctx, tier))
+
+			duplicate := newDiscountTier("Gold", 10)
+			duplicate.ID = 7
+			assert.ErrorIs(t, s.DiscountTiers.Create(ctx, duplicate), ports.ErrDiscountTierExists)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.DiscountTiers.FindByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrDiscountTierNotFound)
+
+			missing := newDiscountTier("Silver", 5)
+			missing.ID = 999
+			assert.ErrorIs(t, s.DiscountTiers.Update(ctx, missing), ports.ErrDiscountTierNotFound)
+			assert.ErrorIs(t, s.DiscountTiers.Delete(ctx, 999), ports.ErrDiscountTierNotFound)
+		}},
+		{"FindAllIsOrderedByPercent", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			for _, percent := range []float64{15, 5, 10} {
+				require.NoError(t, s.DiscountTiers.Create(ctx, newDiscountTier("Tier", percent)))
+			}
+
+			tiers, err := s.DiscountTiers.FindAll(ctx)
+			require.NoError(t, err)
+			var percents []float64
+			for _, tier := range tiers {
+				percents = append(percents, tier.DiscountPercent)
+			}
+			assert.Equal(t, []float64{5, 10, 15}, percents)
+		}},
+		{"UpdateAndDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			tier := newDiscountTier("Silver", 5)
+			require.NoError(t, s.DiscountTiers.Create(ctx, tier))
+
+			tier.DiscountPercent = 7.5
+			require.NoError(t, s.DiscountTiers.Update(ctx, tier))
+			found, err := s.DiscountTiers.FindByID(ctx, tier.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 7.5, found.DiscountPercent)
+
+			require.NoError(t, s.DiscountTiers.Delete(ctx, tier.ID))
+			_, err = s.DiscountTiers.FindByID(ctx, tier.ID)
+			assert.ErrorIs(t, err, ports.ErrDiscountTierNotFound)
+		}},
+	})
+}
+
+// RunRewardTierRepository tests a ports.RewardTierRepository
+func RunRewardTierRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.RewardTiers.FindByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrRewardTierNotFound)
+
+			missing := newRewardTier("d1", "Silver", 5)
+			missing.ID = 999
+			assert.ErrorIs(t, s.RewardTiers.Update(ctx, missing), ports.ErrRewardTierNotFound)
+		}},
+		{"FindByDungeonIDIsOrderedByThreshold", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+			for _, minPurchases := range []int{10, 0, 5} {
+				require.NoError(t, s.RewardTiers.Create(ctx, newRewardTier(dungeon.ID, "Tier", minPurchases)))
+			}
+			require.NoError(t, s.RewardTiers.Create(ctx, newRewardTier(other.ID, "Other", 1)))
+
+			tiers, err := s.RewardTiers.FindByDungeonID(ctx, dungeon.ID)
+			require.NoError(t, err)
+			var thresholds []int
+			for _, tier := range tiers {
+				thresholds = append(thresholds, tier.MinPurchases)
+				assert.Equal(t, dungeon.ID, tier.DungeonID)
+			}
+			assert.Equal(t, []int{0, 5, 10}, thresholds)
+
+			tiers, err = s.RewardTiers.FindAll(ctx)
+			require.NoError(t, err)
+			assert.Len(t, tiers, 4)
+		}},
+		{"UpdateAndDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			tier := newRewardTier(createDungeon(t, s, 1, -100).ID, "Silver", 5)
+			require.NoError(t, s.RewardTiers.Create(ctx, tier))
+			assert.NotZero(t, tier.ID)
+
+			tier.Name = "Gold"
+			require.NoError(t, s.RewardTiers.Update(ctx, tier))
+			found, err := s.RewardTiers.FindByID(ctx, tier.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Gold", found.Name)
+
+			require.NoError(t, s.RewardTiers.Delete(ctx, tier.ID))
+			_, err = s.RewardTiers.FindByID(ctx, tier.ID)
+			assert.ErrorIs(t, err, ports.ErrRewardTierNotFound)
+		}},
+	})
+}
+
+// RunLoyaltyStatusRepository tests a ports.LoyaltyStatusRepository
+func RunLoyaltyStatusRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"SaveAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+			other := createDungeon(t, s, 1, -200)
+			tier := newRewardTier(dungeon.ID, "Silver", 1)
+			require.NoError(t, s.RewardTiers.Create(ctx, tier))
+
+			_, err := s.LoyaltyStatus.Find(ctx, 1, dungeon.ID)
+			assert.ErrorIs(t, err, ports.ErrLoyaltyStatusNotFound)
+
+			status := &entity.LoyaltyStatus{UserID: 1, DungeonID: dungeon.ID, PurchaseCount: 1, UpdatedAt: time.Now()}
+			require.NoError(t, s.LoyaltyStatus.Save(ctx, status))
+			// Saving again replaces the status
+			status.TierID = &tier.ID
+			status.PurchaseCount = 2
+			require.NoError(t, s.LoyaltyStatus.Save(ctx, status))
+
+			found, err := s.LoyaltyStatus.Find(ctx, 1, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, dungeon.ID, found.DungeonID)
+			assert.Equal(t, 2, found.PurchaseCount)
+			require.NotNil(t, found.TierID)
+			assert.Equal(t, tier.ID, *found.TierID)
+			assert.Nil(t, found.NotifiedTierID)
+
+			_, err = s.LoyaltyStatus.Find(ctx, 1, other.ID)
+			assert.ErrorIs(t, err, ports.ErrLoyaltyStatusNotFound)
+		}},
+	})
+}
+
+func newPurchase(userID int64, item *entity.ShopItem, at time.Time) *entity.Purchase {
+	return &entity.Purchase{
+		UserID:         userID,
+		ItemID:         item.ID,
+		DungeonID:      *item.DungeonID,
+		ItemName:       item.Name,
+		ItemPrice:      item.Price,
+		Quantity:       2,
+		Subtotal:       valueobject.NewDecimal("25"),
+		TotalCost:      valueobject.NewDecimal("25"),
+		Status:         "completed",
+		PurchasedAt:    at,
+		RefundedAmount: valueobject.NewDecimal("0"),
+	}
+}
+
+func purchaseIDs(purchases []*entity.Purchase) []int64 {
+	ids := make([]int64, len(purchases))
+	for i, purchase := range purchases {
+		ids[i] = purchase.ID
+	}
+	return ids
+}
+
+func newDiscountTier(name string, percent float64) *entity.DiscountTier {
+	return &entity.DiscountTier{
+		Name:            name,
+		DiscountPercent: percent,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+func newRewardTier(dungeonID, name string, minPurchases int) *entity.RewardTier {
+	return &entity.RewardTier{
+		DungeonID:       dungeonID,
+		Name:            name,
+		DiscountPercent: 5,
+		MinPurchases:    minPurchases,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
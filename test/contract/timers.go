@@ -0,0 +1,211 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunTimerRepository tests a ports.TimerRepository
+func RunTimerRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Timers.FindByID(ctx, uuid.New().String())
+			assert.ErrorIs(t, err, ports.ErrTimerNotFound)
+
+			assert.ErrorIs(t, s.Timers.Update(ctx, newTimer(1, "task", time.Now())), ports.ErrTimerNotFound)
+		}},
+		{"CreateUpdateDelete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			timer := newTimer(1, "task", time.Now())
+			require.NoError(t, s.Timers.Create(ctx, timer))
+
+			notificationID := "n1"
+			timer.Status = "paused"
+			timer.CurrentValue = 120
+			timer.NotificationID = &notificationID
+			require.NoError(t, s.Timers.Update(ctx, timer))
+
+			found, err := s.Timers.FindByID(ctx, timer.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "paused", found.Status)
+			assert.Equal(t, 120, found.CurrentValue)
+			require.NotNil(t, found.NotificationID)
+			assert.Equal(t, "n1", *found.NotificationID)
+
+			require.NoError(t, s.Timers.Delete(ctx, timer.ID))
+			_, err = s.Timers.FindByID(ctx, timer.ID)
+			assert.ErrorIs(t, err, ports.ErrTimerNotFound)
+		}},
+		{"ClaimAndSettleExpiry", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			timer := newTimer(1, "task", time.Now())
+			require.NoError(t, s.Timers.Create(ctx, timer))
+
+			now := time.Now().UTC().Truncate(time.Second)
+			attempts, err := s.Timers.ClaimExpiry(ctx, timer.ID, "claim-1", now, now.Add(-time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 1, attempts)
+			_, err = s.Timers.ClaimExpiry(ctx, timer.ID, "claim-2", now, now.Add(-time.Minute))
+			assert.ErrorIs(t, err, ports.ErrTimerNotificationTaken, "only one process claims the expiry")
+
+			// A claim whose process stopped is claimed again after the retry delay
+			later := now.Add(2 * time.Minute)
+			attempts, err = s.Timers.ClaimExpiry(ctx, timer.ID, "claim-2", later, later.Add(-time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 2, attempts)
+			assert.ErrorIs(t, s.Timers.SettleExpiry(ctx, timer.ID, "claim-1", nil), ports.ErrTimerNotificationTaken,
+				"a stale claim cannot settle the expiry")
+
+			// A released claim waits for the retry delay too
+			require.NoError(t, s.Timers.SettleExpiry(ctx, timer.ID, "claim-2", nil))
+			_, err = s.Timers.ClaimExpiry(ctx, timer.ID, "claim-3", later, later.Add(-time.Minute))
+			assert.ErrorIs(t, err, ports.ErrTimerNotificationTaken)
+			latest := later.Add(2 * time.Minute)
+			attempts, err = s.Timers.ClaimExpiry(ctx, timer.ID, "claim-3", latest, latest.Add(-time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 3, attempts)
+
+			sent := "n1"
+			require.NoError(t, s.Timers.SettleExpiry(ctx, timer.ID, "claim-3", &sent))
+			found, err := s.Timers.FindByID(ctx, timer.ID)
+			require.NoError(t, err)
+			require.NotNil(t, found.NotificationID)
+			assert.Equal(t, "n1", *found.NotificationID)
+			assert.Nil(t, found.NotificationClaimedAt)
+			assert.Equal(t, 3, found.NotificationAttempts)
+
+			end := latest.Add(time.Hour)
+			_, err = s.Timers.ClaimExpiry(ctx, timer.ID, "claim-4", end, end.Add(-time.Minute))
+			assert.ErrorIs(t, err, ports.ErrTimerNotificationTaken, "settled expiries are not claimed again")
+
+			found.Status = "completed"
+			require.NoError(t, s.Timers.Update(ctx, found))
+			assert.ErrorIs(t, s.Timers.SettleExpiry(ctx, timer.ID, "n1", nil), ports.ErrTimerNotificationTaken,
+				"timers that stopped running are left alone")
+			_, err = s.Timers.ClaimExpiry(ctx, uuid.New().String(), "claim", now, now)
+			assert.ErrorIs(t, err, ports.ErrTimerNotificationTaken)
+		}},
+		{"ListsAreNewestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+
+			base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			var ids []string
+			for i, status := range []string{"completed", "running", "paused"} {
+				timer := newTimer(1, "task", base.Add(time.Duration(i)*time.Minute))
+				timer.Status = status
+				require.NoError(t, s.Timers.Create(ctx, timer))
+				ids = append(ids, timer.ID)
+			}
+			other := newTimer(2, "other", base)
+			require.NoError(t, s.Timers.Create(ctx, other))
+
+			timers, err := s.Timers.FindByUser(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{ids[2], ids[1], ids[0]}, timerIDs(timers))
+
+			timers, err = s.Timers.FindByTask(ctx, "task")
+			require.NoError(t, err)
+			assert.Equal(t, []string{ids[2], ids[1], ids[0]}, timerIDs(timers))
+
+			timers, err = s.Timers.FindActiveByUser(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{ids[2], ids[1]}, timerIDs(timers))
+
+			userIDs, err := s.Timers.FindUsersWithActiveTimers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{1, 2}, userIDs)
+		}},
+		{"BulkUpdate", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			first := newTimer(1, "task", time.Now())
+			second := newTimer(1, "task", time.Now())
+			require.NoError(t, s.Timers.Create(ctx, first))
+			require.NoError(t, s.Timers.Create(ctx, second))
+
+			first.Status, second.Status = "completed", "cancelled"
+			require.NoError(t, s.Timers.BulkUpdate(ctx, []*entity.Timer{first, second}))
+
+			timers, err := s.Timers.FindActiveByUser(ctx, 1)
+			require.NoError(t, err)
+			assert.Empty(t, timers)
+		}},
+	})
+}
+
+// RunTimerEventRepository tests a ports.TimerEventRepository
+func RunTimerEventRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"FindByTimerIsOldestFirst", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			timer := newTimer(1, "task", time.Now())
+			require.NoError(t, s.Timers.Create(ctx, timer))
+
+			base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+			events := []struct {
+				eventType string
+				offset    time.Duration
+			}{{"pause", time.Minute}, {"start", 0}, {"resume", 2 * time.Minute}}
+			for _, event := range events {
+				require.NoError(t, s.TimerEvents.Create(ctx, &entity.TimerEvent{
+					ID:        uuid.New().String(),
+					TimerID:   timer.ID,
+					EventType: event.eventType,
+					Timestamp: base.Add(event.offset),
+					Metadata:  map[string]interface{}{"by": "user"},
+				}))
+			}
+
+			found, err := s.TimerEvents.FindByTimer(ctx, timer.ID)
+			require.NoError(t, err)
+			var types []string
+			for _, event := range found {
+				types = append(types, event.EventType)
+			}
+			assert.Equal(t, []string{"start", "pause", "resume"}, types)
+			assert.Equal(t, "user", found[0].Metadata["by"])
+
+			require.NoError(t, s.TimerEvents.DeleteOldEvents(ctx, base.Add(90*time.Second)))
+			found, err = s.TimerEvents.FindByTimer(ctx, timer.ID)
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, "resume", found[0].EventType)
+		}},
+	})
+}
+
+func newTimer(userID int64, taskID string, start time.Time) *entity.Timer {
+	return &entity.Timer{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		UserID:    userID,
+		Type:      "countdown",
+		StartTime: start,
+		Duration:  1500,
+		Status:    "running",
+		Timezone:  "UTC",
+	}
+}
+
+func timerIDs(timers []*entity.Timer) []string {
+	ids := make([]string, len(timers))
+	for i, timer := range timers {
+		ids[i] = timer.ID
+	}
+	return ids
+}
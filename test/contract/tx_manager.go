@@ -0,0 +1,108 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+var errRollback = errors.New("rollback")
+
+// RunTxManager tests that a ports.TxManager covers the backend's repositories
+func RunTxManager(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"WritesAreVisibleInside", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			err := s.TxManager.WithTx(ctx, func(ctx context.Context) error {
+				require.NoError(t, s.Users.Create(ctx, newUser(2)))
+				require.NoError(t, s.DungeonMembers.Add(ctx, dungeon.ID, 2, entity.DungeonRoleMember))
+				require.NoError(t, s.ShopItems.Create(ctx, newShopItem(dungeon.ID, "tea")))
+
+				// Reads in the transaction see its writes, lists included
+				_, err := s.Users.FindByID(ctx, 2)
+				require.NoError(t, err)
+				members, err := s.DungeonMembers.ListUsers(ctx, dungeon.ID)
+				require.NoError(t, err)
+				assert.Equal(t, []int64{2}, members)
+				_, err = s.ShopItems.FindByCode(ctx, dungeon.ID, "tea")
+				require.NoError(t, err)
+				return errRollback
+			})
+			require.ErrorIs(t, err, errRollback)
+		}},
+		{"Rollback", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, -100)
+
+			err := s.TxManager.WithTx(ctx, func(ctx context.Context) error {
+				if _, err := s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("5")); err != nil {
+					return err
+				}
+				if err := s.Users.Create(ctx, newUser(2)); err != nil {
+					return err
+				}
+				if err := s.Quests.Create(ctx, newQuest(dungeon.ID, "Laundry")); err != nil {
+					return err
+				}
+				appendEntries(t, ctx, s, []ledgerPosting{{1, "5", ""}})
+				return errRollback
+			})
+			require.ErrorIs(t, err, errRollback)
+
+			user, err := s.Users.FindByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "10.5", user.Balance.String(), "rolled back balance change should not persist")
+			_, err = s.Users.FindByID(ctx, 2)
+			assert.ErrorIs(t, err, ports.ErrUserNotFound, "rolled back insert should not persist")
+			quests, err := s.Quests.ListByDungeon(ctx, dungeon.ID)
+			require.NoError(t, err)
+			assert.Empty(t, quests)
+			entries, err := s.Ledger.ListByUser(ctx, 1, 10)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		}},
+		{"Commit", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+
+			err := s.TxManager.WithTx(ctx, func(ctx context.Context) error {
+				_, err := s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+				return err
+			})
+			require.NoError(t, err)
+
+			user, err := s.Users.FindByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "15.5", user.Balance.String())
+		}},
+		{"NestedJoinsOuter", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+
+			err := s.TxManager.WithTx(ctx, func(ctx context.Context) error {
+				err := s.TxManager.WithTx(ctx, func(ctx context.Context) error {
+					_, err := s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("5"))
+					return err
+				})
+				require.NoError(t, err)
+				return errRollback
+			})
+			require.ErrorIs(t, err, errRollback)
+
+			user, err := s.Users.FindByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "10.5", user.Balance.String(), "inner transaction should roll back with the outer one")
+		}},
+	})
+}
@@ -0,0 +1,110 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/entity"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+	"github.com/supercakecrumb/adhd-game-bot/internal/ports"
+)
+
+// RunUserRepository tests a ports.UserRepository
+func RunUserRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"CreateAndFind", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+
+			found, err := s.Users.FindByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "User 1", found.Username)
+			assert.Equal(t, "10.5", found.Balance.String())
+			assert.Equal(t, int64(100), found.ChatID)
+		}},
+		{"DuplicateID", func(t *testing.T, s *storage.Storage) {
+			createUser(t, s, 1)
+			err := s.Users.Create(context.Background(), newUser(1))
+			assert.ErrorIs(t, err, ports.ErrUserAlreadyExists)
+		}},
+		{"NotFound", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+
+			_, err := s.Users.FindByID(ctx, 999)
+			assert.ErrorIs(t, err, ports.ErrUserNotFound)
+
+			_, err = s.Users.UpdateBalance(ctx, 999, valueobject.NewDecimal("1"))
+			assert.ErrorIs(t, err, ports.ErrUserNotFound)
+
+			assert.ErrorIs(t, s.Users.Lock(ctx, 999), ports.ErrUserNotFound)
+			assert.ErrorIs(t, s.Users.Delete(ctx, 999), ports.ErrUserNotFound)
+		}},
+		{"UpdateBalance", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+
+			balance, err := s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("5.25"))
+			require.NoError(t, err)
+			assert.Equal(t, "15.75", balance.String())
+
+			balance, err = s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("-0.75"))
+			require.NoError(t, err)
+			assert.Equal(t, "15", balance.String())
+
+			found, err := s.Users.FindByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, "15", found.Balance.String())
+		}},
+		{"LockInTransaction", func(t *testing.T, s *storage.Storage) {
+			createUser(t, s, 1)
+			err := s.TxManager.WithTx(context.Background(), func(ctx context.Context) error {
+				if err := s.Users.Lock(ctx, 1); err != nil {
+					return err
+				}
+				_, err := s.Users.UpdateBalance(ctx, 1, valueobject.NewDecimal("1"))
+				return err
+			})
+			require.NoError(t, err)
+		}},
+		{"ListsAreOrderedByID", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			for _, id := range []int64{3, 1, 2} {
+				createUser(t, s, id)
+			}
+			other := newUser(4)
+			other.ChatID = 200
+			require.NoError(t, s.Users.Create(ctx, other))
+
+			users, err := s.Users.FindAll(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{1, 2, 3, 4}, userIDs(users))
+
+			users, err = s.Users.FindByChatID(ctx, 100)
+			require.NoError(t, err)
+			assert.Equal(t, []int64{1, 2, 3}, userIDs(users))
+
+			users, err = s.Users.FindByChatID(ctx, 300)
+			require.NoError(t, err)
+			assert.Empty(t, users)
+		}},
+		{"Delete", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+
+			require.NoError(t, s.Users.Delete(ctx, 1))
+			_, err := s.Users.FindByID(ctx, 1)
+			assert.ErrorIs(t, err, ports.ErrUserNotFound)
+		}},
+	})
+}
+
+func userIDs(users []*entity.User) []int64 {
+	ids := make([]int64, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
@@ -0,0 +1,77 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supercakecrumb/adhd-game-bot/internal/domain/valueobject"
+	"github.com/supercakecrumb/adhd-game-bot/internal/infra/storage"
+)
+
+// RunWalletRepository tests a ports.WalletRepository
+func RunWalletRepository(t *testing.T, open Opener) {
+	runCases(t, open, []testCase{
+		{"GetMissingIsEmpty", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, 100)
+
+			wallet, err := s.Wallets.Get(ctx, 1, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), wallet.UserID)
+			assert.Equal(t, dungeon.ID, wallet.DungeonID)
+			assert.True(t, wallet.Balance.IsZero())
+		}},
+		{"UpdateBalance", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			dungeon := createDungeon(t, s, 1, 100)
+
+			balance, err := s.Wallets.UpdateBalance(ctx, 1, dungeon.ID, valueobject.NewDecimal("10.5"))
+			require.NoError(t, err)
+			assert.Equal(t, "10.5", balance.String())
+
+			balance, err = s.Wallets.UpdateBalance(ctx, 1, dungeon.ID, valueobject.NewDecimal("-2.25"))
+			require.NoError(t, err)
+			assert.Equal(t, "8.25", balance.String())
+
+			wallet, err := s.Wallets.Get(ctx, 1, dungeon.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "8.25", wallet.Balance.String())
+		}},
+		{"ListByUserAndFindAll", func(t *testing.T, s *storage.Storage) {
+			ctx := context.Background()
+			createUser(t, s, 1)
+			createUser(t, s, 2)
+			first := createDungeon(t, s, 1, 100)
+			second := createDungeon(t, s, 1, 200)
+			if second.ID < first.ID {
+				first, second = second, first
+			}
+
+			for _, update := range []struct {
+				userID    int64
+				dungeonID string
+				delta     string
+			}{{1, second.ID, "4"}, {1, first.ID, "3"}, {2, first.ID, "7"}} {
+				_, err := s.Wallets.UpdateBalance(ctx, update.userID, update.dungeonID, valueobject.NewDecimal(update.delta))
+				require.NoError(t, err)
+			}
+
+			wallets, err := s.Wallets.ListByUser(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, wallets, 2)
+			assert.Equal(t, first.ID, wallets[0].DungeonID)
+			assert.Equal(t, "3", wallets[0].Balance.String())
+			assert.Equal(t, second.ID, wallets[1].DungeonID)
+
+			wallets, err = s.Wallets.FindAll(ctx)
+			require.NoError(t, err)
+			require.Len(t, wallets, 3)
+			assert.Equal(t, int64(2), wallets[2].UserID)
+			assert.Equal(t, "7", wallets[2].Balance.String())
+		}},
+	})
+}